@@ -1,17 +1,30 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"log"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
-	// We will add imports for core components, config, logging, etc. later
-	// "quantum-resonance-ledger/node/internal/core"
+	"time"
+
+	"quantum-resonance-ledger/node/internal/core"
+	// We will add imports for config, logging, etc. later
 	// "quantum-resonance-ledger/node/pkg/config"
-	// "log"
 )
 
+// genesisTime is the fixed timestamp of the genesis block so all nodes derive the same genesis hash.
+var genesisTime = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
 func main() {
+	dataDir := flag.String("datadir", "qrl-data", "Directory for node data (transaction journal, etc.)")
+	proposer := flag.String("proposer", "", "Identity to propose blocks as; block production is disabled when empty")
+	blockInterval := flag.Duration("block-interval", 5*time.Second, "Interval between produced blocks")
+	consensusMode := flag.String("consensus", "pathintegral", "Consensus engine: \"pathintegral\" or \"dev\" (instant seal on every pending transaction, instant finality)")
+	flag.Parse()
+
 	fmt.Println("Starting Quantum Resonance Ledger (QRL) Node...")
 
 	// TODO: Load configuration (from file or CLI flags)
@@ -23,17 +36,78 @@ func main() {
 	// TODO: Initialize logger
 	// log.SetOutput(os.Stdout) // Example
 
-	// TODO: Initialize core components (P2P, DB, Consensus, State, TxPool, Native Functions)
+	if err := os.MkdirAll(*dataDir, 0755); err != nil {
+		log.Fatalf("Failed to create data directory %s: %v", *dataDir, err)
+	}
+
+	// State, consensus and the block tree rooted at genesis
+	// TODO: Load state from persistent storage instead of starting from an empty genesis
+	stateDB := core.NewInMemoryStateDB()
+	stateManager := core.NewStateManager(stateDB)
+
+	// Transaction pool with a journal so locally submitted transactions survive restarts; signatures
+	// are checked against the sender's account (single key or multisig signer set)
+	txPool := core.NewTxPool()
+	txPool.SetAuthorizer(stateManager)
+	txJournal := core.NewTxJournal(filepath.Join(*dataDir, "transactions.journal"))
+	txPool.SetJournal(txJournal)
+	if err := txPool.LoadJournal(); err != nil {
+		log.Fatalf("Failed to load transaction journal: %v", err)
+	}
+	var consensusEngine core.ConsensusEngine
+	producerCfg := core.BlockProducerConfig{Proposer: *proposer, Interval: *blockInterval}
+	switch *consensusMode {
+	case "pathintegral":
+		pic := core.NewPathIntegralConsensus(stateManager)
+		// Report proposers that sign conflicting headers; evidence is submitted as our own
+		// transaction when we have an identity to sign it with.
+		pic.SetEquivocationDetector(core.NewEquivocationDetector(2*stateManager.StakingConfig().UnbondingDelay, func(ev *core.EquivocationEvidence) {
+			submitEvidence(ev, *proposer, stateManager, stateDB, txPool)
+		}))
+		consensusEngine = pic
+	case "dev":
+		consensusEngine = core.NewInstantSealConsensus(stateManager)
+		if producerCfg.Proposer == "" {
+			producerCfg.Proposer = "dev"
+		}
+		producerCfg.Interval = 0 // Seal only when transactions arrive or on demand
+		producerCfg.SealOnPending = true
+	default:
+		log.Fatalf("Unknown consensus engine %q", *consensusMode)
+	}
+	genesisRoot, err := stateDB.Root()
+	if err != nil {
+		log.Fatalf("Failed to compute genesis state root: %v", err)
+	}
+	blockTree, err := core.NewBlockTree(core.NewGenesisBlock(genesisRoot, genesisTime), consensusEngine.CalculateAction)
+	if err != nil {
+		log.Fatalf("Failed to create block tree: %v", err)
+	}
+
+	// TODO: Initialize remaining core components (P2P, Native Functions)
 	// p2pManager := core.NewP2PManager(cfg.P2P)
-	// db := core.NewDatabase(cfg.DB)
-	// stateManager := core.NewStateManager(db)
-	// consensusEngine := core.NewConsensusEngine(cfg.Consensus, p2pManager, stateManager)
-	// txPool := core.NewTransactionPool(cfg.TxPool)
 	// ... initialize other components
 
-	// TODO: Start components
+	var producer *core.BlockProducer
+	if producerCfg.Proposer != "" {
+		// TODO: Pass the P2P layer as broadcaster once it exists
+		producer, err = core.NewBlockProducer(producerCfg, consensusEngine, txPool, stateManager, blockTree, nil)
+		if err != nil {
+			log.Fatalf("Failed to create block producer: %v", err)
+		}
+		if err := producer.Start(); err != nil {
+			log.Fatalf("Failed to start block producer: %v", err)
+		}
+		if producerCfg.SealOnPending {
+			producer.Trigger() // Seal whatever was restored from the journal
+			fmt.Printf("Dev mode: sealing blocks as %s whenever transactions are pending\n", producerCfg.Proposer)
+		} else {
+			fmt.Printf("Producing blocks as %s every %v\n", producerCfg.Proposer, producerCfg.Interval)
+		}
+	}
+
+	// TODO: Start remaining components
 	// go p2pManager.Start()
-	// go consensusEngine.Start()
 	// ... start other components
 
 	fmt.Println("QRL Node Initialization Complete. Running...")
@@ -45,11 +119,46 @@ func main() {
 
 	fmt.Println("Shutting down QRL Node...")
 
-	// TODO: Gracefully shut down components
-	// consensusEngine.Stop()
+	if producer != nil {
+		producer.Stop()
+	}
+	// TODO: Gracefully shut down remaining components
 	// p2pManager.Stop()
 	// db.Close()
 	// ... stop other components
 
+	// Compact the journal down to what is still pending before exiting
+	if err := txPool.RotateJournal(); err != nil {
+		fmt.Printf("Failed to rotate transaction journal: %v\n", err)
+	}
+	if err := txJournal.Close(); err != nil {
+		fmt.Printf("Failed to close transaction journal: %v\n", err)
+	}
+
 	fmt.Println("QRL Node Shutdown Complete.")
 }
+
+// submitEvidence packages equivocation evidence into a transaction from reporter and adds it to the
+// pool. Does nothing without a reporter identity, or while evidence slashing is disabled (the
+// transaction would be rejected; see StakingConfig.EvidenceSlashing).
+func submitEvidence(ev *core.EquivocationEvidence, reporter string, stateManager *core.StateManager, stateDB core.StateDB, txPool *core.TxPool) {
+	if reporter == "" {
+		return
+	}
+	if !stateManager.StakingConfig().EvidenceSlashing {
+		fmt.Printf("Detected equivocation by %s at height %d; not submitted, evidence slashing is disabled\n", ev.Proposer(), ev.Number())
+		return
+	}
+	stateNonce, err := stateDB.GetNonce(reporter)
+	if err != nil {
+		fmt.Printf("Failed to read nonce for evidence transaction: %v\n", err)
+		return
+	}
+	tx, err := core.NewEvidenceTransaction(ev, reporter, txPool.NextNonce(reporter, stateNonce))
+	if err == nil {
+		err = txPool.AddLocalTransaction(tx)
+	}
+	if err != nil {
+		fmt.Printf("Failed to submit evidence against %s: %v\n", ev.Proposer(), err)
+	}
+}
@@ -0,0 +1,266 @@
+package core
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// Randomness beacon
+//
+// Validators commit to a secret in epoch e (TxTypeRandCommit, Payload = BeaconCommitment(secret,
+// sender)) and reveal it in epoch e+1 (TxTypeRandReveal, Payload = secret). Reveals are mixed into
+// an accumulator for the epoch; after its last block (the "beacon" epoch end hook) the accumulator
+// is folded into the previous seed to give the seed of epoch e+2. Committers that did not reveal in
+// time are slashed by StakingConfig.WithholdPenaltyBps. Every node derives the same seed from
+// state, so probabilistic decisions drawn from RandomnessAt agree across nodes.
+
+// Storage key prefixes for beacon state.
+const (
+	beaconCommitPrefix = "beacon/commit/" // beacon/commit/<epoch>/<address> -> commitment
+	beaconMixPrefix    = "beacon/mix/"    // beacon/mix/<epoch> -> running mix of reveals made in the epoch
+	beaconSeedPrefix   = "beacon/seed/"   // beacon/seed/<epoch> -> seed used for the blocks of the epoch
+)
+
+// beaconSecretSize is the required size of a revealed secret.
+const beaconSecretSize = 32
+
+// genesisBeaconSeed is the seed of epochs before the first reveals take effect.
+var genesisBeaconSeed = sha256.Sum256([]byte("qrl-genesis-beacon"))
+
+// beaconCommitKey returns the storage key of address's commitment made in epoch.
+func beaconCommitKey(epoch uint64, address string) string {
+	return fmt.Sprintf("%s%020d/%s", beaconCommitPrefix, epoch, address)
+}
+
+// beaconMixKey returns the storage key of the reveal mix of epoch.
+func beaconMixKey(epoch uint64) string {
+	return fmt.Sprintf("%s%020d", beaconMixPrefix, epoch)
+}
+
+// beaconSeedKey returns the storage key of the seed of epoch.
+func beaconSeedKey(epoch uint64) string {
+	return fmt.Sprintf("%s%020d", beaconSeedPrefix, epoch)
+}
+
+// BeaconCommitment returns the commitment to secret by address. Binding the address prevents a
+// validator from copying another's commitment and reveal.
+func BeaconCommitment(secret []byte, address string) Hash {
+	h := sha256.New()
+	h.Write(secret)
+	h.Write([]byte(address))
+	var out Hash
+	copy(out[:], h.Sum(nil))
+	return out
+}
+
+// getHash reads a 32-byte value stored under key. Returns false if absent.
+func getHash(db StateDB, key string) (Hash, bool, error) {
+	value, err := db.GetStorage(key)
+	if err != nil || len(value) == 0 {
+		return Hash{}, false, err
+	}
+	if len(value) != len(Hash{}) {
+		return Hash{}, false, fmt.Errorf("storage key %s holds %d bytes, expected %d", key, len(value), len(Hash{}))
+	}
+	var h Hash
+	copy(h[:], value)
+	return h, true, nil
+}
+
+// EpochSeed returns the beacon seed for epoch. Epochs 0 and 1 use the genesis seed since no
+// reveal can have taken effect yet.
+func EpochSeed(db StateDB, epoch uint64) (Hash, error) {
+	if epoch < 2 {
+		return genesisBeaconSeed, nil
+	}
+	seed, found, err := getHash(db, beaconSeedKey(epoch))
+	if err != nil {
+		return Hash{}, err
+	}
+	if !found {
+		return Hash{}, fmt.Errorf("no beacon seed for epoch %d", epoch)
+	}
+	return seed, nil
+}
+
+// RandomnessAt returns the beacon output for block number: SHA-256(epoch seed || number).
+// epochLength is the length of an epoch in blocks (see EpochManager).
+func RandomnessAt(db StateDB, epochLength, number uint64) (Hash, error) {
+	if epochLength == 0 {
+		return Hash{}, fmt.Errorf("epoch length must be positive")
+	}
+	epoch := number / epochLength
+	seed, err := EpochSeed(db, epoch)
+	if err != nil {
+		return Hash{}, err
+	}
+	var buf [len(Hash{}) + 8]byte
+	copy(buf[:], seed[:])
+	binary.BigEndian.PutUint64(buf[len(Hash{}):], number)
+	return sha256.Sum256(buf[:]), nil
+}
+
+// RandomStream is a deterministic stream of random values expanded from a seed:
+// the i-th 32-byte block is SHA-256(seed || i). Not safe for concurrent use.
+type RandomStream struct {
+	seed    Hash
+	counter uint64
+	buf     []byte
+}
+
+// NewRandomStream creates a stream expanding seed.
+func NewRandomStream(seed Hash) *RandomStream {
+	return &RandomStream{seed: seed}
+}
+
+// Uint64 returns the next 64 random bits.
+func (rs *RandomStream) Uint64() uint64 {
+	if len(rs.buf) < 8 {
+		var block [len(Hash{}) + 8]byte
+		copy(block[:], rs.seed[:])
+		binary.BigEndian.PutUint64(block[len(Hash{}):], rs.counter)
+		rs.counter++
+		digest := sha256.Sum256(block[:])
+		rs.buf = append(rs.buf[:0], digest[:]...)
+	}
+	v := binary.BigEndian.Uint64(rs.buf[:8])
+	rs.buf = rs.buf[8:]
+	return v
+}
+
+// Float64 returns a uniformly distributed value in [0, 1).
+func (rs *RandomStream) Float64() float64 {
+	return float64(rs.Uint64()>>11) / (1 << 53)
+}
+
+// RandomnessAt returns the beacon output for block number.
+func (sm *StateManager) RandomnessAt(number uint64) (Hash, error) {
+	return RandomnessAt(sm.db, sm.epochs.Length(), number)
+}
+
+// Randomness returns a random stream for the block currently being executed, for native functions
+// that need agreed-upon randomness. Streams for the same block yield the same values.
+func (sm *StateManager) Randomness() (*RandomStream, error) {
+	seed, err := sm.RandomnessAt(sm.blockNumber())
+	if err != nil {
+		return nil, err
+	}
+	return NewRandomStream(seed), nil
+}
+
+// --- Beacon transactions ---
+
+// applyRandCommit records the sender's commitment for the current epoch. Only bonded validators may
+// commit, once per epoch.
+func (sm *StateManager) applyRandCommit(tx *Transaction) error {
+	if tx.Amount != 0 {
+		return fmt.Errorf("beacon commit must not carry an amount")
+	}
+	if len(tx.Payload) != len(Hash{}) {
+		return fmt.Errorf("beacon commitment must be %d bytes, got %d", len(Hash{}), len(tx.Payload))
+	}
+	stake, err := GetStake(sm.db, tx.SenderID)
+	if err != nil {
+		return fmt.Errorf("failed to get stake of %s: %w", tx.SenderID, err)
+	}
+	if stake == 0 {
+		return fmt.Errorf("%s has no bonded stake and cannot commit to the beacon", tx.SenderID)
+	}
+	key := beaconCommitKey(sm.epochs.EpochOf(sm.blockNumber()), tx.SenderID)
+	if existing, err := sm.db.GetStorage(key); err != nil {
+		return err
+	} else if len(existing) > 0 {
+		return fmt.Errorf("%s already committed in this epoch", tx.SenderID)
+	}
+	return sm.db.SetStorage(key, tx.Payload)
+}
+
+// applyRandReveal checks the revealed secret against the sender's commitment from the previous
+// epoch and mixes it into the current epoch's accumulator.
+func (sm *StateManager) applyRandReveal(tx *Transaction) error {
+	if tx.Amount != 0 {
+		return fmt.Errorf("beacon reveal must not carry an amount")
+	}
+	if len(tx.Payload) != beaconSecretSize {
+		return fmt.Errorf("beacon secret must be %d bytes, got %d", beaconSecretSize, len(tx.Payload))
+	}
+	epoch := sm.epochs.EpochOf(sm.blockNumber())
+	if epoch == 0 {
+		return fmt.Errorf("no commitments can be revealed in epoch 0")
+	}
+	commitKey := beaconCommitKey(epoch-1, tx.SenderID)
+	commitment, found, err := getHash(sm.db, commitKey)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("%s has no unrevealed commitment from epoch %d", tx.SenderID, epoch-1)
+	}
+	if BeaconCommitment(tx.Payload, tx.SenderID) != commitment {
+		return fmt.Errorf("revealed secret does not match the commitment of %s", tx.SenderID)
+	}
+	mix, _, err := getHash(sm.db, beaconMixKey(epoch))
+	if err != nil {
+		return err
+	}
+	next := sha256.Sum256(append(mix[:], tx.Payload...))
+	if err := sm.db.SetStorage(beaconMixKey(epoch), next[:]); err != nil {
+		return err
+	}
+	return sm.db.SetStorage(commitKey, nil)
+}
+
+// finalizeBeaconEpoch runs after the last block of epoch: it slashes committers from epoch-1 that
+// withheld their reveal and derives the seed of epoch+2 from the seed of epoch+1 and epoch's mix.
+func (sm *StateManager) finalizeBeaconEpoch(epoch uint64) error {
+	if epoch > 0 {
+		prefix := fmt.Sprintf("%s%020d/", beaconCommitPrefix, epoch-1)
+		keys, err := sm.db.StorageKeys(prefix)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if err := sm.penalizeWithholding(strings.TrimPrefix(key, prefix), epoch-1); err != nil {
+				return err
+			}
+			if err := sm.db.SetStorage(key, nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	prev, err := EpochSeed(sm.db, epoch+1)
+	if err != nil {
+		return err
+	}
+	mix, _, err := getHash(sm.db, beaconMixKey(epoch))
+	if err != nil {
+		return err
+	}
+	seed := sha256.Sum256(bytes.Join([][]byte{prev[:], mix[:]}, nil))
+	if err := sm.db.SetStorage(beaconSeedKey(epoch+2), seed[:]); err != nil {
+		return err
+	}
+	return sm.db.SetStorage(beaconMixKey(epoch), nil)
+}
+
+// penalizeWithholding burns WithholdPenaltyBps of the bonded stake of a validator that committed
+// in commitEpoch but did not reveal.
+func (sm *StateManager) penalizeWithholding(address string, commitEpoch uint64) error {
+	stake, err := GetStake(sm.db, address)
+	if err != nil {
+		return err
+	}
+	penalty := slashAmount(stake, sm.staking.WithholdPenaltyBps)
+	if penalty == 0 {
+		return nil
+	}
+	if err := setStorageUint64(sm.db, stakeKey(address), stake-penalty); err != nil {
+		return err
+	}
+	fmt.Printf("StateManager: Slashed %s by %d for withholding its beacon reveal from epoch %d\n", address, penalty, commitEpoch) // Placeholder log
+	return nil
+}
@@ -0,0 +1,154 @@
+package core
+
+import (
+	"testing"
+)
+
+// beaconTx builds and signs a beacon transaction carrying payload.
+func beaconTx(txType TransactionType, nonce uint64, sender string, payload []byte) *Transaction {
+	tx := NewBaseTransaction(txType, nonce, sender, "", 0)
+	tx.Payload = payload
+	_ = tx.Sign()
+	return tx
+}
+
+// applyBlocks applies blocks from..to (inclusive) with txs[n] as the transactions of block n.
+func applyBlocks(t *testing.T, sm *StateManager, from, to uint64, txs map[uint64][]*Transaction) {
+	t.Helper()
+	for n := from; n <= to; n++ {
+		if err := sm.ApplyBlock(NewBlock(&BlockHeader{Number: n, Proposer: "proposerP"}, txs[n])); err != nil {
+			t.Fatalf("ApplyBlock %d failed: %v", n, err)
+		}
+	}
+}
+
+func TestBeacon_CommitReveal(t *testing.T) {
+	newFixture := func() (*InMemoryStateDB, *StateManager) {
+		db := NewInMemoryStateDB()
+		sm := NewStateManager(db)
+		cfg := DefaultStakingConfig()
+		cfg.WithholdPenaltyBps = 1000
+		sm.SetStakingConfig(cfg)
+		_ = sm.Epochs().SetLength(4)
+		_ = setStorageUint64(db, stakeKey("valA"), 1000)
+		_ = setStorageUint64(db, stakeKey("valB"), 1000)
+		return db, sm
+	}
+	secretA := make([]byte, beaconSecretSize)
+	secretA[0] = 0xA
+	secretB := make([]byte, beaconSecretSize)
+	secretB[0] = 0xB
+	commitA := BeaconCommitment(secretA, "valA")
+	commitB := BeaconCommitment(secretB, "valB")
+
+	t.Run("RevealsChangeSeed", func(t *testing.T) {
+		db, sm := newFixture()
+		// Epoch 0 (blocks 1-3): commit; epoch 1 (4-7): reveal; the mix becomes epoch 3's seed
+		applyBlocks(t, sm, 1, 11, map[uint64][]*Transaction{
+			1: {beaconTx(TxTypeRandCommit, 0, "valA", commitA[:]), beaconTx(TxTypeRandCommit, 0, "valB", commitB[:])},
+			5: {beaconTx(TxTypeRandReveal, 1, "valA", secretA), beaconTx(TxTypeRandReveal, 1, "valB", secretB)},
+		})
+		seed2, err := EpochSeed(db, 2)
+		if err != nil {
+			t.Fatalf("EpochSeed(2) failed: %v", err)
+		}
+		seed3, err := EpochSeed(db, 3)
+		if err != nil {
+			t.Fatalf("EpochSeed(3) failed: %v", err)
+		}
+		if seed2 == seed3 || seed2 == genesisBeaconSeed {
+			t.Errorf("Expected seeds to evolve across epochs")
+		}
+		if stake, _ := GetStake(db, "valA"); stake != 1000 {
+			t.Errorf("Revealing validator must not be penalized, stake is %d", stake)
+		}
+
+		// Same history without valB's reveal yields a different seed and penalizes valB
+		db2, sm2 := newFixture()
+		applyBlocks(t, sm2, 1, 11, map[uint64][]*Transaction{
+			1: {beaconTx(TxTypeRandCommit, 0, "valA", commitA[:]), beaconTx(TxTypeRandCommit, 0, "valB", commitB[:])},
+			5: {beaconTx(TxTypeRandReveal, 1, "valA", secretA)},
+		})
+		if other, _ := EpochSeed(db2, 3); other == seed3 {
+			t.Errorf("Expected the seed to depend on the reveals")
+		}
+		if stake, _ := GetStake(db2, "valB"); stake != 900 {
+			t.Errorf("Expected withholding validator to lose 10%%, stake is %d", stake)
+		}
+		if stake, _ := GetStake(db2, "valA"); stake != 1000 {
+			t.Errorf("Expected revealing validator to keep its stake, got %d", stake)
+		}
+	})
+
+	t.Run("Deterministic", func(t *testing.T) {
+		dbA, smA := newFixture()
+		dbB, smB := newFixture()
+		txs := map[uint64][]*Transaction{1: {beaconTx(TxTypeRandCommit, 0, "valA", commitA[:])}, 4: {beaconTx(TxTypeRandReveal, 1, "valA", secretA)}}
+		applyBlocks(t, smA, 1, 8, txs)
+		applyBlocks(t, smB, 1, 8, txs)
+		for n := uint64(0); n < 16; n++ {
+			ra, errA := RandomnessAt(dbA, 4, n)
+			rb, errB := RandomnessAt(dbB, 4, n)
+			if errA != nil || errB != nil || ra != rb {
+				t.Fatalf("Block %d: nodes with the same history disagree on randomness", n)
+			}
+		}
+		if _, err := RandomnessAt(dbA, 4, 16); err == nil {
+			t.Errorf("Expected error for an epoch whose seed is not determined yet")
+		}
+	})
+
+	t.Run("InvalidTransactions", func(t *testing.T) {
+		_, sm := newFixture()
+		sm.BeginBlock(&BlockHeader{Number: 1, Proposer: "proposerP"})
+		if err := sm.ApplyTransaction(beaconTx(TxTypeRandCommit, 0, "nobody", commitA[:])); err == nil {
+			t.Errorf("Expected commit without stake to be rejected")
+		}
+		if err := sm.ApplyTransaction(beaconTx(TxTypeRandCommit, 0, "valA", commitA[:])); err != nil {
+			t.Fatalf("Commit failed: %v", err)
+		}
+		if err := sm.ApplyTransaction(beaconTx(TxTypeRandCommit, 1, "valA", commitA[:])); err == nil {
+			t.Errorf("Expected second commit in the same epoch to be rejected")
+		}
+		if err := sm.ApplyTransaction(beaconTx(TxTypeRandReveal, 1, "valA", secretA)); err == nil {
+			t.Errorf("Expected reveal in the commit epoch to be rejected")
+		}
+		sm.BeginBlock(&BlockHeader{Number: 4, Proposer: "proposerP"})
+		if err := sm.ApplyTransaction(beaconTx(TxTypeRandReveal, 1, "valA", secretB)); err == nil {
+			t.Errorf("Expected reveal not matching the commitment to be rejected")
+		}
+		if err := sm.ApplyTransaction(beaconTx(TxTypeRandReveal, 1, "valB", secretB)); err == nil {
+			t.Errorf("Expected reveal without commitment to be rejected")
+		}
+		if err := sm.ApplyTransaction(beaconTx(TxTypeRandReveal, 1, "valA", secretA)); err != nil {
+			t.Errorf("Valid reveal failed: %v", err)
+		}
+	})
+}
+
+func TestRandomStream(t *testing.T) {
+	a, b := NewRandomStream(Hash{1}), NewRandomStream(Hash{1})
+	other := NewRandomStream(Hash{2})
+	differs := false
+	sum := 0.0
+	const n = 10000
+	for i := 0; i < n; i++ {
+		x, y := a.Float64(), b.Float64()
+		if x != y {
+			t.Fatalf("Draw %d: streams with the same seed diverged", i)
+		}
+		if x < 0 || x >= 1 {
+			t.Fatalf("Draw %d: %f outside [0, 1)", i, x)
+		}
+		if other.Float64() != x {
+			differs = true
+		}
+		sum += x
+	}
+	if !differs {
+		t.Errorf("Expected streams with different seeds to differ")
+	}
+	if mean := sum / n; mean < 0.48 || mean > 0.52 {
+		t.Errorf("Expected mean near 0.5, got %f", mean)
+	}
+}
@@ -1,7 +1,12 @@
 package core
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
 	"fmt"
+	"math"
 	"time"
 )
 
@@ -13,7 +18,45 @@ type BlockHeader struct {
 	Timestamp  time.Time // Timestamp of block creation
 	StateRoot  Hash      // Root hash of the state trie after applying transactions
 	TxRoot     Hash      // Root hash of the transaction trie
-	// TODO: Add other fields like Difficulty, GasUsed, etc.
+	GasLimit   uint64    // Maximum gas the block's transactions may consume
+	GasUsed    uint64    // Total intrinsic gas of the block's transactions
+	WSIValue   float64   // WSI value reported by the proposer, scored by the action's peg term
+	Proposer   string    // Identifier of the node that proposed (and signed) the block
+	Signature  Signature // Proposer's signature over the header hash
+	// TODO: Add other fields like Difficulty, etc.
+}
+
+// Hash calculates the header hash: SHA-256 over a fixed-layout binary encoding of every header
+// field except Signature. This is also the digest the proposer signs.
+func (h *BlockHeader) Hash() Hash {
+	var buf bytes.Buffer
+	buf.Write(h.ParentHash[:])
+	_ = binary.Write(&buf, binary.BigEndian, h.Number)
+	_ = binary.Write(&buf, binary.BigEndian, h.Timestamp.UnixNano())
+	buf.Write(h.StateRoot[:])
+	buf.Write(h.TxRoot[:])
+	_ = binary.Write(&buf, binary.BigEndian, h.GasLimit)
+	_ = binary.Write(&buf, binary.BigEndian, h.GasUsed)
+	_ = binary.Write(&buf, binary.BigEndian, math.Float64bits(h.WSIValue))
+	_ = binary.Write(&buf, binary.BigEndian, uint32(len(h.Proposer)))
+	buf.WriteString(h.Proposer)
+	return sha256.Sum256(buf.Bytes())
+}
+
+// Sign sets the proposer and attaches the proposer's signature over the header hash.
+// Placeholder implementation using SignDigest.
+func (h *BlockHeader) Sign(proposer string /*, privateKey ... */) error {
+	if proposer == "" {
+		return fmt.Errorf("cannot sign header without a proposer")
+	}
+	h.Proposer = proposer
+	h.Signature = SignDigest(proposer, h.Hash())
+	return nil
+}
+
+// VerifySignature reports whether the header carries a valid signature from its proposer.
+func (h *BlockHeader) VerifySignature() bool {
+	return VerifyDigestSignature(h.Proposer, h.Hash(), h.Signature)
 }
 
 // Block represents a block in the blockchain.
@@ -23,14 +66,54 @@ type Block struct {
 	// TODO: Add Uncles/Ommer headers if applicable
 }
 
-// Hash calculates the block's hash (typically of the header).
-// Placeholder implementation.
+// Hash calculates the block's hash, which is the hash of its header.
 func (b *Block) Hash() (Hash, error) {
-	// TODO: Implement proper hashing (e.g., RLP encode header, then Keccak256)
-	// For now, return a placeholder based on number
-	h := Hash{} // Placeholder
-	copy(h[:], []byte(fmt.Sprintf("block-%d", b.Header.Number)))
-	return h, nil
+	if b == nil || b.Header == nil {
+		return Hash{}, fmt.Errorf("cannot hash nil block or header")
+	}
+	return b.Header.Hash(), nil
+}
+
+// NewGenesisBlock creates block 0 committing to the initial state root.
+func NewGenesisBlock(stateRoot Hash, timestamp time.Time) *Block {
+	return NewBlock(&BlockHeader{
+		Number:    0,
+		Timestamp: timestamp,
+		StateRoot: stateRoot,
+		TxRoot:    MerkleRoot(nil),
+	}, []*Transaction{})
+}
+
+// DeriveTxRoot computes the Merkle root of the transaction hashes, as committed to by BlockHeader.TxRoot.
+func DeriveTxRoot(txs []*Transaction) (Hash, error) {
+	leaves := make([]Hash, 0, len(txs))
+	for i, tx := range txs {
+		if tx == nil {
+			return Hash{}, fmt.Errorf("transaction %d is nil", i)
+		}
+		h, err := tx.Hash()
+		if err != nil {
+			return Hash{}, fmt.Errorf("failed to hash transaction %d: %w", i, err)
+		}
+		leaves = append(leaves, h)
+	}
+	return MerkleRoot(leaves), nil
+}
+
+// Size returns the encoded size of the block's transactions in bytes, used for block size limits.
+func (b *Block) Size() (uint64, error) {
+	var size uint64
+	for i, tx := range b.Transactions {
+		if tx == nil {
+			return 0, fmt.Errorf("transaction %d is nil", i)
+		}
+		data, err := tx.Encode()
+		if err != nil {
+			return 0, err
+		}
+		size += uint64(len(data))
+	}
+	return size, nil
 }
 
 // NewBlock creates a new block.
@@ -44,4 +127,35 @@ func NewBlock(header *BlockHeader, txs []*Transaction) *Block {
 
 // --- Helper Type ---
 
-// TODO: Add helper functions for Hash type (e.g., String(), Bytes(), SetBytes())
+// String returns the hash as a lowercase hex string.
+func (h Hash) String() string {
+	return hex.EncodeToString(h[:])
+}
+
+// MarshalText encodes the hash as hex so it appears as a string in JSON (e.g., RPC responses).
+func (h Hash) MarshalText() ([]byte, error) {
+	return []byte(h.String()), nil
+}
+
+// UnmarshalText decodes a hex-encoded hash.
+func (h *Hash) UnmarshalText(text []byte) error {
+	decoded, err := hex.DecodeString(string(text))
+	if err != nil {
+		return fmt.Errorf("invalid hash hex: %w", err)
+	}
+	if len(decoded) != len(h) {
+		return fmt.Errorf("invalid hash length %d, expected %d", len(decoded), len(h))
+	}
+	copy(h[:], decoded)
+	return nil
+}
+
+// IsZero reports whether the hash is all zeroes (e.g., the genesis parent hash).
+func (h Hash) IsZero() bool {
+	return h == Hash{}
+}
+
+// Less reports whether h sorts before other byte-wise. Used for deterministic tie-breaking.
+func (h Hash) Less(other Hash) bool {
+	return bytes.Compare(h[:], other[:]) < 0
+}
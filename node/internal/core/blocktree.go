@@ -0,0 +1,401 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// ActionFunc computes the action S of a single block on top of its parent (see
+// ConsensusEngine.CalculateAction).
+type ActionFunc func(block *Block, parent *BlockHeader) (float64, error)
+
+// HeadChangeEvent is delivered to subscribers whenever the canonical head of a BlockTree changes.
+type HeadChangeEvent struct {
+	OldHead *Block
+	NewHead *Block
+	Reorg   bool // True when NewHead does not descend from OldHead
+}
+
+// HeadSubscription is a handle on a stream of head change events.
+type HeadSubscription struct {
+	id   uint64
+	tree *BlockTree
+	ch   chan HeadChangeEvent
+}
+
+// Events returns the channel on which head changes are delivered.
+func (s *HeadSubscription) Events() <-chan HeadChangeEvent {
+	return s.ch
+}
+
+// Unsubscribe stops event delivery and closes the events channel.
+// It is safe to call more than once.
+func (s *HeadSubscription) Unsubscribe() {
+	s.tree.mu.Lock()
+	defer s.tree.mu.Unlock()
+	if _, ok := s.tree.subs[s.id]; ok {
+		delete(s.tree.subs, s.id)
+		close(s.ch)
+	}
+}
+
+// BranchInfo summarizes one branch of the tree, i.e. the path from genesis to a leaf.
+type BranchInfo struct {
+	Tip              *Block
+	TipHash          Hash
+	Length           uint64  // Number of blocks after genesis
+	CumulativeAction float64 // Sum of block actions from genesis (exclusive) to the tip
+	WindowAction     float64 // CumulativeAction plus the gap action per block short of the deepest branch; decides fork choice
+}
+
+// treeNode is a block together with its position and accumulated action in the tree.
+type treeNode struct {
+	block            *Block
+	hash             Hash
+	parent           *treeNode
+	children         []*treeNode
+	action           float64
+	cumulativeAction float64
+}
+
+// BlockTree tracks every known block, organised by parent links, and the cumulative action of
+// each branch. Branches are compared by cumulative action over a common window reaching the
+// deepest viable leaf, in which every block a branch is short of that depth costs the gap action.
+// The canonical head is the leaf with the lowest window action, so a short branch only wins by
+// undercutting a deeper one by more than the gap action per missing block; ties go to the longer
+// branch, then the lowest block hash so all nodes agree. Only branches that extend the last
+// finalized block are eligible as head, and blocks conflicting with it are rejected.
+type BlockTree struct {
+	mu        sync.RWMutex
+	nodes     map[Hash]*treeNode
+	tips      map[Hash]*treeNode // Childless nodes extending final, maintained as blocks are added
+	genesis   *treeNode
+	head      *treeNode
+	final     *treeNode // Highest finalized block; it and all its ancestors are irreversible
+	actionFn  ActionFunc
+	gapAction float64 // Action charged per block a branch is short of the deepest one
+
+	subs      map[uint64]*HeadSubscription
+	nextSubID uint64
+}
+
+// NewBlockTree creates a tree rooted at genesis. The genesis block contributes zero action, and
+// missing blocks cost the gap action of the default action weights until SetGapAction.
+func NewBlockTree(genesis *Block, actionFn ActionFunc) (*BlockTree, error) {
+	if genesis == nil || genesis.Header == nil {
+		return nil, fmt.Errorf("block tree requires a genesis block with a header")
+	}
+	if actionFn == nil {
+		return nil, fmt.Errorf("block tree requires an action function")
+	}
+	hash, err := genesis.Hash()
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash genesis block: %w", err)
+	}
+	root := &treeNode{block: genesis, hash: hash}
+	return &BlockTree{
+		nodes:     map[Hash]*treeNode{hash: root},
+		tips:      map[Hash]*treeNode{hash: root},
+		genesis:   root,
+		head:      root,
+		final:     root,
+		actionFn:  actionFn,
+		gapAction: DefaultActionWeights().GapAction(),
+		subs:      make(map[uint64]*HeadSubscription),
+	}, nil
+}
+
+// SetGapAction sets the action charged per block a branch is short of the deepest branch, and
+// re-evaluates the head.
+func (bt *BlockTree) SetGapAction(action float64) error {
+	if !(action >= 0) || math.IsInf(action, 0) {
+		return fmt.Errorf("gap action must be finite and non-negative, got %f", action)
+	}
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+	bt.gapAction = action
+	bt.updateHead()
+	return nil
+}
+
+// AddBlock inserts a block whose parent is already known, computes its action and
+// re-evaluates the canonical head. Adding a known block is a no-op.
+func (bt *BlockTree) AddBlock(block *Block) error {
+	if block == nil || block.Header == nil {
+		return fmt.Errorf("cannot add nil block or header to block tree")
+	}
+	hash, err := block.Hash()
+	if err != nil {
+		return fmt.Errorf("failed to hash block %d: %w", block.Header.Number, err)
+	}
+
+	bt.mu.RLock()
+	_, known := bt.nodes[hash]
+	parent, parentKnown := bt.nodes[block.Header.ParentHash]
+	bt.mu.RUnlock()
+	if known {
+		return nil
+	}
+	if !parentKnown {
+		return fmt.Errorf("unknown parent %s for block %d", block.Header.ParentHash, block.Header.Number)
+	}
+	if block.Header.Number != parent.block.Header.Number+1 {
+		return fmt.Errorf("block %d does not follow parent number %d", block.Header.Number, parent.block.Header.Number)
+	}
+	bt.mu.RLock()
+	final := bt.final
+	conflicts := !bt.isAncestor(final, parent)
+	bt.mu.RUnlock()
+	if conflicts {
+		return fmt.Errorf("block %d conflicts with finalized block %s", block.Header.Number, final.hash)
+	}
+
+	// Compute the action outside the lock; the action function may be expensive.
+	action, err := bt.actionFn(block, parent.block.Header)
+	if err != nil {
+		return fmt.Errorf("failed to calculate action for block %d: %w", block.Header.Number, err)
+	}
+
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+	if _, known := bt.nodes[hash]; known {
+		return nil // Added concurrently
+	}
+	if !bt.isAncestor(bt.final, parent) {
+		return fmt.Errorf("block %d conflicts with finalized block %s", block.Header.Number, bt.final.hash)
+	}
+	node := &treeNode{
+		block:            block,
+		hash:             hash,
+		parent:           parent,
+		action:           action,
+		cumulativeAction: parent.cumulativeAction + action,
+	}
+	parent.children = append(parent.children, node)
+	bt.nodes[hash] = node
+	delete(bt.tips, parent.hash)
+	bt.tips[hash] = node
+
+	bt.updateHead()
+	return nil
+}
+
+// depth returns the height of the deepest viable leaf. Caller must hold bt.mu.
+func (bt *BlockTree) depth() uint64 {
+	var depth uint64
+	for _, leaf := range bt.tips {
+		depth = max(depth, leaf.block.Header.Number)
+	}
+	return depth
+}
+
+// windowAction is the cumulative action of node's branch over the window from genesis (exclusive)
+// to height depth, charging the gap action for each block the branch is short of it. Caller must
+// hold bt.mu.
+func (bt *BlockTree) windowAction(node *treeNode, depth uint64) float64 {
+	return node.cumulativeAction + bt.gapAction*float64(depth-node.block.Header.Number)
+}
+
+// better reports whether leaf a wins fork choice over leaf b in a window reaching depth: lower
+// window action, then the longer branch, then the lower hash. Caller must hold bt.mu.
+func (bt *BlockTree) better(a, b *treeNode, depth uint64) bool {
+	if sa, sb := bt.windowAction(a, depth), bt.windowAction(b, depth); sa != sb {
+		return sa < sb
+	}
+	if na, nb := a.block.Header.Number, b.block.Header.Number; na != nb {
+		return na > nb
+	}
+	return a.hash.Less(b.hash)
+}
+
+// updateHead re-selects the head among the viable leaves and notifies subscribers on change.
+// Caller must hold bt.mu for writing.
+func (bt *BlockTree) updateHead() {
+	var best *treeNode
+	depth := bt.depth()
+	for _, leaf := range bt.tips {
+		if best == nil || bt.better(leaf, best, depth) {
+			best = leaf
+		}
+	}
+	if best == nil || best == bt.head {
+		return
+	}
+	old := bt.head
+	bt.head = best
+	event := HeadChangeEvent{OldHead: old.block, NewHead: best.block, Reorg: !bt.isAncestor(old, best)}
+	for id, sub := range bt.subs {
+		select {
+		case sub.ch <- event:
+		default:
+			fmt.Printf("BlockTree: Dropping head change event for slow subscriber %d\n", id) // Placeholder log
+		}
+	}
+}
+
+// leaves returns all nodes without children that extend the finalized block, in deterministic
+// (hash) order. Branches conflicting with finality can never become canonical and are skipped.
+// Caller must hold bt.mu.
+func (bt *BlockTree) leaves() []*treeNode {
+	leaves := make([]*treeNode, 0, len(bt.tips))
+	for _, node := range bt.tips {
+		leaves = append(leaves, node)
+	}
+	sort.Slice(leaves, func(i, j int) bool { return leaves[i].hash.Less(leaves[j].hash) })
+	return leaves
+}
+
+// isAncestor reports whether ancestor lies on the path from genesis to node (inclusive).
+// The walk stops below ancestor's height, so checks against the finalized block only visit
+// unfinalized blocks.
+func (bt *BlockTree) isAncestor(ancestor, node *treeNode) bool {
+	for n := node; n != nil && n.block.Header.Number >= ancestor.block.Header.Number; n = n.parent {
+		if n == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// SubscribeHeadChanges registers a subscriber for head change events.
+// Delivery never blocks the tree; events are dropped for subscribers whose buffer is full.
+func (bt *BlockTree) SubscribeHeadChanges(buffer int) *HeadSubscription {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+
+	if buffer < 0 {
+		buffer = 0
+	}
+	sub := &HeadSubscription{id: bt.nextSubID, tree: bt, ch: make(chan HeadChangeEvent, buffer)}
+	bt.nextSubID++
+	bt.subs[sub.id] = sub
+	return sub
+}
+
+// Head returns the current canonical head.
+func (bt *BlockTree) Head() *Block {
+	bt.mu.RLock()
+	defer bt.mu.RUnlock()
+	return bt.head.block
+}
+
+// Genesis returns the root block of the tree.
+func (bt *BlockTree) Genesis() *Block {
+	return bt.genesis.block
+}
+
+// GetBlock looks up a block by hash.
+func (bt *BlockTree) GetBlock(hash Hash) (*Block, bool) {
+	bt.mu.RLock()
+	defer bt.mu.RUnlock()
+	node, ok := bt.nodes[hash]
+	if !ok {
+		return nil, false
+	}
+	return node.block, true
+}
+
+// CumulativeAction returns the summed action from genesis to the given block.
+func (bt *BlockTree) CumulativeAction(hash Hash) (float64, error) {
+	bt.mu.RLock()
+	defer bt.mu.RUnlock()
+	node, ok := bt.nodes[hash]
+	if !ok {
+		return 0, fmt.Errorf("unknown block %s", hash)
+	}
+	return node.cumulativeAction, nil
+}
+
+// Branches returns one entry per viable leaf (one that extends the finalized block), ordered by tip hash.
+func (bt *BlockTree) Branches() []BranchInfo {
+	bt.mu.RLock()
+	defer bt.mu.RUnlock()
+
+	leaves := bt.leaves()
+	depth := bt.depth()
+	branches := make([]BranchInfo, 0, len(leaves))
+	for _, leaf := range leaves {
+		branches = append(branches, BranchInfo{
+			Tip:              leaf.block,
+			TipHash:          leaf.hash,
+			Length:           leaf.block.Header.Number - bt.genesis.block.Header.Number,
+			CumulativeAction: leaf.cumulativeAction,
+			WindowAction:     bt.windowAction(leaf, depth),
+		})
+	}
+	return branches
+}
+
+// IsAncestor reports whether the block with hash ancestor is on the path from genesis to descendant
+// (a block counts as its own ancestor).
+func (bt *BlockTree) IsAncestor(ancestor, descendant Hash) bool {
+	bt.mu.RLock()
+	defer bt.mu.RUnlock()
+	a, okA := bt.nodes[ancestor]
+	d, okD := bt.nodes[descendant]
+	if !okA || !okD {
+		return false
+	}
+	return bt.isAncestor(a, d)
+}
+
+// MarkFinal marks the block, and by extension all its ancestors, as final.
+// Finality only moves forward: the block must descend from the current finalized block.
+func (bt *BlockTree) MarkFinal(hash Hash) error {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+
+	node, ok := bt.nodes[hash]
+	if !ok {
+		return fmt.Errorf("unknown block %s", hash)
+	}
+	if node == bt.final || bt.isAncestor(node, bt.final) {
+		return nil // Already final
+	}
+	if !bt.isAncestor(bt.final, node) {
+		return fmt.Errorf("block %s conflicts with finalized block %s", hash, bt.final.hash)
+	}
+	bt.final = node
+	for hash, leaf := range bt.tips {
+		if !bt.isAncestor(node, leaf) {
+			delete(bt.tips, hash)
+		}
+	}
+	bt.updateHead()
+	return nil
+}
+
+// IsFinal reports whether the block is the last finalized block or one of its ancestors.
+func (bt *BlockTree) IsFinal(hash Hash) bool {
+	bt.mu.RLock()
+	defer bt.mu.RUnlock()
+	node, ok := bt.nodes[hash]
+	if !ok {
+		return false
+	}
+	return bt.isAncestor(node, bt.final)
+}
+
+// LastFinalized returns the highest finalized block (genesis until something is finalized).
+func (bt *BlockTree) LastFinalized() *Block {
+	bt.mu.RLock()
+	defer bt.mu.RUnlock()
+	return bt.final.block
+}
+
+// CanonicalChain returns the blocks from genesis to the current head, in order.
+func (bt *BlockTree) CanonicalChain() []*Block {
+	bt.mu.RLock()
+	defer bt.mu.RUnlock()
+
+	chain := make([]*Block, 0, bt.head.block.Header.Number-bt.genesis.block.Header.Number+1)
+	for n := bt.head; n != nil; n = n.parent {
+		chain = append(chain, n.block)
+	}
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain
+}
@@ -0,0 +1,276 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+// fixedActions is a simple ActionFunc for tests: a block's action is looked up by height,
+// defaulting to 1.
+func fixedActions(actions map[uint64]float64) ActionFunc {
+	return func(block *Block, parent *BlockHeader) (float64, error) {
+		if a, ok := actions[block.Header.Number]; ok {
+			return a, nil
+		}
+		return 1.0, nil
+	}
+}
+
+// childOf builds a block extending parent; salt makes sibling blocks hash differently.
+func childOf(parent *Block, salt int64) *Block {
+	parentHash, _ := parent.Hash()
+	header := &BlockHeader{
+		ParentHash: parentHash,
+		Number:     parent.Header.Number + 1,
+		Timestamp:  parent.Header.Timestamp.Add(time.Second + time.Duration(salt)),
+	}
+	return NewBlock(header, []*Transaction{})
+}
+
+func testGenesis() *Block {
+	return NewBlock(&BlockHeader{Number: 0, Timestamp: time.Unix(1700000000, 0)}, []*Transaction{})
+}
+
+func TestBlockHeader_Hash(t *testing.T) {
+	genesis := testGenesis()
+	a := childOf(genesis, 0)
+	b := childOf(genesis, 1)
+
+	hashA, _ := a.Hash()
+	hashB, _ := b.Hash()
+	if hashA == hashB {
+		t.Errorf("Sibling blocks at the same height should have different hashes")
+	}
+	again, _ := a.Hash()
+	if hashA != again {
+		t.Errorf("Block hash should be deterministic")
+	}
+	if _, err := (&Block{}).Hash(); err == nil {
+		t.Errorf("Expected error hashing block without header")
+	}
+}
+
+func TestBlockTree_AddBlock(t *testing.T) {
+	genesis := testGenesis()
+	tree, err := NewBlockTree(genesis, fixedActions(nil))
+	if err != nil {
+		t.Fatalf("NewBlockTree failed: %v", err)
+	}
+
+	t.Run("ExtendGenesis", func(t *testing.T) {
+		b1 := childOf(genesis, 0)
+		if err := tree.AddBlock(b1); err != nil {
+			t.Fatalf("AddBlock failed: %v", err)
+		}
+		if tree.Head() != b1 {
+			t.Errorf("Expected head to move to the only child")
+		}
+		hash, _ := b1.Hash()
+		if s, _ := tree.CumulativeAction(hash); s != 1.0 {
+			t.Errorf("Expected cumulative action 1.0, got %.2f", s)
+		}
+		if err := tree.AddBlock(b1); err != nil {
+			t.Errorf("Re-adding a known block should be a no-op, got %v", err)
+		}
+	})
+
+	t.Run("UnknownParent", func(t *testing.T) {
+		orphan := NewBlock(&BlockHeader{ParentHash: Hash{9}, Number: 5}, nil)
+		if err := tree.AddBlock(orphan); err == nil {
+			t.Errorf("Expected error for block with unknown parent")
+		}
+	})
+
+	t.Run("WrongNumber", func(t *testing.T) {
+		genesisHash, _ := genesis.Hash()
+		skip := NewBlock(&BlockHeader{ParentHash: genesisHash, Number: 3}, nil)
+		if err := tree.AddBlock(skip); err == nil {
+			t.Errorf("Expected error for block that skips a height")
+		}
+	})
+
+	t.Run("NilInputs", func(t *testing.T) {
+		if err := tree.AddBlock(nil); err == nil {
+			t.Errorf("Expected error adding nil block")
+		}
+		if _, err := NewBlockTree(nil, fixedActions(nil)); err == nil {
+			t.Errorf("Expected error creating tree without genesis")
+		}
+		if _, err := NewBlockTree(genesis, nil); err == nil {
+			t.Errorf("Expected error creating tree without action function")
+		}
+	})
+}
+
+func TestBlockTree_HeadSelection(t *testing.T) {
+	genesis := testGenesis()
+	// Action of each block is its timestamp offset from genesis in seconds
+	tree, _ := NewBlockTree(genesis, func(block *Block, parent *BlockHeader) (float64, error) {
+		return float64(block.Header.Timestamp.Sub(genesis.Header.Timestamp).Seconds()), nil
+	})
+	_ = tree.SetGapAction(2)
+	sub := tree.SubscribeHeadChanges(10)
+	defer sub.Unsubscribe()
+
+	a1 := childOf(genesis, 0) // action 1
+	a2 := childOf(a1, 0)      // action 2 -> cumulative 3
+	b1 := childOf(genesis, 0)
+	b1.Header.Timestamp = genesis.Header.Timestamp.Add(500 * time.Millisecond) // action 0.5
+
+	for _, b := range []*Block{a1, a2, b1} {
+		if err := tree.AddBlock(b); err != nil {
+			t.Fatalf("AddBlock failed: %v", err)
+		}
+	}
+	if tree.Head() != b1 {
+		t.Fatalf("Expected the branch with the lowest window action (b1, 0.5 plus a gap of 2 against 3) to be head")
+	}
+	if len(tree.Branches()) != 2 {
+		t.Errorf("Expected 2 branches, got %d", len(tree.Branches()))
+	}
+
+	// Events: genesis->a1, a1->a2, a2->b1 (reorg)
+	var events []HeadChangeEvent
+	for len(events) < 3 {
+		select {
+		case ev := <-sub.Events():
+			events = append(events, ev)
+		default:
+			t.Fatalf("Expected 3 head change events, got %d", len(events))
+		}
+	}
+	if events[1].Reorg || !events[2].Reorg {
+		t.Errorf("Expected only the switch to b1 to be a reorg, got %+v", events)
+	}
+	if events[2].NewHead != b1 || events[2].OldHead != a2 {
+		t.Errorf("Unexpected reorg event contents")
+	}
+
+	chain := tree.CanonicalChain()
+	if len(chain) != 2 || chain[0] != genesis || chain[1] != b1 {
+		t.Errorf("Unexpected canonical chain: %v", chain)
+	}
+	a1Hash, _ := a1.Hash()
+	a2Hash, _ := a2.Hash()
+	b1Hash, _ := b1.Hash()
+	if !tree.IsAncestor(a1Hash, a2Hash) || tree.IsAncestor(a1Hash, b1Hash) {
+		t.Errorf("IsAncestor returned unexpected results")
+	}
+}
+
+func TestBlockTree_CommonWindow(t *testing.T) {
+	genesis := testGenesis()
+	actions := make(map[Hash]float64)
+	tree, _ := NewBlockTree(genesis, func(block *Block, parent *BlockHeader) (float64, error) {
+		hash, _ := block.Hash()
+		if a, ok := actions[hash]; ok {
+			return a, nil
+		}
+		return 1.5, nil
+	})
+	parent := genesis
+	for i := 0; i < 10; i++ {
+		child := childOf(parent, 0)
+		_ = tree.AddBlock(child)
+		parent = child
+	}
+	long := parent
+
+	// A single free block forked off genesis is charged the gap action for the 9 blocks it lacks
+	cheap := childOf(genesis, 1)
+	cheapHash, _ := cheap.Hash()
+	actions[cheapHash] = 0
+	if err := tree.AddBlock(cheap); err != nil {
+		t.Fatalf("AddBlock failed: %v", err)
+	}
+	if tree.Head() != long {
+		t.Fatalf("Expected the 10-block chain (15) to beat a single block forked off genesis (9 gaps)")
+	}
+	for _, b := range tree.Branches() {
+		want := 15.0
+		if b.Tip == cheap {
+			want = 9 * DefaultActionWeights().GapAction()
+		}
+		if b.WindowAction != want {
+			t.Errorf("Expected window action %v for the branch of length %d, got %v", want, b.Length, b.WindowAction)
+		}
+	}
+
+	// Undercutting the deeper branch by more than the gap action per missing block wins
+	if err := tree.SetGapAction(1); err != nil {
+		t.Fatalf("SetGapAction failed: %v", err)
+	}
+	if tree.Head() != cheap {
+		t.Errorf("Expected the single block (9 gaps of 1) to beat the 10-block chain (15)")
+	}
+	if err := tree.SetGapAction(-1); err == nil {
+		t.Errorf("Expected a negative gap action to be rejected")
+	}
+
+	// With equal cumulative action over the window, the longer branch wins
+	flat, _ := NewBlockTree(genesis, fixedActions(map[uint64]float64{2: 0}))
+	a1, b1 := childOf(genesis, 0), childOf(genesis, 1)
+	b2 := childOf(b1, 0)
+	_ = flat.SetGapAction(0)
+	for _, b := range []*Block{a1, b1, b2} {
+		_ = flat.AddBlock(b)
+	}
+	if flat.Head() != b2 {
+		t.Errorf("Expected the longer of two branches with equal window action to be head")
+	}
+}
+
+func TestBlockTree_FinalityPrunesBranches(t *testing.T) {
+	genesis := testGenesis()
+	tree, _ := NewBlockTree(genesis, fixedActions(nil))
+	a1 := childOf(genesis, 0)
+	a2 := childOf(a1, 0)
+	b1 := childOf(genesis, 1)
+	for _, b := range []*Block{a1, a2, b1} {
+		_ = tree.AddBlock(b)
+	}
+	if len(tree.Branches()) != 2 {
+		t.Fatalf("Expected 2 branches, got %d", len(tree.Branches()))
+	}
+	a1Hash, _ := a1.Hash()
+	if err := tree.MarkFinal(a1Hash); err != nil {
+		t.Fatalf("MarkFinal failed: %v", err)
+	}
+	branches := tree.Branches()
+	if len(branches) != 1 || branches[0].Tip != a2 {
+		t.Errorf("Expected only the branch through a1 to remain, got %+v", branches)
+	}
+	if err := tree.AddBlock(childOf(b1, 0)); err == nil {
+		t.Errorf("Expected a block extending a pruned branch to be rejected")
+	}
+}
+
+func TestBlockTree_DeterministicTieBreak(t *testing.T) {
+	genesis := testGenesis()
+	x := childOf(genesis, 0)
+	y := childOf(genesis, 1)
+
+	// Insert in both orders; the head must be the same (lowest hash)
+	heads := make([]*Block, 0, 2)
+	for _, order := range [][]*Block{{x, y}, {y, x}} {
+		tree, _ := NewBlockTree(genesis, fixedActions(nil))
+		for _, b := range order {
+			if err := tree.AddBlock(b); err != nil {
+				t.Fatalf("AddBlock failed: %v", err)
+			}
+		}
+		heads = append(heads, tree.Head())
+	}
+	if heads[0] != heads[1] {
+		t.Fatalf("Head depends on insertion order")
+	}
+	xHash, _ := x.Hash()
+	yHash, _ := y.Hash()
+	want := x
+	if yHash.Less(xHash) {
+		want = y
+	}
+	if heads[0] != want {
+		t.Errorf("Expected tie to be broken by lowest hash")
+	}
+}
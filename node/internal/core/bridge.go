@@ -1,7 +1,12 @@
 package core
 
 import (
+	"bytes"
+	"encoding/gob"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time" // Placeholder for potential timeouts or epoch logic
 )
@@ -17,18 +22,29 @@ const (
 
 // BridgeIntent represents a user's request to move assets between chains.
 type BridgeIntent struct {
-	ID           Hash      // Unique identifier for the intent
-	UserAddress  string    // User's address on QRL
-	SourceChain  ChainID   // Chain the asset is coming from
-	DestChain    ChainID   // Chain the asset is going to
-	Asset        string    // Identifier of the asset (e.g., "QRG", "qETH", "qBTC")
-	Amount       uint64    // Amount to bridge
-	DestAddress  string    // User's address on the destination chain
-	Timestamp    time.Time // Time the intent was created/received
-	Status       string    // e.g., "PendingNetting", "PendingSourceLock", "PendingRelease", "Completed", "Failed"
-	SourceTxHash string    // Hash of the lock transaction on the source chain (if applicable)
-	DestTxHash   string    // Hash of the release transaction on the destination chain (if applicable)
-	// TODO: Add signature, fees, etc.
+	ID            Hash               // Unique identifier for the intent
+	UserAddress   string             // User's address on QRL
+	SourceChain   ChainID            // Chain the asset is coming from
+	DestChain     ChainID            // Chain the asset is going to
+	Asset         string             // Identifier of the asset (e.g., "QRG", "qETH", "qBTC")
+	Amount        uint64             // Amount to bridge
+	DestAddress   string             // User's address on the destination chain
+	Timestamp     time.Time          // Time the intent was created/received
+	Epoch         uint64             // Netting epoch the intent was received in
+	Status        IntentStatus       // Lifecycle state, see IntentStatus
+	Deadline      uint64             // Block height at which the intent expires in its current state; 0 if none
+	History       []IntentTransition // Audit trail of status transitions, oldest first
+	SourceTxHash  string             // Hash of the lock transaction on the source chain (if applicable)
+	SourceBlock   Hash               // Block of the lock transaction, if its chain is verified with SPV
+	DestTxHash    string             // Hash of the release transaction on the destination chain (if applicable)
+	RefundAddress string             // Address the source-chain deposit came from, refunds go there
+	RefundTxHash  string             // Hash of the refund transaction on the source chain (if applicable)
+	Fee           uint64             // Fee in the native asset paid by the user
+	Nonce         uint64             // Chosen by the user to distinguish otherwise identical intents
+	SourceFinal   bool               // The source lock reached finality
+	EarlyRelease  bool               // Paid out from inventory before the source lock was final
+	FromCustody   bool               // Paid out of netted deposits held in custody instead of minted, see BridgeManager.custody
+	Signature     Signature          // User's signature over ID
 }
 
 // BridgeManager handles the logic for cross-chain bridging.
@@ -36,76 +52,695 @@ type BridgeManager struct {
 	mu sync.RWMutex
 	// Store pending intents, perhaps grouped by epoch or destination chain for netting
 	pendingIntents map[Hash]*BridgeIntent
-	// Store state related to inventory pools on different chains (if managed here)
-	inventory map[ChainID]map[string]uint64 // map[ChainID]map[AssetID]Balance
-	// TODO: Add dependencies like StateManager, P2P interface, Oracles for external chain events
+	// Inventory pools on the different chains, see InventoryPool
+	inventory    map[ChainID]map[string]*InventoryPool // map[ChainID]map[AssetID]Pool
+	inventoryCfg InventoryConfig
+	feeModel     BridgeFeeModel
+	fees         uint64                          // Fees of accepted intents, collected into BridgeFeeAccount
+	pauses       map[string]BridgePause          // Pauses set by the manager, by scope
+	statePauses  map[string]BridgePause          // Pauses recorded in the watched chain state, see syncPauses
+	volume       map[string]uint64               // Value bridged per limit scope in the current epoch
+	custody      map[ChainID]map[string]uint64   // Final deposits of wrapped assets kept in custody for netting, see keepsCustody
+	finalTxs     map[ChainID]map[string]struct{} // External transactions seen final, to detect deep reorgs
+	spv          map[ChainID]*spvVerifier        // Header chains verifying lock events, see EnableSPV
+	deferred     []ExternalChainEvent            // Verified lock events waiting for confirmations
+	releases     []BridgeRelease                 // Settled payouts of escrowed intents to report, see ReleaseReports
+	store        StateDB                         // Persisted intents, see AttachStore; nil keeps them in memory only
+	state        StateDB                         // Chain state followed for escrowed intents and pauses, see WatchState
+	dirty        map[Hash]*BridgeIntent          // Intents changed since they were last persisted
+	epoch        uint64                          // Netting epoch new intents are assigned to
+	height       uint64                          // Latest QRL block height, drives intent deadlines
+	timeouts     IntentTimeouts
+	// Connections to the external chains, keyed by chain
+	adapters map[ChainID]ChainAdapter
+	// IDs of all intents ever accepted, to reject resubmissions
+	seen map[Hash]struct{}
+	// TODO: Add dependencies like StateManager, P2P interface
 }
 
 // NewBridgeManager creates a new bridge manager.
 func NewBridgeManager() *BridgeManager {
 	return &BridgeManager{
 		pendingIntents: make(map[Hash]*BridgeIntent),
-		inventory:      make(map[ChainID]map[string]uint64),
+		inventory:      make(map[ChainID]map[string]*InventoryPool),
+		inventoryCfg:   DefaultInventoryConfig(),
+		feeModel:       DefaultBridgeFeeModel(),
+		pauses:         make(map[string]BridgePause),
+		statePauses:    make(map[string]BridgePause),
+		volume:         make(map[string]uint64),
+		custody:        make(map[ChainID]map[string]uint64),
+		finalTxs:       make(map[ChainID]map[string]struct{}),
+		spv:            make(map[ChainID]*spvVerifier),
+		dirty:          make(map[Hash]*BridgeIntent),
+		adapters:       make(map[ChainID]ChainAdapter),
+		timeouts:       DefaultIntentTimeouts(),
+		seen:           make(map[Hash]struct{}),
 	}
 }
 
-// HandleBridgeIntent receives and processes a new bridge intent.
-// Placeholder implementation.
-func (bm *BridgeManager) HandleBridgeIntent(intent *BridgeIntent) error {
+// SetIntentTimeouts sets the per-state deadlines applied to subsequent intent transitions.
+func (bm *BridgeManager) SetIntentTimeouts(timeouts IntentTimeouts) {
 	bm.mu.Lock()
 	defer bm.mu.Unlock()
+	bm.timeouts = timeouts
+}
 
-	if intent == nil {
-		return fmt.Errorf("cannot handle nil bridge intent")
+// WatchState makes the manager follow db, the chain state: it releases the intents leaving QRL that
+// state escrowed and netted (see SubmitReleases) and applies the pauses recorded there (see
+// AdvanceHeight).
+func (bm *BridgeManager) WatchState(db StateDB) {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	bm.state = db
+}
+
+// RegisterAdapter connects the bridge to an external chain.
+func (bm *BridgeManager) RegisterAdapter(adapter ChainAdapter) error {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+
+	chain := adapter.Chain()
+	if chain == ChainID_QRL {
+		return fmt.Errorf("QRL is not an external chain")
 	}
+	if _, exists := bm.adapters[chain]; exists {
+		return fmt.Errorf("adapter for chain %s already registered", chain)
+	}
+	bm.adapters[chain] = adapter
+	return nil
+}
 
-	// TODO: Validate intent (signature, asset validity, amount, addresses)
-	// TODO: Calculate intent hash (ID)
-	// TODO: Store intent, potentially group for netting
+// HandleBridgeIntent validates a signed intent (see BridgeIntent.ValidateBasic) and queues it for
+// netting in the current epoch. Intents already accepted once, paying less than the current fee
+// quote (see QuoteFee), stopped by a pause or exceeding the epoch caps are rejected. Intents leaving
+// QRL must escrow their funds with a bridge intent transaction instead; the manager releases them
+// once state nets them (see SubmitReleases).
+func (bm *BridgeManager) HandleBridgeIntent(intent *BridgeIntent) (err error) {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	defer func() { err = errors.Join(err, bm.flush()) }()
 
-	// Placeholder: Just store it for now
-	intent.ID = Hash{}                                                          // Placeholder ID
-	copy(intent.ID[:], []byte(fmt.Sprintf("intent-%d", time.Now().UnixNano()))) // Very basic unique ID
-	intent.Status = "PendingNetting"                                            // Example initial status
+	if intent == nil {
+		return fmt.Errorf("cannot handle nil bridge intent")
+	}
+	if err := intent.ValidateBasic(); err != nil {
+		return fmt.Errorf("invalid bridge intent: %w", err)
+	}
+	if intent.SourceChain == ChainID_QRL {
+		// The manager cannot see an escrow it did not record, see trackEscrowedIntents
+		return fmt.Errorf("intents leaving QRL must be submitted as bridge intent transactions")
+	}
+	if _, exists := bm.seen[intent.ID]; exists {
+		return fmt.Errorf("bridge intent with ID %s already exists", intent.ID)
+	}
+	if bm.store != nil {
+		if stored, err := GetBridgeIntent(bm.store, intent.ID); err != nil || stored != nil {
+			return errors.Join(err, fmt.Errorf("bridge intent with ID %s already exists", intent.ID))
+		}
+	}
+	quote, err := bm.quoteFee(intent.Asset, intent.SourceChain, intent.DestChain, intent.Amount)
+	if err != nil {
+		return fmt.Errorf("invalid bridge intent: %w", err)
+	}
+	if intent.Fee < quote.Fee {
+		return fmt.Errorf("intent fee %d below quoted fee %d", intent.Fee, quote.Fee)
+	}
+	if err := bm.checkPaused(intent); err != nil {
+		return err
+	}
+	if err := checkBridgeVolume(intent, func(scope string) (uint64, error) { return bm.volume[scope], nil }); err != nil {
+		return err
+	}
 
-	if _, exists := bm.pendingIntents[intent.ID]; exists {
-		return fmt.Errorf("bridge intent with ID %x already exists", intent.ID)
+	intent.Epoch = bm.epoch
+	intent.Status, intent.History = "", nil
+	if err := bm.transition(intent, IntentPendingNetting, "received"); err != nil {
+		return err
 	}
 	bm.pendingIntents[intent.ID] = intent
-	fmt.Printf("BridgeManager: Handled intent %x from %s (%s -> %s)\n", intent.ID, intent.UserAddress, intent.SourceChain, intent.DestChain)
+	bm.seen[intent.ID] = struct{}{}
+	bm.fees += intent.Fee
+	for _, limit := range bridgeVolumeLimits(intent) {
+		bm.volume[limit.Scope] += intent.Amount
+	}
+	fmt.Printf("BridgeManager: Handled intent %s from %s (%s -> %s)\n", intent.ID, intent.UserAddress, intent.SourceChain, intent.DestChain)
 
 	return nil
 }
 
-// ProcessNettingEpoch calculates net flows and initiates required transfers.
-// Placeholder implementation.
-func (bm *BridgeManager) ProcessNettingEpoch( /* epoch identifier? */ ) error {
+// ProcessNettingEpoch nets the pending intents received up to and including epoch (see NetIntents)
+// and moves new intents to the following epoch. Netted intents stay tracked until they complete.
+func (bm *BridgeManager) ProcessNettingEpoch(epoch uint64) (*NettingReport, error) {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+
+	if epoch < bm.epoch {
+		return nil, fmt.Errorf("epoch %d already netted, current epoch is %d", epoch, bm.epoch)
+	}
+	var intents []*BridgeIntent
+	for _, intent := range bm.pendingIntents {
+		if intent.Status == IntentPendingNetting && intent.Epoch <= epoch {
+			intents = append(intents, intent)
+		}
+	}
+	report, err := NetIntents(epoch, bm.height, bm.timeouts, intents)
+	if err != nil {
+		return nil, err
+	}
+	for _, intent := range intents {
+		bm.dirty[intent.ID] = intent
+		if intent.Status.IsTerminal() {
+			delete(bm.pendingIntents, intent.ID)
+		}
+	}
+	bm.epoch = epoch + 1
+	bm.volume = make(map[string]uint64)
+	if err := bm.flush(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// HandleExternalChainEvent advances the intent an external chain event refers to: a deposit into
+// custody carrying an intent's ID as memo locks it, and makes it PendingRelease once final; a final
+// release (or mint) completes it. A reorganized deposit returns the intent to PendingSourceLock, a
+// failed release refunds it. Final deposits replenish the source chain's inventory pool and clear
+// the exposure of early payouts. A transaction reorganized out after it was final pauses its chain.
+// On chains with SPV enabled, lock events must prove their inclusion and wait for confirmations in
+// the tracked headers.
+func (bm *BridgeManager) HandleExternalChainEvent(event ExternalChainEvent) (err error) {
 	bm.mu.Lock()
 	defer bm.mu.Unlock()
+	defer func() { err = errors.Join(err, bm.flush()) }()
 
-	fmt.Println("Warning: ProcessNettingEpoch placeholder called")
-	// TODO: Implement netting logic:
-	// 1. Select intents for the current epoch.
-	// 2. Calculate net flow for each asset between each pair of chains.
-	// 3. Initiate native chain transactions (lock/unlock/mint/burn) for the net amounts.
-	// 4. Update intent statuses.
-	// 5. Manage inventory if applicable.
+	if ready, err := bm.verifyLockEvent(event); err != nil {
+		return err
+	} else if !ready || (event.Type == ExternalEventFinalized && bm.isDeferred(event)) {
+		// A deposit's finalization waits for the deposit to lock its intent
+		bm.deferred = append(bm.deferred, event)
+		return nil
+	}
+	switch event.Type {
+	case ExternalEventFinalized:
+		if bm.finalTxs[event.Chain] == nil {
+			bm.finalTxs[event.Chain] = make(map[string]struct{})
+		}
+		bm.finalTxs[event.Chain][event.TxHash] = struct{}{}
+	case ExternalEventReorged:
+		if _, final := bm.finalTxs[event.Chain][event.TxHash]; final {
+			bm.pause(ChainPauseScope(event.Chain), fmt.Sprintf("final transaction %s reorganized out at height %d", event.TxHash, event.Height), true)
+		}
+	}
+	if event.Type == ExternalEventDeposit {
+		var id Hash
+		if err := id.UnmarshalText([]byte(event.Memo)); err != nil {
+			return fmt.Errorf("deposit %s on %s does not reference an intent: %w", event.TxHash, event.Chain, err)
+		}
+		intent, ok := bm.pendingIntents[id]
+		if !ok {
+			return fmt.Errorf("deposit %s on %s references unknown intent %s", event.TxHash, event.Chain, id)
+		}
+		if intent.SourceTxHash == event.TxHash {
+			return nil // Re-included after a reorg that did not undo the lock
+		}
+		if intent.Status != IntentPendingSourceLock {
+			return fmt.Errorf("intent %s is not awaiting a deposit (status %s)", id, intent.Status)
+		}
+		if intent.SourceChain != event.Chain || intent.Asset != event.Asset || event.Amount < intent.Amount {
+			return fmt.Errorf("deposit %s of %d %s on %s does not match intent %s", event.TxHash, event.Amount, event.Asset, event.Chain, id)
+		}
+		intent.SourceTxHash, intent.RefundAddress = event.TxHash, event.Address
+		if event.Proof != nil {
+			intent.SourceBlock = event.Proof.BlockHash
+		}
+		return bm.transition(intent, IntentLocked, fmt.Sprintf("deposit %s at %s height %d", event.TxHash, event.Chain, event.Height))
+	}
+
+	for _, intent := range bm.pendingIntents {
+		switch {
+		case intent.SourceTxHash == event.TxHash && intent.SourceChain == event.Chain:
+			switch {
+			case event.Type == ExternalEventFinalized && !intent.SourceFinal:
+				waiting := awaitsSourceFinality(intent)
+				bm.finalizeSource(intent)
+				if waiting {
+					return bm.settle(intent, IntentRefunded, "deposit final after the early release failed")
+				}
+				if intent.Status == IntentLocked {
+					return bm.transition(intent, IntentPendingRelease, "deposit final")
+				}
+			case event.Type == ExternalEventReorged && intent.Status == IntentLocked:
+				intent.SourceTxHash, intent.SourceBlock = "", Hash{}
+				return bm.transition(intent, IntentPendingSourceLock, "deposit reorganized out")
+			}
+			return nil
+		case intent.DestTxHash == event.TxHash && intent.DestChain == event.Chain && intent.Status == IntentPendingRelease:
+			switch event.Type {
+			case ExternalEventFinalized:
+				return bm.settle(intent, IntentCompleted, "release final")
+			case ExternalEventFailed:
+				if pool := bm.pool(intent.DestChain, intent.Asset); pool != nil {
+					pool.Balance += intent.Amount
+					if intent.EarlyRelease && !intent.SourceFinal {
+						pool.Exposure -= intent.Amount
+					}
+				}
+				if intent.FromCustody {
+					bm.addCustody(intent.DestChain, intent.Asset, intent.Amount)
+				}
+				early := intent.EarlyRelease && !intent.SourceFinal
+				intent.EarlyRelease, intent.FromCustody = false, false
+				if early {
+					// Refunding a deposit that may still be reorganized out could pay the user twice;
+					// wait for the lock to be final (see awaitsSourceFinality)
+					intent.DestTxHash = ""
+					bm.dirty[intent.ID] = intent
+					return nil
+				}
+				return bm.settle(intent, IntentRefunded, fmt.Sprintf("release %s failed", event.TxHash))
+			}
+			return nil
+		}
+	}
+	return nil // Not about a tracked intent, e.g. another user's transaction
+}
+
+// ReleaseReports returns the payouts of intents leaving QRL settled since the last call. The bridge
+// operators report each on chain with a TxTypeBridgeRelease transaction, which completes the intent
+// or refunds its escrow.
+func (bm *BridgeManager) ReleaseReports() []BridgeRelease {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	releases := bm.releases
+	bm.releases = nil
+	return releases
+}
 
-	return fmt.Errorf("ProcessNettingEpoch not implemented")
+// finalizeSource records that the source lock of intent is final: the deposit joins the source
+// pool and an early payout stops being exposure. An intent that completed before its lock was final
+// is no longer tracked afterwards. Caller must hold bm.mu.
+func (bm *BridgeManager) finalizeSource(intent *BridgeIntent) {
+	intent.SourceFinal = true
+	bm.dirty[intent.ID] = intent
+	if pool := bm.pool(intent.SourceChain, intent.Asset); pool != nil {
+		pool.Balance += intent.Amount
+	} else if bm.keepsCustody(intent.SourceChain, intent.Asset) {
+		bm.addCustody(intent.SourceChain, intent.Asset, intent.Amount)
+	}
+	if pool := bm.pool(intent.DestChain, intent.Asset); pool != nil && intent.EarlyRelease {
+		pool.Exposure -= intent.Amount
+	}
+	if intent.Status.IsTerminal() {
+		delete(bm.pendingIntents, intent.ID)
+	}
+}
+
+// AdvanceHeight records the latest QRL block height, picks up the pauses recorded in the watched
+// chain state and expires the intents whose deadline has passed, refunding the deposits the bridge
+// holds for them. Intents with a payout in flight wait for its outcome instead, and intents whose
+// early payout failed wait for their lock to be final.
+func (bm *BridgeManager) AdvanceHeight(height uint64) error {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+
+	if err := bm.syncPauses(); err != nil {
+		return err
+	}
+
+	bm.height = height
+	ids := make([]Hash, 0, len(bm.pendingIntents))
+	for id, intent := range bm.pendingIntents {
+		if intent.Expired(height) && intent.DestTxHash == "" && !awaitsSourceFinality(intent) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].Less(ids[j]) })
+	var errs []error
+	for _, id := range ids {
+		intent := bm.pendingIntents[id]
+		if err := bm.settle(intent, intent.expiryStatus(), fmt.Sprintf("deadline %d passed in %s", intent.Deadline, intent.Status)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(append(errs, bm.flush())...)
+}
+
+// settle moves intent to a terminal status and stops tracking it, unless it was paid out early and
+// its source lock is not final yet. A refunded deposit on an external source chain is paid back to
+// its sender; the outcome of a payout of native funds escrowed on QRL is queued for ReleaseReports.
+// Caller must hold bm.mu.
+func (bm *BridgeManager) settle(intent *BridgeIntent, status IntentStatus, reason string) error {
+	from := intent.Status
+	if err := bm.transition(intent, status, reason); err != nil {
+		return err
+	}
+	if intent.SourceChain == ChainID_QRL && from == IntentPendingRelease {
+		release := BridgeRelease{ID: intent.ID, Reason: reason}
+		if status == IntentCompleted {
+			release = BridgeRelease{ID: intent.ID, DestTxHash: intent.DestTxHash}
+		}
+		bm.releases = append(bm.releases, release)
+	}
+	if !intent.EarlyRelease || intent.SourceFinal {
+		delete(bm.pendingIntents, intent.ID)
+	}
+	if status != IntentRefunded || intent.SourceChain == ChainID_QRL || intent.SourceTxHash == "" {
+		return nil
+	}
+	kind := InstructionRelease
+	if pool := bm.pool(intent.SourceChain, intent.Asset); pool != nil && intent.SourceFinal {
+		pool.Balance -= min(pool.Balance, intent.Amount)
+		bm.checkFloor(pool)
+	} else if bm.keepsCustody(intent.SourceChain, intent.Asset) && intent.SourceFinal && !bm.takeCustody(intent.SourceChain, intent.Asset, intent.Amount) {
+		// The deposit paid out an opposing intent whose payout stands; the failed payout of this
+		// one left its backing on the home chain
+		kind = InstructionMint
+	}
+	adapter, ok := bm.adapters[intent.SourceChain]
+	if !ok {
+		return fmt.Errorf("no adapter for chain %s to refund intent %s", intent.SourceChain, intent.ID)
+	}
+	txHash, err := adapter.Submit(ExternalTx{Kind: kind, Asset: intent.Asset, Address: intent.RefundAddress, Amount: intent.Amount, Memo: fmt.Sprintf("%x", intent.ID[:])})
+	if err != nil {
+		return fmt.Errorf("failed to submit refund of intent %s: %w", intent.ID, err)
+	}
+	intent.RefundTxHash = txHash
+	return nil
+}
+
+// SyncExternalChains polls every registered adapter and handles the events observed since the last
+// sync, after updating the chain's tracked headers if SPV is enabled for it. Lock events still
+// waiting for confirmations are retried last. Events that cannot be applied are reported together
+// after all events were handled.
+func (bm *BridgeManager) SyncExternalChains() error {
+	bm.mu.RLock()
+	chains := make([]ChainID, 0, len(bm.adapters))
+	for chain := range bm.adapters {
+		chains = append(chains, chain)
+	}
+	bm.mu.RUnlock()
+	sort.Slice(chains, func(i, j int) bool { return chains[i] < chains[j] })
+
+	var errs []error
+	for _, chain := range chains {
+		bm.mu.RLock()
+		adapter, spv := bm.adapters[chain], bm.spv[chain]
+		bm.mu.RUnlock()
+		if src, ok := adapter.(HeaderSource); ok && spv != nil {
+			if err := spv.headers.SyncHeaders(src); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		events, err := adapter.PollEvents()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to poll %s: %w", chain, err))
+			continue
+		}
+		for _, event := range events {
+			if err := bm.HandleExternalChainEvent(event); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if err := bm.retryDeferred(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
 }
 
-// HandleExternalChainEvent processes events from external chains (e.g., lock confirmation).
-// Placeholder implementation.
-func (bm *BridgeManager) HandleExternalChainEvent( /* event details */ ) error {
+// SubmitReleases pays out every PendingRelease intent bound for an external chain that has no
+// release in flight: from the destination inventory pool if there is one (intents wait while it
+// lacks the balance), otherwise by releasing the asset on its home chain, or elsewhere out of the
+// netted deposits in custody and minting it where they fall short (see keepsCustody).
+// Paused intents wait until the pause is lifted. Intents leaving QRL are taken from the watched chain
+// state once it nets them, so without WatchState they are never paid.
+func (bm *BridgeManager) SubmitReleases() (err error) {
 	bm.mu.Lock()
 	defer bm.mu.Unlock()
+	defer func() { err = errors.Join(err, bm.flush()) }()
+
+	if err := bm.trackEscrowedIntents(); err != nil {
+		return err
+	}
+	ids := make([]Hash, 0, len(bm.pendingIntents))
+	for id, intent := range bm.pendingIntents {
+		if intent.Status == IntentPendingRelease && intent.DestTxHash == "" && intent.DestChain != ChainID_QRL && escrowedOutflow(intent) && !awaitsSourceFinality(intent) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].Less(ids[j]) })
+	for _, id := range ids {
+		intent := bm.pendingIntents[id]
+		pool := bm.pool(intent.DestChain, intent.Asset)
+		if (pool != nil && pool.Balance < intent.Amount) || bm.checkPaused(intent) != nil {
+			continue
+		}
+		if err := bm.submitPayout(intent, pool); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trackEscrowedIntents starts tracking the intents leaving QRL that the watched chain state netted
+// for release. Bridge intent transactions record them there after escrowing their amount (see
+// applyBridgeIntentTx). Caller must hold bm.mu.
+func (bm *BridgeManager) trackEscrowedIntents() error {
+	if bm.state == nil {
+		return nil
+	}
+	intents, err := BridgeIntentsByStatus(bm.state, IntentPendingRelease)
+	if err != nil {
+		return err
+	}
+	for _, intent := range intents {
+		if _, tracked := bm.pendingIntents[intent.ID]; tracked || intent.SourceChain != ChainID_QRL || !escrowedOutflow(intent) {
+			continue
+		}
+		bm.pendingIntents[intent.ID] = intent
+		bm.seen[intent.ID] = struct{}{}
+		bm.dirty[intent.ID] = intent
+	}
+	return nil
+}
+
+// awaitsSourceFinality reports whether intent was paid out early from inventory, the payout failed
+// and its source lock is not final yet. It stays PendingRelease without a payout in flight, past
+// its deadline too, and is refunded once the lock is final.
+func awaitsSourceFinality(intent *BridgeIntent) bool {
+	return intent.Status == IntentPendingRelease && intent.DestTxHash == "" && intent.SourceChain != ChainID_QRL && !intent.SourceFinal
+}
+
+// escrowedOutflow reports whether the asset of intent can have been escrowed: only the native asset
+// leaves QRL (see validateBridgeIntentTx).
+func escrowedOutflow(intent *BridgeIntent) bool {
+	return intent.SourceChain != ChainID_QRL || intent.Asset == NativeAsset
+}
+
+// --- Bridge intent transactions ---
+//
+// A TxTypeBridgeIntent transaction records an intent in state so every node sees the same intents
+// for netting. Intents leaving QRL escrow their amount of the native asset with BridgeEscrowAccount.
+
+// Storage key prefixes for bridge state.
+const (
+	bridgeIntentPrefix = "bridge/intent/" // bridge/intent/<id> -> BridgeIntent
+	bridgeEpochPrefix  = "bridge/epoch/"  // bridge/epoch/<epoch>/<id> -> marker indexing intents by submission epoch
+	bridgeOpenPrefix   = "bridge/open/"   // bridge/open/<id> -> marker indexing intents in a non-terminal state
+)
+
+// GasBridgeIntent is the gas charged for a bridge intent on top of the intrinsic gas.
+const GasBridgeIntent uint64 = 10000
+
+// NativeAsset is the asset identifier of the QRL native token.
+const NativeAsset = "QRG"
+
+// BridgeEscrowAccount holds native funds locked by intents leaving QRL until they are released.
+const BridgeEscrowAccount = "bridge-escrow"
+
+// bridgeIntentKey returns the storage key of intent id.
+func bridgeIntentKey(id Hash) string {
+	return fmt.Sprintf("%s%x", bridgeIntentPrefix, id[:])
+}
+
+// bridgeEpochKey returns the storage key indexing intent id under epoch.
+func bridgeEpochKey(epoch uint64, id Hash) string {
+	return fmt.Sprintf("%s%020d/%x", bridgeEpochPrefix, epoch, id[:])
+}
+
+// bridgeOpenKey returns the storage key marking intent id as open.
+func bridgeOpenKey(id Hash) string {
+	return fmt.Sprintf("%s%x", bridgeOpenPrefix, id[:])
+}
+
+// NewBridgeIntentTransaction creates an unsigned transaction submitting intent, which must be signed
+// by its user (see BridgeIntent.Sign). Only the fields chosen by the user are submitted, the rest
+// is assigned when it is applied. Intents leaving QRL carry their amount.
+func NewBridgeIntentTransaction(nonce uint64, intent *BridgeIntent) (*Transaction, error) {
+	if intent == nil {
+		return nil, fmt.Errorf("cannot submit nil bridge intent")
+	}
+	data, err := encodeBridgeIntent(intentRequest(intent))
+	if err != nil {
+		return nil, err
+	}
+	var amount uint64
+	if intent.SourceChain == ChainID_QRL {
+		amount = intent.Amount
+	}
+	tx := NewBaseTransaction(TxTypeBridgeIntent, nonce, intent.UserAddress, "", amount)
+	tx.Payload = data
+	return tx, nil
+}
+
+// intentRequest returns the fields of intent chosen by the user, with its ID and signature;
+// everything else is assigned by the bridge.
+func intentRequest(intent *BridgeIntent) *BridgeIntent {
+	return &BridgeIntent{
+		ID:          intent.ID,
+		Signature:   intent.Signature,
+		Fee:         intent.Fee,
+		Nonce:       intent.Nonce,
+		UserAddress: intent.UserAddress,
+		SourceChain: intent.SourceChain,
+		DestChain:   intent.DestChain,
+		Asset:       intent.Asset,
+		Amount:      intent.Amount,
+		DestAddress: intent.DestAddress,
+	}
+}
+
+// encodeBridgeIntent serializes an intent using gob encoding.
+func encodeBridgeIntent(intent *BridgeIntent) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(intent); err != nil {
+		return nil, fmt.Errorf("failed to encode bridge intent: %w", err)
+	}
+	return buf.Bytes(), nil
+}
 
-	fmt.Println("Warning: HandleExternalChainEvent placeholder called")
-	// TODO: Implement logic to update intent status based on external events.
-	// - e.g., Mark intent as "PendingRelease" after sufficient source chain confirmations.
-	// - Trigger probabilistic release if applicable.
+// decodeBridgeIntent deserializes an intent encoded by encodeBridgeIntent.
+func decodeBridgeIntent(data []byte) (*BridgeIntent, error) {
+	var intent BridgeIntent
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&intent); err != nil {
+		return nil, fmt.Errorf("failed to decode bridge intent: %w", err)
+	}
+	return &intent, nil
+}
+
+// GetBridgeIntent returns the intent with the given ID from state, or nil if unknown.
+func GetBridgeIntent(db StateDB, id Hash) (*BridgeIntent, error) {
+	intent := &BridgeIntent{}
+	found, err := getStorageGob(db, bridgeIntentKey(id), intent)
+	if err != nil || !found {
+		return nil, err
+	}
+	return intent, nil
+}
 
-	return fmt.Errorf("HandleExternalChainEvent not implemented")
+// BridgeIntentIDs returns the IDs of the intents submitted during epoch, in key order.
+func BridgeIntentIDs(db StateDB, epoch uint64) ([]Hash, error) {
+	return bridgeIndexIDs(db, fmt.Sprintf("%s%020d/", bridgeEpochPrefix, epoch))
 }
 
-// TODO: Add methods for probabilistic release, inventory management, etc.
+// OpenBridgeIntentIDs returns the IDs of the intents in a non-terminal state, in key order.
+func OpenBridgeIntentIDs(db StateDB) ([]Hash, error) {
+	return bridgeIndexIDs(db, bridgeOpenPrefix)
+}
+
+// bridgeIndexIDs returns the intent IDs indexed under prefix.
+func bridgeIndexIDs(db StateDB, prefix string) ([]Hash, error) {
+	keys, err := db.StorageKeys(prefix)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]Hash, 0, len(keys))
+	for _, key := range keys {
+		var id Hash
+		if err := id.UnmarshalText([]byte(strings.TrimPrefix(key, prefix))); err != nil {
+			return nil, fmt.Errorf("malformed bridge index key %s: %w", key, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// validateBridgeIntentTx checks the signed intent carried by a bridge intent transaction, that it
+// pays at least the lowest quoted fee, that no pause stops it and it fits the epoch caps, and that
+// the sender can pay its fee and, for intents leaving QRL, escrow its amount.
+func validateBridgeIntentTx(ctx *NativeContext, tx *Transaction) error {
+	decoded, err := decodeBridgeIntent(tx.Payload)
+	if err != nil {
+		return err
+	}
+	intent := intentRequest(decoded)
+	if intent.UserAddress != tx.SenderID {
+		return fmt.Errorf("intent user %s does not match sender %s", intent.UserAddress, tx.SenderID)
+	}
+	if err := intent.ValidateBasic(); err != nil {
+		return err
+	}
+	if minFee := DefaultBridgeFeeModel().MinFee(); intent.Fee < minFee {
+		return fmt.Errorf("intent fee %d below minimum %d", intent.Fee, minFee)
+	}
+	if existing, err := GetBridgeIntent(ctx.State.db, intent.ID); err != nil {
+		return err
+	} else if existing != nil {
+		return fmt.Errorf("bridge intent %s already submitted", intent.ID)
+	}
+	if err := ctx.State.checkBridgeLimits(intent, ctx.State.epochs.EpochOf(ctx.State.blockNumber())); err != nil {
+		return err
+	}
+	if intent.SourceChain != ChainID_QRL {
+		if tx.Amount != 0 {
+			return fmt.Errorf("intent from %s must not carry a QRL amount", intent.SourceChain)
+		}
+	} else {
+		if intent.Asset != NativeAsset {
+			return fmt.Errorf("only %s can be bridged out of QRL, got %s", NativeAsset, intent.Asset)
+		}
+		if tx.Amount != intent.Amount {
+			return fmt.Errorf("transaction amount %d does not match intent amount %d", tx.Amount, intent.Amount)
+		}
+	}
+	if ctx.Spendable < tx.Amount || ctx.Spendable-tx.Amount < intent.Fee {
+		return fmt.Errorf("insufficient funds: sender %s has %d, needs %d plus fee %d", tx.SenderID, ctx.Spendable, tx.Amount, intent.Fee)
+	}
+	return nil
+}
+
+// applyBridgeIntentTx escrows the amount of intents leaving QRL, collects the intent fee and
+// records the intent as PendingNetting under its content-addressed ID.
+func applyBridgeIntentTx(ctx *NativeContext, tx *Transaction) error {
+	sm := ctx.State
+	decoded, err := decodeBridgeIntent(tx.Payload)
+	if err != nil {
+		return err
+	}
+	intent := intentRequest(decoded)
+	if err := intent.Transition(IntentPendingNetting, sm.blockNumber(), stateIntentTimeouts(), "submitted"); err != nil {
+		return err
+	}
+	intent.Epoch = sm.epochs.EpochOf(sm.blockNumber())
+	if sm.block != nil {
+		intent.Timestamp = sm.block.Timestamp.UTC() // Same encoding on every node
+	}
+	if err := sm.debit(tx.SenderID, tx.Amount+intent.Fee); err != nil {
+		return err
+	}
+	if err := sm.credit(BridgeEscrowAccount, tx.Amount); err != nil {
+		return err
+	}
+	if err := sm.credit(BridgeFeeAccount, intent.Fee); err != nil {
+		return err
+	}
+	if err := sm.putBridgeIntent(intent); err != nil {
+		return err
+	}
+	if err := sm.addBridgeVolume(intent); err != nil {
+		return err
+	}
+	ctx.Emit("bridge.intent", intent.ID[:])
+	return nil
+}
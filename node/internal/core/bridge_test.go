@@ -1,6 +1,8 @@
 package core
 
 import (
+	"fmt"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -20,13 +22,15 @@ func TestBridge_IntentHandling(t *testing.T) {
 
 	intent2 := &BridgeIntent{
 		UserAddress: "userB_qrl",
-		SourceChain: ChainID_QRL,
-		DestChain:   ChainID_Bitcoin,
+		SourceChain: ChainID_Bitcoin,
+		DestChain:   ChainID_QRL,
 		Asset:       "qBTC",
 		Amount:      50000000, // 0.5 BTC in satoshis
-		DestAddress: "userB_btc_addr",
+		DestAddress: "userB_qrl",
 		Timestamp:   time.Now().Add(time.Second),
 	}
+	signedIntent(intent1)
+	signedIntent(intent2)
 
 	t.Run("HandleValidIntent", func(t *testing.T) {
 		err := manager.HandleBridgeIntent(intent1)
@@ -67,14 +71,25 @@ func TestBridge_IntentHandling(t *testing.T) {
 	})
 
 	t.Run("HandleDuplicateIntent", func(t *testing.T) {
-		// Assumes HandleBridgeIntent prevents adding exact same intent object or one with same calculated ID
-		// The current placeholder calculates ID based on timestamp, so this might not fail reliably yet.
-		// TODO: Refine this test when proper ID calculation and validation are added.
+		// Intents are content-addressed, so resubmitting intent1 yields the same ID
 		err := manager.HandleBridgeIntent(intent1) // Try adding intent1 again
 		if err == nil {
-			// This might pass with current placeholder ID logic, but shouldn't ideally
-			t.Logf("Warning: Adding duplicate intent did not return an error (may be due to placeholder ID logic)")
-			// t.Errorf("Expected error when handling duplicate intent ID, but got nil")
+			t.Errorf("Expected error when handling duplicate intent ID, but got nil")
+		}
+	})
+
+	t.Run("HandleUnsignedIntent", func(t *testing.T) {
+		unsigned := *intent2
+		unsigned.Nonce++
+		if err := manager.HandleBridgeIntent(&unsigned); err == nil {
+			t.Errorf("Expected error when handling an intent whose signature does not cover it, but got nil")
+		}
+	})
+
+	t.Run("HandleOutflow", func(t *testing.T) {
+		outflow := signedIntent(&BridgeIntent{UserAddress: "userA_qrl", SourceChain: ChainID_QRL, DestChain: ChainID_Ethereum, Asset: NativeAsset, Amount: 100, DestAddress: testETHAddress})
+		if err := manager.HandleBridgeIntent(outflow); err == nil {
+			t.Errorf("Expected intent leaving QRL without an escrow to be rejected")
 		}
 	})
 
@@ -86,7 +101,321 @@ func TestBridge_IntentHandling(t *testing.T) {
 	})
 }
 
-// TODO: Add TestBridge_NettingCalculation
-// TODO: Add TestBridge_ProbabilisticRelease
-// TODO: Add TestBridge_InventoryManagement
+func TestBridge_IntentTransactions(t *testing.T) {
+	db := NewInMemoryStateDB()
+	_ = db.SetBalance("userA_qrl", 1000)
+	sm := NewStateManager(db)
+	_ = sm.BeginBlock(&BlockHeader{Number: 150, Proposer: "proposerP"})
+	submit := func(nonce uint64, intent *BridgeIntent) (*Transaction, error) {
+		tx, err := NewBridgeIntentTransaction(nonce, signedIntent(intent))
+		if err != nil {
+			t.Fatalf("NewBridgeIntentTransaction failed: %v", err)
+		}
+		_ = tx.Sign()
+		return tx, sm.ApplyTransaction(tx)
+	}
+
+	t.Run("OutboundEscrowsAmount", func(t *testing.T) {
+		submitted := &BridgeIntent{UserAddress: "userA_qrl", SourceChain: ChainID_QRL, DestChain: ChainID_Ethereum, Asset: NativeAsset, Amount: 300, DestAddress: testETHAddress}
+		if _, err := submit(0, submitted); err != nil {
+			t.Fatalf("Intent rejected: %v", err)
+		}
+		id := submitted.ID
+		intent, _ := GetBridgeIntent(db, id)
+		if intent == nil || intent.Status != "PendingNetting" || intent.ID != id {
+			t.Fatalf("Expected intent stored as PendingNetting, got %+v", intent)
+		}
+		escrow, _ := db.GetBalance(BridgeEscrowAccount)
+		fees, _ := db.GetBalance(BridgeFeeAccount)
+		bal, _ := db.GetBalance("userA_qrl")
+		if escrow != 300 || fees != DefaultBridgeFeeModel().BaseFee || bal != 660 {
+			t.Errorf("Expected 300 escrowed and the fee collected, got escrow %d, fees %d, balance %d", escrow, fees, bal)
+		}
+		if _, err := submit(1, submitted); err == nil {
+			t.Errorf("Expected resubmitted intent to be rejected")
+		}
+		if ids, _ := BridgeIntentIDs(db, 1); len(ids) != 1 || ids[0] != id {
+			t.Errorf("Expected intent indexed under epoch 1, got %v", ids)
+		}
+		if logs := sm.BlockLogs(); len(logs) != 1 || logs[0].Topic != "bridge.intent" {
+			t.Errorf("Expected a bridge.intent log, got %+v", logs)
+		}
+	})
+
+	t.Run("Inbound", func(t *testing.T) {
+		if _, err := submit(1, &BridgeIntent{UserAddress: "userA_qrl", SourceChain: ChainID_Ethereum, DestChain: ChainID_QRL, Asset: "qETH", Amount: 1_000_000_000_000, DestAddress: "userA_qrl"}); err != nil {
+			t.Fatalf("Inbound intent rejected: %v", err)
+		}
+		if escrow, _ := db.GetBalance(BridgeEscrowAccount); escrow != 300 {
+			t.Errorf("Inbound intent must not escrow QRL funds, escrow is %d", escrow)
+		}
+	})
+
+	invalid := map[string]*BridgeIntent{
+		"SameChain":      {UserAddress: "userA_qrl", SourceChain: ChainID_QRL, DestChain: ChainID_QRL, Asset: NativeAsset, Amount: 1, DestAddress: "x"},
+		"ZeroAmount":     {UserAddress: "userA_qrl", SourceChain: ChainID_Ethereum, DestChain: ChainID_QRL, Asset: "qETH", Amount: 0, DestAddress: "x"},
+		"WrappedOutflow": {UserAddress: "userA_qrl", SourceChain: ChainID_QRL, DestChain: ChainID_Ethereum, Asset: "qETH", Amount: 1_000_000_000_000, DestAddress: testETHAddress},
+		"OverBalance":    {UserAddress: "userA_qrl", SourceChain: ChainID_QRL, DestChain: ChainID_Ethereum, Asset: NativeAsset, Amount: 681, DestAddress: testETHAddress},
+		"OtherUser":      {UserAddress: "userB_qrl", SourceChain: ChainID_Ethereum, DestChain: ChainID_QRL, Asset: "qETH", Amount: 1_000_000_000_000, DestAddress: "userB_qrl"},
+		"BelowLowestFee": {UserAddress: "userA_qrl", SourceChain: ChainID_Ethereum, DestChain: ChainID_QRL, Asset: "qETH", Amount: 1_000_000_000_000, DestAddress: "userA_qrl", Fee: MinBridgeIntentFee},
+	}
+	for name, intent := range invalid {
+		t.Run(name, func(t *testing.T) {
+			if _, err := submit(2, intent); err == nil {
+				t.Errorf("Expected intent to be rejected")
+			}
+		})
+	}
+}
+
+func TestBridge_Netting(t *testing.T) {
+	intent := func(id byte, from, to ChainID, asset string, amount uint64) *BridgeIntent {
+		return &BridgeIntent{ID: Hash{id}, UserAddress: "user", SourceChain: from, DestChain: to, Asset: asset, Amount: amount, Status: IntentPendingNetting}
+	}
+
+	t.Run("OffsetsOpposingFlows", func(t *testing.T) {
+		intents := []*BridgeIntent{
+			intent(1, ChainID_QRL, ChainID_Ethereum, NativeAsset, 700),
+			intent(2, ChainID_Ethereum, ChainID_QRL, NativeAsset, 500),
+			intent(3, ChainID_Ethereum, ChainID_QRL, "qETH", 40),
+			intent(4, ChainID_QRL, ChainID_Ethereum, "qETH", 40),
+		}
+		report, err := NetIntents(3, 10, IntentTimeouts{}, intents)
+		if err != nil {
+			t.Fatalf("NetIntents failed: %v", err)
+		}
+		if report.Intents != 4 || report.GrossVolume() != 1280 || report.NetVolume() != 200 {
+			t.Fatalf("Expected gross 1280 and net 200 over 4 intents, got %s", report)
+		}
+		want := []BridgeInstruction{
+			{Epoch: 3, Kind: InstructionLock, Chain: ChainID_QRL, Asset: NativeAsset, Amount: 200},
+			{Epoch: 3, Kind: InstructionMint, Chain: ChainID_Ethereum, Asset: NativeAsset, Amount: 200},
+		}
+		if !reflect.DeepEqual(report.Instructions, want) {
+			t.Errorf("Expected instructions %+v, got %+v", want, report.Instructions)
+		}
+		if matched := report.Flows[1].Matched(); report.Flows[1].Asset != "qETH" || matched != 40 {
+			t.Errorf("Expected qETH flows to offset completely, got %+v", report.Flows[1])
+		}
+		for _, i := range intents {
+			want := IntentPendingSourceLock
+			if i.SourceChain == ChainID_QRL {
+				want = IntentPendingRelease
+			}
+			if i.Status != want {
+				t.Errorf("Intent %x: expected status %s, got %s", i.ID[:1], want, i.Status)
+			}
+		}
+	})
+
+	t.Run("WrappedResidual", func(t *testing.T) {
+		report, _ := NetIntents(0, 0, IntentTimeouts{}, []*BridgeIntent{
+			intent(1, ChainID_Bitcoin, ChainID_QRL, "qBTC", 10),
+			intent(2, ChainID_QRL, ChainID_Bitcoin, "qBTC", 25),
+		})
+		want := []BridgeInstruction{
+			{Kind: InstructionBurn, Chain: ChainID_QRL, Asset: "qBTC", Amount: 15},
+			{Kind: InstructionRelease, Chain: ChainID_Bitcoin, Asset: "qBTC", Amount: 15},
+		}
+		if !reflect.DeepEqual(report.Instructions, want) {
+			t.Errorf("Expected instructions %+v, got %+v", want, report.Instructions)
+		}
+	})
+
+	t.Run("UnsupportedAssetFails", func(t *testing.T) {
+		bad := intent(9, ChainID_Ethereum, ChainID_QRL, "DOGE", 1)
+		report, _ := NetIntents(0, 0, IntentTimeouts{}, []*BridgeIntent{bad})
+		if bad.Status != IntentFailed || len(report.Failed) != 1 || report.Intents != 0 || len(report.Instructions) != 0 {
+			t.Errorf("Expected intent to fail without instructions, got %s (%s)", bad.Status, report)
+		}
+	})
+
+	t.Run("OrderIndependent", func(t *testing.T) {
+		a, _ := NetIntents(1, 0, IntentTimeouts{}, []*BridgeIntent{intent(1, ChainID_QRL, ChainID_Bitcoin, NativeAsset, 5), intent(2, ChainID_QRL, ChainID_Ethereum, NativeAsset, 7)})
+		b, _ := NetIntents(1, 0, IntentTimeouts{}, []*BridgeIntent{intent(2, ChainID_QRL, ChainID_Ethereum, NativeAsset, 7), intent(1, ChainID_QRL, ChainID_Bitcoin, NativeAsset, 5)})
+		if !reflect.DeepEqual(a, b) {
+			t.Errorf("Expected the same report regardless of intent order:\n%+v\n%+v", a, b)
+		}
+	})
+
+	t.Run("Manager", func(t *testing.T) {
+		manager := NewBridgeManager()
+		first := signedIntent(&BridgeIntent{UserAddress: "user", SourceChain: ChainID_Ethereum, DestChain: ChainID_QRL, Asset: NativeAsset, Amount: 200, DestAddress: "user"})
+		_ = manager.HandleBridgeIntent(first)
+		report, err := manager.ProcessNettingEpoch(0)
+		if err != nil || report.Intents != 1 || first.Status != IntentPendingSourceLock {
+			t.Fatalf("Expected intent netted in epoch 0, got %v (%v), status %s", report, err, first.Status)
+		}
+		second := signedIntent(&BridgeIntent{UserAddress: "user", SourceChain: ChainID_Ethereum, DestChain: ChainID_QRL, Asset: NativeAsset, Amount: 100, DestAddress: "user"})
+		_ = manager.HandleBridgeIntent(second)
+		if second.Epoch != 1 {
+			t.Errorf("Expected new intent assigned to epoch 1, got %d", second.Epoch)
+		}
+		if _, err := manager.ProcessNettingEpoch(0); err == nil {
+			t.Errorf("Expected netting an already netted epoch to fail")
+		}
+		if report, _ := manager.ProcessNettingEpoch(1); report.Intents != 1 {
+			t.Errorf("Expected only the new intent in epoch 1, got %s", report)
+		}
+	})
+}
+
+func TestBridge_NettingEpochHook(t *testing.T) {
+	db := NewInMemoryStateDB()
+	_ = db.SetBalance("userA_qrl", 1000)
+	sm := NewStateManager(db)
+	_ = sm.Epochs().SetLength(4)
+	out := signedIntent(&BridgeIntent{UserAddress: "userA_qrl", SourceChain: ChainID_QRL, DestChain: ChainID_Ethereum, Asset: NativeAsset, Amount: 300, DestAddress: testETHAddress})
+	in := signedIntent(&BridgeIntent{UserAddress: "userA_qrl", SourceChain: ChainID_Ethereum, DestChain: ChainID_QRL, Asset: NativeAsset, Amount: 100, DestAddress: "userA_qrl"})
+	submit := func(nonce uint64, intent *BridgeIntent) *Transaction {
+		tx, _ := NewBridgeIntentTransaction(nonce, intent)
+		_ = tx.Sign()
+		return tx
+	}
+	applyBlocks(t, sm, 0, 3, map[uint64][]*Transaction{1: {submit(0, out)}, 2: {submit(1, in)}})
+
+	report, err := GetNettingReport(db, 0)
+	if err != nil || report == nil {
+		t.Fatalf("Expected a netting report for epoch 0, got %v (%v)", report, err)
+	}
+	if report.GrossVolume() != 400 || report.NetVolume() != 200 || len(report.Instructions) != 2 {
+		t.Errorf("Expected gross 400, net 200 and two instructions, got %s", report)
+	}
+	if intent, _ := GetBridgeIntent(db, out.ID); intent.Status != "PendingRelease" {
+		t.Errorf("Expected outbound intent PendingRelease, got %s", intent.Status)
+	}
+	if intent, _ := GetBridgeIntent(db, in.ID); intent.Status != "PendingSourceLock" {
+		t.Errorf("Expected inbound intent PendingSourceLock, got %s", intent.Status)
+	}
+	summary, _ := GetEpochSummary(db, 0)
+	var noted bool
+	for _, note := range summary.Notes {
+		noted = noted || note.Key == "bridge"
+	}
+	if !noted {
+		t.Errorf("Expected a bridge note in the epoch summary, got %+v", summary.Notes)
+	}
+}
+
+func TestBridge_ExternalChains(t *testing.T) {
+	cfg := DefaultMockChainConfig()
+	cfg.FinalityDepth = 3
+	eth := NewMockChain(ChainID_Ethereum, cfg)
+	btc := NewMockChain(ChainID_Bitcoin, cfg)
+	eth.Fund("alice_eth", "qBTC", 100_000)
+	btc.Fund(MockCustodyAddress, "qBTC", 100_000)
+	manager := NewBridgeManager()
+	for _, chain := range []*MockChain{eth, btc} {
+		if err := manager.RegisterAdapter(chain); err != nil {
+			t.Fatalf("RegisterAdapter failed: %v", err)
+		}
+	}
+	if err := manager.RegisterAdapter(eth); err == nil {
+		t.Errorf("Expected duplicate adapter to be rejected")
+	}
+
+	intent := signedIntent(&BridgeIntent{UserAddress: "alice", SourceChain: ChainID_Ethereum, DestChain: ChainID_Bitcoin, Asset: "qBTC", Amount: 40_000, DestAddress: testBTCAddress})
+	if err := manager.HandleBridgeIntent(intent); err != nil {
+		t.Fatalf("HandleBridgeIntent failed: %v", err)
+	}
+	_, _ = manager.ProcessNettingEpoch(0)
+	memo := fmt.Sprintf("%x", intent.ID[:])
+
+	if _, err := eth.Deposit("alice_eth", "qBTC", 40_000, memo); err != nil {
+		t.Fatalf("Deposit failed: %v", err)
+	}
+	eth.Mine(1)
+	if err := manager.SyncExternalChains(); err != nil || intent.SourceTxHash == "" {
+		t.Fatalf("Expected deposit recorded as source lock, got %q (%v)", intent.SourceTxHash, err)
+	}
+	_ = eth.Reorg(1)
+	if err := manager.SyncExternalChains(); err != nil || intent.SourceTxHash == "" || intent.Status != IntentLocked {
+		t.Fatalf("Expected deposit re-included after the reorg, got %+v (%v)", intent, err)
+	}
+	eth.Mine(2)
+	if err := manager.SyncExternalChains(); err != nil || intent.Status != "PendingRelease" {
+		t.Fatalf("Expected final deposit to make the intent PendingRelease, got %s (%v)", intent.Status, err)
+	}
+
+	if err := manager.SubmitReleases(); err != nil || intent.DestTxHash == "" {
+		t.Fatalf("Expected release submitted, got %q (%v)", intent.DestTxHash, err)
+	}
+	btc.Mine(3)
+	if err := manager.SyncExternalChains(); err != nil || intent.Status != "Completed" {
+		t.Fatalf("Expected final release to complete the intent, got %s (%v)", intent.Status, err)
+	}
+	if btc.Balance(testBTCAddress, "qBTC") != 40_000 || eth.Balance(MockCustodyAddress, "qBTC") != 40_000 {
+		t.Errorf("Expected 40000 qBTC locked on ETH and released on BTC")
+	}
+	if _, tracked := manager.pendingIntents[intent.ID]; tracked {
+		t.Errorf("Expected completed intent to no longer be tracked")
+	}
+
+	_, _ = eth.Deposit("alice_eth", "qBTC", 1, "not an intent")
+	eth.Mine(1)
+	if err := manager.SyncExternalChains(); err == nil {
+		t.Errorf("Expected deposit without an intent to be reported")
+	}
+}
+
 // TODO: Add TestBridge_Security tests
+
+// countingAdapter records the transactions the bridge submits on its mock chain.
+type countingAdapter struct {
+	*MockChain
+	submitted []ExternalTx
+}
+
+func (a *countingAdapter) Submit(tx ExternalTx) (string, error) {
+	a.submitted = append(a.submitted, tx)
+	return a.MockChain.Submit(tx)
+}
+
+func TestBridge_NettedPayouts(t *testing.T) {
+	cfg := DefaultMockChainConfig()
+	cfg.FinalityDepth = 3
+	eth := &countingAdapter{MockChain: NewMockChain(ChainID_Ethereum, cfg)}
+	btc := &countingAdapter{MockChain: NewMockChain(ChainID_Bitcoin, cfg)}
+	eth.Fund("alice_eth", "qBTC", 100_000)
+	btc.Fund("bob_btc", "qBTC", 100_000)
+	manager := NewBridgeManager()
+	_ = manager.RegisterAdapter(eth)
+	_ = manager.RegisterAdapter(btc)
+
+	// Alice moves wrapped qBTC from ETH to BTC, bob moves qBTC from BTC to ETH
+	alice := signedIntent(&BridgeIntent{UserAddress: "alice", SourceChain: ChainID_Ethereum, DestChain: ChainID_Bitcoin, Asset: "qBTC", Amount: 60_000, DestAddress: testBTCAddress})
+	bob := signedIntent(&BridgeIntent{UserAddress: "bob", SourceChain: ChainID_Bitcoin, DestChain: ChainID_Ethereum, Asset: "qBTC", Amount: 60_000, DestAddress: testETHAddress})
+	for _, intent := range []*BridgeIntent{alice, bob} {
+		if err := manager.HandleBridgeIntent(intent); err != nil {
+			t.Fatalf("HandleBridgeIntent failed: %v", err)
+		}
+	}
+	if report, err := manager.ProcessNettingEpoch(0); err != nil || report.Savings() != 1 || len(report.Instructions) != 0 {
+		t.Fatalf("Expected the opposing intents fully netted, got %v (%v)", report, err)
+	}
+	_, _ = eth.Deposit("alice_eth", "qBTC", 60_000, fmt.Sprintf("%x", alice.ID[:]))
+	_, _ = btc.Deposit("bob_btc", "qBTC", 60_000, fmt.Sprintf("%x", bob.ID[:]))
+	eth.Mine(3)
+	btc.Mine(3)
+	if err := manager.SyncExternalChains(); err != nil || alice.Status != IntentPendingRelease || bob.Status != IntentPendingRelease {
+		t.Fatalf("Expected final deposits, got %s and %s (%v)", alice.Status, bob.Status, err)
+	}
+
+	if err := manager.SubmitReleases(); err != nil {
+		t.Fatalf("SubmitReleases failed: %v", err)
+	}
+	for _, a := range []*countingAdapter{eth, btc} {
+		if len(a.submitted) != 1 || a.submitted[0].Kind != InstructionRelease {
+			t.Errorf("Expected one release out of custody on %s, got %+v", a.Chain(), a.submitted)
+		}
+	}
+	eth.Mine(3)
+	btc.Mine(3)
+	if err := manager.SyncExternalChains(); err != nil || alice.Status != IntentCompleted || bob.Status != IntentCompleted {
+		t.Fatalf("Expected both intents completed, got %s and %s (%v)", alice.Status, bob.Status, err)
+	}
+	// Each deposit paid the other user: nothing was minted and custody is empty again
+	if eth.Balance(testETHAddress, "qBTC") != 60_000 || eth.Balance(MockCustodyAddress, "qBTC") != 0 || btc.Balance(MockCustodyAddress, "qBTC") != 0 {
+		t.Errorf("Expected the deposits paid out to the opposing users")
+	}
+}
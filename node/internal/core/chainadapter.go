@@ -0,0 +1,79 @@
+package core
+
+import "fmt"
+
+// External chain adapters
+//
+// The bridge talks to every external chain through a ChainAdapter: it submits the transactions
+// that move assets in and out of the bridge's custody there, and polls for what happened on the
+// chain since the last poll (user deposits into custody, transactions reaching finality, reorgs).
+// Adapters for real chains wrap an RPC client; MockChain is an in-process implementation for
+// development and tests.
+
+// ExternalTx is a transaction the bridge submits on an external chain.
+type ExternalTx struct {
+	Kind    BridgeInstructionKind // Lock, release, mint or burn
+	Asset   string
+	Address string // Account the asset moves from (lock, burn) or to (release, mint)
+	Amount  uint64
+	Memo    string // Free-form reference, e.g. the intent ID
+}
+
+// ExternalEventType identifies what an adapter observed on its chain.
+type ExternalEventType uint8
+
+const (
+	ExternalEventDeposit   ExternalEventType = iota // A user deposit into bridge custody was included in a block
+	ExternalEventFinalized                          // A transaction reached the chain's finality depth
+	ExternalEventReorged                            // A transaction's block was reorganized out of the chain
+	ExternalEventFailed                             // A submitted transaction was included but failed
+)
+
+// String returns a human-readable name for the event type.
+func (t ExternalEventType) String() string {
+	switch t {
+	case ExternalEventDeposit:
+		return "deposit"
+	case ExternalEventFinalized:
+		return "finalized"
+	case ExternalEventReorged:
+		return "reorged"
+	case ExternalEventFailed:
+		return "failed"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(t))
+	}
+}
+
+// ExternalChainEvent is something an adapter observed on its chain.
+type ExternalChainEvent struct {
+	Chain   ChainID
+	Type    ExternalEventType
+	TxHash  string
+	Height  uint64                // Block the transaction was included in (or orphaned from, for reorged transactions)
+	Kind    BridgeInstructionKind // Kind of a bridge-submitted transaction; empty for user deposits
+	Address string
+	Asset   string
+	Amount  uint64
+	Memo    string
+	Proof   *InclusionProof // Inclusion of the transaction in its block, if the adapter provides it (see HeaderSource)
+}
+
+// ChainAdapter connects the bridge to an external chain.
+type ChainAdapter interface {
+	// Chain returns the chain the adapter is connected to.
+	Chain() ChainID
+	// Height returns the height of the chain's current head.
+	Height() uint64
+	// FinalityDepth returns the confirmations after which a transaction is considered final.
+	FinalityDepth() uint64
+	// EstimateFee returns the fee a transaction submitted now is expected to pay.
+	EstimateFee() uint64
+	// Submit broadcasts tx and returns its hash. Inclusion is reported through PollEvents.
+	Submit(tx ExternalTx) (string, error)
+	// Confirmations returns the number of blocks on top of and including the one holding the
+	// transaction, or 0 while it is pending or was reorganized out.
+	Confirmations(txHash string) (uint64, error)
+	// PollEvents returns the events observed since the previous call, in chain order.
+	PollEvents() ([]ExternalChainEvent, error)
+}
@@ -0,0 +1,388 @@
+package core
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Bridge rate limits and circuit breakers
+//
+// The value bridged per netting epoch is capped per asset, per asset on each chain (counting flows
+// into and out of the chain) and per asset for each user, see BridgeAsset. Bridging can be paused
+// entirely, for an asset or for a chain. Pauses are set automatically when the bridge observes an
+// anomaly hinting at a compromised chain or adapter (an inventory pool falling below its floor, a
+// final transaction reorganized out, escrowed funds going missing) or by the bridge admin. Only the
+// bridge admin lifts them, with a TxTypeBridgeAdmin transaction. The admin account is meant to be
+// a multisig account (see MultisigAccount) held by governance.
+
+// BridgeAdminAccount is the only account allowed to send TxTypeBridgeAdmin and TxTypeBridgeRelease
+// transactions.
+const BridgeAdminAccount = "bridge-admin"
+
+// GasBridgeAdmin is the gas charged for a bridge admin transaction on top of the intrinsic gas.
+const GasBridgeAdmin uint64 = 5000
+
+// Storage key prefixes for bridge limits and pauses.
+const (
+	bridgePausePrefix  = "bridge/pause/"  // bridge/pause/<scope> -> BridgePause
+	bridgeVolumePrefix = "bridge/volume/" // bridge/volume/<epoch>/<scope> -> value bridged in the epoch (big-endian uint64)
+)
+
+// PauseScopeAll pauses every transfer.
+const PauseScopeAll = "all"
+
+// AssetPauseScope returns the scope pausing every transfer of asset.
+func AssetPauseScope(asset string) string {
+	return "asset/" + asset
+}
+
+// ChainPauseScope returns the scope pausing every transfer from or to chain.
+func ChainPauseScope(chain ChainID) string {
+	return "chain/" + string(chain)
+}
+
+// ValidatePauseScope checks that scope is PauseScopeAll or names a supported asset or chain.
+func ValidatePauseScope(scope string) error {
+	switch {
+	case scope == PauseScopeAll:
+		return nil
+	case strings.HasPrefix(scope, "asset/"):
+		if _, ok := GetBridgeAsset(strings.TrimPrefix(scope, "asset/")); ok {
+			return nil
+		}
+	case strings.HasPrefix(scope, "chain/"):
+		switch ChainID(strings.TrimPrefix(scope, "chain/")) {
+		case ChainID_QRL, ChainID_Ethereum, ChainID_Bitcoin:
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid pause scope %q", scope)
+}
+
+// intentPauseScopes returns the scopes whose pause stops intent.
+func intentPauseScopes(intent *BridgeIntent) []string {
+	return []string{PauseScopeAll, AssetPauseScope(intent.Asset), ChainPauseScope(intent.SourceChain), ChainPauseScope(intent.DestChain)}
+}
+
+// BridgePause is an active pause.
+type BridgePause struct {
+	Scope  string
+	Reason string
+	Height uint64 // QRL block height the pause was set at
+	Auto   bool   // Set on an anomaly rather than by the admin
+}
+
+// BridgeAdminAction is the payload of TxTypeBridgeAdmin: it pauses or lifts the pause of a scope.
+type BridgeAdminAction struct {
+	Scope  string
+	Pause  bool // False lifts the pause
+	Reason string
+}
+
+// bridgeVolumeLimit is a cap on the value bridged in an epoch within scope. Zero means no cap.
+type bridgeVolumeLimit struct {
+	Scope string
+	Cap   uint64
+}
+
+// bridgeVolumeLimits returns the epoch caps intent counts against.
+func bridgeVolumeLimits(intent *BridgeIntent) []bridgeVolumeLimit {
+	asset, _ := GetBridgeAsset(intent.Asset)
+	return []bridgeVolumeLimit{
+		{Scope: "asset/" + intent.Asset, Cap: asset.EpochCap},
+		{Scope: fmt.Sprintf("chain/%s/%s", intent.SourceChain, intent.Asset), Cap: asset.ChainEpochCap},
+		{Scope: fmt.Sprintf("chain/%s/%s", intent.DestChain, intent.Asset), Cap: asset.ChainEpochCap},
+		{Scope: fmt.Sprintf("user/%s/%s", intent.UserAddress, intent.Asset), Cap: asset.UserEpochCap},
+	}
+}
+
+// checkBridgeVolume checks that intent fits the epoch caps given the volume already bridged in
+// each scope.
+func checkBridgeVolume(intent *BridgeIntent, used func(scope string) (uint64, error)) error {
+	for _, limit := range bridgeVolumeLimits(intent) {
+		if limit.Cap == 0 {
+			continue
+		}
+		volume, err := used(limit.Scope)
+		if err != nil {
+			return err
+		}
+		if volume > limit.Cap || intent.Amount > limit.Cap-volume {
+			return fmt.Errorf("intent of %d %s exceeds epoch cap %d of %s (%d already bridged)", intent.Amount, intent.Asset, limit.Cap, limit.Scope, volume)
+		}
+	}
+	return nil
+}
+
+// --- BridgeManager integration ---
+//
+// The manager pauses on the anomalies it observes itself and follows the pauses recorded in the
+// chain state it watches (see WatchState). Once the scope of one of its own pauses is paused on
+// chain, the admin owns it: lifting it there lifts it for the manager too.
+
+// Pause pauses scope until the admin lifts it.
+func (bm *BridgeManager) Pause(scope, reason string) error {
+	if err := ValidatePauseScope(scope); err != nil {
+		return err
+	}
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	bm.pause(scope, reason, false)
+	return bm.flush()
+}
+
+// pause records a pause of scope unless it is already paused. Caller must hold bm.mu.
+func (bm *BridgeManager) pause(scope, reason string, auto bool) {
+	if bm.paused(scope) != nil {
+		return
+	}
+	bm.pauses[scope] = BridgePause{Scope: scope, Reason: reason, Height: bm.height, Auto: auto}
+	fmt.Printf("BridgeManager: Paused %s: %s\n", scope, reason)
+}
+
+// paused returns the pause of scope, from state or set by the manager, or nil. Caller must hold
+// bm.mu.
+func (bm *BridgeManager) paused(scope string) *BridgePause {
+	if p, ok := bm.statePauses[scope]; ok {
+		return &p
+	}
+	if p, ok := bm.pauses[scope]; ok {
+		return &p
+	}
+	return nil
+}
+
+// Pauses returns the active pauses, sorted by scope.
+func (bm *BridgeManager) Pauses() []BridgePause {
+	bm.mu.RLock()
+	defer bm.mu.RUnlock()
+
+	pauses := make([]BridgePause, 0, len(bm.pauses)+len(bm.statePauses))
+	for _, p := range bm.statePauses {
+		pauses = append(pauses, p)
+	}
+	for scope, p := range bm.pauses {
+		if _, ok := bm.statePauses[scope]; !ok {
+			pauses = append(pauses, p)
+		}
+	}
+	sort.Slice(pauses, func(i, j int) bool { return pauses[i].Scope < pauses[j].Scope })
+	return pauses
+}
+
+// syncPauses reloads the pauses recorded in the watched chain state. The manager's own pauses of
+// the scopes paused there are handed over to the admin. Caller must hold bm.mu.
+func (bm *BridgeManager) syncPauses() error {
+	if bm.state == nil {
+		return nil
+	}
+	pauses, err := BridgePauses(bm.state)
+	if err != nil {
+		return err
+	}
+	clear(bm.statePauses)
+	for _, p := range pauses {
+		bm.statePauses[p.Scope] = p
+		delete(bm.pauses, p.Scope)
+	}
+	return nil
+}
+
+// checkPaused returns an error if a pause stops intent. Caller must hold bm.mu.
+func (bm *BridgeManager) checkPaused(intent *BridgeIntent) error {
+	for _, scope := range intentPauseScopes(intent) {
+		if p := bm.paused(scope); p != nil {
+			return fmt.Errorf("bridging %s from %s to %s is paused (%s: %s)", intent.Asset, intent.SourceChain, intent.DestChain, scope, p.Reason)
+		}
+	}
+	return nil
+}
+
+// checkFloor pauses the pool's asset if its balance fell below its floor. Caller must hold bm.mu.
+func (bm *BridgeManager) checkFloor(pool *InventoryPool) {
+	if pool.Balance < pool.Floor {
+		bm.pause(AssetPauseScope(pool.Asset), fmt.Sprintf("%s pool on %s fell to %d, below its floor %d", pool.Asset, pool.Chain, pool.Balance, pool.Floor), true)
+	}
+}
+
+// --- StateManager integration ---
+
+// bridgePauseKey returns the storage key of the pause of scope.
+func bridgePauseKey(scope string) string {
+	return bridgePausePrefix + scope
+}
+
+// bridgeVolumeKey returns the storage key of the volume bridged within scope during epoch.
+func bridgeVolumeKey(epoch uint64, scope string) string {
+	return fmt.Sprintf("%s%020d/%s", bridgeVolumePrefix, epoch, scope)
+}
+
+// GetBridgePause returns the pause of scope recorded in state, or nil if it is not paused.
+func GetBridgePause(db StateDB, scope string) (*BridgePause, error) {
+	p := &BridgePause{}
+	found, err := getStorageGob(db, bridgePauseKey(scope), p)
+	if err != nil || !found {
+		return nil, err
+	}
+	return p, nil
+}
+
+// BridgePauses returns the pauses recorded in state, sorted by scope.
+func BridgePauses(db StateDB) ([]BridgePause, error) {
+	keys, err := db.StorageKeys(bridgePausePrefix)
+	if err != nil {
+		return nil, err
+	}
+	pauses := make([]BridgePause, 0, len(keys))
+	for _, key := range keys {
+		p, err := GetBridgePause(db, strings.TrimPrefix(key, bridgePausePrefix))
+		if err != nil {
+			return nil, err
+		}
+		pauses = append(pauses, *p)
+	}
+	return pauses, nil
+}
+
+// GetBridgeVolume returns the value bridged within scope during epoch.
+func GetBridgeVolume(db StateDB, epoch uint64, scope string) (uint64, error) {
+	value, err := db.GetStorage(bridgeVolumeKey(epoch, scope))
+	if err != nil || len(value) == 0 {
+		return 0, err
+	}
+	if len(value) != 8 {
+		return 0, fmt.Errorf("malformed bridge volume of %s in epoch %d", scope, epoch)
+	}
+	return binary.BigEndian.Uint64(value), nil
+}
+
+// setBridgeVolume records the value bridged within scope during epoch.
+func setBridgeVolume(db StateDB, epoch uint64, scope string, volume uint64) error {
+	return db.SetStorage(bridgeVolumeKey(epoch, scope), binary.BigEndian.AppendUint64(nil, volume))
+}
+
+// checkBridgeLimits returns an error if a pause recorded in state stops intent or it exceeds the
+// epoch caps.
+func (sm *StateManager) checkBridgeLimits(intent *BridgeIntent, epoch uint64) error {
+	for _, scope := range intentPauseScopes(intent) {
+		p, err := GetBridgePause(sm.db, scope)
+		if err != nil {
+			return err
+		}
+		if p != nil {
+			return fmt.Errorf("bridging %s from %s to %s is paused (%s: %s)", intent.Asset, intent.SourceChain, intent.DestChain, scope, p.Reason)
+		}
+	}
+	return checkBridgeVolume(intent, func(scope string) (uint64, error) {
+		return GetBridgeVolume(sm.db, epoch, scope)
+	})
+}
+
+// addBridgeVolume counts intent against the caps of its epoch.
+func (sm *StateManager) addBridgeVolume(intent *BridgeIntent) error {
+	for _, limit := range bridgeVolumeLimits(intent) {
+		volume, err := GetBridgeVolume(sm.db, intent.Epoch, limit.Scope)
+		if err != nil {
+			return err
+		}
+		if err := setBridgeVolume(sm.db, intent.Epoch, limit.Scope, volume+intent.Amount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pauseBridge records a pause of scope in state unless it is already paused. It reports whether
+// the pause is new.
+func (sm *StateManager) pauseBridge(scope, reason string, auto bool) (bool, error) {
+	if p, err := GetBridgePause(sm.db, scope); err != nil || p != nil {
+		return false, err
+	}
+	return true, setStorageGob(sm.db, bridgePauseKey(scope), &BridgePause{Scope: scope, Reason: reason, Height: sm.blockNumber(), Auto: auto})
+}
+
+// checkBridgeEscrow pauses all bridging if BridgeEscrowAccount holds less than the open intents
+// leaving QRL escrowed. It reports whether it paused.
+func (sm *StateManager) checkBridgeEscrow() (bool, error) {
+	ids, err := OpenBridgeIntentIDs(sm.db)
+	if err != nil {
+		return false, err
+	}
+	var owed uint64
+	for _, id := range ids {
+		intent, err := GetBridgeIntent(sm.db, id)
+		if err != nil {
+			return false, err
+		}
+		if intent != nil && intent.SourceChain == ChainID_QRL {
+			owed += intent.Amount
+		}
+	}
+	escrow, err := sm.db.GetBalance(BridgeEscrowAccount)
+	if err != nil || escrow >= owed {
+		return false, err
+	}
+	return sm.pauseBridge(PauseScopeAll, fmt.Sprintf("escrow holds %d, open intents escrowed %d", escrow, owed), true)
+}
+
+// NewBridgeAdminTransaction creates an unsigned admin transaction applying action. It must be
+// authorized by BridgeAdminAccount.
+func NewBridgeAdminTransaction(nonce uint64, action BridgeAdminAction) (*Transaction, error) {
+	payload, err := encodeGob(&action)
+	if err != nil {
+		return nil, err
+	}
+	tx := NewBaseTransaction(TxTypeBridgeAdmin, nonce, BridgeAdminAccount, "", 0)
+	tx.Payload = payload
+	return tx, nil
+}
+
+// validateBridgeAdmin checks that the admin pauses an active scope or lifts an existing pause.
+func validateBridgeAdmin(ctx *NativeContext, tx *Transaction) error {
+	if tx.SenderID != BridgeAdminAccount {
+		return fmt.Errorf("only %s can send bridge admin transactions, got %s", BridgeAdminAccount, tx.SenderID)
+	}
+	if tx.Amount != 0 {
+		return fmt.Errorf("bridge admin transaction must not carry an amount")
+	}
+	var action BridgeAdminAction
+	if err := decodeGob(tx.Payload, &action); err != nil {
+		return err
+	}
+	if err := ValidatePauseScope(action.Scope); err != nil {
+		return err
+	}
+	p, err := GetBridgePause(ctx.State.db, action.Scope)
+	if err != nil {
+		return err
+	}
+	if action.Pause && p != nil {
+		return fmt.Errorf("%s is already paused", action.Scope)
+	}
+	if !action.Pause && p == nil {
+		return fmt.Errorf("%s is not paused", action.Scope)
+	}
+	return nil
+}
+
+// applyBridgeAdmin records or lifts the pause.
+func applyBridgeAdmin(ctx *NativeContext, tx *Transaction) error {
+	var action BridgeAdminAction
+	if err := decodeGob(tx.Payload, &action); err != nil {
+		return err
+	}
+	if !action.Pause {
+		if err := ctx.State.db.SetStorage(bridgePauseKey(action.Scope), nil); err != nil {
+			return err
+		}
+		ctx.Emit("bridge.unpause", []byte(action.Scope))
+		return nil
+	}
+	if _, err := ctx.State.pauseBridge(action.Scope, action.Reason, false); err != nil {
+		return err
+	}
+	ctx.Emit("bridge.pause", []byte(action.Scope))
+	return nil
+}
@@ -0,0 +1,262 @@
+package core
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCircuitBreaker_ManagerCaps(t *testing.T) {
+	manager := NewBridgeManager()
+	intent := func(user string, nonce uint64) *BridgeIntent {
+		return signedIntent(&BridgeIntent{UserAddress: user, SourceChain: ChainID_Ethereum, DestChain: ChainID_Bitcoin, Asset: "qBTC", Amount: 10_000_000_000, DestAddress: testBTCAddress, Nonce: nonce})
+	}
+	for nonce := uint64(0); nonce < 2; nonce++ {
+		if err := manager.HandleBridgeIntent(intent("alice", nonce)); err != nil {
+			t.Fatalf("Intent %d rejected: %v", nonce, err)
+		}
+	}
+	if err := manager.HandleBridgeIntent(intent("alice", 2)); err == nil {
+		t.Errorf("Expected intent over the user's epoch cap to be rejected")
+	}
+	for nonce := uint64(0); nonce < 3; nonce++ {
+		_ = manager.HandleBridgeIntent(intent(fmt.Sprintf("user%d", nonce), 0))
+	}
+	// ETH and BTC have now seen 50_000_000_000 qBTC, their per-chain cap
+	if err := manager.HandleBridgeIntent(intent("dave", 0)); err == nil {
+		t.Errorf("Expected intent over the chain's epoch cap to be rejected")
+	}
+
+	if _, err := manager.ProcessNettingEpoch(0); err != nil {
+		t.Fatalf("ProcessNettingEpoch failed: %v", err)
+	}
+	if err := manager.HandleBridgeIntent(intent("alice", 2)); err != nil {
+		t.Errorf("Expected caps to reset in the next epoch, got %v", err)
+	}
+}
+
+func TestCircuitBreaker_ManagerPauses(t *testing.T) {
+	t.Run("InventoryFloor", func(t *testing.T) {
+		manager, eth, _, intent := newInventoryFixture(t, 40_000)
+		_ = manager.SetInventoryPool(InventoryPool{Chain: ChainID_Bitcoin, Asset: "qBTC", Balance: 100_000, Floor: 80_000, Min: 80_000, Target: 100_000, Max: 200_000})
+		_, _ = eth.Deposit("alice_eth", "qBTC", 40_000, fmt.Sprintf("%x", intent.ID[:]))
+		eth.Mine(3)
+		if err := manager.SyncExternalChains(); err != nil {
+			t.Fatalf("SyncExternalChains failed: %v", err)
+		}
+		if err := manager.ReleaseFromInventory(); err != nil || !intent.EarlyRelease {
+			t.Fatalf("Expected early release, got %s (%v)", intent.Status, err)
+		}
+		pauses := manager.Pauses()
+		if len(pauses) != 1 || pauses[0].Scope != AssetPauseScope("qBTC") || !pauses[0].Auto {
+			t.Fatalf("Expected qBTC paused once its BTC pool fell below the floor, got %+v", pauses)
+		}
+
+		next := signedIntent(&BridgeIntent{UserAddress: "bob", SourceChain: ChainID_Ethereum, DestChain: ChainID_Bitcoin, Asset: "qBTC", Amount: 10_000, DestAddress: testBTCAddress, Fee: 100})
+		if err := manager.HandleBridgeIntent(next); err == nil {
+			t.Errorf("Expected intent of a paused asset to be rejected")
+		}
+
+		// The admin takes the pause over on chain and lifts it there
+		db := NewInMemoryStateDB()
+		sm := NewStateManager(db)
+		_ = sm.BeginBlock(&BlockHeader{Number: 1, Proposer: "proposerP"})
+		admin := func(nonce uint64, action BridgeAdminAction) {
+			tx, _ := NewBridgeAdminTransaction(nonce, action)
+			_ = tx.Sign()
+			if err := sm.ApplyTransaction(tx); err != nil {
+				t.Fatalf("Admin transaction failed: %v", err)
+			}
+		}
+		manager.WatchState(db)
+		admin(0, BridgeAdminAction{Scope: AssetPauseScope("qBTC"), Pause: true, Reason: "floor breached"})
+		if err := manager.AdvanceHeight(1); err != nil {
+			t.Fatalf("AdvanceHeight failed: %v", err)
+		}
+		if pauses := manager.Pauses(); len(pauses) != 1 || pauses[0].Auto || pauses[0].Reason != "floor breached" {
+			t.Fatalf("Expected the pause taken over from state, got %+v", pauses)
+		}
+		admin(1, BridgeAdminAction{Scope: AssetPauseScope("qBTC")})
+		if err := manager.HandleBridgeIntent(next); err == nil {
+			t.Errorf("Expected the pause to hold until the manager sees the lift")
+		}
+		if err := manager.AdvanceHeight(2); err != nil {
+			t.Fatalf("AdvanceHeight failed: %v", err)
+		}
+		if err := manager.HandleBridgeIntent(next); err != nil {
+			t.Errorf("Expected intent accepted once the pause is lifted on chain, got %v", err)
+		}
+	})
+
+	t.Run("ReorgPastFinality", func(t *testing.T) {
+		cfg := DefaultMockChainConfig()
+		cfg.FinalityDepth = 3
+		eth := NewMockChain(ChainID_Ethereum, cfg)
+		eth.Fund("alice_eth", "qBTC", 100_000)
+		manager := NewBridgeManager()
+		_ = manager.RegisterAdapter(eth)
+		intent := signedIntent(&BridgeIntent{UserAddress: "alice", SourceChain: ChainID_Ethereum, DestChain: ChainID_Bitcoin, Asset: "qBTC", Amount: 40_000, DestAddress: testBTCAddress})
+		_ = manager.HandleBridgeIntent(intent)
+		_, _ = manager.ProcessNettingEpoch(0)
+		_, _ = eth.Deposit("alice_eth", "qBTC", 40_000, fmt.Sprintf("%x", intent.ID[:]))
+		eth.Mine(3)
+		if err := manager.SyncExternalChains(); err != nil || !intent.SourceFinal {
+			t.Fatalf("Expected final deposit, got %+v (%v)", intent, err)
+		}
+		if len(manager.Pauses()) != 0 {
+			t.Fatalf("Expected no pause before the reorg")
+		}
+
+		_ = eth.Reorg(3)
+		if err := manager.SyncExternalChains(); err != nil {
+			t.Fatalf("SyncExternalChains failed: %v", err)
+		}
+		if pauses := manager.Pauses(); len(pauses) != 1 || pauses[0].Scope != ChainPauseScope(ChainID_Ethereum) {
+			t.Errorf("Expected ETH paused after a final deposit was reorganized, got %+v", pauses)
+		}
+	})
+
+	t.Run("Manual", func(t *testing.T) {
+		manager := NewBridgeManager()
+		if err := manager.Pause("chain/DOGE", "test"); err == nil {
+			t.Errorf("Expected invalid scope to be rejected")
+		}
+		if err := manager.Pause(PauseScopeAll, "maintenance"); err != nil {
+			t.Fatalf("Pause failed: %v", err)
+		}
+		intent := signedIntent(&BridgeIntent{UserAddress: "alice", SourceChain: ChainID_Ethereum, DestChain: ChainID_QRL, Asset: NativeAsset, Amount: 100, DestAddress: "alice"})
+		if err := manager.HandleBridgeIntent(intent); err == nil {
+			t.Errorf("Expected intent to be rejected while everything is paused")
+		}
+	})
+}
+
+func TestCircuitBreaker_ManagerStore(t *testing.T) {
+	store := NewInMemoryStateDB()
+	manager := NewBridgeManager()
+	if err := manager.AttachStore(store); err != nil {
+		t.Fatalf("AttachStore failed: %v", err)
+	}
+	intent := func(nonce uint64) *BridgeIntent {
+		return signedIntent(&BridgeIntent{UserAddress: "alice", SourceChain: ChainID_Ethereum, DestChain: ChainID_Bitcoin, Asset: "qBTC", Amount: 10_000_000_000, DestAddress: testBTCAddress, Nonce: nonce})
+	}
+	for nonce := uint64(0); nonce < 2; nonce++ {
+		if err := manager.HandleBridgeIntent(intent(nonce)); err != nil {
+			t.Fatalf("Intent %d rejected: %v", nonce, err)
+		}
+	}
+	if err := manager.Pause(ChainPauseScope(ChainID_Ethereum), "maintenance"); err != nil {
+		t.Fatalf("Pause failed: %v", err)
+	}
+
+	restarted := NewBridgeManager()
+	if err := restarted.AttachStore(store); err != nil {
+		t.Fatalf("AttachStore after restart failed: %v", err)
+	}
+	if pauses := restarted.Pauses(); len(pauses) != 1 || pauses[0].Reason != "maintenance" {
+		t.Errorf("Expected the pause restored, got %+v", pauses)
+	}
+	if err := restarted.HandleBridgeIntent(intent(2)); err == nil {
+		t.Errorf("Expected intent rejected while the restored pause holds")
+	}
+
+	// The admin lifts the pause on chain
+	db := NewInMemoryStateDB()
+	sm := NewStateManager(db)
+	_ = sm.BeginBlock(&BlockHeader{Number: 1, Proposer: "proposerP"})
+	restarted.WatchState(db)
+	for nonce, action := range []BridgeAdminAction{{Scope: ChainPauseScope(ChainID_Ethereum), Pause: true}, {Scope: ChainPauseScope(ChainID_Ethereum)}} {
+		tx, _ := NewBridgeAdminTransaction(uint64(nonce), action)
+		_ = tx.Sign()
+		if err := sm.ApplyTransaction(tx); err != nil {
+			t.Fatalf("Admin transaction failed: %v", err)
+		}
+		if err := restarted.AdvanceHeight(uint64(nonce + 1)); err != nil {
+			t.Fatalf("AdvanceHeight failed: %v", err)
+		}
+	}
+	if len(restarted.Pauses()) != 0 {
+		t.Fatalf("Expected the pause lifted on chain, got %+v", restarted.Pauses())
+	}
+	if paused, _ := BridgePauses(store); len(paused) != 0 {
+		t.Errorf("Expected the lifted pause removed from the store, got %+v", paused)
+	}
+	if err := restarted.HandleBridgeIntent(intent(2)); err == nil {
+		t.Errorf("Expected the restored volume to keep alice at her epoch cap")
+	}
+}
+
+func TestCircuitBreaker_State(t *testing.T) {
+	db := NewInMemoryStateDB()
+	_ = db.SetBalance("alice", 10_000_000_000_000)
+	sm := NewStateManager(db)
+	_ = sm.BeginBlock(&BlockHeader{Number: 150, Proposer: "proposerP"})
+	nonces := make(map[string]uint64)
+	apply := func(tx *Transaction, err error) error {
+		if err != nil {
+			t.Fatalf("Failed to build transaction: %v", err)
+		}
+		tx.Nonce = nonces[tx.SenderID]
+		_ = tx.Sign()
+		if err := sm.ApplyTransaction(tx); err != nil {
+			return err
+		}
+		nonces[tx.SenderID]++
+		return nil
+	}
+	outbound := func(amount, nonce uint64) error {
+		return apply(NewBridgeIntentTransaction(0, signedIntent(&BridgeIntent{UserAddress: "alice", SourceChain: ChainID_QRL, DestChain: ChainID_Ethereum, Asset: NativeAsset, Amount: amount, DestAddress: testETHAddress, Nonce: nonce})))
+	}
+
+	t.Run("AdminPause", func(t *testing.T) {
+		pause := BridgeAdminAction{Scope: AssetPauseScope(NativeAsset), Pause: true, Reason: "audit"}
+		forged, _ := NewBridgeAdminTransaction(0, pause)
+		forged.SenderID = "alice"
+		if err := apply(forged, nil); err == nil {
+			t.Errorf("Expected admin transaction from another account to be rejected")
+		}
+		if err := apply(NewBridgeAdminTransaction(0, pause)); err != nil {
+			t.Fatalf("Admin pause failed: %v", err)
+		}
+		if err := apply(NewBridgeAdminTransaction(0, pause)); err == nil {
+			t.Errorf("Expected pausing twice to be rejected")
+		}
+		if pauses, _ := BridgePauses(db); len(pauses) != 1 || pauses[0].Reason != "audit" || pauses[0].Height != 150 || pauses[0].Auto {
+			t.Errorf("Expected the pause recorded in state, got %+v", pauses)
+		}
+		if err := outbound(100, 0); err == nil {
+			t.Errorf("Expected intent to be rejected while its asset is paused")
+		}
+		if err := apply(NewBridgeAdminTransaction(0, BridgeAdminAction{Scope: AssetPauseScope(NativeAsset)})); err != nil {
+			t.Fatalf("Admin unpause failed: %v", err)
+		}
+		if err := outbound(100, 0); err != nil {
+			t.Errorf("Expected intent accepted once the pause is lifted, got %v", err)
+		}
+	})
+
+	t.Run("UserCap", func(t *testing.T) {
+		if err := outbound(1_000_000_000_000, 1); err != nil {
+			t.Fatalf("Intent rejected: %v", err)
+		}
+		if err := outbound(1_000_000_000_000, 2); err == nil {
+			t.Errorf("Expected intent over the user's epoch cap to be rejected")
+		}
+		epoch := sm.epochs.EpochOf(150)
+		if volume, _ := GetBridgeVolume(db, epoch, "user/alice/"+NativeAsset); volume != 1_000_000_000_100 {
+			t.Errorf("Expected 1000000000100 bridged by alice, got %d", volume)
+		}
+	})
+
+	t.Run("EscrowAnomaly", func(t *testing.T) {
+		if paused, err := sm.checkBridgeEscrow(); err != nil || paused {
+			t.Fatalf("Expected escrow to cover the open intents, got %v (%v)", paused, err)
+		}
+		_ = db.SetBalance(BridgeEscrowAccount, 100)
+		if paused, err := sm.checkBridgeEscrow(); err != nil || !paused {
+			t.Fatalf("Expected missing escrow to pause bridging, got %v (%v)", paused, err)
+		}
+		if p, _ := GetBridgePause(db, PauseScopeAll); p == nil || !p.Auto {
+			t.Errorf("Expected automatic pause of all bridging, got %+v", p)
+		}
+	})
+}
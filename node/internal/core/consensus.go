@@ -2,7 +2,9 @@ package core
 
 import (
 	"fmt"
-	"math" // Needed for placeholder action calculation
+	"math"
+	"sync"
+	"time"
 )
 
 // ConsensusEngine defines the interface for the consensus mechanism.
@@ -13,64 +15,462 @@ type ConsensusEngine interface {
 	VerifyBlock(block *Block) error
 	// Finalize applies state changes for a finalized block.
 	Finalize(block *Block) error
-	// CalculateAction computes the 'action' S for a block, used in path selection.
-	CalculateAction(block *Block) (float64, error)
-	// SelectPath chooses the canonical chain based on path probabilities (actions).
-	SelectPath( /* chain information */ ) (*Block, error) // Placeholder signature
-	// TODO: Add methods for proposing blocks, handling forks, etc.
+	// CalculateAction computes the 'action' S for a block on top of parent, used in path selection.
+	// It must depend only on data committed in the two blocks, so every node scores a block alike.
+	CalculateAction(block *Block, parent *BlockHeader) (float64, error)
+	// SelectPath chooses the head of the canonical chain among the branches of the tree.
+	SelectPath(tree *BlockTree) (*Block, error)
+	// Prepare creates the header for a new block on top of parent, filling in the
+	// consensus fields (number, parent hash, timestamp, gas limit, WSI value).
+	Prepare(parent *BlockHeader) (*BlockHeader, error)
+	// Seal signs a fully assembled block as proposer.
+	Seal(block *Block, proposer string) error
+	// TODO: Add methods for handling forks, etc.
+}
+
+// ActionWeights configures how strongly each Hamiltonian term contributes to a block's action S.
+type ActionWeights struct {
+	PegPenalty float64 // Multiplies the peg penalty (header WSIValue - TargetPeg)^2
+	Latency    float64 // Cost per second of block interval beyond TargetInterval
+	Validity   float64 // Cost per invalid or unsigned transaction in the block
+	Fees       float64 // Reward per unit of transaction fee (subtracted from S)
+
+	// Proposers choose their timestamps, so the interval is clamped to [TargetInterval, MaxInterval]
+	// before weighting: stamping blocks closer than TargetInterval to their parent earns nothing.
+	// Zero disables the respective bound.
+	TargetInterval time.Duration
+	MaxInterval    time.Duration
+	// MaxFeeReward caps the magnitude of the fee term, bounding what fees paid back to the proposer
+	// through other accounts can buy in fork choice. Zero means no cap.
+	MaxFeeReward float64
+}
+
+// DefaultActionWeights returns the weights used when none are configured.
+func DefaultActionWeights() ActionWeights {
+	return ActionWeights{
+		PegPenalty: 1.0,
+		Latency:    1.0,
+		Validity:   10.0,
+		Fees:       0.001,
+
+		TargetInterval: time.Second,
+		MaxInterval:    time.Minute,
+		MaxFeeReward:   1.0,
+	}
+}
+
+// GapAction is the action of an empty block at MaxInterval, what fork choice charges a branch for
+// each block it is short of a deeper one: skipping a block never scores better than producing a
+// slow one. It is zero without a MaxInterval.
+func (w ActionWeights) GapAction() float64 {
+	if w.MaxInterval <= w.TargetInterval {
+		return 0
+	}
+	return w.Latency * (w.MaxInterval - w.TargetInterval).Seconds()
+}
+
+// DefaultBeta is the default inverse temperature used for path probabilities.
+const DefaultBeta = 1.0
+
+// ActionBreakdown lists the weighted terms that make up a block's action.
+// Total = PegPenalty + Latency + Validity + Fees, where Fees is zero or negative.
+type ActionBreakdown struct {
+	PegPenalty float64
+	Latency    float64
+	Validity   float64
+	Fees       float64
+	Total      float64
+}
+
+// String formats the breakdown for logs and debugging.
+func (ab ActionBreakdown) String() string {
+	return fmt.Sprintf("S=%.6f (peg=%.6f latency=%.6f validity=%.6f fees=%.6f)", ab.Total, ab.PegPenalty, ab.Latency, ab.Validity, ab.Fees)
 }
 
 // PathIntegralConsensus implements a placeholder consensus engine based on path integral concepts.
 type PathIntegralConsensus struct {
+	mu sync.RWMutex
 	// Dependencies (e.g., StateManager, TxPool, P2P interface) will be added here
 	stateManager *StateManager
+	wsiManager   *WSIManager // Optional; reports the header WSI value and sets the peg target
 	// TODO: Add other dependencies
+
+	weights ActionWeights
+	beta    float64 // Inverse "temperature" in the Boltzmann weight exp(-beta * S)
+	// Confidence above which a block is considered final (see UpdateFinality)
+	finalityThreshold float64
+	// Canonical descendants a block needs before it may be finalized
+	finalityDepth uint64
+
+	validation ValidationConfig
+	now        func() time.Time // Local clock, replaceable in tests
+
+	detector *EquivocationDetector // Optional; sees every correctly signed header
 }
 
 // NewPathIntegralConsensus creates a new consensus engine instance.
 func NewPathIntegralConsensus(sm *StateManager /*, other deps */) *PathIntegralConsensus {
 	return &PathIntegralConsensus{
-		stateManager: sm,
+		stateManager:      sm,
+		weights:           DefaultActionWeights(),
+		beta:              DefaultBeta,
+		finalityThreshold: DefaultFinalityThreshold,
+		finalityDepth:     DefaultFinalityDepth,
+		validation:        DefaultValidationConfig(),
+		now:               time.Now,
 	}
 }
 
-// VerifyHeader placeholder implementation.
+// SetValidationConfig replaces the limits used by VerifyHeader and VerifyBlock.
+func (pic *PathIntegralConsensus) SetValidationConfig(cfg ValidationConfig) {
+	pic.mu.Lock()
+	defer pic.mu.Unlock()
+	pic.validation = cfg
+}
+
+// SetEquivocationDetector makes VerifyHeader report correctly signed headers to d.
+func (pic *PathIntegralConsensus) SetEquivocationDetector(d *EquivocationDetector) {
+	pic.mu.Lock()
+	defer pic.mu.Unlock()
+	pic.detector = d
+}
+
+// SetWSIManager configures the WSI manager whose value Prepare commits to new headers and whose
+// target peg the peg penalty term measures against. Without one, the peg term is zero.
+// All nodes of a network must agree on the target, as on the action weights.
+func (pic *PathIntegralConsensus) SetWSIManager(wm *WSIManager) {
+	pic.mu.Lock()
+	defer pic.mu.Unlock()
+	pic.wsiManager = wm
+}
+
+// SetActionWeights replaces the weights of the action terms.
+func (pic *PathIntegralConsensus) SetActionWeights(weights ActionWeights) {
+	pic.mu.Lock()
+	defer pic.mu.Unlock()
+	pic.weights = weights
+}
+
+// SetBeta sets the inverse temperature used in exp(-beta * S). It must be positive and finite.
+func (pic *PathIntegralConsensus) SetBeta(beta float64) error {
+	if beta <= 0 || math.IsNaN(beta) || math.IsInf(beta, 0) {
+		return fmt.Errorf("beta must be positive and finite, got %f", beta)
+	}
+	pic.mu.Lock()
+	defer pic.mu.Unlock()
+	pic.beta = beta
+	return nil
+}
+
+// Beta returns the configured inverse temperature.
+func (pic *PathIntegralConsensus) Beta() float64 {
+	pic.mu.RLock()
+	defer pic.mu.RUnlock()
+	return pic.beta
+}
+
+// VerifyHeader checks the header against its parent and the local clock:
+// parent hash linkage, sequential number, timestamp strictly after the parent and not too far in
+// the future, gas limits, the proposer's signature and eligibility (see ExpectedProposer).
+// Correctly signed headers are passed to the equivocation detector, if one is set. Violations are returned as *ValidationError.
 func (pic *PathIntegralConsensus) VerifyHeader(header *BlockHeader, parent *BlockHeader) error {
-	fmt.Printf("Warning: Using placeholder VerifyHeader for block %d\n", header.Number)
-	// TODO: Implement actual header validation (timestamp, number, parent hash match, etc.)
+	if header == nil || parent == nil {
+		return &ValidationError{Rule: ErrNilHeader, Detail: "header and parent are required"}
+	}
+	pic.mu.RLock()
+	cfg := pic.validation
+	now := pic.now()
+	detector := pic.detector
+	pic.mu.RUnlock()
+
+	if header.ParentHash != parent.Hash() {
+		return newValidationError(header.Number, ErrInvalidParentHash, "got %s, parent is %s", header.ParentHash, parent.Hash())
+	}
+	if header.Number != parent.Number+1 {
+		return newValidationError(header.Number, ErrInvalidNumber, "parent number is %d", parent.Number)
+	}
+	if !header.Timestamp.After(parent.Timestamp) {
+		return newValidationError(header.Number, ErrTimestampNotAfterParent, "%v <= %v", header.Timestamp, parent.Timestamp)
+	}
+	if header.Timestamp.After(now.Add(cfg.MaxClockDrift)) {
+		return newValidationError(header.Number, ErrFutureTimestamp, "%v is more than %v ahead of local time %v", header.Timestamp, cfg.MaxClockDrift, now)
+	}
+	if header.GasLimit > cfg.MaxGasLimit {
+		return newValidationError(header.Number, ErrGasLimitTooHigh, "%d > %d", header.GasLimit, cfg.MaxGasLimit)
+	}
+	if header.GasUsed > header.GasLimit {
+		return newValidationError(header.Number, ErrGasUsedExceedsLimit, "%d > %d", header.GasUsed, header.GasLimit)
+	}
+	if header.WSIValue < 0 || math.IsNaN(header.WSIValue) || math.IsInf(header.WSIValue, 0) {
+		return newValidationError(header.Number, ErrInvalidWSIValue, "%v", header.WSIValue)
+	}
+	if header.Proposer == "" {
+		return newValidationError(header.Number, ErrMissingProposer, "")
+	}
+	if !header.VerifySignature() {
+		return newValidationError(header.Number, ErrInvalidProposerSignature, "proposer %s", header.Proposer)
+	}
+	if detector != nil {
+		detector.Observe(header)
+	}
+	expected, err := pic.ExpectedProposer(parent)
+	if err != nil {
+		return fmt.Errorf("failed to determine proposer for block %d: %w", header.Number, err)
+	}
+	if expected != "" && header.Proposer != expected {
+		return newValidationError(header.Number, ErrIneligibleProposer, "proposer %s, expected %s", header.Proposer, expected)
+	}
 	return nil
 }
 
-// VerifyBlock placeholder implementation.
+// ExpectedProposer returns the validator eligible to propose the child of parent, sampled by stake
+// from the validator set snapshot of the child's epoch (minus jailed validators), seeded by the
+// randomness beacon so the proposer cannot grind the selection through the parent hash.
+// Returns "" when any proposer is accepted: no state manager, or no snapshot/stake for the epoch
+// (e.g., before any validator has bonded).
+func (pic *PathIntegralConsensus) ExpectedProposer(parent *BlockHeader) (string, error) {
+	if pic.stateManager == nil || parent == nil {
+		return "", nil
+	}
+	number := parent.Number + 1
+	set, err := pic.stateManager.ValidatorSet(pic.stateManager.Epochs().EpochOf(number))
+	if err != nil {
+		return "", err
+	}
+	// Validators jailed during the epoch lose their slots immediately
+	if set, err = set.WithoutJailed(pic.stateManager.DB(), number); err != nil {
+		return "", err
+	}
+	seed, err := pic.stateManager.RandomnessAt(number)
+	if err != nil {
+		return "", err
+	}
+	return set.ProposerFor(seed, number), nil
+}
+
+// VerifyBlock checks the block body against its header: size limit, TxRoot, GasUsed, that every
+// transaction is well-formed and correctly signed, and that executing the transactions on a copy
+// of the parent's state yields the header's StateRoot. The live state is not modified.
+// Header rules are checked separately by VerifyHeader.
+// Per-block state is not kept, so the only parent state available is the live one: blocks whose
+// parent is not the last block applied to it are rejected with ErrParentStateUnavailable.
+// TODO: Keep per-block state snapshots so blocks on any branch can be verified.
 func (pic *PathIntegralConsensus) VerifyBlock(block *Block) error {
-	fmt.Printf("Warning: Using placeholder VerifyBlock for block %d\n", block.Header.Number)
-	// TODO: Implement actual block validation (verify header, verify transactions, verify state root)
-	// This might involve re-executing transactions using the StateManager
+	if block == nil || block.Header == nil {
+		return &ValidationError{Rule: ErrNilBlock, Detail: "block and header are required"}
+	}
+	header := block.Header
+	pic.mu.RLock()
+	cfg := pic.validation
+	pic.mu.RUnlock()
+
+	size, err := block.Size()
+	if err != nil {
+		return newValidationError(header.Number, ErrInvalidTransaction, "%v", err)
+	}
+	if size > cfg.MaxBlockSize {
+		return newValidationError(header.Number, ErrBlockTooLarge, "%d bytes > %d", size, cfg.MaxBlockSize)
+	}
+
+	txRoot, err := DeriveTxRoot(block.Transactions)
+	if err != nil {
+		return newValidationError(header.Number, ErrInvalidTransaction, "%v", err)
+	}
+	if txRoot != header.TxRoot {
+		return newValidationError(header.Number, ErrTxRootMismatch, "computed %s, header has %s", txRoot, header.TxRoot)
+	}
+
+	var gasUsed uint64
+	for i, tx := range block.Transactions {
+		if err := tx.ValidateBasic(); err != nil {
+			return newValidationError(header.Number, ErrInvalidTransaction, "tx %d: %v", i, err)
+		}
+		if valid, err := tx.VerifySignatures(); err != nil || !valid {
+			return newValidationError(header.Number, ErrInvalidTransaction, "tx %d: bad signature from %s", i, tx.SenderID)
+		}
+		if pic.stateManager != nil {
+			gasUsed += pic.stateManager.TransactionGas(tx)
+		} else {
+			gasUsed += tx.IntrinsicGas()
+		}
+	}
+	if gasUsed != header.GasUsed {
+		return newValidationError(header.Number, ErrGasUsedMismatch, "transactions use %d, header claims %d", gasUsed, header.GasUsed)
+	}
+
+	if pic.stateManager == nil {
+		return nil // No state to check against (stateless verification)
+	}
+	if err := pic.stateManager.checkParentState(header); err != nil {
+		return err
+	}
+	scratch := pic.stateManager.Copy()
+	if err := scratch.ApplyBlock(block); err != nil {
+		return newValidationError(header.Number, ErrInvalidTransaction, "%v", err)
+	}
+	stateRoot, err := scratch.DB().Root()
+	if err != nil {
+		return newValidationError(header.Number, ErrStateExecutionError, "%v", err)
+	}
+	if stateRoot != header.StateRoot {
+		return newValidationError(header.Number, ErrStateRootMismatch, "computed %s, header has %s", stateRoot, header.StateRoot)
+	}
 	return nil
 }
 
-// Finalize placeholder implementation.
+// Finalize applies the block's transactions to the live state and checks the resulting state root.
+// The block must already have passed VerifyHeader and VerifyBlock.
+// TODO: Persist the block and roll back state on failure once a block store exists.
 func (pic *PathIntegralConsensus) Finalize(block *Block) error {
-	fmt.Printf("Warning: Using placeholder Finalize for block %d\n", block.Header.Number)
-	// TODO: Implement state finalization logic (e.g., writing block to DB, updating canonical chain head)
+	if block == nil || block.Header == nil {
+		return fmt.Errorf("cannot finalize nil block or header")
+	}
+	if pic.stateManager == nil {
+		return fmt.Errorf("cannot finalize block %d without a state manager", block.Header.Number)
+	}
+	if err := pic.stateManager.ApplyBlock(block); err != nil {
+		return fmt.Errorf("failed to apply block %d: %w", block.Header.Number, err)
+	}
+	root, err := pic.stateManager.DB().Root()
+	if err != nil {
+		return fmt.Errorf("failed to compute state root after block %d: %w", block.Header.Number, err)
+	}
+	if root != block.Header.StateRoot {
+		return fmt.Errorf("state root after finalizing block %d is %s, header has %s", block.Header.Number, root, block.Header.StateRoot)
+	}
 	return nil
 }
 
-// CalculateAction placeholder implementation.
-// This should calculate S[Path] or an equivalent block 'cost'. Lower is better.
-func (pic *PathIntegralConsensus) CalculateAction(block *Block) (float64, error) {
+// Prepare creates the header of the next block on top of parent. The timestamp is the local time,
+// bumped past the parent's timestamp if the clock lags, and the gas limit is inherited from the parent
+// (or the configured maximum for a parent without one). With a WSI manager, the header commits its
+// current value for the peg term.
+func (pic *PathIntegralConsensus) Prepare(parent *BlockHeader) (*BlockHeader, error) {
+	if parent == nil {
+		return nil, fmt.Errorf("cannot prepare header without a parent")
+	}
+	pic.mu.RLock()
+	cfg := pic.validation
+	now := pic.now()
+	wsi := pic.wsiManager
+	pic.mu.RUnlock()
+
+	var wsiValue float64
+	if wsi != nil {
+		value, err := wsi.GetValue()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read WSI value for block %d: %w", parent.Number+1, err)
+		}
+		wsiValue = value
+	}
+	timestamp := now
+	if !timestamp.After(parent.Timestamp) {
+		timestamp = parent.Timestamp.Add(time.Millisecond)
+	}
+	gasLimit := parent.GasLimit
+	if gasLimit == 0 || gasLimit > cfg.MaxGasLimit {
+		gasLimit = cfg.MaxGasLimit
+	}
+	return &BlockHeader{
+		ParentHash: parent.Hash(),
+		Number:     parent.Number + 1,
+		Timestamp:  timestamp,
+		GasLimit:   gasLimit,
+		WSIValue:   wsiValue,
+	}, nil
+}
+
+// Seal signs the block header as proposer.
+func (pic *PathIntegralConsensus) Seal(block *Block, proposer string) error {
+	if block == nil || block.Header == nil {
+		return fmt.Errorf("cannot seal nil block or header")
+	}
+	return block.Header.Sign(proposer)
+}
+
+// CalculateAction computes the action S of a block on top of parent. Lower is better.
+// See CalculateActionBreakdown for the individual terms.
+func (pic *PathIntegralConsensus) CalculateAction(block *Block, parent *BlockHeader) (float64, error) {
+	breakdown, err := pic.CalculateActionBreakdown(block, parent)
+	if err != nil {
+		return math.Inf(1), err
+	}
+	return breakdown.Total, nil
+}
+
+// CalculateActionBreakdown computes each weighted term of the block action, following the whitepaper's
+// S = Σ (Hamiltonian costs + network costs + validity penalties − fee rewards):
+//   - PegPenalty: deviation of the header's WSIValue from the target peg
+//   - Latency: block interval (timestamp minus the parent's) clamped to the weights' bounds, in
+//     seconds beyond TargetInterval
+//   - Validity: number of transactions that fail basic validation or signature checks
+//   - Fees: sum of fees of transactions not sent by the block's proposer, who is credited them
+//     back, entering with a negative sign and capped at MaxFeeReward
+//
+// Every term is computed from the block and its parent header alone, never from local
+// observations or live oracle readings, so all nodes assign the same action to the same block.
+func (pic *PathIntegralConsensus) CalculateActionBreakdown(block *Block, parent *BlockHeader) (*ActionBreakdown, error) {
 	if block == nil || block.Header == nil {
-		return math.Inf(1), fmt.Errorf("cannot calculate action for nil block or header")
+		return nil, fmt.Errorf("cannot calculate action for nil block or header")
+	}
+	if parent == nil {
+		return nil, fmt.Errorf("cannot calculate action for block %d without its parent", block.Header.Number)
+	}
+
+	pic.mu.RLock()
+	weights := pic.weights
+	wsi := pic.wsiManager
+	pic.mu.RUnlock()
+
+	breakdown := &ActionBreakdown{}
+
+	// Hamiltonian term: deviation of the committed WSI value from the peg
+	if wsi != nil && weights.PegPenalty != 0 {
+		breakdown.PegPenalty = weights.PegPenalty * wsi.PegPenaltyOf(block.Header.WSIValue)
+	}
+
+	// Network term: committed block interval
+	interval := block.Header.Timestamp.Sub(parent.Timestamp)
+	if interval < 0 {
+		return nil, fmt.Errorf("block %d is timestamped before its parent", block.Header.Number)
+	}
+	if weights.MaxInterval > 0 {
+		interval = min(interval, weights.MaxInterval)
+	}
+	interval = max(interval, weights.TargetInterval) - weights.TargetInterval
+	breakdown.Latency = weights.Latency * interval.Seconds()
+
+	// Validity/security term and fee rewards
+	invalidTxs := 0
+	var totalFees uint64
+	for _, tx := range block.Transactions {
+		if tx == nil {
+			invalidTxs++
+			continue
+		}
+		if err := tx.ValidateBasic(); err != nil {
+			invalidTxs++
+			continue
+		}
+		if valid, err := tx.VerifySignatures(); err != nil || !valid {
+			invalidTxs++
+			continue
+		}
+		if tx.SenderID != block.Header.Proposer {
+			totalFees += tx.Fee
+		}
 	}
-	fmt.Printf("Warning: Using placeholder CalculateAction for block %d\n", block.Header.Number)
-	// TODO: Implement actual action calculation based on whitepaper:
-	// - Include costs from Hamiltonian (WSI peg, native function costs)
-	// - Include network costs (latency - if available)
-	// - Include validity/security terms
-	// - Include transaction fees (potentially negative cost)
-	// Placeholder: Use block number as a simple proxy (lower number = lower action/cost)
-	action := float64(block.Header.Number)
-	return action, nil
+	breakdown.Validity = weights.Validity * float64(invalidTxs)
+	breakdown.Fees = -weights.Fees * float64(totalFees)
+	if weights.MaxFeeReward > 0 {
+		breakdown.Fees = max(breakdown.Fees, -weights.MaxFeeReward)
+	}
+
+	breakdown.Total = breakdown.PegPenalty + breakdown.Latency + breakdown.Validity + breakdown.Fees
+	if math.IsNaN(breakdown.Total) || math.IsInf(breakdown.Total, 0) {
+		return nil, fmt.Errorf("action for block %d is not finite: %s", block.Header.Number, breakdown)
+	}
+	return breakdown, nil
 }
 
 // CalculateProbability computes the relative probability of a block/path based on its action.
@@ -81,16 +481,61 @@ func (pic *PathIntegralConsensus) CalculateProbability(action float64) (float64,
 		// Return 0 probability or handle as error depending on desired behavior
 		return 0.0, fmt.Errorf("invalid action value (NaN or Inf)")
 	}
-	// TODO: Make beta configurable
-	beta := 1.0
 	// Note: This is an unnormalized probability
-	probability := math.Exp(-beta * action)
+	probability := math.Exp(-pic.Beta() * action)
 	return probability, nil
 }
 
-// SelectPath placeholder implementation.
-func (pic *PathIntegralConsensus) SelectPath( /* chain information */ ) (*Block, error) {
-	fmt.Println("Warning: Using placeholder SelectPath")
-	// TODO: Implement fork choice rule based on comparing cumulative actions/probabilities of competing chains.
-	return nil, fmt.Errorf("SelectPath not implemented")
+// BranchWeight pairs a branch with its Boltzmann weight relative to the best branch.
+type BranchWeight struct {
+	Branch BranchInfo
+	Weight float64 // exp(-beta * (s - s_min)) of the window action s; the best branch has weight 1
+}
+
+// BranchWeights computes the relative Boltzmann weight of every branch in the tree from its
+// cumulative action over the common window the tree's fork choice uses (see BlockTree), so
+// branches of different lengths are weighed over the same depth. Weights are taken relative to
+// the lowest window action so they do not underflow.
+func (pic *PathIntegralConsensus) BranchWeights(tree *BlockTree) ([]BranchWeight, error) {
+	if tree == nil {
+		return nil, fmt.Errorf("cannot weigh branches of nil block tree")
+	}
+	branches := tree.Branches()
+	minAction := math.Inf(1)
+	for _, b := range branches {
+		minAction = math.Min(minAction, b.WindowAction)
+	}
+
+	weights := make([]BranchWeight, 0, len(branches))
+	for _, b := range branches {
+		w, err := pic.CalculateProbability(b.WindowAction - minAction)
+		if err != nil {
+			return nil, fmt.Errorf("invalid action for branch %s: %w", b.TipHash, err)
+		}
+		weights = append(weights, BranchWeight{Branch: b, Weight: w})
+	}
+	return weights, nil
+}
+
+// SelectPath implements the fork choice rule: the branch with the highest Boltzmann weight wins,
+// which is the branch with the lowest window action. Equal weights go to the longer branch,
+// then the lowest tip hash, so every node selects the same head as the block tree.
+func (pic *PathIntegralConsensus) SelectPath(tree *BlockTree) (*Block, error) {
+	weights, err := pic.BranchWeights(tree)
+	if err != nil {
+		return nil, err
+	}
+	var best *BranchWeight
+	for i := range weights {
+		w := &weights[i]
+		if best == nil || w.Weight > best.Weight ||
+			(w.Weight == best.Weight && w.Branch.Length > best.Branch.Length) ||
+			(w.Weight == best.Weight && w.Branch.Length == best.Branch.Length && w.Branch.TipHash.Less(best.Branch.TipHash)) {
+			best = w
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("block tree has no branches")
+	}
+	return best.Branch.Tip, nil
 }
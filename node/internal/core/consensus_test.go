@@ -1,6 +1,7 @@
 package core
 
 import (
+	"fmt"
 	"math"
 	"testing"
 	"time"
@@ -23,36 +24,183 @@ func TestActionCalculation(t *testing.T) {
 	db := NewInMemoryStateDB()
 	sm := NewStateManager(db)
 	consensus := NewPathIntegralConsensus(sm)
+	// parentAt returns a parent header timestamped interval before block
+	parentAt := func(block *Block, interval time.Duration) *BlockHeader {
+		return &BlockHeader{Number: block.Header.Number - 1, Timestamp: block.Header.Timestamp.Add(-interval)}
+	}
 
-	t.Run("CalculateActionBasic", func(t *testing.T) {
-		parentHash := Hash{} // Genesis block parent hash
-		block1 := createTestBlock(1, parentHash)
-		block2 := createTestBlock(2, Hash{1}) // Assume block1's hash is {1}
+	t.Run("CalculateActionEmptyBlock", func(t *testing.T) {
+		block := createTestBlock(1, Hash{})
+		action, err := consensus.CalculateAction(block, parentAt(block, 0))
+		if err != nil {
+			t.Fatalf("CalculateAction failed unexpectedly: %v", err)
+		}
+		// No WSI manager, no block interval, no transactions: every term is zero
+		if action != 0 {
+			t.Errorf("Expected zero action for an empty block, got %.4f", action)
+		}
+	})
 
-		action1, err1 := consensus.CalculateAction(block1)
-		action2, err2 := consensus.CalculateAction(block2)
+	t.Run("FeesLowerAction", func(t *testing.T) {
+		cheap := createTestBlock(1, Hash{})
+		rich := createTestBlock(1, Hash{})
+		tx := NewBaseTransaction(TxTypeTransfer, 0, "senderA", "recipientB", 10)
+		tx.Fee = 1000
+		_ = tx.Sign()
+		rich.Transactions = []*Transaction{tx}
 
+		cheapAction, err1 := consensus.CalculateAction(cheap, parentAt(cheap, time.Second))
+		richAction, err2 := consensus.CalculateAction(rich, parentAt(rich, time.Second))
 		if err1 != nil || err2 != nil {
 			t.Fatalf("CalculateAction failed unexpectedly: err1=%v, err2=%v", err1, err2)
 		}
+		if richAction >= cheapAction {
+			t.Errorf("Expected fees to lower the action, got rich=%.4f cheap=%.4f", richAction, cheapAction)
+		}
+	})
+
+	t.Run("ProposerFees", func(t *testing.T) {
+		// The proposer is credited the fees of its own transactions back, so they earn no reward
+		block := createTestBlock(1, Hash{})
+		block.Header.Proposer = "senderA"
+		tx := NewBaseTransaction(TxTypeTransfer, 0, "senderA", "recipientB", 10)
+		tx.Fee = 1_000_000
+		_ = tx.Sign()
+		block.Transactions = []*Transaction{tx}
+		breakdown, err := consensus.CalculateActionBreakdown(block, parentAt(block, time.Second))
+		if err != nil || breakdown.Fees != 0 {
+			t.Errorf("Expected no reward for the proposer's own fees, got %v (%v)", breakdown, err)
+		}
+
+		// Fees paid through another account are capped
+		block.Header.Proposer = "proposerP"
+		breakdown, err = consensus.CalculateActionBreakdown(block, parentAt(block, time.Second))
+		if err != nil || breakdown.Fees != -DefaultActionWeights().MaxFeeReward {
+			t.Errorf("Expected the fee reward capped at %v, got %v (%v)", DefaultActionWeights().MaxFeeReward, breakdown, err)
+		}
+	})
+
+	t.Run("IntervalBounds", func(t *testing.T) {
+		block := createTestBlock(1, Hash{})
+		intervals := map[time.Duration]float64{
+			time.Millisecond: 0, // Stamping right after the parent earns nothing below the target
+			time.Second:      0,
+			3 * time.Second:  2,
+			time.Hour:        59,
+		}
+		for interval, want := range intervals {
+			breakdown, err := consensus.CalculateActionBreakdown(block, parentAt(block, interval))
+			if err != nil || math.Abs(breakdown.Latency-want) > 1e-9 {
+				t.Errorf("Expected latency %v for interval %s, got %v (%v)", want, interval, breakdown, err)
+			}
+		}
+	})
+
+	t.Run("BreakdownTerms", func(t *testing.T) {
+		local := NewPathIntegralConsensus(sm)
+		local.SetActionWeights(ActionWeights{PegPenalty: 2.0, Latency: 0.5, Validity: 10.0, Fees: 0.01})
+		wsi := NewWSIManager(1.0)
+		local.SetWSIManager(wsi)
+
+		block := createTestBlock(5, Hash{})
+		block.Header.WSIValue = 1.1
+		signed := NewBaseTransaction(TxTypeTransfer, 0, "senderA", "recipientB", 10)
+		signed.Fee = 100
+		_ = signed.Sign()
+		unsigned := NewBaseTransaction(TxTypeTransfer, 1, "senderA", "recipientB", 10)
+		unsigned.Fee = 500 // Fees of invalid transactions are not rewarded
+		block.Transactions = []*Transaction{signed, unsigned}
+		parent := parentAt(block, 2*time.Second)
+
+		breakdown, err := local.CalculateActionBreakdown(block, parent)
+		if err != nil {
+			t.Fatalf("CalculateActionBreakdown failed: %v", err)
+		}
+		tolerance := 1e-9
+		checks := []struct {
+			name      string
+			got, want float64
+		}{
+			{"PegPenalty", breakdown.PegPenalty, 2.0 * 0.1 * 0.1},
+			{"Latency", breakdown.Latency, 0.5 * 2.0},
+			{"Validity", breakdown.Validity, 10.0},
+			{"Fees", breakdown.Fees, -0.01 * 100},
+			{"Total", breakdown.Total, 0.02 + 1.0 + 10.0 - 1.0},
+		}
+		for _, c := range checks {
+			if math.Abs(c.got-c.want) > tolerance {
+				t.Errorf("%s: expected %.6f, got %.6f", c.name, c.want, c.got)
+			}
+		}
 
-		// Placeholder assertion: Check if action increases with block number
-		// (Based on current placeholder implementation in consensus.go)
-		if action1 >= action2 {
-			t.Errorf("Expected action to increase with block number (placeholder logic), but got action1=%.4f, action2=%.4f", action1, action2)
+		action, err := local.CalculateAction(block, parent)
+		if err != nil || math.Abs(action-breakdown.Total) > tolerance {
+			t.Errorf("CalculateAction (%.6f, %v) should equal breakdown total %.6f", action, err, breakdown.Total)
 		}
+	})
 
-		// Basic check for non-infinite action
-		if math.IsInf(action1, 0) || math.IsInf(action2, 0) { // Correct check for infinity
-			t.Errorf("Expected finite action values, got action1=%.4f, action2=%.4f", action1, action2)
+	t.Run("Deterministic", func(t *testing.T) {
+		// The action depends only on the block and its parent, not on this node's oracle readings
+		offline := NewWSIManager(1.0)
+		weight := NewParameter("w_qUSDC", &mockDistribution{stdDev: 0.1})
+		_ = offline.AddConstituent("qUSDC", weight, &mockWSIOracle{err: fmt.Errorf("oracle offline")})
+		online := NewWSIManager(1.0)
+		weight.CurrentValue = 1.0
+		_ = online.AddConstituent("qUSDC", weight, &mockWSIOracle{prices: map[string]float64{"qUSDC": 3.0}})
+
+		block := createTestBlock(1, Hash{})
+		block.Header.WSIValue = 1.2
+		parent := parentAt(block, time.Second)
+		var actions []float64
+		for _, wsi := range []*WSIManager{offline, online} {
+			local := NewPathIntegralConsensus(sm)
+			local.SetWSIManager(wsi)
+			action, err := local.CalculateAction(block, parent)
+			if err != nil {
+				t.Fatalf("CalculateAction failed: %v", err)
+			}
+			actions = append(actions, action)
+		}
+		if actions[0] != actions[1] {
+			t.Errorf("Expected nodes with different oracle readings to agree on the action, got %v", actions)
+		}
+	})
+
+	t.Run("PrepareCommitsWSIValue", func(t *testing.T) {
+		local := NewPathIntegralConsensus(sm)
+		wsi := NewWSIManager(1.0)
+		weight := NewParameter("w_qUSDC", &mockDistribution{stdDev: 0.1})
+		weight.CurrentValue = 1.0
+		oracle := &mockWSIOracle{prices: map[string]float64{"qUSDC": 1.1}}
+		_ = wsi.AddConstituent("qUSDC", weight, oracle)
+		local.SetWSIManager(wsi)
+
+		parent := &BlockHeader{Number: 0, Timestamp: time.Now().Add(-time.Second)}
+		header, err := local.Prepare(parent)
+		if err != nil {
+			t.Fatalf("Prepare failed: %v", err)
+		}
+		if math.Abs(header.WSIValue-1.1) > 1e-9 {
+			t.Errorf("Expected the header to commit WSI value 1.1, got %f", header.WSIValue)
+		}
+		oracle.err = fmt.Errorf("oracle offline")
+		if _, err := local.Prepare(parent); err == nil {
+			t.Errorf("Expected Prepare to fail when the WSI oracle fails")
+		}
+	})
+
+	t.Run("MissingParent", func(t *testing.T) {
+		action, err := consensus.CalculateAction(createTestBlock(1, Hash{}), nil)
+		if err == nil {
+			t.Errorf("Expected error without the parent header, got nil")
+		}
+		if !math.IsInf(action, 1) {
+			t.Errorf("Expected +Inf action on error, got %.4f", action)
 		}
-		// TODO: Add more sophisticated tests when real action calculation is implemented
-		// - Test influence of transaction fees
-		// - Test influence of Hamiltonian cost terms (mocked)
 	})
 
 	t.Run("CalculateActionNilBlock", func(t *testing.T) {
-		_, err := consensus.CalculateAction(nil)
+		_, err := consensus.CalculateAction(nil, &BlockHeader{})
 		if err == nil {
 			t.Errorf("Expected error when calculating action for nil block, but got nil")
 		}
@@ -107,27 +255,83 @@ func TestProbabilityCalculation(t *testing.T) {
 }
 
 func TestForkChoiceRule(t *testing.T) {
-	// Setup
-	// db := NewInMemoryStateDB() // Uncomment when implementing test
-	// sm := NewStateManager(db) // Uncomment when implementing test
-	// consensus := NewPathIntegralConsensus(sm) // Uncomment when implementing test
+	db := NewInMemoryStateDB()
+	sm := NewStateManager(db)
+	consensus := NewPathIntegralConsensus(sm)
+
+	// Per-block actions keyed by block hash, so sibling blocks can score differently
+	actions := make(map[Hash]float64)
+	actionFn := func(block *Block, parent *BlockHeader) (float64, error) {
+		hash, _ := block.Hash()
+		return actions[hash], nil
+	}
+	addWithAction := func(tree *BlockTree, block *Block, action float64) {
+		hash, _ := block.Hash()
+		actions[hash] = action
+		if err := tree.AddBlock(block); err != nil {
+			t.Fatalf("AddBlock failed: %v", err)
+		}
+	}
 
 	t.Run("SelectBestPath", func(t *testing.T) {
-		t.Skip("Skipping fork choice test: SelectPath not implemented")
-
-		// TODO: Implement this test once blockchain structure and SelectPath are available.
-		// 1. Create mock blocks representing competing chains (e.g., chain A, chain B)
-		//    - Ensure blocks have different actions calculated (using placeholder CalculateAction for now)
-		// 2. Store these blocks/chains in a structure accessible to SelectPath (e.g., a mock Blockchain object)
-		// 3. Call consensus.SelectPath(...)\n\t\t// 4. Verify that the block belonging to the chain with the lowest cumulative action (highest probability) is returned.
-
-		// Example placeholder structure (replace with actual implementation later)
-		// chainA := []*Block{createTestBlock(1, Hash{}), createTestBlock(2, Hash{1})} // Action ~ 1+2 = 3
-		// chainB := []*Block{createTestBlock(1, Hash{}), createTestBlock(3, Hash{1})} // Action ~ 1+3 = 4
-		// mockBlockchain := NewMockBlockchain(chainA, chainB)
-		// bestBlock, err := consensus.SelectPath(mockBlockchain)
-		// if err != nil { t.Fatalf(\"SelectPath failed: %v\", err) }
-		// if bestBlock != chainA[len(chainA)-1] { t.Errorf(\"Expected best block from chain A\") }
+		genesis := testGenesis()
+		tree, _ := NewBlockTree(genesis, actionFn)
+
+		// Chain A: actions 1 + 2, cumulative 3; chain B: actions 1 + 3, cumulative 4
+		a1 := childOf(genesis, 0)
+		addWithAction(tree, a1, 1)
+		a2 := childOf(a1, 0)
+		addWithAction(tree, a2, 2)
+		b1 := childOf(genesis, 1)
+		addWithAction(tree, b1, 1)
+		b2 := childOf(b1, 0)
+		addWithAction(tree, b2, 3)
+
+		best, err := consensus.SelectPath(tree)
+		if err != nil {
+			t.Fatalf("SelectPath failed: %v", err)
+		}
+		if best != a2 {
+			t.Errorf("Expected best block from chain A")
+		}
+		if best != tree.Head() {
+			t.Errorf("SelectPath and the tree head disagree")
+		}
+
+		weights, err := consensus.BranchWeights(tree)
+		if err != nil {
+			t.Fatalf("BranchWeights failed: %v", err)
+		}
+		for _, w := range weights {
+			if w.Branch.Tip == a2 && w.Weight != 1.0 {
+				t.Errorf("Expected best branch to have relative weight 1, got %f", w.Weight)
+			}
+			if w.Branch.Tip == b2 && math.Abs(w.Weight-math.Exp(-1)) > 1e-9 {
+				t.Errorf("Expected chain B weight exp(-(4-3)), got %f", w.Weight)
+			}
+		}
+	})
+
+	t.Run("ConfigurableBeta", func(t *testing.T) {
+		local := NewPathIntegralConsensus(sm)
+		if err := local.SetBeta(2.0); err != nil {
+			t.Fatalf("SetBeta failed: %v", err)
+		}
+		p, _ := local.CalculateProbability(1.0)
+		if math.Abs(p-math.Exp(-2.0)) > 1e-12 {
+			t.Errorf("Expected exp(-2) with beta=2, got %f", p)
+		}
+		for _, invalid := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+			if err := local.SetBeta(invalid); err == nil {
+				t.Errorf("Expected error for beta=%f", invalid)
+			}
+		}
+	})
+
+	t.Run("NilTree", func(t *testing.T) {
+		if _, err := consensus.SelectPath(nil); err == nil {
+			t.Errorf("Expected error selecting path on nil tree")
+		}
 	})
 }
 
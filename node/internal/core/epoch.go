@@ -0,0 +1,305 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultEpochLength is the number of blocks per epoch when none is configured.
+const DefaultEpochLength = 100
+
+// Storage key prefixes for epoch bookkeeping.
+const (
+	epochTxsPrefix     = "epoch/txs/"     // epoch/txs/<epoch> -> transactions applied so far (uint64)
+	epochFeesPrefix    = "epoch/fees/"    // epoch/fees/<epoch> -> fees paid so far (uint64)
+	epochNotesPrefix   = "epoch/notes/"   // epoch/notes/<epoch> -> notes left by begin hooks ([]EpochNote)
+	epochSummaryPrefix = "epoch/summary/" // epoch/summary/<epoch> -> EpochSummary written after the epoch's last block
+)
+
+// epochKey returns the storage key under prefix for epoch. Zero-padded so keys sort by epoch.
+func epochKey(prefix string, epoch uint64) string {
+	return fmt.Sprintf("%s%020d", prefix, epoch)
+}
+
+// EpochNote is a key/value recorded by an epoch hook in the epoch summary.
+type EpochNote struct {
+	Key   string
+	Value string
+}
+
+// EpochSummary is recorded in state after the last block of every epoch.
+type EpochSummary struct {
+	Epoch        uint64
+	FirstBlock   uint64
+	LastBlock    uint64
+	Transactions uint64      // Transactions applied during the epoch
+	Fees         uint64      // Fees paid during the epoch
+	EndHooks     []string    // End-of-epoch hooks that ran, in order
+	Notes        []EpochNote // Notes left by begin and end hooks, sorted by key
+}
+
+// GetEpochSummary returns the summary recorded for epoch, or nil if the epoch has not ended.
+func GetEpochSummary(db StateDB, epoch uint64) (*EpochSummary, error) {
+	summary := &EpochSummary{}
+	found, err := getStorageGob(db, epochKey(epochSummaryPrefix, epoch), summary)
+	if err != nil || !found {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// EpochContext is passed to epoch hooks.
+type EpochContext struct {
+	Epoch uint64
+	Block *BlockHeader  // First block of the epoch for begin hooks, last block for end hooks
+	State *StateManager // State being executed; hooks must only change state through it
+	notes []EpochNote
+}
+
+// Note records a key/value in the epoch summary (e.g., how many intents were netted).
+func (ctx *EpochContext) Note(key, value string) {
+	ctx.notes = append(ctx.notes, EpochNote{Key: key, Value: value})
+}
+
+// EpochHook runs at an epoch boundary. Hooks are part of the state transition: they run whenever a
+// block is executed (validation, production, import), so they must be deterministic functions of
+// the state and must not have side effects outside it.
+type EpochHook func(ctx *EpochContext) error
+
+// namedEpochHook pairs a hook with the name it was registered under.
+type namedEpochHook struct {
+	name string
+	hook EpochHook
+}
+
+// EpochManager defines epochs by block height and runs the hooks subsystems register for
+// epoch boundaries. Begin hooks run before the transactions of an epoch's first block, end hooks
+// after the transactions of its last block, in registration order.
+type EpochManager struct {
+	mu     sync.RWMutex
+	length uint64
+	begin  []namedEpochHook
+	end    []namedEpochHook
+}
+
+// NewEpochManager creates an epoch manager with the given epoch length in blocks.
+func NewEpochManager(length uint64) (*EpochManager, error) {
+	if length == 0 {
+		return nil, fmt.Errorf("epoch length must be positive")
+	}
+	return &EpochManager{length: length}, nil
+}
+
+// Length returns the number of blocks per epoch.
+func (em *EpochManager) Length() uint64 {
+	em.mu.RLock()
+	defer em.mu.RUnlock()
+	return em.length
+}
+
+// SetLength changes the epoch length. Only meant for configuration before the first block.
+func (em *EpochManager) SetLength(length uint64) error {
+	if length == 0 {
+		return fmt.Errorf("epoch length must be positive")
+	}
+	em.mu.Lock()
+	defer em.mu.Unlock()
+	em.length = length
+	return nil
+}
+
+// EpochOf returns the epoch containing block number.
+func (em *EpochManager) EpochOf(number uint64) uint64 {
+	return number / em.Length()
+}
+
+// FirstBlock returns the first block number of epoch.
+func (em *EpochManager) FirstBlock(epoch uint64) uint64 {
+	return epoch * em.Length()
+}
+
+// LastBlock returns the last block number of epoch.
+func (em *EpochManager) LastBlock(epoch uint64) uint64 {
+	return (epoch+1)*em.Length() - 1
+}
+
+// IsFirstBlock reports whether number starts an epoch.
+func (em *EpochManager) IsFirstBlock(number uint64) bool {
+	return number%em.Length() == 0
+}
+
+// IsLastBlock reports whether number ends an epoch.
+func (em *EpochManager) IsLastBlock(number uint64) bool {
+	return (number+1)%em.Length() == 0
+}
+
+// OnEpochBegin registers a hook to run at the start of every epoch. Names must be unique.
+func (em *EpochManager) OnEpochBegin(name string, hook EpochHook) error {
+	return em.register(&em.begin, name, hook)
+}
+
+// OnEpochEnd registers a hook to run at the end of every epoch. Names must be unique.
+func (em *EpochManager) OnEpochEnd(name string, hook EpochHook) error {
+	return em.register(&em.end, name, hook)
+}
+
+// register appends a named hook to hooks.
+func (em *EpochManager) register(hooks *[]namedEpochHook, name string, hook EpochHook) error {
+	if name == "" || hook == nil {
+		return fmt.Errorf("epoch hook needs a name and a function")
+	}
+	em.mu.Lock()
+	defer em.mu.Unlock()
+	for _, h := range *hooks {
+		if h.name == name {
+			return fmt.Errorf("epoch hook %q already registered", name)
+		}
+	}
+	*hooks = append(*hooks, namedEpochHook{name: name, hook: hook})
+	return nil
+}
+
+// hooks returns a snapshot of the registered begin or end hooks.
+func (em *EpochManager) hooks(end bool) []namedEpochHook {
+	em.mu.RLock()
+	defer em.mu.RUnlock()
+	if end {
+		return append([]namedEpochHook(nil), em.end...)
+	}
+	return append([]namedEpochHook(nil), em.begin...)
+}
+
+// run executes hooks in order, returning the names that ran.
+func runEpochHooks(hooks []namedEpochHook, ctx *EpochContext) ([]string, error) {
+	names := make([]string, 0, len(hooks))
+	for _, h := range hooks {
+		if err := h.hook(ctx); err != nil {
+			return nil, fmt.Errorf("epoch %d hook %q: %w", ctx.Epoch, h.name, err)
+		}
+		names = append(names, h.name)
+	}
+	return names, nil
+}
+
+// registerCoreEpochHooks registers the end-of-epoch work of the core subsystems: settling the
+// randomness beacon, rotating the validator set, netting bridge intents, expiring them, checking
+// the bridge escrow and applying scheduled parameter changes.
+func registerCoreEpochHooks(em *EpochManager) {
+	_ = em.OnEpochEnd("beacon", func(ctx *EpochContext) error {
+		return ctx.State.finalizeBeaconEpoch(ctx.Epoch)
+	})
+	_ = em.OnEpochEnd("validators", func(ctx *EpochContext) error {
+		set, err := ctx.State.SnapshotValidatorSet(ctx.Epoch + 1)
+		if err != nil {
+			return err
+		}
+		ctx.Note("validators", fmt.Sprintf("%d validators, total stake %d", len(set.Validators), set.TotalStake))
+		return nil
+	})
+	_ = em.OnEpochEnd("bridge", func(ctx *EpochContext) error {
+		report, err := ctx.State.netBridgeEpoch(ctx.Epoch, ctx.Block.Number)
+		if err != nil {
+			return err
+		}
+		if report.Intents > 0 || len(report.Failed) > 0 {
+			ctx.Note("bridge", report.String())
+		}
+		refunded, failed, err := ctx.State.expireBridgeIntents(ctx.Block.Number)
+		if err != nil {
+			return err
+		}
+		if refunded > 0 || failed > 0 {
+			ctx.Note("bridge.expired", fmt.Sprintf("%d refunded, %d failed", refunded, failed))
+		}
+		paused, err := ctx.State.checkBridgeEscrow()
+		if err != nil {
+			return err
+		}
+		if paused {
+			ctx.Note("bridge.paused", PauseScopeAll)
+		}
+		return nil
+	})
+	// Registered after the other core hooks so they still see this epoch's values
+	_ = em.OnEpochEnd("parameters", func(ctx *EpochContext) error {
+		names, err := ctx.State.applyScheduledParameters(ctx.Epoch)
+		if err != nil {
+			return err
+		}
+		if len(names) > 0 {
+			ctx.Note("parameters", strings.Join(names, ","))
+		}
+		return nil
+	})
+}
+
+// --- StateManager integration ---
+
+// beginEpoch runs the begin hooks for the epoch starting at header and keeps their notes for the summary.
+func (sm *StateManager) beginEpoch(header *BlockHeader) error {
+	ctx := &EpochContext{Epoch: sm.epochs.EpochOf(header.Number), Block: header, State: sm}
+	if _, err := runEpochHooks(sm.epochs.hooks(false), ctx); err != nil {
+		return err
+	}
+	if len(ctx.notes) == 0 {
+		return nil
+	}
+	return setStorageGob(sm.db, epochKey(epochNotesPrefix, ctx.Epoch), ctx.notes)
+}
+
+// endEpoch runs the end hooks for the epoch ending at header and records the epoch summary.
+func (sm *StateManager) endEpoch(header *BlockHeader) error {
+	epoch := sm.epochs.EpochOf(header.Number)
+	ctx := &EpochContext{Epoch: epoch, Block: header, State: sm}
+	if _, err := getStorageGob(sm.db, epochKey(epochNotesPrefix, epoch), &ctx.notes); err != nil {
+		return err
+	}
+	names, err := runEpochHooks(sm.epochs.hooks(true), ctx)
+	if err != nil {
+		return err
+	}
+
+	summary := &EpochSummary{
+		Epoch:      epoch,
+		FirstBlock: sm.epochs.FirstBlock(epoch),
+		LastBlock:  header.Number,
+		EndHooks:   names,
+		Notes:      ctx.notes,
+	}
+	sort.SliceStable(summary.Notes, func(i, j int) bool { return summary.Notes[i].Key < summary.Notes[j].Key })
+	if summary.Transactions, err = getStorageUint64(sm.db, epochKey(epochTxsPrefix, epoch)); err != nil {
+		return err
+	}
+	if summary.Fees, err = getStorageUint64(sm.db, epochKey(epochFeesPrefix, epoch)); err != nil {
+		return err
+	}
+	if err := setStorageGob(sm.db, epochKey(epochSummaryPrefix, epoch), summary); err != nil {
+		return err
+	}
+	// The running counters are folded into the summary
+	for _, prefix := range []string{epochTxsPrefix, epochFeesPrefix, epochNotesPrefix} {
+		if err := sm.db.SetStorage(epochKey(prefix, epoch), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordEpochTransaction counts an applied transaction and its fee towards the current epoch.
+func (sm *StateManager) recordEpochTransaction(tx *Transaction) error {
+	epoch := sm.epochs.EpochOf(sm.blockNumber())
+	txs, err := getStorageUint64(sm.db, epochKey(epochTxsPrefix, epoch))
+	if err != nil {
+		return err
+	}
+	if err := setStorageUint64(sm.db, epochKey(epochTxsPrefix, epoch), txs+1); err != nil {
+		return err
+	}
+	fees, err := getStorageUint64(sm.db, epochKey(epochFeesPrefix, epoch))
+	if err != nil {
+		return err
+	}
+	return setStorageUint64(sm.db, epochKey(epochFeesPrefix, epoch), fees+tx.Fee)
+}
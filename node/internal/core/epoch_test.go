@@ -0,0 +1,177 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestEpochManager_Boundaries(t *testing.T) {
+	if _, err := NewEpochManager(0); err == nil {
+		t.Fatalf("Expected error for a zero epoch length")
+	}
+	em, _ := NewEpochManager(4)
+	if em.EpochOf(3) != 0 || em.EpochOf(4) != 1 || em.FirstBlock(2) != 8 || em.LastBlock(2) != 11 {
+		t.Errorf("Unexpected epoch arithmetic")
+	}
+	if !em.IsFirstBlock(8) || em.IsFirstBlock(9) || !em.IsLastBlock(11) || em.IsLastBlock(8) {
+		t.Errorf("Unexpected epoch boundary detection")
+	}
+
+	t.Run("DuplicateHook", func(t *testing.T) {
+		hook := func(*EpochContext) error { return nil }
+		if err := em.OnEpochEnd("netting", hook); err != nil {
+			t.Fatalf("OnEpochEnd failed: %v", err)
+		}
+		if err := em.OnEpochEnd("netting", hook); err == nil {
+			t.Errorf("Expected duplicate hook name to be rejected")
+		}
+		if err := em.OnEpochBegin("netting", hook); err != nil {
+			t.Errorf("Begin and end hooks have separate names, got %v", err)
+		}
+	})
+}
+
+func TestEpochManager_Hooks(t *testing.T) {
+	db := NewInMemoryStateDB()
+	_ = db.SetBalance("userA", 1000)
+	sm := NewStateManager(db)
+	_ = sm.Epochs().SetLength(4)
+
+	var calls []string
+	_ = sm.Epochs().OnEpochBegin("params", func(ctx *EpochContext) error {
+		calls = append(calls, fmt.Sprintf("begin %d@%d", ctx.Epoch, ctx.Block.Number))
+		ctx.Note("params", "updated")
+		return nil
+	})
+	_ = sm.Epochs().OnEpochEnd("netting", func(ctx *EpochContext) error {
+		calls = append(calls, fmt.Sprintf("end %d@%d", ctx.Epoch, ctx.Block.Number))
+		return nil
+	})
+
+	transfer := func(nonce uint64) *Transaction {
+		tx := NewBaseTransaction(TxTypeTransfer, nonce, "userA", "userB", 10)
+		tx.Fee = 2
+		_ = tx.Sign()
+		return tx
+	}
+	applyBlocks(t, sm, 1, 8, map[uint64][]*Transaction{
+		5: {transfer(0), transfer(1)},
+		6: {transfer(2)},
+	})
+
+	// Epoch 0 has no begin at genesis; epoch 1 (blocks 4-7) gets both; epoch 2 begins at 8
+	want := []string{"end 0@3", "begin 1@4", "end 1@7", "begin 2@8"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("Expected hooks %v, got %v", want, calls)
+	}
+
+	summary, err := GetEpochSummary(db, 1)
+	if err != nil || summary == nil {
+		t.Fatalf("Expected summary for epoch 1, got %v (%v)", summary, err)
+	}
+	if summary.FirstBlock != 4 || summary.LastBlock != 7 || summary.Transactions != 3 || summary.Fees != 6 {
+		t.Errorf("Unexpected summary %+v", summary)
+	}
+	if !reflect.DeepEqual(summary.EndHooks, []string{"beacon", "validators", "bridge", "parameters", "netting"}) {
+		t.Errorf("Expected end hooks in registration order, got %v", summary.EndHooks)
+	}
+	if len(summary.Notes) != 2 || summary.Notes[0].Key != "params" || summary.Notes[1].Key != "validators" {
+		t.Errorf("Expected notes sorted by key, got %+v", summary.Notes)
+	}
+	if s, _ := GetEpochSummary(db, 2); s != nil {
+		t.Errorf("Expected no summary for an epoch that has not ended")
+	}
+
+	t.Run("FailingHookRejectsBlock", func(t *testing.T) {
+		_ = sm.Epochs().OnEpochEnd("broken", func(*EpochContext) error { return fmt.Errorf("boom") })
+		applyBlocks(t, sm, 9, 10, nil)
+		if err := sm.ApplyBlock(NewBlock(&BlockHeader{Number: 11}, nil)); err == nil {
+			t.Errorf("Expected the last block of the epoch to fail with a failing end hook")
+		}
+	})
+}
+
+func TestEpochManager_Parameters(t *testing.T) {
+	db := NewInMemoryStateDB()
+	sm := NewStateManager(db)
+	_ = sm.Epochs().SetLength(4)
+	pm := NewParameterManager()
+	dist, _ := NewTruncatedGaussian(10, 2, 0, 100)
+	fee := NewParameter("bridge_fee_bps", dist)
+	_ = pm.AddParameter(fee)
+	change := func(nonce uint64, c ParameterChange) *Transaction {
+		tx, _ := NewParameterChangeTransaction(nonce, c)
+		_ = tx.Sign()
+		return tx
+	}
+
+	t.Run("Rejected", func(t *testing.T) {
+		scratch := sm.Copy()
+		_ = scratch.BeginBlock(&BlockHeader{Number: 5, Proposer: "proposerP"})
+		if err := scratch.ApplyTransaction(change(0, ParameterChange{Epoch: 1, Name: "bridge_fee_bps", Value: 25})); err == nil {
+			t.Errorf("Expected a change without a ParameterManager to be rejected")
+		}
+		scratch.SetParameterManager(pm)
+		forged, _ := NewParameterChangeTransaction(0, ParameterChange{Epoch: 1, Name: "bridge_fee_bps", Value: 25})
+		forged.SenderID = "userA"
+		_ = forged.Sign()
+		for name, tx := range map[string]*Transaction{
+			"OtherSender": forged,
+			"Unmanaged":   change(0, ParameterChange{Epoch: 1, Name: "unknown", Value: 1}),
+			"OutOfBounds": change(0, ParameterChange{Epoch: 1, Name: "bridge_fee_bps", Value: 150}),
+			"PastEpoch":   change(0, ParameterChange{Epoch: 0, Name: "bridge_fee_bps", Value: 25}),
+		} {
+			if err := scratch.ApplyTransaction(tx); err == nil {
+				t.Errorf("%s: expected the parameter change to be rejected", name)
+			}
+		}
+	})
+
+	sm.SetParameterManager(pm)
+	applyBlocks(t, sm, 1, 1, map[uint64][]*Transaction{1: {change(0, ParameterChange{Epoch: 1, Name: "bridge_fee_bps", Value: 25})}})
+	if changes, _ := ScheduledParameterChanges(db, 1); changes["bridge_fee_bps"] != 25 {
+		t.Fatalf("Expected the change scheduled for epoch 1, got %v", changes)
+	}
+	applyBlocks(t, sm, 2, 6, nil)
+	if _, found, _ := ParameterValue(db, "bridge_fee_bps"); found {
+		t.Fatalf("Expected the change to wait for the end of epoch 1")
+	}
+	applyBlocks(t, sm, 7, 7, nil)
+	if value, found, _ := ParameterValue(db, "bridge_fee_bps"); !found || value != 25 {
+		t.Fatalf("Expected the scheduled value applied at the end of epoch 1, got %f (%v)", value, found)
+	}
+	if changes, _ := ScheduledParameterChanges(db, 1); len(changes) != 0 {
+		t.Errorf("Expected the applied schedule to be cleared, got %v", changes)
+	}
+	if summary, _ := GetEpochSummary(db, 1); summary == nil || len(summary.Notes) == 0 || summary.Notes[0].Key != "parameters" {
+		t.Errorf("Expected the epoch summary to note the parameter change, got %+v", summary)
+	}
+
+	if err := pm.SyncFromState(db); err != nil {
+		t.Fatalf("SyncFromState failed: %v", err)
+	}
+	if fee.CurrentValue != 25 {
+		t.Errorf("Expected the manager to pick up the on-chain value, got %f", fee.CurrentValue)
+	}
+}
+
+func TestEpochManager_Deterministic(t *testing.T) {
+	run := func() Hash {
+		db := NewInMemoryStateDB()
+		_ = db.SetBalance("userA", 1000)
+		sm := NewStateManager(db)
+		_ = sm.Epochs().SetLength(3)
+		tx := NewBaseTransaction(TxTypeTransfer, 0, "userA", "userB", 10)
+		_ = tx.Sign()
+		applyBlocks(t, sm, 1, 9, map[uint64][]*Transaction{4: {tx}})
+		root, err := db.Root()
+		if err != nil {
+			t.Fatalf("Root failed: %v", err)
+		}
+		return root
+	}
+	if run() != run() {
+		t.Errorf("Expected nodes applying the same blocks to reach the same state root")
+	}
+}
@@ -0,0 +1,380 @@
+package core
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"fmt"
+	"strings"
+)
+
+// Hash-time-locked escrows
+//
+// TxTypeEscrowCreate locks Amount of the sender's balance for RecipientID under a hash lock and a
+// timeout height. Before the timeout the recipient can claim it by revealing the preimage of the
+// hash lock (TxTypeEscrowClaim); from the timeout on only the sender can take it back
+// (TxTypeEscrowRefund). The escrow ID is the hash of the create transaction. Claimed escrows keep
+// their preimage in state so a counterparty can learn it (e.g., to complete an atomic swap, see
+// swap.go); EscrowsByHashLock finds them.
+
+// Storage key prefixes for escrow state.
+const (
+	escrowPrefix      = "escrow/id/"    // escrow/id/<id> -> Escrow
+	escrowPartyPrefix = "escrow/party/" // escrow/party/<hex address>/<id> -> marker for open escrows the address is party to
+	escrowLockPrefix  = "escrow/lock/"  // escrow/lock/<hash lock>/<id> -> marker for all escrows under the hash lock
+)
+
+// Escrow limits and gas.
+const (
+	MaxEscrowPreimageSize        = 64
+	GasEscrow             uint64 = 5000 // Gas charged for an escrow transaction on top of the intrinsic gas
+)
+
+// EscrowStatus is the state of an escrow.
+type EscrowStatus uint8
+
+const (
+	EscrowOpen EscrowStatus = iota
+	EscrowClaimed
+	EscrowRefunded
+)
+
+// String returns the name of the status.
+func (s EscrowStatus) String() string {
+	switch s {
+	case EscrowOpen:
+		return "Open"
+	case EscrowClaimed:
+		return "Claimed"
+	case EscrowRefunded:
+		return "Refunded"
+	default:
+		return fmt.Sprintf("EscrowStatus(%d)", uint8(s))
+	}
+}
+
+// EscrowTerms is the payload of TxTypeEscrowCreate.
+type EscrowTerms struct {
+	HashLock Hash   // SHA-256 of the preimage the recipient must reveal
+	Timeout  uint64 // First block height at which the escrow can no longer be claimed but can be refunded
+}
+
+// EscrowClaim is the payload of TxTypeEscrowClaim.
+type EscrowClaim struct {
+	ID       Hash
+	Preimage []byte
+}
+
+// Escrow is an escrow recorded in state.
+type Escrow struct {
+	ID        Hash
+	Sender    string
+	Recipient string
+	Amount    uint64
+	HashLock  Hash
+	Timeout   uint64
+	Created   uint64 // Block height of creation
+	Status    EscrowStatus
+	Preimage  []byte // Revealed preimage once claimed
+}
+
+// escrowKey returns the storage key of escrow id.
+func escrowKey(id Hash) string {
+	return fmt.Sprintf("%s%x", escrowPrefix, id[:])
+}
+
+// escrowPartyKey returns the storage key indexing escrow id under party.
+func escrowPartyKey(party string, id Hash) string {
+	return fmt.Sprintf("%s%x", escrowPartyIndex(party), id[:])
+}
+
+// escrowPartyIndex returns the key prefix of the escrows indexed under party. The address is hex
+// encoded so that one containing "/" cannot reach into the index of another.
+func escrowPartyIndex(party string) string {
+	return fmt.Sprintf("%s%x/", escrowPartyPrefix, party)
+}
+
+// escrowLockKey returns the storage key indexing escrow id under its hash lock.
+func escrowLockKey(hashLock, id Hash) string {
+	return fmt.Sprintf("%s%x/%x", escrowLockPrefix, hashLock[:], id[:])
+}
+
+// GetEscrow returns escrow id, or nil if unknown.
+func GetEscrow(db StateDB, id Hash) (*Escrow, error) {
+	escrow := &Escrow{}
+	found, err := getStorageGob(db, escrowKey(id), escrow)
+	if err != nil || !found {
+		return nil, err
+	}
+	return escrow, nil
+}
+
+// OpenEscrows returns the open escrows address is sender or recipient of, ordered by ID.
+func OpenEscrows(db StateDB, address string) ([]*Escrow, error) {
+	return loadEscrows(db, escrowPartyIndex(address))
+}
+
+// EscrowsByHashLock returns the escrows ever created under hashLock, ordered by ID, so that the
+// parties to a swap can find each other's escrows and a revealed preimage.
+func EscrowsByHashLock(db StateDB, hashLock Hash) ([]*Escrow, error) {
+	return loadEscrows(db, fmt.Sprintf("%s%x/", escrowLockPrefix, hashLock[:]))
+}
+
+// loadEscrows returns the escrows indexed under prefix, ordered by ID.
+func loadEscrows(db StateDB, prefix string) ([]*Escrow, error) {
+	keys, err := db.StorageKeys(prefix)
+	if err != nil {
+		return nil, err
+	}
+	escrows := make([]*Escrow, 0, len(keys))
+	for _, key := range keys {
+		var id Hash
+		if err := id.UnmarshalText([]byte(strings.TrimPrefix(key, prefix))); err != nil {
+			return nil, fmt.Errorf("malformed escrow index key %s: %w", key, err)
+		}
+		escrow, err := GetEscrow(db, id)
+		if err != nil {
+			return nil, err
+		}
+		if escrow == nil {
+			return nil, fmt.Errorf("escrow index key %s points to a missing escrow", key)
+		}
+		escrows = append(escrows, escrow)
+	}
+	return escrows, nil
+}
+
+// HashLockOf returns the hash lock for preimage.
+func HashLockOf(preimage []byte) Hash {
+	return sha256.Sum256(preimage)
+}
+
+// NewEscrowCreateTransaction creates an unsigned transaction locking amount for recipient until timeout.
+func NewEscrowCreateTransaction(nonce uint64, sender, recipient string, amount uint64, hashLock Hash, timeout uint64) (*Transaction, error) {
+	payload, err := encodeGob(&EscrowTerms{HashLock: hashLock, Timeout: timeout})
+	if err != nil {
+		return nil, err
+	}
+	tx := NewBaseTransaction(TxTypeEscrowCreate, nonce, sender, recipient, amount)
+	tx.Payload = payload
+	return tx, nil
+}
+
+// NewEscrowClaimTransaction creates an unsigned transaction claiming escrow id with preimage.
+func NewEscrowClaimTransaction(nonce uint64, recipient string, id Hash, preimage []byte) (*Transaction, error) {
+	payload, err := encodeGob(&EscrowClaim{ID: id, Preimage: preimage})
+	if err != nil {
+		return nil, err
+	}
+	tx := NewBaseTransaction(TxTypeEscrowClaim, nonce, recipient, "", 0)
+	tx.Payload = payload
+	return tx, nil
+}
+
+// NewEscrowRefundTransaction creates an unsigned transaction refunding escrow id to its sender.
+func NewEscrowRefundTransaction(nonce uint64, sender string, id Hash) *Transaction {
+	tx := NewBaseTransaction(TxTypeEscrowRefund, nonce, sender, "", 0)
+	tx.Payload = append([]byte(nil), id[:]...)
+	return tx
+}
+
+// encodeGob gob-encodes v.
+func encodeGob(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, fmt.Errorf("failed to encode %T: %w", v, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeGob decodes gob-encoded data into out.
+func decodeGob(data []byte, out interface{}) error {
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %T: %w", out, err)
+	}
+	return nil
+}
+
+// --- Escrow transactions ---
+
+// validateEscrowCreate checks the terms of a new escrow and that the sender can fund it.
+func validateEscrowCreate(ctx *NativeContext, tx *Transaction) error {
+	var terms EscrowTerms
+	if err := decodeGob(tx.Payload, &terms); err != nil {
+		return err
+	}
+	if tx.RecipientID == "" || tx.RecipientID == tx.SenderID {
+		return fmt.Errorf("escrow needs a recipient other than the sender")
+	}
+	if tx.Amount == 0 {
+		return fmt.Errorf("escrow amount must be positive")
+	}
+	if terms.Timeout <= ctx.State.blockNumber() {
+		return fmt.Errorf("escrow timeout %d must be after the current block %d", terms.Timeout, ctx.State.blockNumber())
+	}
+	if ctx.Spendable < tx.Amount {
+		return fmt.Errorf("insufficient funds: sender %s has %d, needs %d", tx.SenderID, ctx.Spendable, tx.Amount)
+	}
+	return nil
+}
+
+// applyEscrowCreate locks the amount and records the escrow.
+func applyEscrowCreate(ctx *NativeContext, tx *Transaction) error {
+	sm := ctx.State
+	var terms EscrowTerms
+	if err := decodeGob(tx.Payload, &terms); err != nil {
+		return err
+	}
+	id, err := tx.Hash()
+	if err != nil {
+		return err
+	}
+	escrow := &Escrow{
+		ID:        id,
+		Sender:    tx.SenderID,
+		Recipient: tx.RecipientID,
+		Amount:    tx.Amount,
+		HashLock:  terms.HashLock,
+		Timeout:   terms.Timeout,
+		Created:   sm.blockNumber(),
+	}
+	if err := sm.debit(tx.SenderID, tx.Amount); err != nil {
+		return err
+	}
+	if err := sm.putEscrow(escrow); err != nil {
+		return err
+	}
+	ctx.Emit("escrow.create", id[:])
+	return nil
+}
+
+// loadOpenEscrow returns the open escrow id.
+func loadOpenEscrow(db StateDB, id Hash) (*Escrow, error) {
+	escrow, err := GetEscrow(db, id)
+	if err != nil {
+		return nil, err
+	}
+	if escrow == nil {
+		return nil, fmt.Errorf("unknown escrow %s", id)
+	}
+	if escrow.Status != EscrowOpen {
+		return nil, fmt.Errorf("escrow %s is %s", id, escrow.Status)
+	}
+	return escrow, nil
+}
+
+// validateEscrowClaim checks that the recipient claims before the timeout with the right preimage.
+func validateEscrowClaim(ctx *NativeContext, tx *Transaction) error {
+	if tx.Amount != 0 {
+		return fmt.Errorf("escrow claim must not carry an amount")
+	}
+	var claim EscrowClaim
+	if err := decodeGob(tx.Payload, &claim); err != nil {
+		return err
+	}
+	if len(claim.Preimage) == 0 || len(claim.Preimage) > MaxEscrowPreimageSize {
+		return fmt.Errorf("preimage must be 1 to %d bytes, got %d", MaxEscrowPreimageSize, len(claim.Preimage))
+	}
+	escrow, err := loadOpenEscrow(ctx.State.db, claim.ID)
+	if err != nil {
+		return err
+	}
+	if escrow.Recipient != tx.SenderID {
+		return fmt.Errorf("only recipient %s can claim escrow %s", escrow.Recipient, claim.ID)
+	}
+	if ctx.State.blockNumber() >= escrow.Timeout {
+		return fmt.Errorf("escrow %s timed out at block %d", claim.ID, escrow.Timeout)
+	}
+	if HashLockOf(claim.Preimage) != escrow.HashLock {
+		return fmt.Errorf("preimage does not match the hash lock of escrow %s", claim.ID)
+	}
+	return nil
+}
+
+// applyEscrowClaim pays the escrow to its recipient and records the preimage.
+func applyEscrowClaim(ctx *NativeContext, tx *Transaction) error {
+	var claim EscrowClaim
+	if err := decodeGob(tx.Payload, &claim); err != nil {
+		return err
+	}
+	escrow, err := loadOpenEscrow(ctx.State.db, claim.ID)
+	if err != nil {
+		return err
+	}
+	escrow.Status = EscrowClaimed
+	escrow.Preimage = claim.Preimage
+	if err := ctx.State.closeEscrow(escrow, escrow.Recipient); err != nil {
+		return err
+	}
+	ctx.Emit("escrow.claim", claim.Preimage)
+	return nil
+}
+
+// validateEscrowRefund checks that the sender refunds a timed-out escrow.
+func validateEscrowRefund(ctx *NativeContext, tx *Transaction) error {
+	if tx.Amount != 0 {
+		return fmt.Errorf("escrow refund must not carry an amount")
+	}
+	if len(tx.Payload) != len(Hash{}) {
+		return fmt.Errorf("escrow refund payload must be a %d-byte escrow ID", len(Hash{}))
+	}
+	var id Hash
+	copy(id[:], tx.Payload)
+	escrow, err := loadOpenEscrow(ctx.State.db, id)
+	if err != nil {
+		return err
+	}
+	if escrow.Sender != tx.SenderID {
+		return fmt.Errorf("only sender %s can refund escrow %s", escrow.Sender, id)
+	}
+	if ctx.State.blockNumber() < escrow.Timeout {
+		return fmt.Errorf("escrow %s cannot be refunded before block %d", id, escrow.Timeout)
+	}
+	return nil
+}
+
+// applyEscrowRefund returns the escrow to its sender.
+func applyEscrowRefund(ctx *NativeContext, tx *Transaction) error {
+	var id Hash
+	copy(id[:], tx.Payload)
+	escrow, err := loadOpenEscrow(ctx.State.db, id)
+	if err != nil {
+		return err
+	}
+	escrow.Status = EscrowRefunded
+	if err := ctx.State.closeEscrow(escrow, escrow.Sender); err != nil {
+		return err
+	}
+	ctx.Emit("escrow.refund", id[:])
+	return nil
+}
+
+// putEscrow stores an open escrow and indexes it under both parties and its hash lock.
+func (sm *StateManager) putEscrow(escrow *Escrow) error {
+	if err := setStorageGob(sm.db, escrowKey(escrow.ID), escrow); err != nil {
+		return err
+	}
+	if err := sm.db.SetStorage(escrowLockKey(escrow.HashLock, escrow.ID), []byte{1}); err != nil {
+		return err
+	}
+	for _, party := range []string{escrow.Sender, escrow.Recipient} {
+		if err := sm.db.SetStorage(escrowPartyKey(party, escrow.ID), []byte{1}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// closeEscrow stores a claimed or refunded escrow, removes it from the party index and pays its
+// amount to payee.
+func (sm *StateManager) closeEscrow(escrow *Escrow, payee string) error {
+	if err := setStorageGob(sm.db, escrowKey(escrow.ID), escrow); err != nil {
+		return err
+	}
+	for _, party := range []string{escrow.Sender, escrow.Recipient} {
+		if err := sm.db.SetStorage(escrowPartyKey(party, escrow.ID), nil); err != nil {
+			return err
+		}
+	}
+	return sm.credit(payee, escrow.Amount)
+}
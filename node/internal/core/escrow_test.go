@@ -0,0 +1,116 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEscrow_ClaimAndRefund(t *testing.T) {
+	preimage := []byte("swap secret")
+	newFixture := func() (*InMemoryStateDB, *StateManager, Hash) {
+		db := NewInMemoryStateDB()
+		_ = db.SetBalance("alice", 1000)
+		sm := NewStateManager(db)
+		_ = sm.BeginBlock(&BlockHeader{Number: 10, Proposer: "proposerP"})
+		tx, _ := NewEscrowCreateTransaction(0, "alice", "bob", 400, HashLockOf(preimage), 20)
+		_ = tx.Sign()
+		if err := sm.ApplyTransaction(tx); err != nil {
+			t.Fatalf("Escrow create failed: %v", err)
+		}
+		id, _ := tx.Hash()
+		return db, sm, id
+	}
+	signed := func(tx *Transaction, err error) *Transaction {
+		if err != nil {
+			t.Fatalf("Failed to build transaction: %v", err)
+		}
+		_ = tx.Sign()
+		return tx
+	}
+
+	t.Run("Create", func(t *testing.T) {
+		db, _, id := newFixture()
+		if bal, _ := db.GetBalance("alice"); bal != 600 {
+			t.Errorf("Expected 400 locked, alice has %d", bal)
+		}
+		for _, party := range []string{"alice", "bob"} {
+			open, err := OpenEscrows(db, party)
+			if err != nil || len(open) != 1 || open[0].ID != id || open[0].Status != EscrowOpen {
+				t.Errorf("Expected one open escrow for %s, got %+v (%v)", party, open, err)
+			}
+		}
+	})
+
+	t.Run("Claim", func(t *testing.T) {
+		db, sm, id := newFixture()
+		if err := sm.ApplyTransaction(signed(NewEscrowClaimTransaction(0, "bob", id, []byte("wrong")))); err == nil {
+			t.Errorf("Expected claim with the wrong preimage to be rejected")
+		}
+		if err := sm.ApplyTransaction(signed(NewEscrowClaimTransaction(0, "carol", id, preimage))); err == nil {
+			t.Errorf("Expected claim by a non-recipient to be rejected")
+		}
+		if err := sm.ApplyTransaction(signed(NewEscrowClaimTransaction(0, "bob", id, preimage))); err != nil {
+			t.Fatalf("Claim failed: %v", err)
+		}
+		escrow, _ := GetEscrow(db, id)
+		if bal, _ := db.GetBalance("bob"); bal != 400 || escrow.Status != EscrowClaimed || !bytes.Equal(escrow.Preimage, preimage) {
+			t.Errorf("Expected bob paid and the preimage recorded, got balance %d, escrow %+v", bal, escrow)
+		}
+		if open, _ := OpenEscrows(db, "alice"); len(open) != 0 {
+			t.Errorf("Expected no open escrows after the claim, got %d", len(open))
+		}
+		if byLock, _ := EscrowsByHashLock(db, HashLockOf(preimage)); len(byLock) != 1 || !bytes.Equal(byLock[0].Preimage, preimage) {
+			t.Errorf("Expected the claimed escrow found by its hash lock, got %+v", byLock)
+		}
+		if err := sm.ApplyTransaction(signed(NewEscrowRefundTransaction(1, "alice", id), nil)); err == nil {
+			t.Errorf("Expected refund of a claimed escrow to be rejected")
+		}
+	})
+
+	t.Run("Refund", func(t *testing.T) {
+		db, sm, id := newFixture()
+		if err := sm.ApplyTransaction(signed(NewEscrowRefundTransaction(1, "alice", id), nil)); err == nil {
+			t.Errorf("Expected refund before the timeout to be rejected")
+		}
+		_ = sm.BeginBlock(&BlockHeader{Number: 20, Proposer: "proposerP"})
+		if err := sm.ApplyTransaction(signed(NewEscrowClaimTransaction(0, "bob", id, preimage))); err == nil {
+			t.Errorf("Expected claim at the timeout to be rejected")
+		}
+		if err := sm.ApplyTransaction(signed(NewEscrowRefundTransaction(1, "alice", id), nil)); err != nil {
+			t.Fatalf("Refund failed: %v", err)
+		}
+		escrow, _ := GetEscrow(db, id)
+		if bal, _ := db.GetBalance("alice"); bal != 1000 || escrow.Status != EscrowRefunded {
+			t.Errorf("Expected alice refunded, got balance %d, escrow %+v", bal, escrow)
+		}
+	})
+
+	t.Run("InvalidCreate", func(t *testing.T) {
+		_, sm, _ := newFixture()
+		cases := map[string]*Transaction{
+			"PastTimeout":   signed(NewEscrowCreateTransaction(1, "alice", "bob", 1, HashLockOf(preimage), 10)),
+			"SelfRecipient": signed(NewEscrowCreateTransaction(1, "alice", "alice", 1, HashLockOf(preimage), 30)),
+			"OverBalance":   signed(NewEscrowCreateTransaction(1, "alice", "bob", 601, HashLockOf(preimage), 30)),
+		}
+		for name, tx := range cases {
+			if err := sm.ApplyTransaction(tx); err == nil {
+				t.Errorf("%s: expected escrow to be rejected", name)
+			}
+		}
+	})
+
+	t.Run("SlashInAddress", func(t *testing.T) {
+		db, sm, _ := newFixture()
+		tx := signed(NewEscrowCreateTransaction(1, "alice", "bob/x", 100, HashLockOf(preimage), 30))
+		if err := sm.ApplyTransaction(tx); err != nil {
+			t.Fatalf("Escrow create failed: %v", err)
+		}
+		id, _ := tx.Hash()
+		if open, _ := OpenEscrows(db, "bob"); len(open) != 1 || open[0].Recipient != "bob" {
+			t.Errorf("Expected bob to see only his own escrow, got %+v", open)
+		}
+		if open, _ := OpenEscrows(db, "bob/x"); len(open) != 1 || open[0].ID != id {
+			t.Errorf("Expected bob/x to see its escrow, got %+v", open)
+		}
+	})
+}
@@ -0,0 +1,286 @@
+package core
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"sync"
+)
+
+// Storage key prefixes for slashing state.
+const (
+	jailKeyPrefix    = "jail/"    // jail/<address> -> block number from which the validator is released (uint64)
+	slashedKeyPrefix = "slashed/" // slashed/<address>/<number> -> marker that an equivocation was already punished
+)
+
+// slashBasisPoints is the denominator of StakingConfig.SlashPenaltyBps.
+const slashBasisPoints = 10_000
+
+// EquivocationEvidence proves that a proposer signed two different headers at the same height.
+type EquivocationEvidence struct {
+	HeaderA BlockHeader
+	HeaderB BlockHeader
+}
+
+// Proposer returns the address accused by the evidence.
+func (ev *EquivocationEvidence) Proposer() string {
+	return ev.HeaderA.Proposer
+}
+
+// Number returns the height at which the proposer equivocated.
+func (ev *EquivocationEvidence) Number() uint64 {
+	return ev.HeaderA.Number
+}
+
+// Verify checks that both headers are at the same height, signed by the same proposer, and differ.
+// With the placeholder SignDigest scheme a valid signature proves nothing about who produced a
+// header, so verified evidence is only trustworthy once real signatures are in place.
+func (ev *EquivocationEvidence) Verify() error {
+	a, b := &ev.HeaderA, &ev.HeaderB
+	if a.Proposer == "" || a.Proposer != b.Proposer {
+		return fmt.Errorf("evidence headers have different proposers (%q, %q)", a.Proposer, b.Proposer)
+	}
+	if a.Number != b.Number {
+		return fmt.Errorf("evidence headers are at different heights (%d, %d)", a.Number, b.Number)
+	}
+	if a.Hash() == b.Hash() {
+		return fmt.Errorf("evidence headers are identical")
+	}
+	if !a.VerifySignature() || !b.VerifySignature() {
+		return fmt.Errorf("evidence header signature invalid for proposer %s", a.Proposer)
+	}
+	return nil
+}
+
+// Encode serializes the evidence using gob encoding (the payload of an evidence transaction).
+func (ev *EquivocationEvidence) Encode() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(ev); err != nil {
+		return nil, fmt.Errorf("failed to gob encode evidence: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeEquivocationEvidence deserializes evidence produced by Encode.
+func DecodeEquivocationEvidence(data []byte) (*EquivocationEvidence, error) {
+	ev := &EquivocationEvidence{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(ev); err != nil {
+		return nil, fmt.Errorf("failed to gob decode evidence: %w", err)
+	}
+	return ev, nil
+}
+
+// NewEvidenceTransaction packages evidence into a signed transaction from reporter.
+func NewEvidenceTransaction(ev *EquivocationEvidence, reporter string, nonce uint64) (*Transaction, error) {
+	payload, err := ev.Encode()
+	if err != nil {
+		return nil, err
+	}
+	tx := NewBaseTransaction(TxTypeEvidence, nonce, reporter, "", 0)
+	tx.Payload = payload
+	if err := tx.Sign(); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// --- Detection ---
+
+// equivocationKey identifies a proposer's slot.
+type equivocationKey struct {
+	proposer string
+	number   uint64
+}
+
+// EquivocationDetector remembers the signed headers seen for each (proposer, height) and reports
+// evidence when a proposer signs a second, different header for the same height.
+// Only headers within window blocks of the highest observed height are remembered.
+type EquivocationDetector struct {
+	mu         sync.Mutex
+	window     uint64
+	highest    uint64
+	seen       map[equivocationKey]*BlockHeader
+	reported   map[equivocationKey]bool
+	onEvidence func(*EquivocationEvidence) // Optional
+}
+
+// NewEquivocationDetector creates a detector. onEvidence, if non-nil, is called (outside the
+// detector's lock) for every new piece of evidence, e.g. to submit an evidence transaction.
+func NewEquivocationDetector(window uint64, onEvidence func(*EquivocationEvidence)) *EquivocationDetector {
+	return &EquivocationDetector{
+		window:     window,
+		seen:       make(map[equivocationKey]*BlockHeader),
+		reported:   make(map[equivocationKey]bool),
+		onEvidence: onEvidence,
+	}
+}
+
+// Observe records a header whose signature has already been verified. It returns evidence if the
+// proposer previously signed a different header at the same height; each slot is reported once.
+func (d *EquivocationDetector) Observe(header *BlockHeader) *EquivocationEvidence {
+	if header == nil || header.Proposer == "" {
+		return nil
+	}
+	key := equivocationKey{proposer: header.Proposer, number: header.Number}
+
+	d.mu.Lock()
+	if header.Number > d.highest {
+		d.highest = header.Number
+		d.prune()
+	}
+	if d.window > 0 && header.Number+d.window < d.highest {
+		d.mu.Unlock()
+		return nil // Too old to be remembered
+	}
+	first, exists := d.seen[key]
+	if !exists {
+		cpy := *header
+		d.seen[key] = &cpy
+		d.mu.Unlock()
+		return nil
+	}
+	if first.Hash() == header.Hash() || d.reported[key] {
+		d.mu.Unlock()
+		return nil
+	}
+	d.reported[key] = true
+	ev := &EquivocationEvidence{HeaderA: *first, HeaderB: *header}
+	onEvidence := d.onEvidence
+	d.mu.Unlock()
+
+	fmt.Printf("EquivocationDetector: %s signed two headers at height %d\n", key.proposer, key.number) // Placeholder log
+	if onEvidence != nil {
+		onEvidence(ev)
+	}
+	return ev
+}
+
+// prune forgets headers that fell out of the window. Caller must hold d.mu.
+func (d *EquivocationDetector) prune() {
+	if d.window == 0 || d.highest <= d.window {
+		return
+	}
+	cutoff := d.highest - d.window
+	for key := range d.seen {
+		if key.number < cutoff {
+			delete(d.seen, key)
+			delete(d.reported, key)
+		}
+	}
+}
+
+// --- Slashing ---
+
+// jailKey returns the storage key holding the jail release height of address.
+func jailKey(address string) string {
+	return jailKeyPrefix + address
+}
+
+// slashedKey returns the storage key marking that address was punished for equivocating at number.
+func slashedKey(address string, number uint64) string {
+	return fmt.Sprintf("%s%s/%020d", slashedKeyPrefix, address, number)
+}
+
+// JailedUntil returns the block number from which address is released from jail (0 if never jailed).
+func JailedUntil(db StateDB, address string) (uint64, error) {
+	return getStorageUint64(db, jailKey(address))
+}
+
+// IsJailed reports whether address is jailed at block number.
+func IsJailed(db StateDB, address string, number uint64) (bool, error) {
+	until, err := JailedUntil(db, address)
+	if err != nil {
+		return false, err
+	}
+	return number < until, nil
+}
+
+// slashAmount returns the penalty for stake at the given basis points, rounding down.
+func slashAmount(stake, bps uint64) uint64 {
+	if bps >= slashBasisPoints {
+		return stake
+	}
+	return stake/slashBasisPoints*bps + stake%slashBasisPoints*bps/slashBasisPoints
+}
+
+// applyEvidence verifies equivocation evidence and slashes the offender: SlashPenaltyBps of their
+// bonded and unbonding stake is burned and they are jailed for JailPeriod blocks. Evidence older
+// than the unbonding delay is rejected, as is evidence for an already punished height.
+// Evidence is only accepted with StakingConfig.EvidenceSlashing: until headers carry real
+// signatures, Verify cannot tell a proposer's headers from ones forged in its name.
+func (sm *StateManager) applyEvidence(tx *Transaction) error {
+	if !sm.staking.EvidenceSlashing {
+		return fmt.Errorf("equivocation slashing is disabled until header signatures are unforgeable")
+	}
+	if tx.Amount != 0 {
+		return fmt.Errorf("evidence transaction must not carry an amount")
+	}
+	ev, err := DecodeEquivocationEvidence(tx.Payload)
+	if err != nil {
+		return err
+	}
+	if err := ev.Verify(); err != nil {
+		return fmt.Errorf("invalid evidence: %w", err)
+	}
+	offender, number := ev.Proposer(), ev.Number()
+	height := sm.blockNumber()
+	if number > height {
+		return fmt.Errorf("evidence for height %d is ahead of current height %d", number, height)
+	}
+	if height-number > sm.staking.UnbondingDelay {
+		return fmt.Errorf("evidence for height %d expired at current height %d", number, height)
+	}
+	if marker, err := sm.db.GetStorage(slashedKey(offender, number)); err != nil {
+		return err
+	} else if len(marker) > 0 {
+		return fmt.Errorf("%s was already slashed for height %d", offender, number)
+	}
+
+	stake, err := GetStake(sm.db, offender)
+	if err != nil {
+		return fmt.Errorf("failed to get stake of %s: %w", offender, err)
+	}
+	entries, err := GetUnbonding(sm.db, offender)
+	if err != nil {
+		return fmt.Errorf("failed to get unbonding entries of %s: %w", offender, err)
+	}
+	total := stake
+	for _, e := range entries {
+		total += e.Amount
+	}
+	if total == 0 {
+		return fmt.Errorf("%s has no stake to slash", offender)
+	}
+
+	// All checks passed; apply the penalty
+	bps := sm.staking.SlashPenaltyBps
+	burned := slashAmount(stake, bps)
+	if err := setStorageUint64(sm.db, stakeKey(offender), stake-burned); err != nil {
+		return fmt.Errorf("failed to set stake of %s: %w", offender, err)
+	}
+	remaining := entries[:0]
+	for _, e := range entries {
+		penalty := slashAmount(e.Amount, bps)
+		burned += penalty
+		if e.Amount > penalty {
+			remaining = append(remaining, UnbondingEntry{Amount: e.Amount - penalty, ReleaseHeight: e.ReleaseHeight})
+		}
+	}
+	if err := setUnbonding(sm.db, offender, remaining); err != nil {
+		return fmt.Errorf("failed to set unbonding entries of %s: %w", offender, err)
+	}
+	until, err := JailedUntil(sm.db, offender)
+	if err != nil {
+		return err
+	}
+	if release := height + sm.staking.JailPeriod; release > until {
+		if err := setStorageUint64(sm.db, jailKey(offender), release); err != nil {
+			return fmt.Errorf("failed to jail %s: %w", offender, err)
+		}
+	}
+	if err := sm.db.SetStorage(slashedKey(offender, number), []byte{1}); err != nil {
+		return err
+	}
+	fmt.Printf("StateManager: Slashed %s by %d for equivocating at height %d\n", offender, burned, number) // Placeholder log
+	return nil
+}
@@ -0,0 +1,179 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+// signedHeader returns a header at number signed by proposer; salt makes otherwise equal headers differ.
+func signedHeader(proposer string, number uint64, salt byte) *BlockHeader {
+	h := &BlockHeader{Number: number, Timestamp: time.Unix(1700000000, 0), StateRoot: Hash{salt}, GasLimit: 1_000_000}
+	_ = h.Sign(proposer)
+	return h
+}
+
+func TestEquivocationEvidence_Verify(t *testing.T) {
+	valid := &EquivocationEvidence{HeaderA: *signedHeader("valA", 5, 1), HeaderB: *signedHeader("valA", 5, 2)}
+	if err := valid.Verify(); err != nil {
+		t.Fatalf("Expected valid evidence, got %v", err)
+	}
+
+	cases := map[string]*EquivocationEvidence{
+		"DifferentProposers": {HeaderA: *signedHeader("valA", 5, 1), HeaderB: *signedHeader("valB", 5, 2)},
+		"DifferentHeights":   {HeaderA: *signedHeader("valA", 5, 1), HeaderB: *signedHeader("valA", 6, 2)},
+		"SameHeader":         {HeaderA: *signedHeader("valA", 5, 1), HeaderB: *signedHeader("valA", 5, 1)},
+	}
+	forged := *signedHeader("valA", 5, 2)
+	forged.Signature = Signature("forged")
+	cases["BadSignature"] = &EquivocationEvidence{HeaderA: *signedHeader("valA", 5, 1), HeaderB: forged}
+	for name, ev := range cases {
+		t.Run(name, func(t *testing.T) {
+			if err := ev.Verify(); err == nil {
+				t.Errorf("Expected evidence to be rejected")
+			}
+		})
+	}
+
+	t.Run("EncodeDecode", func(t *testing.T) {
+		data, err := valid.Encode()
+		if err != nil {
+			t.Fatalf("Encode failed: %v", err)
+		}
+		decoded, err := DecodeEquivocationEvidence(data)
+		if err != nil {
+			t.Fatalf("Decode failed: %v", err)
+		}
+		if err := decoded.Verify(); err != nil {
+			t.Errorf("Decoded evidence no longer verifies: %v", err)
+		}
+	})
+}
+
+func TestEquivocationDetector(t *testing.T) {
+	var reported []*EquivocationEvidence
+	d := NewEquivocationDetector(10, func(ev *EquivocationEvidence) { reported = append(reported, ev) })
+
+	if ev := d.Observe(signedHeader("valA", 5, 1)); ev != nil {
+		t.Fatalf("Expected no evidence for the first header")
+	}
+	if ev := d.Observe(signedHeader("valA", 5, 1)); ev != nil {
+		t.Fatalf("Expected no evidence for a re-observed header")
+	}
+	if ev := d.Observe(signedHeader("valB", 5, 2)); ev != nil {
+		t.Fatalf("Expected no evidence for a different proposer")
+	}
+	ev := d.Observe(signedHeader("valA", 5, 3))
+	if ev == nil || ev.Proposer() != "valA" || ev.Number() != 5 {
+		t.Fatalf("Expected evidence against valA at 5, got %+v", ev)
+	}
+	if err := ev.Verify(); err != nil {
+		t.Errorf("Detected evidence does not verify: %v", err)
+	}
+	if again := d.Observe(signedHeader("valA", 5, 4)); again != nil {
+		t.Errorf("Expected a slot to be reported only once")
+	}
+	if len(reported) != 1 {
+		t.Errorf("Expected callback once, got %d", len(reported))
+	}
+
+	t.Run("Window", func(t *testing.T) {
+		d.Observe(signedHeader("valC", 100, 1))
+		if ev := d.Observe(signedHeader("valA", 6, 1)); ev != nil {
+			t.Fatalf("Expected no evidence for a header outside the window")
+		}
+		if ev := d.Observe(signedHeader("valA", 6, 2)); ev != nil {
+			t.Errorf("Expected headers outside the window to be forgotten")
+		}
+	})
+}
+
+func TestStateTransition_Evidence(t *testing.T) {
+	newFixture := func() (*InMemoryStateDB, *StateManager) {
+		db := NewInMemoryStateDB()
+		sm := NewStateManager(db)
+		sm.SetStakingConfig(StakingConfig{UnbondingDelay: 20, MinValidatorStake: 1, SlashPenaltyBps: 1000, JailPeriod: 50, EvidenceSlashing: true})
+		_ = setStorageUint64(db, stakeKey("valA"), 1000)
+		_ = setUnbonding(db, "valA", []UnbondingEntry{{Amount: 200, ReleaseHeight: 30}})
+		sm.BeginBlock(&BlockHeader{Number: 10, Proposer: "proposerP"})
+		return db, sm
+	}
+	evidenceTx := func(number uint64, nonce uint64) *Transaction {
+		ev := &EquivocationEvidence{HeaderA: *signedHeader("valA", number, 1), HeaderB: *signedHeader("valA", number, 2)}
+		tx, err := NewEvidenceTransaction(ev, "reporterR", nonce)
+		if err != nil {
+			t.Fatalf("NewEvidenceTransaction failed: %v", err)
+		}
+		return tx
+	}
+
+	t.Run("SlashAndJail", func(t *testing.T) {
+		db, sm := newFixture()
+		if err := sm.ApplyTransaction(evidenceTx(8, 0)); err != nil {
+			t.Fatalf("Evidence rejected: %v", err)
+		}
+		stake, _ := GetStake(db, "valA")
+		entries, _ := GetUnbonding(db, "valA")
+		if stake != 900 || len(entries) != 1 || entries[0].Amount != 180 {
+			t.Errorf("Expected 10%% slashed from stake and unbonding, got stake %d, entries %+v", stake, entries)
+		}
+		if jailed, _ := IsJailed(db, "valA", 59); !jailed {
+			t.Errorf("Expected valA jailed until height 60")
+		}
+		if jailed, _ := IsJailed(db, "valA", 60); jailed {
+			t.Errorf("Expected valA released at height 60")
+		}
+		if err := sm.ApplyTransaction(evidenceTx(8, 1)); err == nil {
+			t.Errorf("Expected duplicate evidence for the same height to be rejected")
+		}
+	})
+
+	t.Run("DisabledByDefault", func(t *testing.T) {
+		db, sm := newFixture()
+		sm.SetStakingConfig(DefaultStakingConfig())
+		// Anyone can compute placeholder signatures, so these headers need not come from valA
+		if err := sm.ApplyTransaction(evidenceTx(8, 0)); err == nil {
+			t.Errorf("Expected evidence to be rejected while slashing is disabled")
+		}
+		if stake, _ := GetStake(db, "valA"); stake != 1000 {
+			t.Errorf("Expected valA's stake untouched, got %d", stake)
+		}
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		_, sm := newFixture()
+		sm.BeginBlock(&BlockHeader{Number: 40, Proposer: "proposerP"})
+		if err := sm.ApplyTransaction(evidenceTx(8, 0)); err == nil {
+			t.Errorf("Expected evidence older than the unbonding delay to be rejected")
+		}
+	})
+
+	t.Run("NoStake", func(t *testing.T) {
+		db, sm := newFixture()
+		_ = setStorageUint64(db, stakeKey("valA"), 0)
+		_ = setUnbonding(db, "valA", nil)
+		if err := sm.ApplyTransaction(evidenceTx(8, 0)); err == nil {
+			t.Errorf("Expected evidence against an unstaked proposer to be rejected")
+		}
+		if nonce, _ := db.GetNonce("reporterR"); nonce != 0 {
+			t.Errorf("Rejected evidence must not change state, reporter nonce is %d", nonce)
+		}
+	})
+
+	t.Run("JailedValidatorLosesSlot", func(t *testing.T) {
+		db, sm := newFixture()
+		_ = setStorageUint64(db, stakeKey("valB"), 1)
+		if _, err := sm.SnapshotValidatorSet(0); err != nil {
+			t.Fatalf("SnapshotValidatorSet failed: %v", err)
+		}
+		if err := sm.ApplyTransaction(evidenceTx(8, 0)); err != nil {
+			t.Fatalf("Evidence rejected: %v", err)
+		}
+		consensus := NewPathIntegralConsensus(sm)
+		for n := uint64(10); n < 20; n++ {
+			parent := &BlockHeader{Number: n, StateRoot: Hash{byte(n)}}
+			if p, _ := consensus.ExpectedProposer(parent); p != "valB" {
+				t.Fatalf("Expected only valB to be eligible while valA is jailed, got %s", p)
+			}
+		}
+	})
+}
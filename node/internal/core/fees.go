@@ -0,0 +1,139 @@
+package core
+
+import "fmt"
+
+// Dynamic bridge fees
+//
+// The fee of an intent depends on what it does to the bridge's inventory. A transfer paid out of a
+// destination pool that is (or will be, counting the payouts still owed) below its target pays a
+// surcharge growing with the shortfall it leaves; a transfer depositing into a source pool below its
+// target is discounted by the shortfall it helps to fill. Fees are paid in the native asset and
+// collected in the protocol account BridgeFeeAccount. Users obtain a quote with
+// BridgeManager.QuoteFee before signing an intent; an intent paying less than the current quote is
+// rejected. State does not see the pools, so intents recorded there must pay at least the lowest
+// fee the default model quotes (see BridgeFeeModel.MinFee).
+
+// BridgeFeeModel parameterizes dynamic bridge fees.
+type BridgeFeeModel struct {
+	BaseFee         uint64 // Fee between balanced pools, in the native asset
+	MaxSurchargeBps uint64 // Surcharge on the base fee for emptying the destination pool, in basis points
+	MaxDiscountBps  uint64 // Discount on the base fee for depositing into an empty source pool, in basis points
+}
+
+// DefaultBridgeFeeModel returns the default fee model: up to four times the base fee for draining a
+// pool, down to half of it for refilling one. The base fee leaves the full discount above
+// MinBridgeIntentFee.
+func DefaultBridgeFeeModel() BridgeFeeModel {
+	return BridgeFeeModel{
+		BaseFee:         4 * MinBridgeIntentFee,
+		MaxSurchargeBps: 30000,
+		MaxDiscountBps:  5000,
+	}
+}
+
+// FeeQuote is the fee quoted for a transfer and how it was derived.
+type FeeQuote struct {
+	Asset        string
+	SourceChain  ChainID
+	DestChain    ChainID
+	Amount       uint64
+	BaseFee      uint64
+	SurchargeBps uint64 // From the destination pool's shortfall after the payout
+	DiscountBps  uint64 // From the source pool's shortfall before the deposit
+	Fee          uint64 // Never below MinBridgeIntentFee
+}
+
+// shortfallBps returns how far projected is below target, in basis points of target.
+func shortfallBps(projected int64, target uint64) uint64 {
+	if target == 0 || projected >= int64(target) {
+		return 0
+	}
+	if projected <= 0 {
+		return 10000
+	}
+	return (target - uint64(projected)) * 10000 / target
+}
+
+// Quote returns the fee of moving amount into a source pool and out of a destination pool whose
+// balances, counting pending flows, are projected to be sourceBalance and destBalance. Nil pools
+// contribute no adjustment.
+func (m BridgeFeeModel) Quote(source, dest *InventoryPool, sourceBalance, destBalance int64, amount uint64) FeeQuote {
+	q := FeeQuote{Amount: amount, BaseFee: m.BaseFee}
+	if dest != nil {
+		q.SurchargeBps = m.MaxSurchargeBps * shortfallBps(destBalance-int64(amount), dest.Target) / 10000
+	}
+	if source != nil {
+		q.DiscountBps = m.MaxDiscountBps * shortfallBps(sourceBalance, source.Target) / 10000
+	}
+	bps := 10000 + q.SurchargeBps
+	bps -= min(bps, q.DiscountBps)
+	q.Fee = max(m.BaseFee*bps/10000, MinBridgeIntentFee)
+	return q
+}
+
+// MinFee returns the lowest fee the model quotes: the base fee at the full discount, and never
+// below MinBridgeIntentFee.
+func (m BridgeFeeModel) MinFee() uint64 {
+	return max(m.BaseFee*(10000-min(m.MaxDiscountBps, 10000))/10000, MinBridgeIntentFee)
+}
+
+// --- BridgeManager integration ---
+
+// SetFeeModel sets the model used to quote subsequent fees.
+func (bm *BridgeManager) SetFeeModel(model BridgeFeeModel) {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	bm.feeModel = model
+}
+
+// QuoteFee returns the fee currently charged for bridging amount of asset from source to dest.
+func (bm *BridgeManager) QuoteFee(asset string, source, dest ChainID, amount uint64) (FeeQuote, error) {
+	bm.mu.RLock()
+	defer bm.mu.RUnlock()
+	return bm.quoteFee(asset, source, dest, amount)
+}
+
+// quoteFee implements QuoteFee. Caller must hold bm.mu.
+func (bm *BridgeManager) quoteFee(asset string, source, dest ChainID, amount uint64) (FeeQuote, error) {
+	info, ok := GetBridgeAsset(asset)
+	if !ok {
+		return FeeQuote{}, fmt.Errorf("unsupported asset %q", asset)
+	}
+	if source == dest || !info.On(source) || !info.On(dest) {
+		return FeeQuote{}, fmt.Errorf("%s cannot be bridged from %s to %s", asset, source, dest)
+	}
+	sourcePool, destPool := bm.pool(source, asset), bm.pool(dest, asset)
+	q := bm.feeModel.Quote(sourcePool, destPool, bm.projectedBalance(sourcePool), bm.projectedBalance(destPool), amount)
+	q.Asset, q.SourceChain, q.DestChain = asset, source, dest
+	return q, nil
+}
+
+// projectedBalance returns the balance pool will have once the tracked intents settle: incoming
+// rebalancing and source deposits not yet final are added, payouts not yet submitted subtracted.
+// Caller must hold bm.mu.
+func (bm *BridgeManager) projectedBalance(pool *InventoryPool) int64 {
+	if pool == nil {
+		return 0
+	}
+	projected := int64(pool.Balance + pool.Incoming)
+	for _, intent := range bm.pendingIntents {
+		if intent.Asset != pool.Asset || intent.Status.IsTerminal() {
+			continue
+		}
+		if intent.SourceChain == pool.Chain && !intent.SourceFinal {
+			projected += int64(intent.Amount)
+		}
+		if intent.DestChain == pool.Chain && intent.DestTxHash == "" {
+			projected -= int64(intent.Amount)
+		}
+	}
+	return projected
+}
+
+// ProtocolFees returns the fees collected from accepted intents. They are not refunded when an
+// intent fails or is refunded.
+func (bm *BridgeManager) ProtocolFees() uint64 {
+	bm.mu.RLock()
+	defer bm.mu.RUnlock()
+	return bm.fees
+}
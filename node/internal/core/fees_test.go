@@ -0,0 +1,74 @@
+package core
+
+import "testing"
+
+func TestFees_Quote(t *testing.T) {
+	model := BridgeFeeModel{BaseFee: 100, MaxSurchargeBps: 30000, MaxDiscountBps: 5000}
+	pool := &InventoryPool{Target: 1_000}
+	tests := []struct {
+		name         string
+		source, dest *InventoryPool
+		srcBal, dBal int64
+		amount       uint64
+		want         uint64
+	}{
+		{"NoPools", nil, nil, 0, 0, 500, 100},
+		{"Balanced", pool, pool, 1_000, 1_500, 500, 100},
+		{"DrainsDest", nil, pool, 0, 1_000, 500, 250},
+		{"EmptiesDest", nil, pool, 0, 200, 500, 400},
+		{"RefillsSource", pool, nil, 0, 0, 500, 50},
+		{"Both", pool, pool, 500, 500, 500, 375},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := model.Quote(tt.source, tt.dest, tt.srcBal, tt.dBal, tt.amount); got.Fee != tt.want {
+				t.Errorf("Quote() = %+v, want fee %d", got, tt.want)
+			}
+		})
+	}
+	if q := DefaultBridgeFeeModel().Quote(pool, nil, 0, 0, 1); q.Fee != 20 || q.Fee != DefaultBridgeFeeModel().MinFee() {
+		t.Errorf("Expected the default model to discount the base fee of 40 by half, got %d", q.Fee)
+	}
+	if fee := (BridgeFeeModel{BaseFee: 12, MaxDiscountBps: 5000}).MinFee(); fee != MinBridgeIntentFee {
+		t.Errorf("Expected the lowest fee to stay at the minimum %d, got %d", MinBridgeIntentFee, fee)
+	}
+}
+
+func TestFees_Manager(t *testing.T) {
+	manager := NewBridgeManager()
+	if q, err := manager.QuoteFee("qBTC", ChainID_Ethereum, ChainID_Bitcoin, 40_000); err != nil || q.Fee != DefaultBridgeFeeModel().BaseFee {
+		t.Errorf("Expected base fee without pools, got %+v (%v)", q, err)
+	}
+	if _, err := manager.QuoteFee("qBTC", ChainID_Bitcoin, ChainID_Bitcoin, 40_000); err == nil {
+		t.Errorf("Expected quote for an invalid route to fail")
+	}
+	if _, err := manager.QuoteFee("DOGE", ChainID_Ethereum, ChainID_Bitcoin, 40_000); err == nil {
+		t.Errorf("Expected quote for an unsupported asset to fail")
+	}
+
+	_ = manager.SetInventoryPool(InventoryPool{Chain: ChainID_Ethereum, Asset: "qBTC", Target: 100_000, Max: 100_000})
+	_ = manager.SetInventoryPool(InventoryPool{Chain: ChainID_Bitcoin, Asset: "qBTC", Balance: 100_000, Target: 100_000, Max: 100_000})
+	refill, _ := manager.QuoteFee("qBTC", ChainID_Ethereum, ChainID_Bitcoin, 40_000)
+	drain, _ := manager.QuoteFee("qBTC", ChainID_Bitcoin, ChainID_Ethereum, 40_000)
+	if refill.Fee != 68 || drain.Fee != 160 {
+		t.Errorf("Expected fees 68 towards the scarce pool's refill and 160 draining it, got %+v and %+v", refill, drain)
+	}
+
+	intent := signedIntent(&BridgeIntent{UserAddress: "alice", SourceChain: ChainID_Ethereum, DestChain: ChainID_Bitcoin, Asset: "qBTC", Amount: 40_000, DestAddress: testBTCAddress})
+	if err := manager.HandleBridgeIntent(intent); err == nil {
+		t.Errorf("Expected intent paying less than the quote to be rejected")
+	}
+	intent = signedIntent(&BridgeIntent{UserAddress: "alice", SourceChain: ChainID_Ethereum, DestChain: ChainID_Bitcoin, Asset: "qBTC", Amount: 40_000, DestAddress: testBTCAddress, Fee: refill.Fee})
+	if err := manager.HandleBridgeIntent(intent); err != nil {
+		t.Fatalf("HandleBridgeIntent failed: %v", err)
+	}
+
+	// The pending intent is owed out of the BTC pool and will refill the ETH one
+	next, _ := manager.QuoteFee("qBTC", ChainID_Ethereum, ChainID_Bitcoin, 40_000)
+	if next.SurchargeBps != 24000 || next.DiscountBps != 3000 || next.Fee != 124 {
+		t.Errorf("Expected quote to count the pending flow, got %+v", next)
+	}
+	if got := manager.ProtocolFees(); got != refill.Fee {
+		t.Errorf("Expected %d protocol fees, got %d", refill.Fee, got)
+	}
+}
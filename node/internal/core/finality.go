@@ -0,0 +1,152 @@
+package core
+
+import (
+	"fmt"
+	"math"
+)
+
+// DefaultFinalityThreshold is the confidence a block needs before it is marked final.
+const DefaultFinalityThreshold = 0.999
+
+// DefaultFinalityDepth is the number of canonical descendants a block needs before it is marked
+// final. Confidence alone is not enough: a block without competitors has confidence 1 the moment
+// it arrives, before any sibling had time to propagate.
+const DefaultFinalityDepth = 6
+
+// FinalityStatus describes how settled a block is. It is the shape returned to RPC clients
+// (e.g., exchange integrations deciding how many confirmations a deposit needs).
+type FinalityStatus struct {
+	Hash       Hash    `json:"hash"`
+	Number     uint64  `json:"number"`
+	Confidence float64 `json:"confidence"` // Probability the block stays on the canonical path, in [0, 1]
+	Final      bool    `json:"final"`      // True once the block has been irreversibly finalized
+}
+
+// SetFinalityThreshold sets the confidence above which UpdateFinality finalizes blocks.
+// The threshold must lie in (0, 1].
+func (pic *PathIntegralConsensus) SetFinalityThreshold(threshold float64) error {
+	if !(threshold > 0 && threshold <= 1) {
+		return fmt.Errorf("finality threshold must be in (0, 1], got %f", threshold)
+	}
+	pic.mu.Lock()
+	defer pic.mu.Unlock()
+	pic.finalityThreshold = threshold
+	return nil
+}
+
+// FinalityThreshold returns the configured finality threshold.
+func (pic *PathIntegralConsensus) FinalityThreshold() float64 {
+	pic.mu.RLock()
+	defer pic.mu.RUnlock()
+	return pic.finalityThreshold
+}
+
+// SetFinalityDepth sets how many canonical descendants a block needs before UpdateFinality may
+// finalize it.
+func (pic *PathIntegralConsensus) SetFinalityDepth(depth uint64) {
+	pic.mu.Lock()
+	defer pic.mu.Unlock()
+	pic.finalityDepth = depth
+}
+
+// FinalityDepth returns the configured finality depth.
+func (pic *PathIntegralConsensus) FinalityDepth() uint64 {
+	pic.mu.RLock()
+	defer pic.mu.RUnlock()
+	return pic.finalityDepth
+}
+
+// FinalityConfidence returns the normalized probability that the block remains on the canonical path:
+//
+//	P(block) = Σ_{branches through block} exp(-beta S) / Σ_{all branches} exp(-beta S)
+//
+// where S is each branch's cumulative action over the common window fork choice compares (see
+// BlockTree). Finalized blocks have confidence 1; blocks on
+// branches that conflict with finality have confidence 0.
+func (pic *PathIntegralConsensus) FinalityConfidence(tree *BlockTree, hash Hash) (float64, error) {
+	if tree == nil {
+		return 0, fmt.Errorf("cannot compute finality on nil block tree")
+	}
+	if _, ok := tree.GetBlock(hash); !ok {
+		return 0, fmt.Errorf("unknown block %s", hash)
+	}
+	if tree.IsFinal(hash) {
+		return 1.0, nil
+	}
+
+	weights, err := pic.BranchWeights(tree)
+	if err != nil {
+		return 0, err
+	}
+	total, through := 0.0, 0.0
+	for _, w := range weights {
+		total += w.Weight
+		if tree.IsAncestor(hash, w.Branch.TipHash) {
+			through += w.Weight
+		}
+	}
+	if total == 0 || math.IsNaN(total) {
+		return 0, fmt.Errorf("branch weights do not normalize (total %f)", total)
+	}
+	return through / total, nil
+}
+
+// FinalityStatus reports the finality confidence of a block for RPC consumers.
+func (pic *PathIntegralConsensus) FinalityStatus(tree *BlockTree, hash Hash) (*FinalityStatus, error) {
+	confidence, err := pic.FinalityConfidence(tree, hash)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := tree.GetBlock(hash)
+	return &FinalityStatus{
+		Hash:       hash,
+		Number:     block.Header.Number,
+		Confidence: confidence,
+		Final:      tree.IsFinal(hash),
+	}, nil
+}
+
+// UpdateFinality finalizes the highest block on the canonical chain that is buried under at least
+// FinalityDepth canonical descendants and whose confidence exceeds the threshold (ancestors are at
+// least as confident, so they are finalized with it). It returns the newly finalized blocks in
+// ascending order, or nil if finality did not advance.
+func (pic *PathIntegralConsensus) UpdateFinality(tree *BlockTree) ([]*Block, error) {
+	if tree == nil {
+		return nil, fmt.Errorf("cannot update finality on nil block tree")
+	}
+	threshold := pic.FinalityThreshold()
+	depth := pic.FinalityDepth()
+	lastFinal := tree.LastFinalized()
+
+	chain := tree.CanonicalChain()
+	if uint64(len(chain)) <= depth {
+		return nil, nil
+	}
+	for i := len(chain) - 1 - int(depth); i >= 0; i-- {
+		block := chain[i]
+		if block == lastFinal {
+			return nil, nil // Nothing above the finalized block is confident enough yet
+		}
+		hash, err := block.Hash()
+		if err != nil {
+			return nil, err
+		}
+		confidence, err := pic.FinalityConfidence(tree, hash)
+		if err != nil {
+			return nil, err
+		}
+		if confidence < threshold {
+			continue
+		}
+		if err := tree.MarkFinal(hash); err != nil {
+			return nil, fmt.Errorf("failed to finalize block %d: %w", block.Header.Number, err)
+		}
+		// Collect everything between the previous finalized block and this one
+		start := i
+		for start > 0 && chain[start-1] != lastFinal {
+			start--
+		}
+		return chain[start : i+1], nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,180 @@
+package core
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+// buildForkedTree creates genesis -> a1 -> a2 and genesis -> b1 with the given per-block actions,
+// charging nothing for b1's missing block so the branches compare on cumulative action alone.
+func buildForkedTree(t *testing.T, actionA1, actionA2, actionB1 float64) (*BlockTree, *Block, *Block, *Block) {
+	t.Helper()
+	actions := make(map[Hash]float64)
+	genesis := testGenesis()
+	tree, err := NewBlockTree(genesis, func(block *Block, parent *BlockHeader) (float64, error) {
+		hash, _ := block.Hash()
+		return actions[hash], nil
+	})
+	if err != nil {
+		t.Fatalf("NewBlockTree failed: %v", err)
+	}
+	_ = tree.SetGapAction(0)
+	a1 := childOf(genesis, 0)
+	a2 := childOf(a1, 0)
+	b1 := childOf(genesis, 1)
+	for block, action := range map[*Block]float64{a1: actionA1, a2: actionA2, b1: actionB1} {
+		hash, _ := block.Hash()
+		actions[hash] = action
+	}
+	for _, block := range []*Block{a1, a2, b1} {
+		if err := tree.AddBlock(block); err != nil {
+			t.Fatalf("AddBlock failed: %v", err)
+		}
+	}
+	return tree, a1, a2, b1
+}
+
+func TestFinalityConfidence(t *testing.T) {
+	consensus := NewPathIntegralConsensus(NewStateManager(NewInMemoryStateDB()))
+
+	// Branch A has cumulative action 1, branch B has cumulative action 3
+	tree, a1, a2, b1 := buildForkedTree(t, 0.5, 0.5, 3.0)
+	a1Hash, _ := a1.Hash()
+	a2Hash, _ := a2.Hash()
+	b1Hash, _ := b1.Hash()
+
+	wA, wB := 1.0, math.Exp(-2.0)
+	expectedA := wA / (wA + wB)
+
+	t.Run("CanonicalBranch", func(t *testing.T) {
+		for _, hash := range []Hash{a1Hash, a2Hash} {
+			confidence, err := consensus.FinalityConfidence(tree, hash)
+			if err != nil {
+				t.Fatalf("FinalityConfidence failed: %v", err)
+			}
+			if math.Abs(confidence-expectedA) > 1e-9 {
+				t.Errorf("Expected confidence %.6f, got %.6f", expectedA, confidence)
+			}
+		}
+	})
+
+	t.Run("CompetingBranch", func(t *testing.T) {
+		confidence, _ := consensus.FinalityConfidence(tree, b1Hash)
+		if math.Abs(confidence-(1-expectedA)) > 1e-9 {
+			t.Errorf("Expected confidence %.6f, got %.6f", 1-expectedA, confidence)
+		}
+	})
+
+	t.Run("GenesisIsFinal", func(t *testing.T) {
+		genesisHash, _ := tree.Genesis().Hash()
+		confidence, _ := consensus.FinalityConfidence(tree, genesisHash)
+		if confidence != 1.0 {
+			t.Errorf("Expected genesis confidence 1, got %f", confidence)
+		}
+	})
+
+	t.Run("UnknownBlock", func(t *testing.T) {
+		if _, err := consensus.FinalityConfidence(tree, Hash{42}); err == nil {
+			t.Errorf("Expected error for unknown block")
+		}
+	})
+}
+
+func TestUpdateFinality(t *testing.T) {
+	consensus := NewPathIntegralConsensus(NewStateManager(NewInMemoryStateDB()))
+
+	t.Run("BelowThreshold", func(t *testing.T) {
+		tree, _, _, _ := buildForkedTree(t, 0.5, 0.5, 3.0) // confidence ~0.88
+		finalized, err := consensus.UpdateFinality(tree)
+		if err != nil {
+			t.Fatalf("UpdateFinality failed: %v", err)
+		}
+		if len(finalized) != 0 {
+			t.Errorf("Expected nothing finalized below threshold, got %d blocks", len(finalized))
+		}
+	})
+
+	t.Run("SingleBranchNeedsDepth", func(t *testing.T) {
+		genesis := testGenesis()
+		tree, _ := NewBlockTree(genesis, fixedActions(nil))
+		fresh := childOf(genesis, 0)
+		_ = tree.AddBlock(fresh)
+		freshHash, _ := fresh.Hash()
+		if confidence, _ := consensus.FinalityConfidence(tree, freshHash); confidence != 1.0 {
+			t.Fatalf("Expected confidence 1 without competitors, got %f", confidence)
+		}
+		if finalized, _ := consensus.UpdateFinality(tree); len(finalized) != 0 || tree.IsFinal(freshHash) {
+			t.Fatalf("Expected a fresh block not to be final, got %d finalized", len(finalized))
+		}
+
+		parent := fresh
+		for i := uint64(0); i < DefaultFinalityDepth; i++ {
+			child := childOf(parent, 0)
+			_ = tree.AddBlock(child)
+			parent = child
+		}
+		finalized, err := consensus.UpdateFinality(tree)
+		if err != nil {
+			t.Fatalf("UpdateFinality failed: %v", err)
+		}
+		if len(finalized) != 1 || finalized[0] != fresh {
+			t.Errorf("Expected only the block %d deep to be finalized, got %d blocks", DefaultFinalityDepth, len(finalized))
+		}
+	})
+
+	t.Run("AboveThreshold", func(t *testing.T) {
+		consensus := NewPathIntegralConsensus(NewStateManager(NewInMemoryStateDB()))
+		consensus.SetFinalityDepth(0)                          // Finalize on confidence alone
+		tree, a1, a2, b1 := buildForkedTree(t, 0.5, 0.5, 20.0) // competing branch is negligible
+		finalized, err := consensus.UpdateFinality(tree)
+		if err != nil {
+			t.Fatalf("UpdateFinality failed: %v", err)
+		}
+		if len(finalized) != 2 || finalized[0] != a1 || finalized[1] != a2 {
+			t.Fatalf("Expected a1 and a2 to be finalized, got %v", finalized)
+		}
+		if tree.LastFinalized() != a2 {
+			t.Errorf("Expected a2 to be the last finalized block")
+		}
+
+		// Blocks building on the conflicting branch are now rejected
+		if err := tree.AddBlock(childOf(b1, 0)); err == nil {
+			t.Errorf("Expected error extending a branch that conflicts with finality")
+		}
+
+		// Repeated updates do not re-finalize
+		again, _ := consensus.UpdateFinality(tree)
+		if len(again) != 0 {
+			t.Errorf("Expected no newly finalized blocks on repeat, got %d", len(again))
+		}
+
+		a2Hash, _ := a2.Hash()
+		status, err := consensus.FinalityStatus(tree, a2Hash)
+		if err != nil {
+			t.Fatalf("FinalityStatus failed: %v", err)
+		}
+		if !status.Final || status.Confidence != 1.0 || status.Number != 2 {
+			t.Errorf("Unexpected finality status: %+v", status)
+		}
+		encoded, err := json.Marshal(status)
+		if err != nil {
+			t.Fatalf("Failed to marshal finality status: %v", err)
+		}
+		if !strings.Contains(string(encoded), `"hash":"`+a2Hash.String()+`"`) {
+			t.Errorf("Expected hex hash in JSON, got %s", encoded)
+		}
+	})
+
+	t.Run("ThresholdValidation", func(t *testing.T) {
+		for _, invalid := range []float64{0, -0.5, 1.5, math.NaN()} {
+			if err := consensus.SetFinalityThreshold(invalid); err == nil {
+				t.Errorf("Expected error for threshold %f", invalid)
+			}
+		}
+		if err := consensus.SetFinalityThreshold(0.8); err != nil {
+			t.Errorf("SetFinalityThreshold(0.8) failed: %v", err)
+		}
+	})
+}
@@ -0,0 +1,168 @@
+package core
+
+import (
+	"fmt"
+	"time"
+)
+
+// InstantSealConsensus is a development ConsensusEngine: blocks are sealed as soon as they are
+// produced, header rules are limited to parent linkage, and every block on the canonical chain is
+// final immediately. It lets the rest of the stack (state, pool, RPC) be exercised without the
+// probabilistic path-integral engine. Not for production networks.
+type InstantSealConsensus struct {
+	stateManager *StateManager
+	gasLimit     uint64
+	now          func() time.Time // Local clock, replaceable in tests
+}
+
+// NewInstantSealConsensus creates a dev-mode consensus engine over the given state.
+func NewInstantSealConsensus(sm *StateManager) *InstantSealConsensus {
+	return &InstantSealConsensus{
+		stateManager: sm,
+		gasLimit:     DefaultValidationConfig().MaxGasLimit,
+		now:          time.Now,
+	}
+}
+
+// VerifyHeader only checks that the header links to its parent.
+func (isc *InstantSealConsensus) VerifyHeader(header *BlockHeader, parent *BlockHeader) error {
+	if header == nil || parent == nil {
+		return &ValidationError{Rule: ErrNilHeader, Detail: "header and parent are required"}
+	}
+	if header.ParentHash != parent.Hash() {
+		return newValidationError(header.Number, ErrInvalidParentHash, "got %s, parent is %s", header.ParentHash, parent.Hash())
+	}
+	if header.Number != parent.Number+1 {
+		return newValidationError(header.Number, ErrInvalidNumber, "parent number is %d", parent.Number)
+	}
+	return nil
+}
+
+// VerifyBlock checks the TxRoot and that executing the block on a copy of the parent's state
+// yields the header's StateRoot. Like PathIntegralConsensus, it only has the live state and rejects
+// blocks that do not extend the last applied block.
+func (isc *InstantSealConsensus) VerifyBlock(block *Block) error {
+	if block == nil || block.Header == nil {
+		return &ValidationError{Rule: ErrNilBlock, Detail: "block and header are required"}
+	}
+	header := block.Header
+	txRoot, err := DeriveTxRoot(block.Transactions)
+	if err != nil {
+		return newValidationError(header.Number, ErrInvalidTransaction, "%v", err)
+	}
+	if txRoot != header.TxRoot {
+		return newValidationError(header.Number, ErrTxRootMismatch, "computed %s, header has %s", txRoot, header.TxRoot)
+	}
+	if isc.stateManager == nil {
+		return nil
+	}
+	if err := isc.stateManager.checkParentState(header); err != nil {
+		return err
+	}
+	scratch := isc.stateManager.Copy()
+	if err := scratch.ApplyBlock(block); err != nil {
+		return newValidationError(header.Number, ErrStateExecutionError, "%v", err)
+	}
+	root, err := scratch.DB().Root()
+	if err != nil {
+		return newValidationError(header.Number, ErrStateExecutionError, "%v", err)
+	}
+	if root != header.StateRoot {
+		return newValidationError(header.Number, ErrStateRootMismatch, "computed %s, header has %s", root, header.StateRoot)
+	}
+	return nil
+}
+
+// Finalize applies the block to the live state.
+func (isc *InstantSealConsensus) Finalize(block *Block) error {
+	if block == nil || block.Header == nil {
+		return fmt.Errorf("cannot finalize nil block or header")
+	}
+	if isc.stateManager == nil {
+		return fmt.Errorf("cannot finalize block %d without a state manager", block.Header.Number)
+	}
+	if err := isc.stateManager.ApplyBlock(block); err != nil {
+		return fmt.Errorf("failed to apply block %d: %w", block.Header.Number, err)
+	}
+	return nil
+}
+
+// CalculateAction is -1 for every block, so every extra block lowers a branch's window action and
+// the block tree's head is the longest chain.
+func (isc *InstantSealConsensus) CalculateAction(block *Block, parent *BlockHeader) (float64, error) {
+	if block == nil || block.Header == nil {
+		return 0, fmt.Errorf("cannot calculate action for nil block or header")
+	}
+	return -1, nil
+}
+
+// SelectPath picks the longest branch (ties broken by lowest tip hash), matching the block tree's
+// head under CalculateAction.
+func (isc *InstantSealConsensus) SelectPath(tree *BlockTree) (*Block, error) {
+	if tree == nil {
+		return nil, fmt.Errorf("cannot select path on nil block tree")
+	}
+	var best *BranchInfo
+	branches := tree.Branches()
+	for i := range branches {
+		b := &branches[i]
+		if best == nil || b.Length > best.Length || (b.Length == best.Length && b.TipHash.Less(best.TipHash)) {
+			best = b
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("block tree has no branches")
+	}
+	return best.Tip, nil
+}
+
+// Prepare creates a header on top of parent with the current time (at least 1ms after the parent).
+func (isc *InstantSealConsensus) Prepare(parent *BlockHeader) (*BlockHeader, error) {
+	if parent == nil {
+		return nil, fmt.Errorf("cannot prepare header without a parent")
+	}
+	timestamp := isc.now()
+	if !timestamp.After(parent.Timestamp) {
+		timestamp = parent.Timestamp.Add(time.Millisecond)
+	}
+	return &BlockHeader{
+		ParentHash: parent.Hash(),
+		Number:     parent.Number + 1,
+		Timestamp:  timestamp,
+		GasLimit:   isc.gasLimit,
+	}, nil
+}
+
+// Seal signs the header as proposer.
+func (isc *InstantSealConsensus) Seal(block *Block, proposer string) error {
+	if block == nil || block.Header == nil {
+		return fmt.Errorf("cannot seal nil block or header")
+	}
+	return block.Header.Sign(proposer)
+}
+
+// UpdateFinality finalizes the current head of the tree immediately.
+// Returns the newly finalized blocks, oldest first.
+func (isc *InstantSealConsensus) UpdateFinality(tree *BlockTree) ([]*Block, error) {
+	if tree == nil {
+		return nil, fmt.Errorf("cannot update finality on nil block tree")
+	}
+	lastFinal := tree.LastFinalized()
+	head := tree.Head()
+	if head == lastFinal {
+		return nil, nil
+	}
+	hash, err := head.Hash()
+	if err != nil {
+		return nil, err
+	}
+	if err := tree.MarkFinal(hash); err != nil {
+		return nil, fmt.Errorf("failed to finalize block %d: %w", head.Header.Number, err)
+	}
+	chain := tree.CanonicalChain()
+	start := len(chain) - 1
+	for start > 0 && chain[start-1] != lastFinal {
+		start--
+	}
+	return chain[start:], nil
+}
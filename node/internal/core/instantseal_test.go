@@ -0,0 +1,115 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// newInstantSealFixture wires a dev-mode producer on top of a funded in-memory state.
+func newInstantSealFixture(t *testing.T) (*BlockProducer, *TxPool, *InMemoryStateDB, *BlockTree) {
+	t.Helper()
+	db := NewInMemoryStateDB()
+	_ = db.SetBalance("senderA", 1000)
+	sm := NewStateManager(db)
+	engine := NewInstantSealConsensus(sm)
+	root, _ := db.Root()
+	tree, err := NewBlockTree(NewGenesisBlock(root, time.Now().Add(-time.Minute)), engine.CalculateAction)
+	if err != nil {
+		t.Fatalf("NewBlockTree failed: %v", err)
+	}
+	pool := NewTxPool()
+	producer, err := NewBlockProducer(BlockProducerConfig{Proposer: "dev", SealOnPending: true}, engine, pool, sm, tree, nil)
+	if err != nil {
+		t.Fatalf("NewBlockProducer failed: %v", err)
+	}
+	return producer, pool, db, tree
+}
+
+func TestInstantSeal_SealOnPending(t *testing.T) {
+	producer, pool, db, tree := newInstantSealFixture(t)
+	if err := producer.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer producer.Stop()
+
+	tx := NewBaseTransaction(TxTypeTransfer, 0, "senderA", "recipientB", 100)
+	_ = tx.Sign()
+	if err := pool.AddTransaction(tx); err != nil {
+		t.Fatalf("AddTransaction failed: %v", err)
+	}
+
+	deadline := time.After(2 * time.Second)
+	for tree.Head().Header.Number != 1 {
+		select {
+		case <-deadline:
+			t.Fatalf("Pending transaction was not sealed")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+	head := tree.Head()
+	hash, _ := head.Hash()
+	if !tree.IsFinal(hash) {
+		t.Errorf("Expected the sealed block to be final immediately")
+	}
+	if bal, _ := db.GetBalance("recipientB"); bal != 100 {
+		t.Errorf("Expected live state to reflect the sealed block, recipient has %d", bal)
+	}
+}
+
+func TestInstantSeal_SealNow(t *testing.T) {
+	producer, _, _, tree := newInstantSealFixture(t)
+
+	if _, err := producer.ProduceBlock(); !errors.Is(err, ErrNothingToProduce) {
+		t.Errorf("Expected ErrNothingToProduce without pending transactions, got %v", err)
+	}
+	for i := 1; i <= 3; i++ {
+		block, err := producer.SealNow()
+		if err != nil {
+			t.Fatalf("SealNow failed: %v", err)
+		}
+		if block.Header.Number != uint64(i) || len(block.Transactions) != 0 {
+			t.Fatalf("Expected empty block %d, got block %d with %d txs", i, block.Header.Number, len(block.Transactions))
+		}
+		if tree.LastFinalized() != block {
+			t.Errorf("Expected block %d to be the last finalized block", i)
+		}
+	}
+}
+
+func TestInstantSeal_Rules(t *testing.T) {
+	engine := NewInstantSealConsensus(NewStateManager(NewInMemoryStateDB()))
+	parent := &BlockHeader{Number: 3, Timestamp: time.Unix(1700000000, 0)}
+
+	header, err := engine.Prepare(parent)
+	if err != nil {
+		t.Fatalf("Prepare failed: %v", err)
+	}
+	if err := engine.VerifyHeader(header, parent); err != nil {
+		t.Errorf("Expected unsigned prepared header to pass dev rules, got %v", err)
+	}
+	header.Number = 9
+	if err := engine.VerifyHeader(header, parent); !errors.Is(err, ErrInvalidNumber) {
+		t.Errorf("Expected ErrInvalidNumber, got %v", err)
+	}
+
+	t.Run("LongestChainIsHead", func(t *testing.T) {
+		genesis := testGenesis()
+		tree, _ := NewBlockTree(genesis, engine.CalculateAction)
+		short := childOf(genesis, 1)
+		long1 := childOf(genesis, 2)
+		long2 := childOf(long1, 3)
+		for _, b := range []*Block{short, long1, long2} {
+			if err := tree.AddBlock(b); err != nil {
+				t.Fatalf("AddBlock failed: %v", err)
+			}
+		}
+		selected, err := engine.SelectPath(tree)
+		if err != nil {
+			t.Fatalf("SelectPath failed: %v", err)
+		}
+		if selected != long2 || tree.Head() != long2 {
+			t.Errorf("Expected the longest branch to be selected and be the head")
+		}
+	})
+}
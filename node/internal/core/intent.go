@@ -0,0 +1,164 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"regexp"
+)
+
+// Signed bridge intents
+//
+// An intent is identified by the hash of its canonical encoding, which covers every field chosen by
+// the user (including a user-chosen nonce, so identical transfers remain distinct) and is signed by
+// the user. Submitting the same intent twice yields the same ID and is rejected as a duplicate.
+
+// bridgeIntentDomain separates intent digests from other signed digests.
+const bridgeIntentDomain = "qrl-bridge-intent:"
+
+// MinBridgeIntentFee is the smallest fee, in the native asset, an intent must pay.
+const MinBridgeIntentFee uint64 = 10
+
+// BridgeFeeAccount collects the fees paid by bridge intents.
+const BridgeFeeAccount = "bridge-fees"
+
+// BridgeAsset describes an asset the bridge supports.
+type BridgeAsset struct {
+	Symbol    string
+	Home      ChainID   // Chain the asset is native to
+	Chains    []ChainID // Chains the asset exists on, natively or wrapped
+	MinAmount uint64    // Smallest amount per intent, in the asset's base unit
+	MaxAmount uint64    // Largest amount per intent, in the asset's base unit
+	// Caps on the amount bridged per netting epoch, see checkBridgeVolume. Zero means no cap.
+	EpochCap      uint64 // In total
+	ChainEpochCap uint64 // Into and out of each chain
+	UserEpochCap  uint64 // By each user
+}
+
+// bridgeAssets lists the supported assets. Bitcoin cannot host wrapped assets, so only its native
+// coin moves to and from it.
+var bridgeAssets = map[string]BridgeAsset{
+	NativeAsset: {
+		Symbol: NativeAsset, Home: ChainID_QRL, Chains: []ChainID{ChainID_QRL, ChainID_Ethereum},
+		MinAmount: 1, MaxAmount: 1_000_000_000_000,
+		EpochCap: 10_000_000_000_000, ChainEpochCap: 10_000_000_000_000, UserEpochCap: 2_000_000_000_000,
+	},
+	"qETH": {
+		Symbol: "qETH", Home: ChainID_Ethereum, Chains: []ChainID{ChainID_Ethereum, ChainID_QRL},
+		MinAmount: 1_000_000_000_000, MaxAmount: 1_000_000_000_000_000_000,
+		EpochCap: 10_000_000_000_000_000_000, ChainEpochCap: 10_000_000_000_000_000_000, UserEpochCap: 2_000_000_000_000_000_000,
+	},
+	"qBTC": {
+		Symbol: "qBTC", Home: ChainID_Bitcoin, Chains: []ChainID{ChainID_Bitcoin, ChainID_QRL, ChainID_Ethereum},
+		MinAmount: 10_000, MaxAmount: 10_000_000_000,
+		EpochCap: 100_000_000_000, ChainEpochCap: 50_000_000_000, UserEpochCap: 20_000_000_000,
+	},
+}
+
+// GetBridgeAsset returns the description of a supported asset.
+func GetBridgeAsset(symbol string) (BridgeAsset, bool) {
+	asset, ok := bridgeAssets[symbol]
+	return asset, ok
+}
+
+// On reports whether the asset exists on chain.
+func (a BridgeAsset) On(chain ChainID) bool {
+	for _, c := range a.Chains {
+		if c == chain {
+			return true
+		}
+	}
+	return false
+}
+
+// Address formats of the supported chains.
+var (
+	qrlAddressPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+	ethAddressPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+	btcAddressPattern = regexp.MustCompile(`^(bc1[02-9ac-hj-np-z]{11,71}|[13][1-9A-HJ-NP-Za-km-z]{25,34})$`)
+)
+
+// ValidateChainAddress checks that address is well-formed for chain.
+func ValidateChainAddress(chain ChainID, address string) error {
+	var pattern *regexp.Regexp
+	switch chain {
+	case ChainID_QRL:
+		pattern = qrlAddressPattern
+	case ChainID_Ethereum:
+		pattern = ethAddressPattern
+	case ChainID_Bitcoin:
+		pattern = btcAddressPattern
+	default:
+		return fmt.Errorf("unsupported chain %s", chain)
+	}
+	if !pattern.MatchString(address) {
+		return fmt.Errorf("malformed %s address %q", chain, address)
+	}
+	return nil
+}
+
+// CanonicalBytes returns the encoding of the fields chosen by the user that the intent ID commits
+// to. Strings are length-prefixed and integers big-endian, so the encoding is unambiguous.
+func (i *BridgeIntent) CanonicalBytes() []byte {
+	buf := []byte(bridgeIntentDomain)
+	for _, s := range []string{i.UserAddress, string(i.SourceChain), string(i.DestChain), i.Asset, i.DestAddress} {
+		buf = binary.BigEndian.AppendUint32(buf, uint32(len(s)))
+		buf = append(buf, s...)
+	}
+	for _, n := range []uint64{i.Amount, i.Fee, i.Nonce} {
+		buf = binary.BigEndian.AppendUint64(buf, n)
+	}
+	return buf
+}
+
+// ComputeID returns the content address of the intent.
+func (i *BridgeIntent) ComputeID() Hash {
+	return sha256.Sum256(i.CanonicalBytes())
+}
+
+// Sign assigns the intent's ID and signs it as its user.
+// Placeholder implementation: uses the insecure SignDigest scheme keyed by the user address.
+func (i *BridgeIntent) Sign() {
+	i.ID = i.ComputeID()
+	i.Signature = SignDigest(i.UserAddress, i.ID)
+}
+
+// VerifySignature checks that the intent's ID matches its content and is signed by its user.
+func (i *BridgeIntent) VerifySignature() error {
+	if i.ID != i.ComputeID() {
+		return fmt.Errorf("intent ID %s does not match its content", i.ID)
+	}
+	if !VerifyDigestSignature(i.UserAddress, i.ID, i.Signature) {
+		return fmt.Errorf("invalid signature on intent %s", i.ID)
+	}
+	return nil
+}
+
+// ValidateBasic checks the intent's fields without reference to bridge state: a supported asset
+// present on both distinct chains, an amount within the asset's limits, the minimum fee, well-formed
+// addresses and a valid signature.
+func (i *BridgeIntent) ValidateBasic() error {
+	if i.SourceChain == i.DestChain {
+		return fmt.Errorf("intent needs distinct source and destination chains, got %s -> %s", i.SourceChain, i.DestChain)
+	}
+	asset, ok := GetBridgeAsset(i.Asset)
+	if !ok {
+		return fmt.Errorf("unsupported asset %q", i.Asset)
+	}
+	if !asset.On(i.SourceChain) || !asset.On(i.DestChain) {
+		return fmt.Errorf("%s cannot be bridged from %s to %s", i.Asset, i.SourceChain, i.DestChain)
+	}
+	if i.Amount < asset.MinAmount || i.Amount > asset.MaxAmount {
+		return fmt.Errorf("amount %d of %s outside limits [%d, %d]", i.Amount, i.Asset, asset.MinAmount, asset.MaxAmount)
+	}
+	if i.Fee < MinBridgeIntentFee {
+		return fmt.Errorf("intent fee %d below minimum %d", i.Fee, MinBridgeIntentFee)
+	}
+	if err := ValidateChainAddress(ChainID_QRL, i.UserAddress); err != nil {
+		return fmt.Errorf("invalid user address: %w", err)
+	}
+	if err := ValidateChainAddress(i.DestChain, i.DestAddress); err != nil {
+		return fmt.Errorf("invalid destination address: %w", err)
+	}
+	return i.VerifySignature()
+}
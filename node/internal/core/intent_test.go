@@ -0,0 +1,101 @@
+package core
+
+import (
+	"testing"
+)
+
+// Well-formed external chain addresses for tests.
+const (
+	testETHAddress = "0x52908400098527886E0F7030069857D2E4169EE7"
+	testBTCAddress = "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"
+)
+
+// signedIntent pays the minimum fee if intent has none and signs it as its user.
+func signedIntent(intent *BridgeIntent) *BridgeIntent {
+	if intent.Fee == 0 {
+		intent.Fee = DefaultBridgeFeeModel().BaseFee
+	}
+	intent.Sign()
+	return intent
+}
+
+func TestIntent_ContentAddress(t *testing.T) {
+	base := BridgeIntent{UserAddress: "alice", SourceChain: ChainID_QRL, DestChain: ChainID_Ethereum, Asset: NativeAsset, Amount: 100, DestAddress: testETHAddress, Fee: 10}
+	a, b := base, base
+	a.Timestamp, a.Status, a.Epoch = a.Timestamp.AddDate(1, 0, 0), IntentCompleted, 9
+	if a.ComputeID() != b.ComputeID() {
+		t.Errorf("Expected fields assigned by the bridge not to affect the ID")
+	}
+	for name, mutate := range map[string]func(*BridgeIntent){
+		"Amount":  func(i *BridgeIntent) { i.Amount++ },
+		"Fee":     func(i *BridgeIntent) { i.Fee++ },
+		"Nonce":   func(i *BridgeIntent) { i.Nonce++ },
+		"Dest":    func(i *BridgeIntent) { i.DestAddress = "0x52908400098527886E0F7030069857D2E4169EE8" },
+		"Chains":  func(i *BridgeIntent) { i.SourceChain, i.DestChain = i.DestChain, i.SourceChain },
+		"Shifted": func(i *BridgeIntent) { i.UserAddress, i.Asset = "aliceQ", "RG" },
+	} {
+		c := base
+		mutate(&c)
+		if c.ComputeID() == base.ComputeID() {
+			t.Errorf("%s: expected a different ID", name)
+		}
+	}
+
+	signed := base
+	signed.Sign()
+	if err := signed.VerifySignature(); err != nil {
+		t.Fatalf("Expected signature to verify, got %v", err)
+	}
+	tampered := signed
+	tampered.Amount++
+	if err := tampered.VerifySignature(); err == nil {
+		t.Errorf("Expected tampered intent to be rejected")
+	}
+	forged := signed
+	forged.Signature = SignDigest("mallory", forged.ID)
+	if err := forged.VerifySignature(); err == nil {
+		t.Errorf("Expected signature by another user to be rejected")
+	}
+}
+
+func TestIntent_ValidateBasic(t *testing.T) {
+	valid := func() *BridgeIntent {
+		return &BridgeIntent{UserAddress: "alice", SourceChain: ChainID_Ethereum, DestChain: ChainID_Bitcoin, Asset: "qBTC", Amount: 50_000, DestAddress: testBTCAddress}
+	}
+	if err := signedIntent(valid()).ValidateBasic(); err != nil {
+		t.Fatalf("Expected valid intent, got %v", err)
+	}
+	cases := map[string]func(*BridgeIntent){
+		"SameChain":     func(i *BridgeIntent) { i.DestChain = ChainID_Ethereum; i.DestAddress = testETHAddress },
+		"UnknownAsset":  func(i *BridgeIntent) { i.Asset = "DOGE" },
+		"UnknownChain":  func(i *BridgeIntent) { i.SourceChain = "SOL" },
+		"WrappedOnBTC":  func(i *BridgeIntent) { i.Asset, i.Amount = "qETH", 1_000_000_000_000 },
+		"BelowMinimum":  func(i *BridgeIntent) { i.Amount = 9_999 },
+		"AboveMaximum":  func(i *BridgeIntent) { i.Amount = 10_000_000_001 },
+		"LowFee":        func(i *BridgeIntent) { i.Fee = MinBridgeIntentFee - 1 },
+		"BadBTCAddress": func(i *BridgeIntent) { i.DestAddress = "userB_btc_addr" },
+		"BadETHAddress": func(i *BridgeIntent) {
+			i.DestChain, i.SourceChain, i.DestAddress = ChainID_Ethereum, ChainID_Bitcoin, "0xabc"
+		},
+		"BadUserAddress": func(i *BridgeIntent) { i.UserAddress = "alice with spaces" },
+	}
+	for name, mutate := range cases {
+		t.Run(name, func(t *testing.T) {
+			intent := valid()
+			intent.Fee = MinBridgeIntentFee
+			mutate(intent)
+			intent.Sign()
+			if err := intent.ValidateBasic(); err == nil {
+				t.Errorf("Expected intent to be rejected")
+			}
+		})
+	}
+	t.Run("Unsigned", func(t *testing.T) {
+		intent := valid()
+		intent.Fee = MinBridgeIntentFee
+		intent.ID = intent.ComputeID()
+		if err := intent.ValidateBasic(); err == nil {
+			t.Errorf("Expected unsigned intent to be rejected")
+		}
+	})
+}
@@ -0,0 +1,280 @@
+package core
+
+import "fmt"
+
+// Bridge intent state machine
+//
+//	PendingNetting ──> PendingSourceLock ──> Locked ──> PendingRelease ──> Completed
+//	      │                   │  ^              │             │
+//	      │                   │  └── reorg ─────┘             │
+//	      ├──> Failed <───────┘                               │
+//	      └──> PendingRelease (source QRL, already escrowed)  │
+//	Locked, PendingRelease, PendingNetting (source QRL) ──────┴──> Refunded
+//
+// Each non-terminal state has a deadline in block height. An intent still in the state when the
+// deadline passes is expired: if the bridge holds the user's funds they are refunded, otherwise
+// the intent fails. Intents recorded in state have no PendingRelease deadline: state cannot see
+// whether the payout was made, so a TxTypeBridgeRelease transaction completes or refunds them.
+
+// IntentStatus is the lifecycle state of a bridge intent.
+type IntentStatus string
+
+const (
+	IntentPendingNetting    IntentStatus = "PendingNetting"    // Waiting for the end of its netting epoch
+	IntentPendingSourceLock IntentStatus = "PendingSourceLock" // Waiting for the user's deposit on the source chain
+	IntentLocked            IntentStatus = "Locked"            // Deposit included on the source chain, not yet final
+	IntentPendingRelease    IntentStatus = "PendingRelease"    // Source funds secured, waiting for the payout
+	IntentCompleted         IntentStatus = "Completed"         // Paid out on the destination chain
+	IntentFailed            IntentStatus = "Failed"            // Abandoned without the bridge holding user funds
+	IntentRefunded          IntentStatus = "Refunded"          // Abandoned, source funds returned to the user
+)
+
+// intentTransitions lists the states reachable from each state.
+var intentTransitions = map[IntentStatus][]IntentStatus{
+	IntentPendingNetting:    {IntentPendingSourceLock, IntentPendingRelease, IntentFailed, IntentRefunded},
+	IntentPendingSourceLock: {IntentLocked, IntentFailed},
+	IntentLocked:            {IntentPendingRelease, IntentPendingSourceLock, IntentRefunded},
+	IntentPendingRelease:    {IntentCompleted, IntentRefunded},
+}
+
+// IsTerminal reports whether no transitions leave s.
+func (s IntentStatus) IsTerminal() bool {
+	return len(intentTransitions[s]) == 0
+}
+
+// CanTransition reports whether an intent may move from s to next.
+func (s IntentStatus) CanTransition(next IntentStatus) bool {
+	for _, allowed := range intentTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IntentTimeouts are the blocks an intent may spend in each non-terminal state. Zero means no deadline.
+type IntentTimeouts struct {
+	PendingNetting    uint64
+	PendingSourceLock uint64
+	Locked            uint64
+	PendingRelease    uint64
+}
+
+// DefaultIntentTimeouts returns the timeouts a BridgeManager starts with.
+func DefaultIntentTimeouts() IntentTimeouts {
+	return IntentTimeouts{
+		PendingNetting:    300,
+		PendingSourceLock: 100,
+		Locked:            100,
+		PendingRelease:    200,
+	}
+}
+
+// stateIntentTimeouts returns the timeouts of intents recorded in state. PendingRelease has none,
+// since the escrow must not be refunded while the release may already have been paid.
+func stateIntentTimeouts() IntentTimeouts {
+	timeouts := DefaultIntentTimeouts()
+	timeouts.PendingRelease = 0
+	return timeouts
+}
+
+// For returns the timeout of status.
+func (t IntentTimeouts) For(status IntentStatus) uint64 {
+	switch status {
+	case IntentPendingNetting:
+		return t.PendingNetting
+	case IntentPendingSourceLock:
+		return t.PendingSourceLock
+	case IntentLocked:
+		return t.Locked
+	case IntentPendingRelease:
+		return t.PendingRelease
+	default:
+		return 0
+	}
+}
+
+// IntentTransition is an entry of an intent's audit trail.
+type IntentTransition struct {
+	From   IntentStatus // Empty for the intent's creation
+	To     IntentStatus
+	Height uint64 // Block height at which the transition happened
+	Reason string
+}
+
+// Transition moves the intent to status next at height, recording it in the audit trail and
+// setting the deadline of the new state from timeouts.
+func (i *BridgeIntent) Transition(next IntentStatus, height uint64, timeouts IntentTimeouts, reason string) error {
+	if i.Status != "" && !i.Status.CanTransition(next) {
+		return fmt.Errorf("intent %s cannot move from %s to %s", i.ID, i.Status, next)
+	}
+	i.History = append(i.History, IntentTransition{From: i.Status, To: next, Height: height, Reason: reason})
+	i.Status = next
+	i.Deadline = 0
+	if timeout := timeouts.For(next); timeout > 0 {
+		i.Deadline = height + timeout
+	}
+	return nil
+}
+
+// Expired reports whether the intent's deadline has passed at height.
+func (i *BridgeIntent) Expired(height uint64) bool {
+	return i.Deadline != 0 && height >= i.Deadline
+}
+
+// expiryStatus returns the state an expired intent moves to: Refunded if the bridge holds the
+// user's funds (an escrowed QRL amount, or a deposit seen on the source chain), Failed otherwise.
+func (i *BridgeIntent) expiryStatus() IntentStatus {
+	switch i.Status {
+	case IntentPendingNetting:
+		if i.SourceChain == ChainID_QRL {
+			return IntentRefunded
+		}
+		return IntentFailed
+	case IntentPendingSourceLock:
+		return IntentFailed
+	default:
+		return IntentRefunded
+	}
+}
+
+// Expire moves an intent whose deadline passed at height to Failed or Refunded and returns the new
+// status. It returns false if the intent has not expired.
+func (i *BridgeIntent) Expire(height uint64, timeouts IntentTimeouts) (IntentStatus, bool, error) {
+	if !i.Expired(height) {
+		return i.Status, false, nil
+	}
+	next := i.expiryStatus()
+	if err := i.Transition(next, height, timeouts, fmt.Sprintf("deadline %d passed in %s", i.Deadline, i.Status)); err != nil {
+		return i.Status, false, err
+	}
+	return next, true, nil
+}
+
+// --- Intents recorded in state ---
+
+// putBridgeIntent stores intent, keeping its index entries in sync with its status.
+func (sm *StateManager) putBridgeIntent(intent *BridgeIntent) error {
+	return storeBridgeIntent(sm.db, intent)
+}
+
+// settleBridgeIntent stores intent after a transition, returning escrowed native funds to the user
+// if it was refunded.
+func (sm *StateManager) settleBridgeIntent(intent *BridgeIntent) error {
+	if intent.Status == IntentRefunded && intent.SourceChain == ChainID_QRL {
+		if err := sm.debit(BridgeEscrowAccount, intent.Amount); err != nil {
+			return err
+		}
+		if err := sm.credit(intent.UserAddress, intent.Amount); err != nil {
+			return err
+		}
+	}
+	return sm.putBridgeIntent(intent)
+}
+
+// expireBridgeIntents expires the open intents whose deadline passed at height and returns how
+// many were refunded and failed. Escrowed native funds of refunded intents leaving QRL are returned
+// to the user; deposits on external chains are refunded by the bridge operators' adapters.
+func (sm *StateManager) expireBridgeIntents(height uint64) (refunded, failed int, err error) {
+	ids, err := OpenBridgeIntentIDs(sm.db)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, id := range ids {
+		intent, err := GetBridgeIntent(sm.db, id)
+		if err != nil {
+			return 0, 0, err
+		}
+		if intent == nil {
+			return 0, 0, fmt.Errorf("bridge index points to missing intent %s", id)
+		}
+		status, expired, err := intent.Expire(height, stateIntentTimeouts())
+		if err != nil {
+			return 0, 0, err
+		}
+		if !expired {
+			continue
+		}
+		if status == IntentRefunded {
+			refunded++
+		} else {
+			failed++
+		}
+		if err := sm.settleBridgeIntent(intent); err != nil {
+			return 0, 0, err
+		}
+	}
+	return refunded, failed, nil
+}
+
+// BridgeRelease is the payload of TxTypeBridgeRelease: the bridge operators report the outcome of
+// the payout of an intent leaving QRL.
+type BridgeRelease struct {
+	ID         Hash   // Intent paid out
+	DestTxHash string // Final payout transaction on the destination chain; empty if the payout failed
+	Reason     string // Why the payout failed
+}
+
+// NewBridgeReleaseTransaction creates an unsigned transaction reporting release. It must be
+// authorized by BridgeAdminAccount.
+func NewBridgeReleaseTransaction(nonce uint64, release BridgeRelease) (*Transaction, error) {
+	payload, err := encodeGob(&release)
+	if err != nil {
+		return nil, err
+	}
+	tx := NewBaseTransaction(TxTypeBridgeRelease, nonce, BridgeAdminAccount, "", 0)
+	tx.Payload = payload
+	return tx, nil
+}
+
+// validateBridgeRelease checks that the admin reports on an escrowed intent waiting for its release.
+func validateBridgeRelease(ctx *NativeContext, tx *Transaction) error {
+	if tx.SenderID != BridgeAdminAccount {
+		return fmt.Errorf("only %s can report bridge releases, got %s", BridgeAdminAccount, tx.SenderID)
+	}
+	if tx.Amount != 0 {
+		return fmt.Errorf("bridge release transaction must not carry an amount")
+	}
+	var release BridgeRelease
+	if err := decodeGob(tx.Payload, &release); err != nil {
+		return err
+	}
+	intent, err := GetBridgeIntent(ctx.State.db, release.ID)
+	if err != nil {
+		return err
+	}
+	if intent == nil {
+		return fmt.Errorf("unknown bridge intent %s", release.ID)
+	}
+	if intent.SourceChain != ChainID_QRL || intent.Status != IntentPendingRelease {
+		return fmt.Errorf("bridge intent %s from %s is %s, not an escrowed intent pending release", release.ID, intent.SourceChain, intent.Status)
+	}
+	return nil
+}
+
+// applyBridgeRelease completes the intent, keeping its amount in escrow behind the payout, or
+// refunds the escrow if the payout failed.
+func applyBridgeRelease(ctx *NativeContext, tx *Transaction) error {
+	sm := ctx.State
+	var release BridgeRelease
+	if err := decodeGob(tx.Payload, &release); err != nil {
+		return err
+	}
+	intent, err := GetBridgeIntent(sm.db, release.ID)
+	if err != nil {
+		return err
+	}
+	next, reason, event := IntentCompleted, fmt.Sprintf("release %s confirmed", release.DestTxHash), "bridge.release"
+	if release.DestTxHash == "" {
+		next, reason, event = IntentRefunded, fmt.Sprintf("release failed: %s", release.Reason), "bridge.refund"
+	}
+	intent.DestTxHash = release.DestTxHash
+	if err := intent.Transition(next, sm.blockNumber(), stateIntentTimeouts(), reason); err != nil {
+		return err
+	}
+	if err := sm.settleBridgeIntent(intent); err != nil {
+		return err
+	}
+	ctx.Emit(event, intent.ID[:])
+	return nil
+}
@@ -0,0 +1,186 @@
+package core
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestIntentState_Transitions(t *testing.T) {
+	timeouts := IntentTimeouts{PendingNetting: 10, PendingSourceLock: 5, Locked: 5, PendingRelease: 20}
+
+	t.Run("ValidPath", func(t *testing.T) {
+		intent := &BridgeIntent{SourceChain: ChainID_Ethereum}
+		path := []IntentStatus{IntentPendingNetting, IntentPendingSourceLock, IntentLocked, IntentPendingSourceLock, IntentLocked, IntentPendingRelease, IntentCompleted}
+		for i, status := range path {
+			if err := intent.Transition(status, uint64(i), timeouts, "step"); err != nil {
+				t.Fatalf("Transition to %s failed: %v", status, err)
+			}
+		}
+		if len(intent.History) != len(path) || intent.History[0].From != "" || intent.History[3].From != IntentLocked || intent.History[3].Height != 3 {
+			t.Errorf("Unexpected audit trail %+v", intent.History)
+		}
+		if !intent.Status.IsTerminal() || intent.Deadline != 0 {
+			t.Errorf("Expected terminal state without deadline, got %s, deadline %d", intent.Status, intent.Deadline)
+		}
+	})
+
+	t.Run("InvalidTransitions", func(t *testing.T) {
+		cases := [][2]IntentStatus{
+			{IntentPendingNetting, IntentLocked},
+			{IntentPendingSourceLock, IntentPendingRelease},
+			{IntentPendingSourceLock, IntentRefunded},
+			{IntentPendingRelease, IntentFailed},
+			{IntentCompleted, IntentRefunded},
+			{IntentRefunded, IntentPendingNetting},
+		}
+		for _, c := range cases {
+			intent := &BridgeIntent{Status: c[0]}
+			if err := intent.Transition(c[1], 1, timeouts, "invalid"); err == nil {
+				t.Errorf("Expected %s -> %s to be rejected", c[0], c[1])
+			}
+			if intent.Status != c[0] || len(intent.History) != 0 {
+				t.Errorf("Rejected transition must not change the intent, got %+v", intent)
+			}
+		}
+	})
+
+	t.Run("Expiry", func(t *testing.T) {
+		cases := []struct {
+			source ChainID
+			status IntentStatus
+			want   IntentStatus
+		}{
+			{ChainID_QRL, IntentPendingNetting, IntentRefunded},
+			{ChainID_Ethereum, IntentPendingNetting, IntentFailed},
+			{ChainID_Ethereum, IntentPendingSourceLock, IntentFailed},
+			{ChainID_Ethereum, IntentLocked, IntentRefunded},
+			{ChainID_QRL, IntentPendingRelease, IntentRefunded},
+		}
+		for _, c := range cases {
+			intent := &BridgeIntent{SourceChain: c.source, Status: c.status, Deadline: 100}
+			if _, expired, _ := intent.Expire(99, timeouts); expired {
+				t.Errorf("%s: expected no expiry before the deadline", c.status)
+			}
+			status, expired, err := intent.Expire(100, timeouts)
+			if err != nil || !expired || status != c.want {
+				t.Errorf("%s from %s: expected expiry to %s, got %s (%v)", c.status, c.source, c.want, status, err)
+			}
+		}
+	})
+}
+
+func TestIntentState_ManagerRefunds(t *testing.T) {
+	cfg := DefaultMockChainConfig()
+	cfg.FinalityDepth = 3
+	newFixture := func(custody uint64) (*BridgeManager, *MockChain, *MockChain, *BridgeIntent) {
+		eth := NewMockChain(ChainID_Ethereum, cfg)
+		btc := NewMockChain(ChainID_Bitcoin, cfg)
+		eth.Fund("alice_eth", "qBTC", 100_000)
+		btc.Fund(MockCustodyAddress, "qBTC", custody)
+		manager := NewBridgeManager()
+		manager.SetIntentTimeouts(IntentTimeouts{PendingSourceLock: 10, Locked: 10, PendingRelease: 10})
+		_ = manager.RegisterAdapter(eth)
+		_ = manager.RegisterAdapter(btc)
+		intent := signedIntent(&BridgeIntent{UserAddress: "alice", SourceChain: ChainID_Ethereum, DestChain: ChainID_Bitcoin, Asset: "qBTC", Amount: 40_000, DestAddress: testBTCAddress})
+		_ = manager.HandleBridgeIntent(intent)
+		_, _ = manager.ProcessNettingEpoch(0)
+		_, _ = eth.Deposit("alice_eth", "qBTC", 40_000, fmt.Sprintf("%x", intent.ID[:]))
+		eth.Mine(1)
+		if err := manager.SyncExternalChains(); err != nil || intent.Status != IntentLocked {
+			t.Fatalf("Expected intent locked, got %s (%v)", intent.Status, err)
+		}
+		return manager, eth, btc, intent
+	}
+
+	t.Run("LockNeverConfirms", func(t *testing.T) {
+		manager, eth, _, intent := newFixture(100_000)
+		if err := manager.AdvanceHeight(9); err != nil || intent.Status != IntentLocked {
+			t.Fatalf("Expected intent still locked before the deadline, got %s (%v)", intent.Status, err)
+		}
+		if err := manager.AdvanceHeight(10); err != nil || intent.Status != IntentRefunded || intent.RefundTxHash == "" {
+			t.Fatalf("Expected expired lock refunded, got %+v (%v)", intent, err)
+		}
+		eth.Mine(1)
+		if eth.Balance("alice_eth", "qBTC") != 100_000 {
+			t.Errorf("Expected deposit returned to alice, balance %d", eth.Balance("alice_eth", "qBTC"))
+		}
+		last := intent.History[len(intent.History)-1]
+		if last.From != IntentLocked || last.To != IntentRefunded || last.Height != 10 {
+			t.Errorf("Expected refund recorded in the audit trail, got %+v", last)
+		}
+	})
+
+	t.Run("ReleaseFails", func(t *testing.T) {
+		// qBTC is released from custody on BTC, which is empty
+		manager, eth, btc, intent := newFixture(0)
+		eth.Mine(2)
+		_ = manager.SyncExternalChains()
+		if err := manager.SubmitReleases(); err != nil {
+			t.Fatalf("SubmitReleases failed: %v", err)
+		}
+		btc.Mine(1)
+		if err := manager.SyncExternalChains(); err != nil || intent.Status != IntentRefunded || intent.RefundTxHash == "" {
+			t.Fatalf("Expected failed release refunded, got %s (%v)", intent.Status, err)
+		}
+		eth.Mine(1)
+		if eth.Balance("alice_eth", "qBTC") != 100_000 {
+			t.Errorf("Expected deposit returned to alice, balance %d", eth.Balance("alice_eth", "qBTC"))
+		}
+	})
+}
+
+func TestIntentState_StateRelease(t *testing.T) {
+	db := NewInMemoryStateDB()
+	_ = db.SetBalance("userA_qrl", 1000)
+	sm := NewStateManager(db)
+	_ = sm.Epochs().SetLength(4)
+	submitted := signedIntent(&BridgeIntent{UserAddress: "userA_qrl", SourceChain: ChainID_QRL, DestChain: ChainID_Ethereum, Asset: NativeAsset, Amount: 300, DestAddress: testETHAddress})
+	tx, _ := NewBridgeIntentTransaction(0, submitted)
+	_ = tx.Sign()
+	id := submitted.ID
+	applyBlocks(t, sm, 0, 3, map[uint64][]*Transaction{1: {tx}})
+
+	intent, _ := GetBridgeIntent(db, id)
+	if intent.Status != IntentPendingRelease || intent.Deadline != 0 {
+		t.Fatalf("Expected PendingRelease without a deadline, got %s, deadline %d", intent.Status, intent.Deadline)
+	}
+	applyBlocks(t, sm, 4, 3+DefaultIntentTimeouts().PendingRelease, nil)
+	if intent, _ = GetBridgeIntent(db, id); intent.Status != IntentPendingRelease {
+		t.Fatalf("Expected escrow kept while the release may be paid, got %s", intent.Status)
+	}
+
+	next := 4 + DefaultIntentTimeouts().PendingRelease
+	_ = sm.BeginBlock(&BlockHeader{Number: next, Proposer: "proposerP"})
+	report := func(nonce uint64, release BridgeRelease) error {
+		tx, _ := NewBridgeReleaseTransaction(nonce, release)
+		_ = tx.Sign()
+		return sm.ApplyTransaction(tx)
+	}
+	forged, _ := NewBridgeReleaseTransaction(0, BridgeRelease{ID: id, DestTxHash: "0xpaid"})
+	forged.SenderID = "userA_qrl"
+	forged.Nonce = 1
+	_ = forged.Sign()
+	if err := sm.ApplyTransaction(forged); err == nil {
+		t.Errorf("Expected release report from another account to be rejected")
+	}
+	if err := report(0, BridgeRelease{ID: Hash{1}, DestTxHash: "0xpaid"}); err == nil {
+		t.Errorf("Expected release report for an unknown intent to be rejected")
+	}
+	if err := report(0, BridgeRelease{ID: id, Reason: "custody empty"}); err != nil {
+		t.Fatalf("Release failure report failed: %v", err)
+	}
+	intent, _ = GetBridgeIntent(db, id)
+	if intent.Status != IntentRefunded || len(intent.History) != 3 {
+		t.Errorf("Expected intent refunded after its failed release, got %s with history %+v", intent.Status, intent.History)
+	}
+	escrow, _ := db.GetBalance(BridgeEscrowAccount)
+	if bal, _ := db.GetBalance("userA_qrl"); escrow != 0 || bal != 1000-DefaultBridgeFeeModel().BaseFee {
+		t.Errorf("Expected escrow returned, escrow %d, user %d", escrow, bal)
+	}
+	if err := report(1, BridgeRelease{ID: id, DestTxHash: "0xpaid"}); err == nil {
+		t.Errorf("Expected a settled intent to reject another report")
+	}
+	if open, _ := OpenBridgeIntentIDs(db); len(open) != 0 {
+		t.Errorf("Expected no open intents, got %d", len(open))
+	}
+}
@@ -0,0 +1,256 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Bridge intent storage and queries
+//
+// Intents are stored with their transition history under bridge/intent/<id> and indexed by
+// submission epoch, user, status and route. The same layout is used in consensus state, for
+// intents submitted with TxTypeBridgeIntent, and in the store a BridgeManager persists its intents
+// to (see AttachStore), so the queries below work on either.
+
+// Storage key prefixes of the bridge intent indexes, next to those in bridge.go.
+const (
+	bridgeUserPrefix   = "bridge/user/"   // bridge/user/<address>/<id> -> marker
+	bridgeStatusPrefix = "bridge/status/" // bridge/status/<status>/<id> -> marker
+	bridgeRoutePrefix  = "bridge/route/"  // bridge/route/<source>/<dest>/<id> -> marker
+)
+
+// bridgeUserKey returns the storage key indexing intent id under user.
+func bridgeUserKey(user string, id Hash) string {
+	return fmt.Sprintf("%s%s/%x", bridgeUserPrefix, user, id[:])
+}
+
+// bridgeStatusKey returns the storage key indexing intent id under status.
+func bridgeStatusKey(status IntentStatus, id Hash) string {
+	return fmt.Sprintf("%s%s/%x", bridgeStatusPrefix, status, id[:])
+}
+
+// bridgeRouteKey returns the storage key indexing intent id under its route.
+func bridgeRouteKey(source, dest ChainID, id Hash) string {
+	return fmt.Sprintf("%s%s/%s/%x", bridgeRoutePrefix, source, dest, id[:])
+}
+
+// storeBridgeIntent writes intent to db and keeps its index entries in sync with its status.
+func storeBridgeIntent(db StateDB, intent *BridgeIntent) error {
+	prev, err := GetBridgeIntent(db, intent.ID)
+	if err != nil {
+		return err
+	}
+	if prev != nil && prev.Status != intent.Status {
+		if err := db.SetStorage(bridgeStatusKey(prev.Status, intent.ID), nil); err != nil {
+			return err
+		}
+	}
+	if err := setStorageGob(db, bridgeIntentKey(intent.ID), intent); err != nil {
+		return err
+	}
+	for _, key := range []string{
+		bridgeEpochKey(intent.Epoch, intent.ID),
+		bridgeUserKey(intent.UserAddress, intent.ID),
+		bridgeStatusKey(intent.Status, intent.ID),
+		bridgeRouteKey(intent.SourceChain, intent.DestChain, intent.ID),
+	} {
+		if err := db.SetStorage(key, []byte{1}); err != nil {
+			return err
+		}
+	}
+	var open []byte
+	if !intent.Status.IsTerminal() {
+		open = []byte{1}
+	}
+	return db.SetStorage(bridgeOpenKey(intent.ID), open)
+}
+
+// loadBridgeIntents returns the intents indexed under prefix, ordered by ID.
+func loadBridgeIntents(db StateDB, prefix string) ([]*BridgeIntent, error) {
+	ids, err := bridgeIndexIDs(db, prefix)
+	if err != nil {
+		return nil, err
+	}
+	intents := make([]*BridgeIntent, 0, len(ids))
+	for _, id := range ids {
+		intent, err := GetBridgeIntent(db, id)
+		if err != nil {
+			return nil, err
+		}
+		if intent == nil {
+			return nil, fmt.Errorf("bridge index %s points to missing intent %s", prefix, id)
+		}
+		intents = append(intents, intent)
+	}
+	return intents, nil
+}
+
+// BridgeIntentsByUser returns the intents of user, ordered by ID.
+func BridgeIntentsByUser(db StateDB, user string) ([]*BridgeIntent, error) {
+	return loadBridgeIntents(db, bridgeUserPrefix+user+"/")
+}
+
+// BridgeIntentsByStatus returns the intents currently in status, ordered by ID.
+func BridgeIntentsByStatus(db StateDB, status IntentStatus) ([]*BridgeIntent, error) {
+	return loadBridgeIntents(db, fmt.Sprintf("%s%s/", bridgeStatusPrefix, status))
+}
+
+// BridgeIntentsByRoute returns the intents from source to dest, ordered by ID.
+func BridgeIntentsByRoute(db StateDB, source, dest ChainID) ([]*BridgeIntent, error) {
+	return loadBridgeIntents(db, fmt.Sprintf("%s%s/%s/", bridgeRoutePrefix, source, dest))
+}
+
+// BridgeIntentsByEpoch returns the intents submitted during epoch, ordered by ID.
+func BridgeIntentsByEpoch(db StateDB, epoch uint64) ([]*BridgeIntent, error) {
+	return loadBridgeIntents(db, fmt.Sprintf("%s%020d/", bridgeEpochPrefix, epoch))
+}
+
+// AssetVolume aggregates the intents of one asset submitted during an epoch.
+type AssetVolume struct {
+	Epoch     uint64
+	Asset     string
+	Intents   int
+	Volume    uint64 // Amount of all intents
+	Completed uint64 // Amount of the intents paid out
+	Refunded  uint64 // Amount of the intents refunded
+	Fees      uint64 // Fees paid in the native asset
+}
+
+// BridgeAssetVolumes returns the volume of each asset submitted during epoch, sorted by asset.
+func BridgeAssetVolumes(db StateDB, epoch uint64) ([]AssetVolume, error) {
+	intents, err := BridgeIntentsByEpoch(db, epoch)
+	if err != nil {
+		return nil, err
+	}
+	byAsset := make(map[string]*AssetVolume)
+	for _, intent := range intents {
+		v, ok := byAsset[intent.Asset]
+		if !ok {
+			v = &AssetVolume{Epoch: epoch, Asset: intent.Asset}
+			byAsset[intent.Asset] = v
+		}
+		v.Intents++
+		v.Volume += intent.Amount
+		v.Fees += intent.Fee
+		switch intent.Status {
+		case IntentCompleted:
+			v.Completed += intent.Amount
+		case IntentRefunded:
+			v.Refunded += intent.Amount
+		}
+	}
+	volumes := make([]AssetVolume, 0, len(byAsset))
+	for _, v := range byAsset {
+		volumes = append(volumes, *v)
+	}
+	sort.Slice(volumes, func(i, j int) bool { return volumes[i].Asset < volumes[j].Asset })
+	return volumes, nil
+}
+
+// --- BridgeManager integration ---
+
+// AttachStore makes the manager persist its intents, with their transition history, its pauses and
+// the volume bridged in the current epoch to db, and resumes from what it finds there, e.g. after a
+// restart. What the manager already tracks is written to db.
+func (bm *BridgeManager) AttachStore(db StateDB) error {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+
+	ids, err := OpenBridgeIntentIDs(db)
+	if err != nil {
+		return err
+	}
+	for _, intent := range bm.pendingIntents {
+		bm.dirty[intent.ID] = intent
+	}
+	for _, id := range ids {
+		intent, err := GetBridgeIntent(db, id)
+		if err != nil {
+			return err
+		}
+		if intent == nil {
+			return fmt.Errorf("bridge store marks missing intent %s open", id)
+		}
+		bm.pendingIntents[id] = intent
+		bm.seen[id] = struct{}{}
+		bm.epoch = max(bm.epoch, intent.Epoch)
+	}
+	pauses, err := BridgePauses(db)
+	if err != nil {
+		return err
+	}
+	for _, p := range pauses {
+		if _, paused := bm.pauses[p.Scope]; !paused {
+			bm.pauses[p.Scope] = p
+		}
+	}
+	prefix := bridgeVolumeKey(bm.epoch, "")
+	keys, err := db.StorageKeys(prefix)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		scope := strings.TrimPrefix(key, prefix)
+		volume, err := GetBridgeVolume(db, bm.epoch, scope)
+		if err != nil {
+			return err
+		}
+		bm.volume[scope] = max(bm.volume[scope], volume)
+	}
+	bm.store = db
+	return bm.flush()
+}
+
+// transition moves intent to status next at the current height and marks it for persistence.
+// Caller must hold bm.mu.
+func (bm *BridgeManager) transition(intent *BridgeIntent, next IntentStatus, reason string) error {
+	if err := intent.Transition(next, bm.height, bm.timeouts, reason); err != nil {
+		return err
+	}
+	bm.dirty[intent.ID] = intent
+	return nil
+}
+
+// flush writes the intents changed since the last flush, the pauses and the current epoch's volume
+// to the store, if one is attached. Caller must hold bm.mu.
+func (bm *BridgeManager) flush() error {
+	if bm.store == nil {
+		clear(bm.dirty)
+		return nil
+	}
+	ids := make([]Hash, 0, len(bm.dirty))
+	for id := range bm.dirty {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].Less(ids[j]) })
+	for _, id := range ids {
+		if err := storeBridgeIntent(bm.store, bm.dirty[id]); err != nil {
+			return fmt.Errorf("failed to persist intent %s: %w", id, err)
+		}
+		delete(bm.dirty, id)
+	}
+
+	stored, err := BridgePauses(bm.store)
+	if err != nil {
+		return err
+	}
+	for _, p := range stored {
+		if _, paused := bm.pauses[p.Scope]; !paused {
+			if err := bm.store.SetStorage(bridgePauseKey(p.Scope), nil); err != nil {
+				return err
+			}
+		}
+	}
+	for scope, p := range bm.pauses {
+		if err := setStorageGob(bm.store, bridgePauseKey(scope), &p); err != nil {
+			return fmt.Errorf("failed to persist pause of %s: %w", scope, err)
+		}
+	}
+	for scope, volume := range bm.volume {
+		if err := setBridgeVolume(bm.store, bm.epoch, scope, volume); err != nil {
+			return fmt.Errorf("failed to persist volume of %s: %w", scope, err)
+		}
+	}
+	return nil
+}
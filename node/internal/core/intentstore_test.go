@@ -0,0 +1,179 @@
+package core
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestIntentStore_StateQueries(t *testing.T) {
+	db := NewInMemoryStateDB()
+	_ = db.SetBalance("alice", 10_000)
+	_ = db.SetBalance("bob", 10_000)
+	sm := NewStateManager(db)
+	_ = sm.Epochs().SetLength(4)
+	submit := func(user string, amount uint64) *Transaction {
+		tx, err := NewBridgeIntentTransaction(0, signedIntent(&BridgeIntent{UserAddress: user, SourceChain: ChainID_QRL, DestChain: ChainID_Ethereum, Asset: NativeAsset, Amount: amount, DestAddress: testETHAddress}))
+		if err != nil {
+			t.Fatalf("Failed to build transaction: %v", err)
+		}
+		_ = tx.Sign()
+		return tx
+	}
+	txs := []*Transaction{submit("alice", 300), submit("bob", 500)}
+	applyBlocks(t, sm, 0, 1, map[uint64][]*Transaction{1: txs})
+	epoch := sm.epochs.EpochOf(1)
+
+	if intents, _ := BridgeIntentsByUser(db, "alice"); len(intents) != 1 || intents[0].Amount != 300 {
+		t.Errorf("Expected alice's intent, got %+v", intents)
+	}
+	if intents, _ := BridgeIntentsByRoute(db, ChainID_QRL, ChainID_Ethereum); len(intents) != 2 {
+		t.Errorf("Expected 2 intents on the QRL -> ETH route, got %d", len(intents))
+	}
+	if intents, _ := BridgeIntentsByRoute(db, ChainID_Ethereum, ChainID_QRL); len(intents) != 0 {
+		t.Errorf("Expected no intents on the ETH -> QRL route, got %d", len(intents))
+	}
+	if intents, _ := BridgeIntentsByEpoch(db, epoch); len(intents) != 2 {
+		t.Errorf("Expected 2 intents in epoch %d, got %d", epoch, len(intents))
+	}
+	if intents, _ := BridgeIntentsByStatus(db, IntentPendingNetting); len(intents) != 2 {
+		t.Errorf("Expected 2 intents pending netting, got %d", len(intents))
+	}
+
+	applyBlocks(t, sm, 2, 3, nil)
+	if intents, _ := BridgeIntentsByStatus(db, IntentPendingNetting); len(intents) != 0 {
+		t.Errorf("Expected the status index to drop netted intents, got %d", len(intents))
+	}
+	intents, _ := BridgeIntentsByStatus(db, IntentPendingRelease)
+	if len(intents) != 2 || len(intents[0].History) != 2 {
+		t.Fatalf("Expected 2 intents pending release with their history, got %+v", intents)
+	}
+
+	volumes, err := BridgeAssetVolumes(db, epoch)
+	if err != nil {
+		t.Fatalf("BridgeAssetVolumes failed: %v", err)
+	}
+	if len(volumes) != 1 || volumes[0].Asset != NativeAsset || volumes[0].Intents != 2 || volumes[0].Volume != 800 || volumes[0].Fees != 2*DefaultBridgeFeeModel().BaseFee {
+		t.Errorf("Expected 2 intents of 800 %s, got %+v", NativeAsset, volumes)
+	}
+
+	report := func(nonce uint64, release BridgeRelease) *Transaction {
+		tx, _ := NewBridgeReleaseTransaction(nonce, release)
+		_ = tx.Sign()
+		return tx
+	}
+	alice, _ := BridgeIntentsByUser(db, "alice")
+	bob, _ := BridgeIntentsByUser(db, "bob")
+	applyBlocks(t, sm, 4, 4, map[uint64][]*Transaction{4: {
+		report(0, BridgeRelease{ID: alice[0].ID, DestTxHash: "0xpaid"}),
+		report(1, BridgeRelease{ID: bob[0].ID, Reason: "custody empty"}),
+	}})
+	volumes, _ = BridgeAssetVolumes(db, epoch)
+	if len(volumes) != 1 || volumes[0].Completed != 300 || volumes[0].Refunded != 500 {
+		t.Errorf("Expected 300 completed and 500 refunded once the releases are reported, got %+v", volumes)
+	}
+	if intents, _ := BridgeIntentsByStatus(db, IntentCompleted); len(intents) != 1 || intents[0].DestTxHash != "0xpaid" {
+		t.Errorf("Expected alice's intent completed by its release, got %+v", intents)
+	}
+}
+
+func TestIntentStore_ManagerRestart(t *testing.T) {
+	db := NewInMemoryStateDB()
+	eth := NewMockChain(ChainID_Ethereum, DefaultMockChainConfig())
+	eth.Fund("alice_eth", "qBTC", 100_000)
+	manager := NewBridgeManager()
+	if err := manager.AttachStore(db); err != nil {
+		t.Fatalf("AttachStore failed: %v", err)
+	}
+	intent := signedIntent(&BridgeIntent{UserAddress: "alice", SourceChain: ChainID_Ethereum, DestChain: ChainID_Bitcoin, Asset: "qBTC", Amount: 40_000, DestAddress: testBTCAddress})
+	if err := manager.HandleBridgeIntent(intent); err != nil {
+		t.Fatalf("HandleBridgeIntent failed: %v", err)
+	}
+	if _, err := manager.ProcessNettingEpoch(0); err != nil {
+		t.Fatalf("ProcessNettingEpoch failed: %v", err)
+	}
+	stored, _ := GetBridgeIntent(db, intent.ID)
+	if stored == nil || stored.Status != IntentPendingSourceLock || len(stored.History) != 2 {
+		t.Fatalf("Expected intent persisted pending its source lock, got %+v", stored)
+	}
+
+	restarted := NewBridgeManager()
+	_ = restarted.RegisterAdapter(eth)
+	if err := restarted.AttachStore(db); err != nil {
+		t.Fatalf("AttachStore after restart failed: %v", err)
+	}
+	if err := restarted.HandleBridgeIntent(signedIntent(&BridgeIntent{UserAddress: "alice", SourceChain: ChainID_Ethereum, DestChain: ChainID_Bitcoin, Asset: "qBTC", Amount: 40_000, DestAddress: testBTCAddress})); err == nil {
+		t.Errorf("Expected intent submitted before the restart to be rejected as a duplicate")
+	}
+
+	_, _ = eth.Deposit("alice_eth", "qBTC", 40_000, fmt.Sprintf("%x", intent.ID[:]))
+	eth.Mine(1)
+	if err := restarted.SyncExternalChains(); err != nil {
+		t.Fatalf("SyncExternalChains failed: %v", err)
+	}
+	stored, _ = GetBridgeIntent(db, intent.ID)
+	if stored.Status != IntentLocked || len(stored.History) != 3 {
+		t.Errorf("Expected resumed intent locked by its deposit, got %s with history %+v", stored.Status, stored.History)
+	}
+	if intents, _ := BridgeIntentsByUser(db, "alice"); len(intents) != 1 {
+		t.Errorf("Expected alice's intent indexed once, got %d", len(intents))
+	}
+}
+
+func TestIntentStore_EscrowedReleases(t *testing.T) {
+	db := NewInMemoryStateDB()
+	_ = db.SetBalance("alice", 10_000)
+	sm := NewStateManager(db)
+	_ = sm.Epochs().SetLength(4)
+	eth := NewMockChain(ChainID_Ethereum, DefaultMockChainConfig())
+	manager := NewBridgeManager()
+	_ = manager.RegisterAdapter(eth)
+	store := NewInMemoryStateDB()
+	if err := manager.AttachStore(store); err != nil {
+		t.Fatalf("AttachStore failed: %v", err)
+	}
+
+	unescrowed := signedIntent(&BridgeIntent{UserAddress: "alice", SourceChain: ChainID_QRL, DestChain: ChainID_Ethereum, Asset: NativeAsset, Amount: 500, DestAddress: testETHAddress})
+	if err := manager.HandleBridgeIntent(unescrowed); err == nil {
+		t.Errorf("Expected intent leaving QRL without an escrow to be rejected")
+	}
+	escrowed := signedIntent(&BridgeIntent{UserAddress: "alice", SourceChain: ChainID_QRL, DestChain: ChainID_Ethereum, Asset: NativeAsset, Amount: 300, DestAddress: testETHAddress})
+	tx, _ := NewBridgeIntentTransaction(0, escrowed)
+	_ = tx.Sign()
+	applyBlocks(t, sm, 0, 3, map[uint64][]*Transaction{1: {tx}})
+
+	if err := manager.SubmitReleases(); err != nil {
+		t.Fatalf("SubmitReleases failed: %v", err)
+	}
+	if stored, _ := GetBridgeIntent(store, escrowed.ID); stored != nil {
+		t.Fatalf("Expected no release before the chain state is watched, got %+v", stored)
+	}
+	manager.WatchState(db)
+	if err := manager.SubmitReleases(); err != nil {
+		t.Fatalf("SubmitReleases failed: %v", err)
+	}
+	stored, _ := GetBridgeIntent(store, escrowed.ID)
+	if stored == nil || stored.DestTxHash == "" {
+		t.Fatalf("Expected the escrowed intent released, got %+v", stored)
+	}
+	eth.Mine(int(DefaultMockChainConfig().FinalityDepth))
+	if balance := eth.Balance(testETHAddress, NativeAsset); balance != 300 {
+		t.Errorf("Expected only the escrowed 300 paid out, got %d", balance)
+	}
+
+	if err := manager.SyncExternalChains(); err != nil {
+		t.Fatalf("SyncExternalChains failed: %v", err)
+	}
+	reports := manager.ReleaseReports()
+	if len(reports) != 1 || reports[0].ID != escrowed.ID || reports[0].DestTxHash != stored.DestTxHash {
+		t.Fatalf("Expected the final release reported, got %+v", reports)
+	}
+	release, _ := NewBridgeReleaseTransaction(0, reports[0])
+	_ = release.Sign()
+	applyBlocks(t, sm, 4, 4, map[uint64][]*Transaction{4: {release}})
+	if intent, _ := GetBridgeIntent(db, escrowed.ID); intent.Status != IntentCompleted {
+		t.Errorf("Expected the intent completed in state by the report, got %s", intent.Status)
+	}
+	if escrow, _ := db.GetBalance(BridgeEscrowAccount); escrow != 300 {
+		t.Errorf("Expected the paid amount kept in escrow, got %d", escrow)
+	}
+}
@@ -0,0 +1,276 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Bridge inventory and probabilistic release
+//
+// The bridge keeps an inventory pool of each asset on each chain in its custody there. Instead of
+// waiting for a user's source lock to become final, the bridge pays the user out of the destination
+// pool as soon as its confidence in the lock exceeds a threshold (probabilistic release). Until the
+// lock is final the payout is exposure: value the bridge loses if the lock is reorganized away.
+// Final deposits replenish the source pool; pools that drift outside their bounds are rebalanced.
+
+// InventoryPool is the bridge's inventory of one asset on one chain.
+type InventoryPool struct {
+	Chain    ChainID
+	Asset    string
+	Balance  uint64 // Available for payouts
+	Exposure uint64 // Paid out against source locks that are not final yet
+	Incoming uint64 // Scheduled to arrive by rebalancing
+	Min      uint64 // Rebalance into the pool below this balance
+	Target   uint64 // Balance rebalancing aims for
+	Max      uint64 // Rebalance out of the pool above this balance
+	Floor    uint64 // Pause the asset if payouts take the balance below this
+}
+
+// InventoryConfig tunes probabilistic release.
+type InventoryConfig struct {
+	ConfidenceThreshold float64 // Confidence in a source lock required for an early payout
+	AttackerShare       float64 // Share of the source chain's block production assumed hostile
+	MaxExposureBps      uint64  // Exposure a pool may carry, in basis points of its target
+}
+
+// DefaultInventoryConfig returns the default probabilistic release parameters.
+func DefaultInventoryConfig() InventoryConfig {
+	return InventoryConfig{
+		ConfidenceThreshold: 0.99,
+		AttackerShare:       0.1,
+		MaxExposureBps:      5000,
+	}
+}
+
+// LockConfidence returns the probability that a lock with the given confirmations stays in the
+// chain, against an attacker producing attackerShare of the blocks: 1 - (q/p)^z as in the Bitcoin
+// whitepaper's catch-up estimate. A lock at finality depth is certain.
+func LockConfidence(confirmations, finalityDepth uint64, attackerShare float64) float64 {
+	if confirmations == 0 {
+		return 0
+	}
+	if confirmations >= finalityDepth || attackerShare <= 0 {
+		return 1
+	}
+	if attackerShare >= 0.5 {
+		return 0
+	}
+	return 1 - math.Pow(attackerShare/(1-attackerShare), float64(confirmations))
+}
+
+// RebalanceTransfer moves inventory of an asset between two chains' pools.
+type RebalanceTransfer struct {
+	Asset  string
+	From   ChainID
+	To     ChainID
+	Amount uint64
+}
+
+// PlanRebalancing returns the transfers bringing pools below their minimum back to target out of
+// pools above their maximum, which give up no more than their excess over target. Incoming
+// transfers count towards a pool's balance. The plan is deterministic.
+func PlanRebalancing(pools []InventoryPool) []RebalanceTransfer {
+	sorted := append([]InventoryPool(nil), pools...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Asset != sorted[j].Asset {
+			return sorted[i].Asset < sorted[j].Asset
+		}
+		return sorted[i].Chain < sorted[j].Chain
+	})
+	var transfers []RebalanceTransfer
+	for start := 0; start < len(sorted); {
+		end := start
+		for end < len(sorted) && sorted[end].Asset == sorted[start].Asset {
+			end++
+		}
+		var surplus, deficit []*InventoryPool
+		excess := make(map[ChainID]uint64)
+		need := make(map[ChainID]uint64)
+		for i := start; i < end; i++ {
+			p := &sorted[i]
+			switch effective := p.Balance + p.Incoming; {
+			case effective > p.Max && p.Balance > p.Target:
+				surplus = append(surplus, p)
+				excess[p.Chain] = p.Balance - p.Target
+			case effective < p.Min:
+				deficit = append(deficit, p)
+				need[p.Chain] = p.Target - effective
+			}
+		}
+		for _, to := range deficit {
+			for _, from := range surplus {
+				amount := min(need[to.Chain], excess[from.Chain])
+				if amount == 0 {
+					continue
+				}
+				transfers = append(transfers, RebalanceTransfer{Asset: to.Asset, From: from.Chain, To: to.Chain, Amount: amount})
+				need[to.Chain] -= amount
+				excess[from.Chain] -= amount
+			}
+		}
+		start = end
+	}
+	return transfers
+}
+
+// --- BridgeManager integration ---
+
+// SetInventoryPool sets the inventory pool of pool.Asset on pool.Chain, replacing an existing one.
+func (bm *BridgeManager) SetInventoryPool(pool InventoryPool) error {
+	if pool.Floor > pool.Min || pool.Min > pool.Target || pool.Target > pool.Max {
+		return fmt.Errorf("inventory bounds must satisfy floor <= min <= target <= max, got %d, %d, %d, %d", pool.Floor, pool.Min, pool.Target, pool.Max)
+	}
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+
+	if bm.inventory[pool.Chain] == nil {
+		bm.inventory[pool.Chain] = make(map[string]*InventoryPool)
+	}
+	bm.inventory[pool.Chain][pool.Asset] = &pool
+	return nil
+}
+
+// SetInventoryConfig sets the probabilistic release parameters.
+func (bm *BridgeManager) SetInventoryConfig(cfg InventoryConfig) {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	bm.inventoryCfg = cfg
+}
+
+// InventoryPools returns a snapshot of all pools, sorted by chain and asset.
+func (bm *BridgeManager) InventoryPools() []InventoryPool {
+	bm.mu.RLock()
+	defer bm.mu.RUnlock()
+
+	var pools []InventoryPool
+	for _, assets := range bm.inventory {
+		for _, pool := range assets {
+			pools = append(pools, *pool)
+		}
+	}
+	sort.Slice(pools, func(i, j int) bool {
+		if pools[i].Chain != pools[j].Chain {
+			return pools[i].Chain < pools[j].Chain
+		}
+		return pools[i].Asset < pools[j].Asset
+	})
+	return pools
+}
+
+// pool returns the inventory pool of asset on chain, or nil. Caller must hold bm.mu.
+func (bm *BridgeManager) pool(chain ChainID, asset string) *InventoryPool {
+	return bm.inventory[chain][asset]
+}
+
+// ReleaseFromInventory pays out Locked intents whose source lock has reached the confidence
+// threshold from the destination pool, if it has the balance and room for the exposure and no pause
+// stops them. The intents become PendingRelease; the exposure is cleared once their lock is final.
+func (bm *BridgeManager) ReleaseFromInventory() (err error) {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	defer func() { err = errors.Join(err, bm.flush()) }()
+
+	ids := make([]Hash, 0, len(bm.pendingIntents))
+	for id, intent := range bm.pendingIntents {
+		if intent.Status == IntentLocked && intent.DestChain != ChainID_QRL {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].Less(ids[j]) })
+	for _, id := range ids {
+		intent := bm.pendingIntents[id]
+		pool := bm.pool(intent.DestChain, intent.Asset)
+		if pool == nil || pool.Balance < intent.Amount || pool.Exposure+intent.Amount > pool.Target*bm.inventoryCfg.MaxExposureBps/10000 {
+			continue
+		}
+		if bm.checkPaused(intent) != nil {
+			continue
+		}
+		confirmations, finalityDepth, err := bm.lockConfirmations(intent)
+		if err != nil {
+			return fmt.Errorf("failed to check lock of intent %s: %w", id, err)
+		}
+		confidence := LockConfidence(confirmations, finalityDepth, bm.inventoryCfg.AttackerShare)
+		if confidence < bm.inventoryCfg.ConfidenceThreshold {
+			continue
+		}
+		if err := bm.submitPayout(intent, pool); err != nil {
+			return err
+		}
+		intent.EarlyRelease = true
+		pool.Exposure += intent.Amount
+		reason := fmt.Sprintf("released from inventory at %d confirmations (confidence %.4f)", confirmations, confidence)
+		if err := bm.transition(intent, IntentPendingRelease, reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// submitPayout pays intent out on its destination chain: from pool if it is set, otherwise by
+// releasing the asset on its home chain or out of the netted deposits in custody, and minting it
+// where they fall short. A pool drained below its floor
+// pauses the asset. Caller must hold bm.mu.
+func (bm *BridgeManager) submitPayout(intent *BridgeIntent, pool *InventoryPool) error {
+	adapter, ok := bm.adapters[intent.DestChain]
+	if !ok {
+		return fmt.Errorf("no adapter for chain %s of intent %s", intent.DestChain, intent.ID)
+	}
+	kind := InstructionMint
+	fromCustody := pool == nil && bm.keepsCustody(intent.DestChain, intent.Asset) && bm.custody[intent.DestChain][intent.Asset] >= intent.Amount
+	if home, _ := AssetHomeChain(intent.Asset); home == intent.DestChain || pool != nil || fromCustody {
+		kind = InstructionRelease
+	}
+	txHash, err := adapter.Submit(ExternalTx{Kind: kind, Asset: intent.Asset, Address: intent.DestAddress, Amount: intent.Amount, Memo: fmt.Sprintf("%x", intent.ID[:])})
+	if err != nil {
+		return fmt.Errorf("failed to submit release of intent %s: %w", intent.ID, err)
+	}
+	intent.DestTxHash = txHash
+	bm.dirty[intent.ID] = intent
+	if fromCustody {
+		bm.takeCustody(intent.DestChain, intent.Asset, intent.Amount)
+		intent.FromCustody = true
+	}
+	if pool != nil {
+		pool.Balance -= intent.Amount
+		bm.checkFloor(pool)
+	}
+	return nil
+}
+
+// ScheduleRebalancing plans transfers for the pools outside their bounds (see PlanRebalancing),
+// takes the amounts out of the sending pools and records them as incoming at the receiving ones.
+// The caller carries the transfers out and reports each with CompleteRebalance.
+func (bm *BridgeManager) ScheduleRebalancing() []RebalanceTransfer {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+
+	var pools []InventoryPool
+	for _, assets := range bm.inventory {
+		for _, pool := range assets {
+			pools = append(pools, *pool)
+		}
+	}
+	transfers := PlanRebalancing(pools)
+	for _, t := range transfers {
+		bm.pool(t.From, t.Asset).Balance -= t.Amount
+		bm.pool(t.To, t.Asset).Incoming += t.Amount
+	}
+	return transfers
+}
+
+// CompleteRebalance credits a scheduled transfer to its receiving pool.
+func (bm *BridgeManager) CompleteRebalance(t RebalanceTransfer) error {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+
+	pool := bm.pool(t.To, t.Asset)
+	if pool == nil || pool.Incoming < t.Amount {
+		return fmt.Errorf("no scheduled transfer of %d %s to %s", t.Amount, t.Asset, t.To)
+	}
+	pool.Incoming -= t.Amount
+	pool.Balance += t.Amount
+	return nil
+}
@@ -0,0 +1,242 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestInventory_LockConfidence(t *testing.T) {
+	tests := []struct {
+		confirmations, finality uint64
+		share                   float64
+		min, max                float64
+	}{
+		{0, 6, 0.1, 0, 0},
+		{1, 6, 0.1, 0.88, 0.89},
+		{3, 6, 0.1, 0.998, 0.999},
+		{6, 6, 0.1, 1, 1},
+		{3, 6, 0.5, 0, 0},
+		{1, 6, 0, 1, 1},
+	}
+	for _, tt := range tests {
+		got := LockConfidence(tt.confirmations, tt.finality, tt.share)
+		if got < tt.min || got > tt.max {
+			t.Errorf("LockConfidence(%d, %d, %v) = %v, want within [%v, %v]", tt.confirmations, tt.finality, tt.share, got, tt.min, tt.max)
+		}
+	}
+	if LockConfidence(2, 6, 0.1) <= LockConfidence(1, 6, 0.1) {
+		t.Errorf("Expected confidence to grow with confirmations")
+	}
+}
+
+func TestInventory_PlanRebalancing(t *testing.T) {
+	pools := []InventoryPool{
+		{Chain: ChainID_Ethereum, Asset: "qBTC", Balance: 10_000, Min: 50_000, Target: 100_000, Max: 200_000},
+		{Chain: ChainID_Bitcoin, Asset: "qBTC", Balance: 300_000, Min: 50_000, Target: 100_000, Max: 200_000},
+		{Chain: ChainID_QRL, Asset: "qBTC", Balance: 20_000, Incoming: 40_000, Min: 50_000, Target: 100_000, Max: 200_000},
+		{Chain: ChainID_Ethereum, Asset: "qETH", Balance: 0, Min: 1, Target: 2, Max: 3},
+	}
+	want := []RebalanceTransfer{
+		{Asset: "qBTC", From: ChainID_Bitcoin, To: ChainID_Ethereum, Amount: 90_000},
+	}
+	if got := PlanRebalancing(pools); !reflect.DeepEqual(got, want) {
+		t.Errorf("PlanRebalancing() = %+v, want %+v", got, want)
+	}
+
+	pools[2].Incoming = 0
+	want = append(want, RebalanceTransfer{Asset: "qBTC", From: ChainID_Bitcoin, To: ChainID_QRL, Amount: 80_000})
+	if got := PlanRebalancing(pools); !reflect.DeepEqual(got, want) {
+		t.Errorf("PlanRebalancing() = %+v, want %+v", got, want)
+	}
+
+	pools[1].Balance = 150_000 // Within bounds: nothing to give up
+	if got := PlanRebalancing(pools); len(got) != 0 {
+		t.Errorf("Expected no transfers without a pool above its maximum, got %+v", got)
+	}
+}
+
+// newInventoryFixture returns a manager bridging qBTC from ETH to BTC with pools on both chains,
+// and a signed intent of amount waiting for its deposit.
+func newInventoryFixture(t *testing.T, amount uint64) (*BridgeManager, *MockChain, *MockChain, *BridgeIntent) {
+	t.Helper()
+	cfg := DefaultMockChainConfig()
+	eth := NewMockChain(ChainID_Ethereum, cfg)
+	btc := NewMockChain(ChainID_Bitcoin, cfg)
+	eth.Fund("alice_eth", "qBTC", 1_000_000)
+	btc.Fund(MockCustodyAddress, "qBTC", 100_000)
+	manager := NewBridgeManager()
+	for _, chain := range []*MockChain{eth, btc} {
+		if err := manager.RegisterAdapter(chain); err != nil {
+			t.Fatalf("RegisterAdapter failed: %v", err)
+		}
+	}
+	for _, pool := range []InventoryPool{
+		{Chain: ChainID_Ethereum, Asset: "qBTC", Min: 10_000, Target: 100_000, Max: 200_000},
+		{Chain: ChainID_Bitcoin, Asset: "qBTC", Balance: 100_000, Min: 10_000, Target: 100_000, Max: 200_000},
+	} {
+		if err := manager.SetInventoryPool(pool); err != nil {
+			t.Fatalf("SetInventoryPool failed: %v", err)
+		}
+	}
+	quote, err := manager.QuoteFee("qBTC", ChainID_Ethereum, ChainID_Bitcoin, amount)
+	if err != nil {
+		t.Fatalf("QuoteFee failed: %v", err)
+	}
+	intent := signedIntent(&BridgeIntent{UserAddress: "alice", SourceChain: ChainID_Ethereum, DestChain: ChainID_Bitcoin, Asset: "qBTC", Amount: amount, DestAddress: testBTCAddress, Fee: quote.Fee})
+	if err := manager.HandleBridgeIntent(intent); err != nil {
+		t.Fatalf("HandleBridgeIntent failed: %v", err)
+	}
+	if _, err := manager.ProcessNettingEpoch(0); err != nil {
+		t.Fatalf("ProcessNettingEpoch failed: %v", err)
+	}
+	return manager, eth, btc, intent
+}
+
+func TestInventory_EarlyRelease(t *testing.T) {
+	manager, eth, btc, intent := newInventoryFixture(t, 40_000)
+	if _, err := eth.Deposit("alice_eth", "qBTC", 40_000, fmt.Sprintf("%x", intent.ID[:])); err != nil {
+		t.Fatalf("Deposit failed: %v", err)
+	}
+
+	eth.Mine(1)
+	if err := manager.SyncExternalChains(); err != nil || intent.Status != IntentLocked {
+		t.Fatalf("Expected intent Locked, got %s (%v)", intent.Status, err)
+	}
+	if err := manager.ReleaseFromInventory(); err != nil || intent.DestTxHash != "" {
+		t.Fatalf("Expected no release at one confirmation, got %q (%v)", intent.DestTxHash, err)
+	}
+
+	eth.Mine(2) // Three confirmations, confidence above 0.99 but not final at depth 6
+	if err := manager.ReleaseFromInventory(); err != nil || intent.Status != IntentPendingRelease || !intent.EarlyRelease {
+		t.Fatalf("Expected early release, got %s (%v)", intent.Status, err)
+	}
+	pools := manager.InventoryPools()
+	if btcPool := pools[0]; btcPool.Chain != ChainID_Bitcoin || btcPool.Balance != 60_000 || btcPool.Exposure != 40_000 {
+		t.Errorf("Expected BTC pool debited with 40000 exposure, got %+v", btcPool)
+	}
+
+	btc.Mine(6)
+	if err := manager.SyncExternalChains(); err != nil || intent.Status != IntentCompleted {
+		t.Fatalf("Expected final release to complete the intent, got %s (%v)", intent.Status, err)
+	}
+	if _, tracked := manager.pendingIntents[intent.ID]; !tracked {
+		t.Errorf("Expected intent tracked until its source lock is final")
+	}
+
+	eth.Mine(3)
+	if err := manager.SyncExternalChains(); err != nil {
+		t.Fatalf("SyncExternalChains failed: %v", err)
+	}
+	pools = manager.InventoryPools()
+	if pools[0].Exposure != 0 || pools[1].Balance != 40_000 {
+		t.Errorf("Expected exposure cleared and ETH pool credited once final, got %+v", pools)
+	}
+	if _, tracked := manager.pendingIntents[intent.ID]; tracked || !intent.SourceFinal {
+		t.Errorf("Expected intent untracked once its source lock is final")
+	}
+	if btc.Balance(testBTCAddress, "qBTC") != 40_000 {
+		t.Errorf("Expected 40000 qBTC paid out on BTC, got %d", btc.Balance(testBTCAddress, "qBTC"))
+	}
+}
+
+func TestInventory_EarlyReleaseFails(t *testing.T) {
+	manager, eth, btc, intent := newInventoryFixture(t, 40_000)
+	_, _ = eth.Deposit("alice_eth", "qBTC", 40_000, fmt.Sprintf("%x", intent.ID[:]))
+	// Empty the BTC custody so the payout from inventory fails on inclusion
+	_, _ = btc.Submit(ExternalTx{Kind: InstructionRelease, Asset: "qBTC", Address: "mallory", Amount: 100_000})
+	btc.Mine(1)
+	eth.Mine(3)
+	if err := manager.SyncExternalChains(); err != nil {
+		t.Fatalf("SyncExternalChains failed: %v", err)
+	}
+	if err := manager.ReleaseFromInventory(); err != nil || !intent.EarlyRelease {
+		t.Fatalf("Expected early release, got %s (%v)", intent.Status, err)
+	}
+
+	btc.Mine(1)
+	if err := manager.SyncExternalChains(); err != nil {
+		t.Fatalf("SyncExternalChains failed: %v", err)
+	}
+	if intent.Status != IntentPendingRelease || intent.DestTxHash != "" || intent.RefundTxHash != "" {
+		t.Fatalf("Expected the intent to wait for its lock without a refund, got %s (refund %q)", intent.Status, intent.RefundTxHash)
+	}
+	if err := manager.SubmitReleases(); err != nil || intent.DestTxHash != "" {
+		t.Errorf("Expected no payout before the lock is final, got %q (%v)", intent.DestTxHash, err)
+	}
+	if err := manager.AdvanceHeight(intent.Deadline + 1); err != nil || intent.Status != IntentPendingRelease {
+		t.Errorf("Expected the deadline not to refund an unfinal deposit, got %s (%v)", intent.Status, err)
+	}
+
+	eth.Mine(3)
+	if err := manager.SyncExternalChains(); err != nil {
+		t.Fatalf("SyncExternalChains failed: %v", err)
+	}
+	if intent.Status != IntentRefunded || intent.RefundTxHash == "" {
+		t.Fatalf("Expected a refund once the lock is final, got %s (refund %q)", intent.Status, intent.RefundTxHash)
+	}
+	if _, tracked := manager.pendingIntents[intent.ID]; tracked {
+		t.Errorf("Expected the refunded intent untracked")
+	}
+	pools := manager.InventoryPools()
+	if pools[0].Balance != 100_000 || pools[0].Exposure != 0 || pools[1].Balance != 0 {
+		t.Errorf("Expected the payout returned to the BTC pool and the deposit refunded from ETH, got %+v", pools)
+	}
+	eth.Mine(1)
+	if eth.Balance("alice_eth", "qBTC") != 1_000_000 {
+		t.Errorf("Expected the deposit refunded, alice_eth holds %d", eth.Balance("alice_eth", "qBTC"))
+	}
+}
+
+func TestInventory_ExposureCap(t *testing.T) {
+	manager, eth, _, intent := newInventoryFixture(t, 60_000)
+	_, _ = eth.Deposit("alice_eth", "qBTC", 60_000, fmt.Sprintf("%x", intent.ID[:]))
+	eth.Mine(3)
+	if err := manager.SyncExternalChains(); err != nil {
+		t.Fatalf("SyncExternalChains failed: %v", err)
+	}
+
+	// 60000 exceeds half of the BTC pool's target of 100000
+	if err := manager.ReleaseFromInventory(); err != nil || intent.EarlyRelease {
+		t.Fatalf("Expected release above the exposure cap to wait, got %s (%v)", intent.Status, err)
+	}
+	eth.Mine(3)
+	if err := manager.SyncExternalChains(); err != nil || intent.Status != IntentPendingRelease {
+		t.Fatalf("Expected final deposit to make the intent PendingRelease, got %s (%v)", intent.Status, err)
+	}
+	if err := manager.SubmitReleases(); err != nil || intent.DestTxHash == "" {
+		t.Fatalf("Expected release from the pool once final, got %q (%v)", intent.DestTxHash, err)
+	}
+	if pools := manager.InventoryPools(); pools[0].Balance != 40_000 || pools[0].Exposure != 0 {
+		t.Errorf("Expected BTC pool debited without exposure, got %+v", pools[0])
+	}
+}
+
+func TestInventory_Rebalancing(t *testing.T) {
+	manager := NewBridgeManager()
+	if err := manager.SetInventoryPool(InventoryPool{Chain: ChainID_Bitcoin, Asset: "qBTC", Min: 3, Target: 2, Max: 1}); err == nil {
+		t.Errorf("Expected inverted bounds to be rejected")
+	}
+	_ = manager.SetInventoryPool(InventoryPool{Chain: ChainID_Ethereum, Asset: "qBTC", Balance: 5_000, Min: 10_000, Target: 50_000, Max: 100_000})
+	_ = manager.SetInventoryPool(InventoryPool{Chain: ChainID_Bitcoin, Asset: "qBTC", Balance: 150_000, Min: 10_000, Target: 50_000, Max: 100_000})
+
+	transfers := manager.ScheduleRebalancing()
+	want := RebalanceTransfer{Asset: "qBTC", From: ChainID_Bitcoin, To: ChainID_Ethereum, Amount: 45_000}
+	if len(transfers) != 1 || transfers[0] != want {
+		t.Fatalf("ScheduleRebalancing() = %+v, want [%+v]", transfers, want)
+	}
+	if again := manager.ScheduleRebalancing(); len(again) != 0 {
+		t.Errorf("Expected scheduled transfer to count as incoming, got %+v", again)
+	}
+
+	if err := manager.CompleteRebalance(want); err != nil {
+		t.Fatalf("CompleteRebalance failed: %v", err)
+	}
+	if err := manager.CompleteRebalance(want); err == nil {
+		t.Errorf("Expected completing an unscheduled transfer to fail")
+	}
+	pools := manager.InventoryPools()
+	if pools[0].Balance != 105_000 || pools[1].Balance != 50_000 || pools[1].Incoming != 0 {
+		t.Errorf("Expected pools rebalanced, got %+v", pools)
+	}
+}
@@ -0,0 +1,80 @@
+package core
+
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
+// MerkleRoot computes a binary SHA-256 Merkle root over the given leaves.
+// An odd node at any level is paired with itself. The root of an empty list is the zero hash.
+func MerkleRoot(leaves []Hash) Hash {
+	if len(leaves) == 0 {
+		return Hash{}
+	}
+	level := make([]Hash, len(leaves))
+	copy(level, leaves)
+	for len(level) > 1 {
+		next := make([]Hash, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			right := level[i]
+			if i+1 < len(level) {
+				right = level[i+1]
+			}
+			next = append(next, hashPair(level[i], right))
+		}
+		level = next
+	}
+	return level[0]
+}
+
+// hashPair hashes two child nodes into their parent.
+func hashPair(left, right Hash) Hash {
+	var buf [64]byte
+	copy(buf[:32], left[:])
+	copy(buf[32:], right[:])
+	return sha256.Sum256(buf[:])
+}
+
+// MerkleProof returns the sibling hashes proving that leaves[index] is included in
+// MerkleRoot(leaves), from the leaf level up.
+func MerkleProof(leaves []Hash, index int) ([]Hash, error) {
+	if index < 0 || index >= len(leaves) {
+		return nil, fmt.Errorf("leaf index %d out of range [0, %d)", index, len(leaves))
+	}
+	var proof []Hash
+	level := make([]Hash, len(leaves))
+	copy(level, leaves)
+	for len(level) > 1 {
+		sibling := index ^ 1
+		if sibling >= len(level) {
+			sibling = index // An odd node is paired with itself
+		}
+		proof = append(proof, level[sibling])
+		next := make([]Hash, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			right := level[i]
+			if i+1 < len(level) {
+				right = level[i+1]
+			}
+			next = append(next, hashPair(level[i], right))
+		}
+		level = next
+		index /= 2
+	}
+	return proof, nil
+}
+
+// VerifyMerkleProof reports whether proof (see MerkleProof) shows leaf at index to be included
+// under root.
+func VerifyMerkleProof(leaf Hash, index uint64, proof []Hash, root Hash) bool {
+	node := leaf
+	for _, sibling := range proof {
+		if index%2 == 0 {
+			node = hashPair(node, sibling)
+		} else {
+			node = hashPair(sibling, node)
+		}
+		index /= 2
+	}
+	return index == 0 && node == root
+}
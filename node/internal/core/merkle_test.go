@@ -0,0 +1,31 @@
+package core
+
+import (
+	"crypto/sha256"
+	"testing"
+)
+
+func TestMerkle_Proof(t *testing.T) {
+	for n := 1; n <= 7; n++ {
+		leaves := make([]Hash, n)
+		for i := range leaves {
+			leaves[i] = sha256.Sum256([]byte{byte(i)})
+		}
+		root := MerkleRoot(leaves)
+		for i, leaf := range leaves {
+			proof, err := MerkleProof(leaves, i)
+			if err != nil {
+				t.Fatalf("MerkleProof(%d leaves, %d) failed: %v", n, i, err)
+			}
+			if !VerifyMerkleProof(leaf, uint64(i), proof, root) {
+				t.Errorf("Expected proof of leaf %d of %d to verify", i, n)
+			}
+			if n > 1 && VerifyMerkleProof(sha256.Sum256([]byte("other")), uint64(i), proof, root) {
+				t.Errorf("Expected proof of leaf %d of %d to reject another leaf", i, n)
+			}
+		}
+	}
+	if _, err := MerkleProof([]Hash{{}}, 1); err == nil {
+		t.Errorf("Expected out-of-range index to be rejected")
+	}
+}
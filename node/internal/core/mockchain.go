@@ -0,0 +1,534 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// MockChain is an in-process ChainAdapter simulating an external chain: an account ledger per
+// asset, a mempool, blocks mined on demand (Mine) or on a timer (Start), congestion-dependent fees
+// and reorganizations. Blocks have proof-of-work headers served through HeaderSource, and events
+// carry inclusion proofs. The bridge's custody account on the chain is MockCustodyAddress. A
+// built-in HTLC contract (see HTLCAdapter) serves atomic swaps.
+
+// MockCustodyAddress is the account holding assets locked by the bridge on a mock chain.
+const MockCustodyAddress = "bridge-custody"
+
+// MockChainConfig configures the behavior of a mock chain.
+type MockChainConfig struct {
+	BlockTime      time.Duration // Interval between blocks when running with Start
+	FinalityDepth  uint64        // Confirmations after which a transaction is reported final
+	BaseFee        uint64        // Fee of a transaction submitted to an empty mempool
+	FeePerPending  uint64        // Fee increase per transaction already waiting in the mempool
+	MaxTxsPerBlock int           // Transactions included per block; 0 means unlimited
+	ReorgInterval  uint64        // Reorganize ReorgDepth blocks every ReorgInterval heights; 0 disables
+	ReorgDepth     uint64
+	Difficulty     uint64 // Proof of work of each block header; 0 means 1
+}
+
+// DefaultMockChainConfig returns a configuration resembling a fast proof-of-work chain.
+func DefaultMockChainConfig() MockChainConfig {
+	return MockChainConfig{
+		BlockTime:     100 * time.Millisecond,
+		FinalityDepth: 6,
+		BaseFee:       10,
+		FeePerPending: 1,
+		Difficulty:    16,
+	}
+}
+
+// mockBalanceKey identifies an account's balance of one asset.
+type mockBalanceKey struct {
+	address string
+	asset   string
+}
+
+// mockTx is a transaction on a mock chain.
+type mockTx struct {
+	hash      string
+	tx        ExternalTx
+	deposit   bool          // Submitted by a user (Deposit) rather than the bridge (Submit)
+	fee       uint64        // Fee paid at submission
+	height    uint64        // Block the transaction is included in; 0 while pending
+	failed    bool          // Included, but the transfer could not be executed
+	finalized bool          // Finalized event emitted
+	htlc      *mockHTLCCall // Call of the HTLC contract rather than a transfer
+}
+
+// MockChain simulates an external chain. It is safe for concurrent use.
+type MockChain struct {
+	mu       sync.Mutex
+	chain    ChainID
+	cfg      MockChainConfig
+	blocks   [][]*mockTx      // blocks[i] holds the transactions of height i+1
+	headers  []ExternalHeader // headers[i] is the header of height i+1
+	clock    uint64           // Timestamp of the last block; distinguishes fork blocks with the same transactions
+	mempool  []*mockTx
+	txs      map[string]*mockTx
+	genesis  map[mockBalanceKey]uint64 // Allocations made with Fund, replayed after reorgs
+	balances map[mockBalanceKey]uint64
+	htlcs    map[Hash]*ExternalHTLC // HTLC contract state by hash lock
+	events   []ExternalChainEvent   // Events not yet returned by PollEvents
+	seq      uint64                 // Counter making transaction hashes unique
+	fees     uint64                 // Fees paid by the bridge
+	stop     chan struct{}
+}
+
+// NewMockChain creates a mock chain identified as chain with an empty ledger at height 0.
+func NewMockChain(chain ChainID, cfg MockChainConfig) *MockChain {
+	return &MockChain{
+		chain:    chain,
+		cfg:      cfg,
+		txs:      make(map[string]*mockTx),
+		genesis:  make(map[mockBalanceKey]uint64),
+		balances: make(map[mockBalanceKey]uint64),
+		htlcs:    make(map[Hash]*ExternalHTLC),
+	}
+}
+
+// Chain implements ChainAdapter.
+func (mc *MockChain) Chain() ChainID {
+	return mc.chain
+}
+
+// Height implements ChainAdapter.
+func (mc *MockChain) Height() uint64 {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	return uint64(len(mc.blocks))
+}
+
+// FinalityDepth implements ChainAdapter.
+func (mc *MockChain) FinalityDepth() uint64 {
+	return mc.cfg.FinalityDepth
+}
+
+// EstimateFee implements ChainAdapter. The fee grows with the number of pending transactions.
+func (mc *MockChain) EstimateFee() uint64 {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	return mc.currentFee()
+}
+
+// currentFee returns the fee of a transaction submitted now. Caller must hold mc.mu.
+func (mc *MockChain) currentFee() uint64 {
+	return mc.cfg.BaseFee + mc.cfg.FeePerPending*uint64(len(mc.mempool))
+}
+
+// Submit implements ChainAdapter, queueing a bridge transaction for the next block.
+func (mc *MockChain) Submit(tx ExternalTx) (string, error) {
+	switch tx.Kind {
+	case InstructionLock, InstructionRelease, InstructionMint, InstructionBurn:
+	default:
+		return "", fmt.Errorf("unsupported transaction kind %q", tx.Kind)
+	}
+	if tx.Amount == 0 || tx.Asset == "" || tx.Address == "" {
+		return "", fmt.Errorf("transaction needs an asset, an address and a positive amount")
+	}
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mtx := mc.enqueue(tx, false)
+	mc.fees += mtx.fee
+	return mtx.hash, nil
+}
+
+// Deposit queues a user's transfer of amount into bridge custody, as a user's wallet would.
+// memo identifies the intent the deposit pays for.
+func (mc *MockChain) Deposit(from, asset string, amount uint64, memo string) (string, error) {
+	if amount == 0 || asset == "" || from == "" {
+		return "", fmt.Errorf("deposit needs an asset, a sender and a positive amount")
+	}
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	tx := ExternalTx{Kind: InstructionLock, Asset: asset, Address: from, Amount: amount, Memo: memo}
+	return mc.enqueue(tx, true).hash, nil
+}
+
+// enqueue adds a transaction to the mempool. Caller must hold mc.mu.
+func (mc *MockChain) enqueue(tx ExternalTx, deposit bool) *mockTx {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], mc.seq)
+	mc.seq++
+	sum := sha256.Sum256(append([]byte(mc.chain), buf[:]...))
+	mtx := &mockTx{hash: fmt.Sprintf("%x", sum[:]), tx: tx, deposit: deposit, fee: mc.currentFee()}
+	mc.mempool = append(mc.mempool, mtx)
+	mc.txs[mtx.hash] = mtx
+	return mtx
+}
+
+// Confirmations implements ChainAdapter.
+func (mc *MockChain) Confirmations(txHash string) (uint64, error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mtx, ok := mc.txs[txHash]
+	if !ok {
+		return 0, fmt.Errorf("unknown transaction %s on %s", txHash, mc.chain)
+	}
+	if mtx.height == 0 {
+		return 0, nil
+	}
+	return uint64(len(mc.blocks)) - mtx.height + 1, nil
+}
+
+// PollEvents implements ChainAdapter.
+func (mc *MockChain) PollEvents() ([]ExternalChainEvent, error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	events := mc.events
+	mc.events = nil
+	return events, nil
+}
+
+// Fund credits amount of asset to address at genesis, e.g. a user's wallet.
+func (mc *MockChain) Fund(address, asset string, amount uint64) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	key := mockBalanceKey{address: address, asset: asset}
+	mc.genesis[key] += amount
+	mc.balances[key] += amount
+}
+
+// Balance returns the balance of asset held by address at the current head.
+func (mc *MockChain) Balance(address, asset string) uint64 {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	return mc.balances[mockBalanceKey{address: address, asset: asset}]
+}
+
+// FeesPaid returns the total fees paid for transactions submitted by the bridge.
+func (mc *MockChain) FeesPaid() uint64 {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	return mc.fees
+}
+
+// Mine produces n blocks from the mempool.
+func (mc *MockChain) Mine(n int) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	for i := 0; i < n; i++ {
+		mc.mineBlock()
+		if mc.cfg.ReorgInterval > 0 && uint64(len(mc.blocks))%mc.cfg.ReorgInterval == 0 {
+			mc.reorg(mc.cfg.ReorgDepth)
+		}
+	}
+}
+
+// Reorg replaces the last depth blocks with a longer fork of depth+1 blocks. The orphaned
+// transactions return to the mempool and are included again in the fork, in their original order.
+func (mc *MockChain) Reorg(depth uint64) error {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	if depth > uint64(len(mc.blocks)) {
+		return fmt.Errorf("cannot reorganize %d blocks at height %d", depth, len(mc.blocks))
+	}
+	mc.reorg(depth)
+	return nil
+}
+
+// reorg implements Reorg. Caller must hold mc.mu.
+func (mc *MockChain) reorg(depth uint64) {
+	if depth == 0 || depth > uint64(len(mc.blocks)) {
+		return
+	}
+	keep := uint64(len(mc.blocks)) - depth
+	var orphaned []*mockTx
+	for _, block := range mc.blocks[keep:] {
+		for _, mtx := range block {
+			if mtx.htlc == nil {
+				mc.events = append(mc.events, mc.event(ExternalEventReorged, mtx))
+			}
+			mtx.height, mtx.failed, mtx.finalized = 0, false, false
+			orphaned = append(orphaned, mtx)
+		}
+	}
+	mc.blocks = mc.blocks[:keep]
+	mc.headers = mc.headers[:keep]
+	mc.mempool = append(orphaned, mc.mempool...)
+
+	// Replay the remaining chain to undo the orphaned transfers
+	mc.balances = make(map[mockBalanceKey]uint64, len(mc.genesis))
+	for key, amount := range mc.genesis {
+		mc.balances[key] = amount
+	}
+	mc.htlcs = make(map[Hash]*ExternalHTLC)
+	for _, block := range mc.blocks {
+		for _, mtx := range block {
+			mc.execute(mtx)
+		}
+	}
+	for i := uint64(0); i <= depth; i++ {
+		mc.mineBlock()
+	}
+}
+
+// mineBlock includes pending transactions in a new block and reports what happened. Caller must
+// hold mc.mu.
+func (mc *MockChain) mineBlock() {
+	count := len(mc.mempool)
+	if mc.cfg.MaxTxsPerBlock > 0 && count > mc.cfg.MaxTxsPerBlock {
+		count = mc.cfg.MaxTxsPerBlock
+	}
+	block := append([]*mockTx(nil), mc.mempool[:count]...)
+	mc.mempool = append([]*mockTx(nil), mc.mempool[count:]...)
+	mc.blocks = append(mc.blocks, block)
+	height := uint64(len(mc.blocks))
+	mc.headers = append(mc.headers, mc.mineHeader(height, block))
+
+	for _, mtx := range block {
+		mtx.height = height
+		mc.execute(mtx)
+		switch {
+		case mtx.htlc != nil: // Contract calls are observed through HTLC
+		case mtx.failed:
+			mc.events = append(mc.events, mc.event(ExternalEventFailed, mtx))
+		case mtx.deposit:
+			mc.events = append(mc.events, mc.event(ExternalEventDeposit, mtx))
+		}
+	}
+	if mc.cfg.FinalityDepth == 0 || height < mc.cfg.FinalityDepth {
+		return
+	}
+	for _, block := range mc.blocks[:height-mc.cfg.FinalityDepth+1] {
+		for _, mtx := range block {
+			if !mtx.finalized && !mtx.failed && mtx.htlc == nil {
+				mtx.finalized = true
+				mc.events = append(mc.events, mc.event(ExternalEventFinalized, mtx))
+			}
+		}
+	}
+}
+
+// execute applies the transfer of an included transaction, marking it failed if the paying
+// account lacks the funds. Caller must hold mc.mu.
+func (mc *MockChain) execute(mtx *mockTx) {
+	if mtx.htlc != nil {
+		mtx.failed = !mc.executeHTLC(mtx)
+		return
+	}
+	tx := mtx.tx
+	user := mockBalanceKey{address: tx.Address, asset: tx.Asset}
+	custody := mockBalanceKey{address: MockCustodyAddress, asset: tx.Asset}
+	var from, to *mockBalanceKey
+	switch tx.Kind {
+	case InstructionLock:
+		from, to = &user, &custody
+	case InstructionRelease:
+		from, to = &custody, &user
+	case InstructionMint:
+		to = &user
+	case InstructionBurn:
+		from = &user
+	}
+	mtx.failed = from != nil && mc.balances[*from] < tx.Amount
+	if mtx.failed {
+		return
+	}
+	if from != nil {
+		mc.balances[*from] -= tx.Amount
+	}
+	if to != nil {
+		mc.balances[*to] += tx.Amount
+	}
+}
+
+// event builds an event about mtx. Caller must hold mc.mu.
+func (mc *MockChain) event(typ ExternalEventType, mtx *mockTx) ExternalChainEvent {
+	event := ExternalChainEvent{
+		Chain:   mc.chain,
+		Type:    typ,
+		TxHash:  mtx.hash,
+		Height:  mtx.height,
+		Address: mtx.tx.Address,
+		Asset:   mtx.tx.Asset,
+		Amount:  mtx.tx.Amount,
+		Memo:    mtx.tx.Memo,
+	}
+	if !mtx.deposit {
+		event.Kind = mtx.tx.Kind
+	}
+	if mtx.height > 0 {
+		event.Proof = mc.proof(mtx)
+	}
+	return event
+}
+
+// GenesisHeader returns the header of height 0, a checkpoint for tracking the chain's headers.
+func (mc *MockChain) GenesisHeader() ExternalHeader {
+	return ExternalHeader{Chain: mc.chain, Difficulty: mc.difficulty()}
+}
+
+// HeadersFrom implements HeaderSource.
+func (mc *MockChain) HeadersFrom(height uint64) ([]ExternalHeader, error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	if height == 0 {
+		return nil, fmt.Errorf("height 0 is the genesis header")
+	}
+	if height > uint64(len(mc.headers)) {
+		return nil, nil
+	}
+	return append([]ExternalHeader(nil), mc.headers[height-1:]...), nil
+}
+
+// difficulty returns the proof of work of each header.
+func (mc *MockChain) difficulty() uint64 {
+	return max(mc.cfg.Difficulty, 1)
+}
+
+// mineHeader returns a header of height over block's transactions with a nonce meeting the
+// difficulty. Caller must hold mc.mu.
+func (mc *MockChain) mineHeader(height uint64, block []*mockTx) ExternalHeader {
+	parent := mc.GenesisHeader()
+	if height > 1 {
+		parent = mc.headers[height-2]
+	}
+	leaves := make([]Hash, len(block))
+	for i, mtx := range block {
+		leaves[i] = ExternalTxLeaf(mtx.hash, mtx.tx)
+	}
+	mc.clock++
+	header := ExternalHeader{Chain: mc.chain, Height: height, Parent: parent.Hash(), TxRoot: MerkleRoot(leaves), Difficulty: mc.difficulty(), Timestamp: mc.clock}
+	for !header.MeetsDifficulty() {
+		header.Nonce++
+	}
+	return header
+}
+
+// proof returns the inclusion proof of mtx in its block. Caller must hold mc.mu.
+func (mc *MockChain) proof(mtx *mockTx) *InclusionProof {
+	block := mc.blocks[mtx.height-1]
+	leaves := make([]Hash, len(block))
+	index := 0
+	for i, other := range block {
+		leaves[i] = ExternalTxLeaf(other.hash, other.tx)
+		if other == mtx {
+			index = i
+		}
+	}
+	siblings, _ := MerkleProof(leaves, index)
+	return &InclusionProof{BlockHash: mc.headers[mtx.height-1].Hash(), Index: uint64(index), Siblings: siblings}
+}
+
+// --- HTLC contract ---
+
+// mockHTLCCall is a call of a mock chain's HTLC contract.
+type mockHTLCCall struct {
+	op       string       // "lock", "claim" or "refund"
+	htlc     ExternalHTLC // Terms of a lock; only HashLock for claims and refunds
+	preimage []byte       // Preimage revealed by a claim
+}
+
+// LockHTLC implements HTLCAdapter.
+func (mc *MockChain) LockHTLC(htlc ExternalHTLC) (string, error) {
+	if htlc.Amount == 0 || htlc.Asset == "" || htlc.Sender == "" || htlc.Recipient == "" {
+		return "", fmt.Errorf("HTLC needs an asset, a sender, a recipient and a positive amount")
+	}
+	return mc.callHTLC(&mockHTLCCall{op: "lock", htlc: htlc}), nil
+}
+
+// ClaimHTLC implements HTLCAdapter.
+func (mc *MockChain) ClaimHTLC(hashLock Hash, preimage []byte) (string, error) {
+	if len(preimage) == 0 {
+		return "", fmt.Errorf("HTLC claim needs a preimage")
+	}
+	return mc.callHTLC(&mockHTLCCall{op: "claim", htlc: ExternalHTLC{HashLock: hashLock}, preimage: preimage}), nil
+}
+
+// RefundHTLC implements HTLCAdapter.
+func (mc *MockChain) RefundHTLC(hashLock Hash) (string, error) {
+	return mc.callHTLC(&mockHTLCCall{op: "refund", htlc: ExternalHTLC{HashLock: hashLock}}), nil
+}
+
+// callHTLC queues a contract call for the next block and returns its hash.
+func (mc *MockChain) callHTLC(call *mockHTLCCall) string {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	tx := ExternalTx{Asset: call.htlc.Asset, Address: call.htlc.Sender, Amount: call.htlc.Amount, Memo: fmt.Sprintf("htlc/%s/%x", call.op, call.htlc.HashLock[:])}
+	mtx := mc.enqueue(tx, false)
+	mtx.htlc = call
+	return mtx.hash
+}
+
+// HTLC implements HTLCAdapter.
+func (mc *MockChain) HTLC(hashLock Hash) (*ExternalHTLC, error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	htlc, ok := mc.htlcs[hashLock]
+	if !ok {
+		return nil, nil
+	}
+	cp := *htlc
+	return &cp, nil
+}
+
+// executeHTLC applies an included contract call and reports whether it succeeded: a lock needs an
+// unused hash lock, the sender's funds and a timeout after its block; a claim needs the preimage
+// before the timeout, a refund the timeout to have passed. Caller must hold mc.mu.
+func (mc *MockChain) executeHTLC(mtx *mockTx) bool {
+	call := mtx.htlc
+	htlc := mc.htlcs[call.htlc.HashLock]
+	switch call.op {
+	case "lock":
+		sender := mockBalanceKey{address: call.htlc.Sender, asset: call.htlc.Asset}
+		if htlc != nil || mc.balances[sender] < call.htlc.Amount || call.htlc.Timeout <= mtx.height {
+			return false
+		}
+		mc.balances[sender] -= call.htlc.Amount
+		locked := call.htlc
+		locked.TxHash, locked.Status, locked.Preimage = mtx.hash, EscrowOpen, nil
+		mc.htlcs[locked.HashLock] = &locked
+	case "claim":
+		if htlc == nil || htlc.Status != EscrowOpen || mtx.height >= htlc.Timeout || HashLockOf(call.preimage) != htlc.HashLock {
+			return false
+		}
+		htlc.Status, htlc.Preimage = EscrowClaimed, call.preimage
+		mc.balances[mockBalanceKey{address: htlc.Recipient, asset: htlc.Asset}] += htlc.Amount
+	case "refund":
+		if htlc == nil || htlc.Status != EscrowOpen || mtx.height < htlc.Timeout {
+			return false
+		}
+		htlc.Status = EscrowRefunded
+		mc.balances[mockBalanceKey{address: htlc.Sender, asset: htlc.Asset}] += htlc.Amount
+	default:
+		return false
+	}
+	return true
+}
+
+// Start mines a block every BlockTime until Stop is called. It does nothing if BlockTime is zero
+// or the chain is already running.
+func (mc *MockChain) Start() {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	if mc.cfg.BlockTime <= 0 || mc.stop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	mc.stop = stop
+	go func() {
+		ticker := time.NewTicker(mc.cfg.BlockTime)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				mc.Mine(1)
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop halts block production started by Start.
+func (mc *MockChain) Stop() {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	if mc.stop != nil {
+		close(mc.stop)
+		mc.stop = nil
+	}
+}
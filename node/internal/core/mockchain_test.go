@@ -0,0 +1,170 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMockChain(t *testing.T) {
+	newChain := func() *MockChain {
+		cfg := DefaultMockChainConfig()
+		cfg.FinalityDepth = 3
+		mc := NewMockChain(ChainID_Ethereum, cfg)
+		mc.Fund("alice", "ETH", 100)
+		return mc
+	}
+	types := func(events []ExternalChainEvent) []string {
+		var out []string
+		for _, e := range events {
+			out = append(out, e.Type.String())
+		}
+		return out
+	}
+
+	t.Run("DepositAndFinality", func(t *testing.T) {
+		mc := newChain()
+		hash, err := mc.Deposit("alice", "ETH", 40, "memo")
+		if err != nil {
+			t.Fatalf("Deposit failed: %v", err)
+		}
+		if conf, _ := mc.Confirmations(hash); conf != 0 {
+			t.Errorf("Expected pending deposit to have no confirmations, got %d", conf)
+		}
+		mc.Mine(2)
+		events, _ := mc.PollEvents()
+		if len(events) != 1 || events[0].Type != ExternalEventDeposit || events[0].Memo != "memo" || events[0].Height != 1 {
+			t.Fatalf("Expected one deposit event at height 1, got %+v", events)
+		}
+		if mc.Balance("alice", "ETH") != 60 || mc.Balance(MockCustodyAddress, "ETH") != 40 {
+			t.Errorf("Expected 40 ETH moved into custody")
+		}
+		mc.Mine(1)
+		events, _ = mc.PollEvents()
+		if len(events) != 1 || events[0].Type != ExternalEventFinalized || events[0].TxHash != hash {
+			t.Errorf("Expected deposit finalized at 3 confirmations, got %v", types(events))
+		}
+		if conf, _ := mc.Confirmations(hash); conf != 3 {
+			t.Errorf("Expected 3 confirmations, got %d", conf)
+		}
+		if _, err := mc.Confirmations("unknown"); err == nil {
+			t.Errorf("Expected unknown transaction to be rejected")
+		}
+	})
+
+	t.Run("Reorg", func(t *testing.T) {
+		mc := newChain()
+		mc.Mine(1)
+		hash, _ := mc.Deposit("alice", "ETH", 40, "memo")
+		mc.Mine(1)
+		_, _ = mc.PollEvents()
+		if err := mc.Reorg(1); err != nil {
+			t.Fatalf("Reorg failed: %v", err)
+		}
+		events, _ := mc.PollEvents()
+		got := types(events)
+		if len(got) != 2 || got[0] != "reorged" || got[1] != "deposit" || events[1].Height != 2 {
+			t.Fatalf("Expected reorged deposit to be included again at height 2, got %v", events)
+		}
+		if mc.Height() != 3 || mc.Balance("alice", "ETH") != 60 {
+			t.Errorf("Expected a longer fork with the deposit applied once, height %d, balance %d", mc.Height(), mc.Balance("alice", "ETH"))
+		}
+		if conf, _ := mc.Confirmations(hash); conf != 2 {
+			t.Errorf("Expected 2 confirmations after the reorg, got %d", conf)
+		}
+		if err := mc.Reorg(10); err == nil {
+			t.Errorf("Expected reorg deeper than the chain to be rejected")
+		}
+	})
+
+	t.Run("FailedAndFees", func(t *testing.T) {
+		mc := newChain()
+		first, _ := mc.Submit(ExternalTx{Kind: InstructionRelease, Asset: "ETH", Address: "bob", Amount: 1})
+		if fee := mc.EstimateFee(); fee != 11 {
+			t.Errorf("Expected fee to grow with the mempool, got %d", fee)
+		}
+		_, _ = mc.Submit(ExternalTx{Kind: InstructionMint, Asset: "qQRG", Address: "bob", Amount: 5})
+		if mc.FeesPaid() != 21 {
+			t.Errorf("Expected 21 in fees, got %d", mc.FeesPaid())
+		}
+		mc.Mine(1)
+		events, _ := mc.PollEvents()
+		if len(events) != 1 || events[0].Type != ExternalEventFailed || events[0].TxHash != first {
+			t.Errorf("Expected release from empty custody to fail, got %+v", events)
+		}
+		if mc.Balance("bob", "qQRG") != 5 {
+			t.Errorf("Expected mint to credit bob")
+		}
+		if _, err := mc.Submit(ExternalTx{Kind: "swap", Asset: "ETH", Address: "bob", Amount: 1}); err == nil {
+			t.Errorf("Expected unsupported kind to be rejected")
+		}
+	})
+
+	t.Run("BlockLimitAndAutoReorg", func(t *testing.T) {
+		cfg := DefaultMockChainConfig()
+		cfg.MaxTxsPerBlock = 1
+		cfg.ReorgInterval, cfg.ReorgDepth = 2, 1
+		mc := NewMockChain(ChainID_Bitcoin, cfg)
+		mc.Fund("alice", "BTC", 10)
+		_, _ = mc.Deposit("alice", "BTC", 1, "a")
+		_, _ = mc.Deposit("alice", "BTC", 1, "b")
+		mc.Mine(2)
+		if mc.Height() != 3 || mc.Balance(MockCustodyAddress, "BTC") != 2 {
+			t.Errorf("Expected reorg at height 2 to extend the chain to 3 with both deposits, height %d", mc.Height())
+		}
+	})
+
+	t.Run("HTLC", func(t *testing.T) {
+		mc := newChain()
+		secret := []byte("secret")
+		lock := ExternalHTLC{HashLock: HashLockOf(secret), Sender: "alice", Recipient: "bob", Asset: "ETH", Amount: 30, Timeout: 5}
+		_, _ = mc.LockHTLC(lock)
+		_, _ = mc.LockHTLC(lock) // Hash lock already used
+		_, _ = mc.ClaimHTLC(lock.HashLock, []byte("wrong"))
+		mc.Mine(1)
+		htlc, _ := mc.HTLC(lock.HashLock)
+		if htlc == nil || htlc.Status != EscrowOpen || mc.Balance("alice", "ETH") != 70 {
+			t.Fatalf("Expected one open HTLC of 30 ETH, got %+v", htlc)
+		}
+		if events, _ := mc.PollEvents(); len(events) != 0 {
+			t.Errorf("Expected no bridge events for contract calls, got %+v", events)
+		}
+		_, _ = mc.RefundHTLC(lock.HashLock)
+		mc.Mine(1)
+		_, _ = mc.ClaimHTLC(lock.HashLock, secret)
+		mc.Mine(1)
+		htlc, _ = mc.HTLC(lock.HashLock)
+		if htlc.Status != EscrowClaimed || string(htlc.Preimage) != "secret" || mc.Balance("bob", "ETH") != 30 {
+			t.Errorf("Expected refund before the timeout to fail and the claim to pay bob, got %+v", htlc)
+		}
+
+		_ = mc.Reorg(2)
+		if htlc, _ = mc.HTLC(lock.HashLock); htlc.Status != EscrowClaimed || mc.Balance("alice", "ETH") != 70 {
+			t.Errorf("Expected the claim replayed after a reorg, got %+v", htlc)
+		}
+
+		late := ExternalHTLC{HashLock: HashLockOf([]byte("late")), Sender: "alice", Recipient: "bob", Asset: "ETH", Amount: 10, Timeout: mc.Height() + 2}
+		_, _ = mc.LockHTLC(late)
+		mc.Mine(2)
+		_, _ = mc.ClaimHTLC(late.HashLock, []byte("late"))
+		_, _ = mc.RefundHTLC(late.HashLock)
+		mc.Mine(1)
+		if htlc, _ = mc.HTLC(late.HashLock); htlc.Status != EscrowRefunded || mc.Balance("alice", "ETH") != 70 {
+			t.Errorf("Expected claim at the timeout to fail and the refund to return the lock, got %+v", htlc)
+		}
+	})
+
+	t.Run("Start", func(t *testing.T) {
+		cfg := DefaultMockChainConfig()
+		cfg.BlockTime = time.Millisecond
+		mc := NewMockChain(ChainID_Ethereum, cfg)
+		mc.Start()
+		deadline := time.Now().Add(time.Second)
+		for mc.Height() < 3 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		mc.Stop()
+		if mc.Height() < 3 {
+			t.Errorf("Expected blocks to be produced on a timer, height %d", mc.Height())
+		}
+	})
+}
@@ -0,0 +1,165 @@
+package core
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"sort"
+)
+
+// Multisig accounts
+//
+// An account becomes a multisig account with a TxTypeMultisigUpdate transaction signed by its own
+// key. From then on its transactions are authorized by co-signatures (Transaction.CoSignatures)
+// from at least Threshold of its Signers instead of the account key; that includes further updates
+// of the signer set and dissolving it, which turns the account back into a single-key account.
+
+// multisigPrefix is the storage key prefix of signer sets: multisig/<address> -> MultisigAccount.
+const multisigPrefix = "multisig/"
+
+// Limits and gas of multisig accounts.
+const (
+	MaxMultisigSigners        = 32
+	GasMultisigUpdate  uint64 = 5000 // Gas charged for a signer set update on top of the intrinsic gas
+)
+
+// MultisigAccount is the signer set controlling an account.
+type MultisigAccount struct {
+	Signers   []string // Sorted, without duplicates
+	Threshold uint32   // Signatures required to authorize a transaction
+}
+
+// Validate checks that the threshold can be met and the signers are sorted and distinct.
+func (m *MultisigAccount) Validate() error {
+	if len(m.Signers) == 0 || len(m.Signers) > MaxMultisigSigners {
+		return fmt.Errorf("multisig account needs 1 to %d signers, got %d", MaxMultisigSigners, len(m.Signers))
+	}
+	if m.Threshold == 0 || int(m.Threshold) > len(m.Signers) {
+		return fmt.Errorf("threshold must be between 1 and %d, got %d", len(m.Signers), m.Threshold)
+	}
+	for i, signer := range m.Signers {
+		if signer == "" {
+			return fmt.Errorf("multisig signer must not be empty")
+		}
+		if i > 0 && m.Signers[i-1] >= signer {
+			return fmt.Errorf("multisig signers must be sorted and distinct")
+		}
+	}
+	return nil
+}
+
+// IsSigner reports whether address is one of the account's signers.
+func (m *MultisigAccount) IsSigner(address string) bool {
+	i := sort.SearchStrings(m.Signers, address)
+	return i < len(m.Signers) && m.Signers[i] == address
+}
+
+// GetMultisigAccount returns the signer set of address, or nil for a single-key account.
+func GetMultisigAccount(db StateDB, address string) (*MultisigAccount, error) {
+	account := &MultisigAccount{}
+	found, err := getStorageGob(db, multisigPrefix+address, account)
+	if err != nil || !found {
+		return nil, err
+	}
+	return account, nil
+}
+
+// NewMultisigUpdateTransaction creates an unsigned transaction setting the signer set of account.
+// No signers and a zero threshold dissolve the signer set.
+func NewMultisigUpdateTransaction(nonce uint64, account string, signers []string, threshold uint32) (*Transaction, error) {
+	update := MultisigAccount{Signers: append([]string(nil), signers...), Threshold: threshold}
+	sort.Strings(update.Signers)
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&update); err != nil {
+		return nil, fmt.Errorf("failed to encode multisig update: %w", err)
+	}
+	tx := NewBaseTransaction(TxTypeMultisigUpdate, nonce, account, "", 0)
+	tx.Payload = buf.Bytes()
+	return tx, nil
+}
+
+// decodeMultisigUpdate decodes the payload of a TxTypeMultisigUpdate transaction. A nil result
+// means the signer set is dissolved.
+func decodeMultisigUpdate(tx *Transaction) (*MultisigAccount, error) {
+	var update MultisigAccount
+	if err := gob.NewDecoder(bytes.NewReader(tx.Payload)).Decode(&update); err != nil {
+		return nil, fmt.Errorf("failed to decode multisig update: %w", err)
+	}
+	if len(update.Signers) == 0 && update.Threshold == 0 {
+		return nil, nil
+	}
+	if err := update.Validate(); err != nil {
+		return nil, err
+	}
+	return &update, nil
+}
+
+// validateMultisigUpdate checks the signer set carried by an update.
+func validateMultisigUpdate(ctx *NativeContext, tx *Transaction) error {
+	if tx.Amount != 0 {
+		return fmt.Errorf("multisig update must not carry an amount")
+	}
+	update, err := decodeMultisigUpdate(tx)
+	if err != nil {
+		return err
+	}
+	if update == nil {
+		existing, err := GetMultisigAccount(ctx.State.db, tx.SenderID)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return fmt.Errorf("%s is not a multisig account", tx.SenderID)
+		}
+	}
+	return nil
+}
+
+// applyMultisigUpdate stores (or removes) the sender's signer set.
+func applyMultisigUpdate(ctx *NativeContext, tx *Transaction) error {
+	update, err := decodeMultisigUpdate(tx)
+	if err != nil {
+		return err
+	}
+	if update == nil {
+		ctx.Emit("multisig.dissolve", []byte(tx.SenderID))
+		return ctx.State.db.SetStorage(multisigPrefix+tx.SenderID, nil)
+	}
+	ctx.Emit("multisig.update", []byte(tx.SenderID))
+	return setStorageGob(ctx.State.db, multisigPrefix+tx.SenderID, update)
+}
+
+// VerifyAuthorization checks that the signatures on tx authorize its sender: the sender's own
+// signature for a single-key account, or co-signatures from at least Threshold signers for a
+// multisig account.
+func (sm *StateManager) VerifyAuthorization(tx *Transaction) error {
+	if valid, err := tx.VerifySignatures(); err != nil {
+		return fmt.Errorf("signature verification failed: %w", err)
+	} else if !valid {
+		return fmt.Errorf("invalid signature for transaction from %s", tx.SenderID)
+	}
+	account, err := GetMultisigAccount(sm.db, tx.SenderID)
+	if err != nil {
+		return fmt.Errorf("failed to get signer set of %s: %w", tx.SenderID, err)
+	}
+	if account == nil {
+		if tx.Signature == nil {
+			return fmt.Errorf("transaction from single-key account %s is not signed by it", tx.SenderID)
+		}
+		if len(tx.CoSignatures) > 0 {
+			return fmt.Errorf("co-signatures on a transaction from single-key account %s", tx.SenderID)
+		}
+		return nil
+	}
+	var approvals uint32
+	for _, cs := range tx.CoSignatures {
+		if !account.IsSigner(cs.Signer) {
+			return fmt.Errorf("%s is not a signer of %s", cs.Signer, tx.SenderID)
+		}
+		approvals++
+	}
+	if approvals < account.Threshold {
+		return fmt.Errorf("transaction from %s has %d of %d required signatures", tx.SenderID, approvals, account.Threshold)
+	}
+	return nil
+}
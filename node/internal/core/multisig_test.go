@@ -0,0 +1,108 @@
+package core
+
+import (
+	"testing"
+)
+
+// coSigned returns tx signed by each of signers.
+func coSigned(tx *Transaction, signers ...string) *Transaction {
+	for _, signer := range signers {
+		_ = tx.CoSign(signer)
+	}
+	return tx
+}
+
+func TestMultisig_Account(t *testing.T) {
+	db := NewInMemoryStateDB()
+	_ = db.SetBalance("treasury", 1000)
+	sm := NewStateManager(db)
+	transfer := func(nonce uint64) *Transaction {
+		return NewBaseTransaction(TxTypeTransfer, nonce, "treasury", "userB", 100)
+	}
+
+	create, _ := NewMultisigUpdateTransaction(0, "treasury", []string{"carol", "alice", "bob"}, 2)
+	_ = create.Sign()
+	if err := sm.ApplyTransaction(create); err != nil {
+		t.Fatalf("Creating signer set failed: %v", err)
+	}
+	account, _ := GetMultisigAccount(db, "treasury")
+	if account == nil || account.Threshold != 2 || len(account.Signers) != 3 || account.Signers[0] != "alice" {
+		t.Fatalf("Unexpected signer set %+v", account)
+	}
+
+	t.Run("AccountKeyNoLongerSuffices", func(t *testing.T) {
+		tx := transfer(1)
+		_ = tx.Sign()
+		if err := sm.ApplyTransaction(tx); err == nil {
+			t.Errorf("Expected the account key alone to be rejected")
+		}
+	})
+
+	t.Run("BelowThreshold", func(t *testing.T) {
+		if err := sm.ApplyTransaction(coSigned(transfer(1), "alice")); err == nil {
+			t.Errorf("Expected 1 of 2 signatures to be rejected")
+		}
+	})
+
+	t.Run("Outsider", func(t *testing.T) {
+		if err := sm.ApplyTransaction(coSigned(transfer(1), "alice", "mallory")); err == nil {
+			t.Errorf("Expected signature by a non-signer to be rejected")
+		}
+	})
+
+	t.Run("ForgedCoSignature", func(t *testing.T) {
+		tx := coSigned(transfer(1), "alice")
+		tx.CoSignatures = append(tx.CoSignatures, CoSignature{Signer: "bob", Signature: Signature("forged")})
+		if err := sm.ApplyTransaction(tx); err == nil {
+			t.Errorf("Expected forged co-signature to be rejected")
+		}
+	})
+
+	t.Run("Threshold", func(t *testing.T) {
+		if err := sm.ApplyTransaction(coSigned(transfer(1), "alice", "carol")); err != nil {
+			t.Fatalf("Expected 2 of 3 signatures to authorize, got %v", err)
+		}
+		if bal, _ := db.GetBalance("userB"); bal != 100 {
+			t.Errorf("Expected transfer to be applied, recipient has %d", bal)
+		}
+	})
+
+	t.Run("UpdateAndDissolve", func(t *testing.T) {
+		update, _ := NewMultisigUpdateTransaction(2, "treasury", []string{"alice", "bob"}, 3)
+		if err := sm.ApplyTransaction(coSigned(update, "alice", "bob")); err == nil {
+			t.Errorf("Expected threshold above the signer count to be rejected")
+		}
+		dissolve, _ := NewMultisigUpdateTransaction(2, "treasury", nil, 0)
+		if err := sm.ApplyTransaction(coSigned(dissolve, "bob", "carol")); err != nil {
+			t.Fatalf("Dissolve failed: %v", err)
+		}
+		if account, _ := GetMultisigAccount(db, "treasury"); account != nil {
+			t.Errorf("Expected single-key account after dissolving, got %+v", account)
+		}
+		tx := transfer(3)
+		_ = tx.Sign()
+		if err := sm.ApplyTransaction(tx); err != nil {
+			t.Errorf("Expected the account key to authorize again, got %v", err)
+		}
+	})
+}
+
+func TestTxPool_Authorization(t *testing.T) {
+	db := NewInMemoryStateDB()
+	sm := NewStateManager(db)
+	_ = setStorageGob(db, multisigPrefix+"treasury", &MultisigAccount{Signers: []string{"alice", "bob"}, Threshold: 2})
+	pool := NewTxPool()
+	pool.SetAuthorizer(sm)
+
+	if err := pool.AddTransaction(coSigned(NewBaseTransaction(TxTypeTransfer, 0, "treasury", "userB", 1), "alice")); err == nil {
+		t.Errorf("Expected pool to reject a transaction below the threshold")
+	}
+	if err := pool.AddTransaction(coSigned(NewBaseTransaction(TxTypeTransfer, 0, "treasury", "userB", 1), "alice", "bob")); err != nil {
+		t.Errorf("Expected pool to accept a fully signed transaction, got %v", err)
+	}
+	forged := NewBaseTransaction(TxTypeTransfer, 0, "userC", "userB", 1)
+	forged.Signature = Signature("forged")
+	if err := pool.AddTransaction(forged); err == nil {
+		t.Errorf("Expected pool to reject a forged signature")
+	}
+}
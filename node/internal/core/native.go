@@ -0,0 +1,207 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Native functions
+//
+// Every transaction type is executed by a NativeFunction registered for it. ApplyTransaction
+// performs the checks common to all transactions (signature, nonce, fee), then calls Validate and
+// Apply of the function registered for tx.Type. Protocol features (staking, QSD, bridging,
+// anchoring, ...) plug into the state transition this way instead of living in disconnected managers.
+
+// Log is an event emitted by a native function while applying a transaction. Logs are not part of
+// the state root; they are collected per block for clients and indexers (see StateManager.BlockLogs).
+type Log struct {
+	TxHash Hash   // Transaction that emitted the log
+	Topic  string // e.g., "qsd.mint", "bridge.intent"
+	Data   []byte
+}
+
+// NativeContext is passed to native functions.
+type NativeContext struct {
+	State     *StateManager // State being executed; functions must only change state through it
+	Spendable uint64        // Sender's balance minus the transaction fee
+	logs      []Log
+}
+
+// Emit records a log. Logs of a transaction are kept only if it applies successfully.
+func (ctx *NativeContext) Emit(topic string, data []byte) {
+	ctx.logs = append(ctx.logs, Log{Topic: topic, Data: data})
+}
+
+// NativeFunction is the state transition of a transaction type.
+type NativeFunction interface {
+	// Validate checks tx against the current state without changing it.
+	Validate(ctx *NativeContext, tx *Transaction) error
+	// Apply performs the state transition; called only after Validate succeeded. It must not leave
+	// partial changes behind when it fails.
+	Apply(ctx *NativeContext, tx *Transaction) error
+	// Gas returns the gas tx consumes on top of its intrinsic gas.
+	Gas(tx *Transaction) uint64
+}
+
+// nativeFunc adapts plain functions to NativeFunction. A nil validate means apply checks all of its
+// preconditions before writing anything.
+type nativeFunc struct {
+	validate func(ctx *NativeContext, tx *Transaction) error
+	apply    func(ctx *NativeContext, tx *Transaction) error
+	gas      uint64
+}
+
+func (f *nativeFunc) Validate(ctx *NativeContext, tx *Transaction) error {
+	if f.validate == nil {
+		return nil
+	}
+	return f.validate(ctx, tx)
+}
+
+func (f *nativeFunc) Apply(ctx *NativeContext, tx *Transaction) error {
+	return f.apply(ctx, tx)
+}
+
+func (f *nativeFunc) Gas(*Transaction) uint64 {
+	return f.gas
+}
+
+// namedNative pairs a native function with the name it was registered under.
+type namedNative struct {
+	name string
+	fn   NativeFunction
+}
+
+// NativeRegistry maps transaction types to the native functions executing them.
+type NativeRegistry struct {
+	mu    sync.RWMutex
+	funcs map[TransactionType]namedNative
+}
+
+// NewNativeRegistry creates an empty registry.
+func NewNativeRegistry() *NativeRegistry {
+	return &NativeRegistry{funcs: make(map[TransactionType]namedNative)}
+}
+
+// Register installs fn as the handler of txType. Each type has at most one handler.
+func (r *NativeRegistry) Register(txType TransactionType, name string, fn NativeFunction) error {
+	if name == "" || fn == nil {
+		return fmt.Errorf("native function needs a name and an implementation")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.funcs[txType]; ok {
+		return fmt.Errorf("transaction type %d already handled by %q", txType, existing.name)
+	}
+	r.funcs[txType] = namedNative{name: name, fn: fn}
+	return nil
+}
+
+// Lookup returns the native function registered for txType and its name.
+func (r *NativeRegistry) Lookup(txType TransactionType) (NativeFunction, string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	n, ok := r.funcs[txType]
+	return n.fn, n.name, ok
+}
+
+// Types returns the registered transaction types in ascending order.
+func (r *NativeRegistry) Types() []TransactionType {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	types := make([]TransactionType, 0, len(r.funcs))
+	for t := range r.funcs {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// registerCoreNatives registers the native functions of the core transaction types.
+func registerCoreNatives(r *NativeRegistry) {
+	_ = r.Register(TxTypeTransfer, "transfer", &nativeFunc{
+		validate: validateTransfer,
+		apply:    func(ctx *NativeContext, tx *Transaction) error { return ctx.State.applyTransfer(tx) },
+	})
+	_ = r.Register(TxTypeAnchor, "anchor", &nativeFunc{validate: validateAnchor, apply: applyAnchor, gas: GasAnchor})
+	_ = r.Register(TxTypeBond, "bond", &nativeFunc{
+		apply: func(ctx *NativeContext, tx *Transaction) error { return ctx.State.applyBond(tx, ctx.Spendable) },
+	})
+	_ = r.Register(TxTypeUnbond, "unbond", &nativeFunc{
+		apply: func(ctx *NativeContext, tx *Transaction) error { return ctx.State.applyUnbond(tx) },
+	})
+	_ = r.Register(TxTypeWithdraw, "withdraw", &nativeFunc{
+		apply: func(ctx *NativeContext, tx *Transaction) error { return ctx.State.applyWithdraw(tx) },
+	})
+	// Slashing through evidence is gated by StakingConfig.EvidenceSlashing (off by default) because
+	// placeholder header signatures can be forged.
+	_ = r.Register(TxTypeEvidence, "evidence", &nativeFunc{
+		apply: func(ctx *NativeContext, tx *Transaction) error { return ctx.State.applyEvidence(tx) },
+	})
+	_ = r.Register(TxTypeRandCommit, "beacon-commit", &nativeFunc{
+		apply: func(ctx *NativeContext, tx *Transaction) error { return ctx.State.applyRandCommit(tx) },
+	})
+	_ = r.Register(TxTypeRandReveal, "beacon-reveal", &nativeFunc{
+		apply: func(ctx *NativeContext, tx *Transaction) error { return ctx.State.applyRandReveal(tx) },
+	})
+	_ = r.Register(TxTypeQSDMint, "qsd-mint", &nativeFunc{validate: validateQSDMint, apply: applyQSDMint, gas: GasQSD})
+	_ = r.Register(TxTypeQSDBurn, "qsd-burn", &nativeFunc{validate: validateQSDBurn, apply: applyQSDBurn, gas: GasQSD})
+	_ = r.Register(TxTypeBridgeIntent, "bridge-intent", &nativeFunc{
+		validate: validateBridgeIntentTx, apply: applyBridgeIntentTx, gas: GasBridgeIntent,
+	})
+	_ = r.Register(TxTypeMultisigUpdate, "multisig-update", &nativeFunc{
+		validate: validateMultisigUpdate, apply: applyMultisigUpdate, gas: GasMultisigUpdate,
+	})
+	_ = r.Register(TxTypeEscrowCreate, "escrow-create", &nativeFunc{validate: validateEscrowCreate, apply: applyEscrowCreate, gas: GasEscrow})
+	_ = r.Register(TxTypeEscrowClaim, "escrow-claim", &nativeFunc{validate: validateEscrowClaim, apply: applyEscrowClaim, gas: GasEscrow})
+	_ = r.Register(TxTypeEscrowRefund, "escrow-refund", &nativeFunc{validate: validateEscrowRefund, apply: applyEscrowRefund, gas: GasEscrow})
+	_ = r.Register(TxTypeBridgeAdmin, "bridge-admin", &nativeFunc{validate: validateBridgeAdmin, apply: applyBridgeAdmin, gas: GasBridgeAdmin})
+	_ = r.Register(TxTypeBridgeRelease, "bridge-release", &nativeFunc{validate: validateBridgeRelease, apply: applyBridgeRelease, gas: GasBridgeAdmin})
+	_ = r.Register(TxTypeParamChange, "param-change", &nativeFunc{validate: validateParameterChange, apply: applyParameterChange, gas: GasParamChange})
+}
+
+// validateTransfer checks that the sender can pay tx.Amount on top of the fee.
+func validateTransfer(ctx *NativeContext, tx *Transaction) error {
+	if ctx.Spendable < tx.Amount {
+		return fmt.Errorf("insufficient funds: sender %s has %d, needs %d", tx.SenderID, ctx.Spendable+tx.Fee, tx.Amount+tx.Fee)
+	}
+	return nil
+}
+
+// --- StateManager integration ---
+
+// Natives returns the native function registry used to execute transactions.
+func (sm *StateManager) Natives() *NativeRegistry {
+	return sm.natives
+}
+
+// TransactionGas returns the gas tx consumes: its intrinsic gas plus the gas of its native function.
+func (sm *StateManager) TransactionGas(tx *Transaction) uint64 {
+	gas := tx.IntrinsicGas()
+	if fn, _, ok := sm.natives.Lookup(tx.Type); ok {
+		gas += fn.Gas(tx)
+	}
+	return gas
+}
+
+// BlockLogs returns the logs emitted by the transactions applied in the current block so far.
+func (sm *StateManager) BlockLogs() []Log {
+	return append([]Log(nil), sm.logs...)
+}
+
+// applyNative validates and applies tx with its registered native function, returning the logs it emitted.
+func (sm *StateManager) applyNative(tx *Transaction, spendable uint64) ([]Log, error) {
+	fn, _, ok := sm.natives.Lookup(tx.Type)
+	if !ok {
+		return nil, fmt.Errorf("unsupported transaction type %d", tx.Type)
+	}
+	ctx := &NativeContext{State: sm, Spendable: spendable}
+	if err := fn.Validate(ctx, tx); err != nil {
+		return nil, err
+	}
+	if err := fn.Apply(ctx, tx); err != nil {
+		return nil, err
+	}
+	return ctx.logs, nil
+}
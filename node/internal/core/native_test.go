@@ -0,0 +1,112 @@
+package core
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TxTypeTest is a transaction type no core native function handles.
+const TxTypeTest TransactionType = 200
+
+func TestNativeRegistry(t *testing.T) {
+	r := NewNativeRegistry()
+	fn := &nativeFunc{apply: func(*NativeContext, *Transaction) error { return nil }}
+	if err := r.Register(TxTypeTest, "test", fn); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if err := r.Register(TxTypeTest, "other", fn); err == nil {
+		t.Errorf("Expected second handler for the same type to be rejected")
+	}
+	if err := r.Register(TxTypeTransfer, "", fn); err == nil {
+		t.Errorf("Expected handler without a name to be rejected")
+	}
+	if _, name, ok := r.Lookup(TxTypeTest); !ok || name != "test" {
+		t.Errorf("Expected lookup to find the test handler, got %q", name)
+	}
+
+	core := NewNativeRegistry()
+	registerCoreNatives(core)
+	for _, txType := range []TransactionType{TxTypeTransfer, TxTypeAnchor, TxTypeBond, TxTypeQSDMint, TxTypeQSDBurn, TxTypeBridgeIntent} {
+		if _, _, ok := core.Lookup(txType); !ok {
+			t.Errorf("Expected a core handler for transaction type %d", txType)
+		}
+	}
+}
+
+func TestApplyTransaction_NativeDispatch(t *testing.T) {
+	db := NewInMemoryStateDB()
+	_ = db.SetBalance("userA", 100)
+	sm := NewStateManager(db)
+	applied := 0
+	_ = sm.Natives().Register(TxTypeTest, "test", &nativeFunc{
+		validate: func(ctx *NativeContext, tx *Transaction) error {
+			if len(tx.Payload) == 0 {
+				return fmt.Errorf("empty payload")
+			}
+			return nil
+		},
+		apply: func(ctx *NativeContext, tx *Transaction) error {
+			applied++
+			ctx.Emit("test.applied", tx.Payload)
+			return ctx.State.DB().SetStorage("test/"+tx.SenderID, tx.Payload)
+		},
+		gas: 500,
+	})
+	testTx := func(nonce uint64, payload []byte) *Transaction {
+		tx := NewBaseTransaction(TxTypeTest, nonce, "userA", "", 0)
+		tx.Payload = payload
+		tx.Fee = 1
+		_ = tx.Sign()
+		return tx
+	}
+
+	t.Run("ValidationFailure", func(t *testing.T) {
+		if err := sm.ApplyTransaction(testTx(0, nil)); err == nil {
+			t.Fatalf("Expected validation failure")
+		}
+		if applied != 0 {
+			t.Errorf("Apply must not run when Validate fails")
+		}
+		if nonce, _ := db.GetNonce("userA"); nonce != 0 {
+			t.Errorf("Rejected transaction must not change state, nonce is %d", nonce)
+		}
+	})
+
+	t.Run("AppliedWithLogs", func(t *testing.T) {
+		_ = sm.BeginBlock(&BlockHeader{Number: 1, Proposer: "proposerP"})
+		tx := testTx(0, []byte("hello"))
+		if err := sm.ApplyTransaction(tx); err != nil {
+			t.Fatalf("ApplyTransaction failed: %v", err)
+		}
+		if value, _ := db.GetStorage("test/userA"); string(value) != "hello" {
+			t.Errorf("Expected native function to write state, got %q", value)
+		}
+		if bal, _ := db.GetBalance("userA"); bal != 99 {
+			t.Errorf("Expected fee to be charged, balance is %d", bal)
+		}
+		logs := sm.BlockLogs()
+		txHash, _ := tx.Hash()
+		if len(logs) != 1 || logs[0].Topic != "test.applied" || logs[0].TxHash != txHash {
+			t.Errorf("Unexpected logs %+v", logs)
+		}
+		_ = sm.BeginBlock(&BlockHeader{Number: 2})
+		if len(sm.BlockLogs()) != 0 {
+			t.Errorf("Expected logs to reset with the next block")
+		}
+	})
+
+	t.Run("Gas", func(t *testing.T) {
+		tx := testTx(1, []byte("hello"))
+		if gas := sm.TransactionGas(tx); gas != tx.IntrinsicGas()+500 {
+			t.Errorf("Expected intrinsic gas plus 500, got %d", gas)
+		}
+	})
+
+	t.Run("UnsupportedType", func(t *testing.T) {
+		tx := NewBaseTransaction(TxTypeTest+1, 1, "userA", "", 0)
+		_ = tx.Sign()
+		if err := sm.ApplyTransaction(tx); err == nil {
+			t.Errorf("Expected transaction type without a handler to be rejected")
+		}
+	})
+}
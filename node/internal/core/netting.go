@@ -0,0 +1,275 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Bridge netting
+//
+// Intents collected during an epoch are netted per asset and chain pair: value moving A -> B is
+// offset against value of the same asset moving B -> A, so opposing users pay each other out of
+// their deposits and only the residual crosses the bridge. For the residual the bridge emits one
+// instruction on each chain: the asset is locked on (or burned from) the source chain and released
+// on (or minted on) the destination chain, depending on which chain the asset is native to.
+//
+// The bridge manager carries the instructions out as it pays the intents: deposits are the locks
+// and payouts on the home chain the releases. Final deposits of a wrapped asset stay in custody
+// and pay out the opposing intents, so only the residual is minted, and a residual burn is kept
+// in custody for later intents instead (see keepsCustody).
+
+// AssetHomeChain returns the chain asset is native to. Elsewhere it exists as a wrapped
+// representation that is minted and burned by the bridge.
+func AssetHomeChain(asset string) (ChainID, bool) {
+	a, ok := GetBridgeAsset(asset)
+	return a.Home, ok
+}
+
+// BridgeInstructionKind is the action the bridge takes on a chain.
+type BridgeInstructionKind string
+
+const (
+	InstructionLock    BridgeInstructionKind = "lock"    // Take the asset into custody on its home chain
+	InstructionRelease BridgeInstructionKind = "release" // Pay the asset out of custody on its home chain
+	InstructionMint    BridgeInstructionKind = "mint"    // Mint the wrapped asset on a foreign chain
+	InstructionBurn    BridgeInstructionKind = "burn"    // Burn the wrapped asset on a foreign chain
+)
+
+// BridgeInstruction is an action on a chain resulting from netting.
+type BridgeInstruction struct {
+	Epoch  uint64
+	Kind   BridgeInstructionKind
+	Chain  ChainID
+	Asset  string
+	Amount uint64
+}
+
+// NetFlow is the netting result for one asset between two chains, with ChainA < ChainB.
+type NetFlow struct {
+	Asset   string
+	ChainA  ChainID
+	ChainB  ChainID
+	GrossAB uint64 // Total of intents moving the asset from ChainA to ChainB
+	GrossBA uint64 // Total of intents moving the asset from ChainB to ChainA
+}
+
+// Net returns the residual that has to cross the bridge and its direction.
+func (f NetFlow) Net() (amount uint64, from, to ChainID) {
+	if f.GrossAB >= f.GrossBA {
+		return f.GrossAB - f.GrossBA, f.ChainA, f.ChainB
+	}
+	return f.GrossBA - f.GrossAB, f.ChainB, f.ChainA
+}
+
+// Matched returns the volume settled between opposing intents without touching external chains.
+func (f NetFlow) Matched() uint64 {
+	return min(f.GrossAB, f.GrossBA)
+}
+
+// NettingReport summarizes the netting of one epoch.
+type NettingReport struct {
+	Epoch        uint64
+	Intents      int       // Intents netted
+	Failed       []Hash    // Intents that could not be netted (e.g., unsupported asset)
+	Flows        []NetFlow // Sorted by asset, then chain pair
+	Instructions []BridgeInstruction
+}
+
+// GrossVolume returns the total amount requested by the netted intents.
+func (r *NettingReport) GrossVolume() uint64 {
+	var total uint64
+	for _, f := range r.Flows {
+		total += f.GrossAB + f.GrossBA
+	}
+	return total
+}
+
+// NetVolume returns the total amount that still crosses the bridge after netting.
+func (r *NettingReport) NetVolume() uint64 {
+	var total uint64
+	for _, f := range r.Flows {
+		amount, _, _ := f.Net()
+		total += amount
+	}
+	return total
+}
+
+// Savings returns the fraction of the gross volume that netting kept off the external chains.
+func (r *NettingReport) Savings() float64 {
+	gross := r.GrossVolume()
+	if gross == 0 {
+		return 0
+	}
+	return 1 - float64(r.NetVolume())/float64(gross)
+}
+
+// String formats the gross vs. net comparison.
+func (r *NettingReport) String() string {
+	return fmt.Sprintf("epoch %d: %d intents, gross %d, net %d (%.1f%% netted), %d instructions",
+		r.Epoch, r.Intents, r.GrossVolume(), r.NetVolume(), 100*r.Savings(), len(r.Instructions))
+}
+
+// NetIntents nets intents for epoch at block height and advances each out of PendingNetting:
+// intents leaving QRL already escrowed their funds and wait for the release, intents from external
+// chains wait for the user's lock there. Intents for unsupported assets fail, or are refunded if
+// their funds are escrowed. The result does not depend on the order of intents.
+func NetIntents(epoch, height uint64, timeouts IntentTimeouts, intents []*BridgeIntent) (*NettingReport, error) {
+	sorted := append([]*BridgeIntent(nil), intents...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID.Less(sorted[j].ID) })
+
+	report := &NettingReport{Epoch: epoch}
+	type pairKey struct {
+		asset  string
+		chainA ChainID
+		chainB ChainID
+	}
+	flows := make(map[pairKey]*NetFlow)
+	for _, intent := range sorted {
+		if _, ok := AssetHomeChain(intent.Asset); !ok {
+			next := IntentFailed
+			if intent.SourceChain == ChainID_QRL {
+				next = IntentRefunded
+			}
+			if err := intent.Transition(next, height, timeouts, fmt.Sprintf("unsupported asset %s", intent.Asset)); err != nil {
+				return nil, err
+			}
+			report.Failed = append(report.Failed, intent.ID)
+			continue
+		}
+		a, b := intent.SourceChain, intent.DestChain
+		if b < a {
+			a, b = b, a
+		}
+		key := pairKey{asset: intent.Asset, chainA: a, chainB: b}
+		flow, ok := flows[key]
+		if !ok {
+			flow = &NetFlow{Asset: intent.Asset, ChainA: a, ChainB: b}
+			flows[key] = flow
+		}
+		if intent.SourceChain == a {
+			flow.GrossAB += intent.Amount
+		} else {
+			flow.GrossBA += intent.Amount
+		}
+		next := IntentPendingSourceLock
+		if intent.SourceChain == ChainID_QRL {
+			next = IntentPendingRelease
+		}
+		if err := intent.Transition(next, height, timeouts, fmt.Sprintf("netted in epoch %d", epoch)); err != nil {
+			return nil, err
+		}
+		report.Intents++
+	}
+
+	for _, flow := range flows {
+		report.Flows = append(report.Flows, *flow)
+	}
+	sort.Slice(report.Flows, func(i, j int) bool {
+		fi, fj := report.Flows[i], report.Flows[j]
+		if fi.Asset != fj.Asset {
+			return fi.Asset < fj.Asset
+		}
+		if fi.ChainA != fj.ChainA {
+			return fi.ChainA < fj.ChainA
+		}
+		return fi.ChainB < fj.ChainB
+	})
+	for _, flow := range report.Flows {
+		amount, from, to := flow.Net()
+		if amount == 0 {
+			continue
+		}
+		home, _ := AssetHomeChain(flow.Asset)
+		out, in := InstructionBurn, InstructionMint
+		if home == from {
+			out = InstructionLock
+		}
+		if home == to {
+			in = InstructionRelease
+		}
+		report.Instructions = append(report.Instructions,
+			BridgeInstruction{Epoch: epoch, Kind: out, Chain: from, Asset: flow.Asset, Amount: amount},
+			BridgeInstruction{Epoch: epoch, Kind: in, Chain: to, Asset: flow.Asset, Amount: amount},
+		)
+	}
+	return report, nil
+}
+
+// keepsCustody reports whether final deposits of asset on chain stay in custody to pay out the
+// intents moving the asset there: the chain is external, the asset is wrapped there, and no
+// inventory pool accounts for it instead. Caller must hold bm.mu.
+func (bm *BridgeManager) keepsCustody(chain ChainID, asset string) bool {
+	home, _ := AssetHomeChain(asset)
+	return chain != ChainID_QRL && chain != home && bm.pool(chain, asset) == nil
+}
+
+// addCustody credits amount of asset held in custody on chain. Caller must hold bm.mu.
+func (bm *BridgeManager) addCustody(chain ChainID, asset string, amount uint64) {
+	if bm.custody[chain] == nil {
+		bm.custody[chain] = make(map[string]uint64)
+	}
+	bm.custody[chain][asset] += amount
+}
+
+// takeCustody debits amount of asset held in custody on chain, reporting whether there was enough.
+// Caller must hold bm.mu.
+func (bm *BridgeManager) takeCustody(chain ChainID, asset string, amount uint64) bool {
+	if bm.custody[chain][asset] < amount {
+		return false
+	}
+	bm.custody[chain][asset] -= amount
+	return true
+}
+
+// --- Netting of on-chain intents ---
+
+// bridgeReportPrefix is the storage key prefix of netting reports: bridge/report/<epoch> -> NettingReport.
+const bridgeReportPrefix = "bridge/report/"
+
+// GetNettingReport returns the netting report recorded for epoch, or nil if there is none.
+func GetNettingReport(db StateDB, epoch uint64) (*NettingReport, error) {
+	report := &NettingReport{}
+	found, err := getStorageGob(db, epochKey(bridgeReportPrefix, epoch), report)
+	if err != nil || !found {
+		return nil, err
+	}
+	return report, nil
+}
+
+// netBridgeEpoch nets the intents submitted on-chain during epoch, ending at block height (part of
+// the "bridge" epoch end hook), storing their new statuses and the report.
+func (sm *StateManager) netBridgeEpoch(epoch, height uint64) (*NettingReport, error) {
+	ids, err := BridgeIntentIDs(sm.db, epoch)
+	if err != nil {
+		return nil, err
+	}
+	intents := make([]*BridgeIntent, 0, len(ids))
+	for _, id := range ids {
+		intent, err := GetBridgeIntent(sm.db, id)
+		if err != nil {
+			return nil, err
+		}
+		if intent == nil {
+			return nil, fmt.Errorf("bridge index points to missing intent %s", id)
+		}
+		if intent.Status == IntentPendingNetting {
+			intents = append(intents, intent)
+		}
+	}
+	report, err := NetIntents(epoch, height, stateIntentTimeouts(), intents)
+	if err != nil {
+		return nil, err
+	}
+	for _, intent := range intents {
+		if err := sm.settleBridgeIntent(intent); err != nil {
+			return nil, err
+		}
+	}
+	if report.Intents == 0 && len(report.Failed) == 0 {
+		return report, nil
+	}
+	if err := setStorageGob(sm.db, epochKey(bridgeReportPrefix, epoch), report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
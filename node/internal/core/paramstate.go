@@ -0,0 +1,176 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// On-chain parameter schedule
+//
+// Parameter values that every node must agree on live in state. Changes are scheduled for the end
+// of an epoch by TxTypeParamChange transactions from ParameterAdminAccount, checked against the
+// bounds of the StateManager's ParameterManager, and applied by the "parameters" epoch end hook, so
+// all nodes switch to the new value at the same block. A ParameterManager mirrors the values into
+// its Parameters with SyncFromState.
+
+// Storage key prefixes for on-chain parameters.
+const (
+	paramValuePrefix    = "param/value/"    // param/value/<name> -> current value (gob float64)
+	paramSchedulePrefix = "param/schedule/" // param/schedule/<epoch>/<name> -> value applied at the end of epoch (gob float64)
+)
+
+func paramValueKey(name string) string {
+	return paramValuePrefix + name
+}
+
+func paramScheduleKey(epoch uint64, name string) string {
+	return fmt.Sprintf("%s%020d/%s", paramSchedulePrefix, epoch, name)
+}
+
+// ParameterValue returns the on-chain value of a parameter and whether one has been set.
+func ParameterValue(db StateDB, name string) (float64, bool, error) {
+	var value float64
+	found, err := getStorageGob(db, paramValueKey(name), &value)
+	return value, found, err
+}
+
+// ScheduledParameterChanges returns the values scheduled for the end of epoch, keyed by name.
+func ScheduledParameterChanges(db StateDB, epoch uint64) (map[string]float64, error) {
+	prefix := paramScheduleKey(epoch, "")
+	keys, err := db.StorageKeys(prefix)
+	if err != nil {
+		return nil, err
+	}
+	changes := make(map[string]float64, len(keys))
+	for _, key := range keys {
+		var value float64
+		if _, err := getStorageGob(db, key, &value); err != nil {
+			return nil, err
+		}
+		changes[strings.TrimPrefix(key, prefix)] = value
+	}
+	return changes, nil
+}
+
+// ParameterAdminAccount is the only account allowed to send TxTypeParamChange.
+const ParameterAdminAccount = "param-admin"
+
+// GasParamChange is the gas charged for a parameter change on top of the intrinsic gas.
+const GasParamChange uint64 = 5000
+
+// ParameterChange is the payload of a TxTypeParamChange: Value replaces the parameter Name at the
+// end of Epoch.
+type ParameterChange struct {
+	Epoch uint64
+	Name  string
+	Value float64
+}
+
+// checkChange validates a new value for a managed parameter: finite, and within the bounds of a
+// truncated Gaussian distribution.
+func (pm *ParameterManager) checkChange(name string, value float64) error {
+	param, err := pm.GetParameter(name)
+	if err != nil {
+		return err
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return fmt.Errorf("parameter '%s' cannot be set to %f", name, value)
+	}
+	if tg, ok := param.Distribution.(*TruncatedGaussian); ok && (value < tg.Min || value > tg.Max) {
+		return fmt.Errorf("parameter '%s' value %f outside [%f, %f]", name, value, tg.Min, tg.Max)
+	}
+	return nil
+}
+
+// NewParameterChangeTransaction creates an unsigned transaction scheduling change. It must be
+// authorized by ParameterAdminAccount.
+func NewParameterChangeTransaction(nonce uint64, change ParameterChange) (*Transaction, error) {
+	payload, err := encodeGob(&change)
+	if err != nil {
+		return nil, err
+	}
+	tx := NewBaseTransaction(TxTypeParamChange, nonce, ParameterAdminAccount, "", 0)
+	tx.Payload = payload
+	return tx, nil
+}
+
+// validateParameterChange checks that the admin schedules a valid value for a parameter of the
+// state's ParameterManager, no earlier than the end of the current epoch.
+func validateParameterChange(ctx *NativeContext, tx *Transaction) error {
+	if tx.SenderID != ParameterAdminAccount {
+		return fmt.Errorf("only %s can change parameters, got %s", ParameterAdminAccount, tx.SenderID)
+	}
+	if tx.Amount != 0 {
+		return fmt.Errorf("parameter change must not carry an amount")
+	}
+	var change ParameterChange
+	if err := decodeGob(tx.Payload, &change); err != nil {
+		return err
+	}
+	pm := ctx.State.ParameterManager()
+	if pm == nil {
+		return fmt.Errorf("no parameters are managed on this chain")
+	}
+	if err := pm.checkChange(change.Name, change.Value); err != nil {
+		return err
+	}
+	if epoch := ctx.State.epochs.EpochOf(ctx.State.blockNumber()); change.Epoch < epoch {
+		return fmt.Errorf("cannot schedule '%s' for past epoch %d (current %d)", change.Name, change.Epoch, epoch)
+	}
+	return nil
+}
+
+// applyParameterChange records the value for the end of its epoch. Scheduling the same parameter
+// twice for an epoch keeps the later value.
+func applyParameterChange(ctx *NativeContext, tx *Transaction) error {
+	var change ParameterChange
+	if err := decodeGob(tx.Payload, &change); err != nil {
+		return err
+	}
+	if err := setStorageGob(ctx.State.db, paramScheduleKey(change.Epoch, change.Name), change.Value); err != nil {
+		return err
+	}
+	ctx.Emit("param.schedule", []byte(change.Name))
+	return nil
+}
+
+// SyncFromState sets the CurrentValue of every managed parameter that has an on-chain value.
+func (pm *ParameterManager) SyncFromState(db StateDB) error {
+	for name, param := range pm.Parameters {
+		value, found, err := ParameterValue(db, name)
+		if err != nil {
+			return fmt.Errorf("failed to read parameter '%s': %w", name, err)
+		}
+		if found {
+			param.CurrentValue = value
+		}
+	}
+	return nil
+}
+
+// applyScheduledParameters moves the values scheduled for epoch into the current values and clears
+// the schedule. Returns the names of the updated parameters, sorted.
+func (sm *StateManager) applyScheduledParameters(epoch uint64) ([]string, error) {
+	prefix := paramScheduleKey(epoch, "")
+	keys, err := sm.db.StorageKeys(prefix)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(keys))
+	for _, key := range keys {
+		var value float64
+		if _, err := getStorageGob(sm.db, key, &value); err != nil {
+			return nil, err
+		}
+		name := strings.TrimPrefix(key, prefix)
+		if err := setStorageGob(sm.db, paramValueKey(name), value); err != nil {
+			return nil, fmt.Errorf("failed to set parameter '%s': %w", name, err)
+		}
+		if err := sm.db.SetStorage(key, nil); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
@@ -0,0 +1,257 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// PathSamplerConfig configures Metropolis-Hastings sampling over competing branches.
+type PathSamplerConfig struct {
+	Chains        int     // Independent Markov chains (at least 2 for R-hat)
+	Samples       int     // Recorded steps per chain, after burn-in
+	BurnIn        int     // Discarded steps per chain
+	Seed          int64   // Seed of the proposal/acceptance random source; equal seeds give equal results
+	RHatThreshold float64 // Converged when the Gelman-Rubin statistic is below this
+}
+
+// DefaultPathSamplerConfig returns the sampler settings used when none are configured.
+func DefaultPathSamplerConfig() PathSamplerConfig {
+	return PathSamplerConfig{
+		Chains:        4,
+		Samples:       5000,
+		BurnIn:        500,
+		Seed:          1,
+		RHatThreshold: 1.1,
+	}
+}
+
+// BranchEstimate is the sampled probability mass of one branch.
+type BranchEstimate struct {
+	Branch           BranchInfo
+	Visits           int     // Recorded samples that were at this branch, over all chains
+	Probability      float64 // Visits / total samples
+	ExactProbability float64 // exp(-βS) / Z computed in closed form, for comparison
+}
+
+// SamplerDiagnostics reports how trustworthy a sampling run is.
+type SamplerDiagnostics struct {
+	AcceptanceRate   float64 // Accepted proposals / proposals, over all chains including burn-in
+	RHat             float64 // Max over branches of the Gelman-Rubin statistic of the branch indicator
+	EffectiveSamples float64 // Sum over chains of the lag-1 autocorrelation ESS of the action trace
+	TotalVariation   float64 // Total variation distance between sampled and exact branch probabilities
+	PartitionRelErr  float64 // |Z_est - Z| / Z
+	Converged        bool    // RHat below the configured threshold
+	TotalSamples     int     // Recorded samples over all chains
+}
+
+// PathSampleResult is the outcome of sampling paths through the block tree.
+type PathSampleResult struct {
+	Branches []BranchEstimate // In the order of the input branches
+	// LogPartitionFunction is the importance-sampling estimate of ln Z, Z = Σ exp(-βS) over branches,
+	// using the uniformly drawn proposals. Kept in log space since exp(-βS) underflows quickly.
+	LogPartitionFunction      float64
+	ExactLogPartitionFunction float64
+	Selected                  BranchInfo // Most visited branch (ties broken by lowest tip hash)
+	Diagnostics               SamplerDiagnostics
+}
+
+// SamplePaths draws paths (root-to-tip branches) with Metropolis-Hastings weighted by exp(-βS).
+// Proposals are uniform over the branches, so a move from S to S' is accepted with probability
+// min(1, exp(-β(S'-S))). Each chain starts at a uniformly drawn branch.
+func SamplePaths(branches []BranchInfo, beta float64, cfg PathSamplerConfig) (*PathSampleResult, error) {
+	if len(branches) == 0 {
+		return nil, fmt.Errorf("no branches to sample")
+	}
+	if beta <= 0 || math.IsNaN(beta) || math.IsInf(beta, 0) {
+		return nil, fmt.Errorf("beta must be positive and finite, got %f", beta)
+	}
+	if cfg.Chains < 1 || cfg.Samples < 2 || cfg.BurnIn < 0 {
+		return nil, fmt.Errorf("sampler needs at least 1 chain, 2 samples and non-negative burn-in")
+	}
+	minAction := math.Inf(1)
+	for _, b := range branches {
+		if math.IsNaN(b.CumulativeAction) || math.IsInf(b.CumulativeAction, 0) {
+			return nil, fmt.Errorf("invalid cumulative action for branch %s", b.TipHash)
+		}
+		minAction = math.Min(minAction, b.CumulativeAction)
+	}
+	// Weights relative to the best branch; the exp(-β·S_min) factor is restored in log space
+	weights := make([]float64, len(branches))
+	exactZ := 0.0
+	for i, b := range branches {
+		weights[i] = math.Exp(-beta * (b.CumulativeAction - minAction))
+		exactZ += weights[i]
+	}
+
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	k := len(branches)
+	visits := make([][]int, cfg.Chains) // visits[chain][branch]
+	traces := make([][]float64, cfg.Chains)
+	accepted, proposals := 0, 0
+	proposalWeightSum := 0.0
+	for c := 0; c < cfg.Chains; c++ {
+		visits[c] = make([]int, k)
+		traces[c] = make([]float64, 0, cfg.Samples)
+		current := rng.Intn(k)
+		for step := 0; step < cfg.BurnIn+cfg.Samples; step++ {
+			candidate := rng.Intn(k)
+			proposals++
+			proposalWeightSum += weights[candidate]
+			if candidate == current || rng.Float64() < weights[candidate]/weights[current] {
+				current = candidate
+				accepted++
+			}
+			if step >= cfg.BurnIn {
+				visits[c][current]++
+				traces[c] = append(traces[c], branches[current].CumulativeAction)
+			}
+		}
+	}
+
+	total := cfg.Chains * cfg.Samples
+	result := &PathSampleResult{
+		Branches:                  make([]BranchEstimate, k),
+		LogPartitionFunction:      -beta*minAction + math.Log(float64(k)*proposalWeightSum/float64(proposals)),
+		ExactLogPartitionFunction: -beta*minAction + math.Log(exactZ),
+	}
+	best := -1
+	tv := 0.0
+	for i, b := range branches {
+		n := 0
+		for c := range visits {
+			n += visits[c][i]
+		}
+		est := BranchEstimate{
+			Branch:           b,
+			Visits:           n,
+			Probability:      float64(n) / float64(total),
+			ExactProbability: weights[i] / exactZ,
+		}
+		result.Branches[i] = est
+		tv += math.Abs(est.Probability - est.ExactProbability)
+		if best < 0 || n > result.Branches[best].Visits ||
+			(n == result.Branches[best].Visits && b.TipHash.Less(branches[best].TipHash)) {
+			best = i
+		}
+	}
+	result.Selected = branches[best]
+
+	rhat := maxIndicatorRHat(visits, cfg.Samples)
+	result.Diagnostics = SamplerDiagnostics{
+		AcceptanceRate:   float64(accepted) / float64(proposals),
+		RHat:             rhat,
+		EffectiveSamples: effectiveSamples(traces),
+		TotalVariation:   tv / 2,
+		PartitionRelErr:  math.Abs(math.Exp(result.LogPartitionFunction-result.ExactLogPartitionFunction) - 1),
+		Converged:        cfg.Chains > 1 && rhat < cfg.RHatThreshold,
+		TotalSamples:     total,
+	}
+	return result, nil
+}
+
+// maxIndicatorRHat returns the largest Gelman-Rubin statistic over the per-branch indicator
+// variables. Returns 1 for a single chain or when every chain agrees exactly.
+func maxIndicatorRHat(visits [][]int, n int) float64 {
+	chains := len(visits)
+	if chains < 2 {
+		return 1
+	}
+	maxR := 1.0
+	for i := range visits[0] {
+		means := make([]float64, chains)
+		grand, within := 0.0, 0.0
+		for c := range visits {
+			m := float64(visits[c][i]) / float64(n)
+			means[c] = m
+			grand += m
+			within += m * (1 - m) * float64(n) / float64(n-1) // Sample variance of a 0/1 variable
+		}
+		grand /= float64(chains)
+		within /= float64(chains)
+		between := 0.0
+		for _, m := range means {
+			between += (m - grand) * (m - grand)
+		}
+		between = between * float64(n) / float64(chains-1)
+		if within == 0 {
+			if between > 0 {
+				return math.Inf(1) // Chains stuck in different branches
+			}
+			continue
+		}
+		varPlus := float64(n-1)/float64(n)*within + between/float64(n)
+		maxR = math.Max(maxR, math.Sqrt(varPlus/within))
+	}
+	return maxR
+}
+
+// effectiveSamples estimates the effective sample size of the action traces from their lag-1
+// autocorrelation ρ, as n(1-ρ)/(1+ρ) per chain. A constant trace counts fully.
+func effectiveSamples(traces [][]float64) float64 {
+	ess := 0.0
+	for _, trace := range traces {
+		n := len(trace)
+		mean := 0.0
+		for _, x := range trace {
+			mean += x
+		}
+		mean /= float64(n)
+		variance, cov := 0.0, 0.0
+		for j, x := range trace {
+			variance += (x - mean) * (x - mean)
+			if j > 0 {
+				cov += (x - mean) * (trace[j-1] - mean)
+			}
+		}
+		if variance == 0 {
+			ess += float64(n)
+			continue
+		}
+		rho := math.Max(cov/variance, 0)
+		ess += float64(n) * (1 - rho) / (1 + rho)
+	}
+	return ess
+}
+
+// SamplePaths samples the viable branches of the tree with the engine's β.
+func (pic *PathIntegralConsensus) SamplePaths(tree *BlockTree, cfg PathSamplerConfig) (*PathSampleResult, error) {
+	if tree == nil {
+		return nil, fmt.Errorf("cannot sample paths of nil block tree")
+	}
+	return SamplePaths(tree.Branches(), pic.Beta(), cfg)
+}
+
+// ForkChoiceComparison contrasts the sampler's most probable branch with the deterministic fork choice.
+type ForkChoiceComparison struct {
+	Deterministic *Block // SelectPath
+	Sampled       *Block // Most visited branch tip
+	Agree         bool
+	// Probability mass the sampler assigned to the deterministic choice
+	DeterministicMass float64
+	Result            *PathSampleResult
+}
+
+// CompareForkChoice runs the sampler and SelectPath on the same tree.
+func (pic *PathIntegralConsensus) CompareForkChoice(tree *BlockTree, cfg PathSamplerConfig) (*ForkChoiceComparison, error) {
+	deterministic, err := pic.SelectPath(tree)
+	if err != nil {
+		return nil, err
+	}
+	result, err := pic.SamplePaths(tree, cfg)
+	if err != nil {
+		return nil, err
+	}
+	cmp := &ForkChoiceComparison{
+		Deterministic: deterministic,
+		Sampled:       result.Selected.Tip,
+		Agree:         deterministic == result.Selected.Tip,
+		Result:        result,
+	}
+	for _, b := range result.Branches {
+		if b.Branch.Tip == deterministic {
+			cmp.DeterministicMass = b.Probability
+		}
+	}
+	return cmp, nil
+}
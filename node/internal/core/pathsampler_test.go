@@ -0,0 +1,143 @@
+package core
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestSamplePaths_MatchesExactDistribution(t *testing.T) {
+	branches := []BranchInfo{
+		{TipHash: Hash{1}, CumulativeAction: 10.0},
+		{TipHash: Hash{2}, CumulativeAction: 10.5},
+		{TipHash: Hash{3}, CumulativeAction: 12.0},
+	}
+	cfg := DefaultPathSamplerConfig()
+	result, err := SamplePaths(branches, 1.0, cfg)
+	if err != nil {
+		t.Fatalf("SamplePaths failed: %v", err)
+	}
+
+	z := math.Exp(-10.0) + math.Exp(-10.5) + math.Exp(-12.0)
+	if math.Abs(result.ExactLogPartitionFunction-math.Log(z)) > 1e-9 {
+		t.Errorf("Expected exact ln Z %f, got %f", math.Log(z), result.ExactLogPartitionFunction)
+	}
+	if d := result.Diagnostics; d.PartitionRelErr > 0.05 {
+		t.Errorf("Partition function estimate off by %.3f", d.PartitionRelErr)
+	}
+	for i, b := range result.Branches {
+		want := math.Exp(-branches[i].CumulativeAction) / z
+		if math.Abs(b.ExactProbability-want) > 1e-9 {
+			t.Errorf("Branch %d: expected exact probability %f, got %f", i, want, b.ExactProbability)
+		}
+		if math.Abs(b.Probability-want) > 0.03 {
+			t.Errorf("Branch %d: sampled probability %f too far from exact %f", i, b.Probability, want)
+		}
+	}
+	d := result.Diagnostics
+	if !d.Converged || d.RHat >= cfg.RHatThreshold {
+		t.Errorf("Expected convergence, got R-hat %f", d.RHat)
+	}
+	if d.TotalVariation > 0.03 || d.AcceptanceRate <= 0 || d.AcceptanceRate > 1 {
+		t.Errorf("Unexpected diagnostics %+v", d)
+	}
+	if d.EffectiveSamples <= 0 || d.EffectiveSamples > float64(d.TotalSamples) {
+		t.Errorf("Effective samples %f outside (0, %d]", d.EffectiveSamples, d.TotalSamples)
+	}
+	if result.Selected.TipHash != (Hash{1}) {
+		t.Errorf("Expected the lowest-action branch to be selected, got %s", result.Selected.TipHash)
+	}
+
+	t.Run("Reproducible", func(t *testing.T) {
+		again, _ := SamplePaths(branches, 1.0, cfg)
+		for i := range again.Branches {
+			if again.Branches[i].Visits != result.Branches[i].Visits {
+				t.Fatalf("Expected identical visits for the same seed")
+			}
+		}
+	})
+
+	t.Run("InvalidInput", func(t *testing.T) {
+		if _, err := SamplePaths(nil, 1.0, cfg); err == nil {
+			t.Errorf("Expected error for no branches")
+		}
+		if _, err := SamplePaths(branches, 0, cfg); err == nil {
+			t.Errorf("Expected error for non-positive beta")
+		}
+		bad := []BranchInfo{{CumulativeAction: math.Inf(1)}}
+		if _, err := SamplePaths(bad, 1.0, cfg); err == nil {
+			t.Errorf("Expected error for infinite action")
+		}
+		if _, err := SamplePaths(branches, 1.0, PathSamplerConfig{Chains: 0, Samples: 10}); err == nil {
+			t.Errorf("Expected error for zero chains")
+		}
+	})
+}
+
+func TestMaxIndicatorRHat_DetectsStuckChains(t *testing.T) {
+	// Two chains that never left their own branch
+	visits := [][]int{{100, 0}, {0, 100}}
+	if r := maxIndicatorRHat(visits, 100); !math.IsInf(r, 1) {
+		t.Errorf("Expected infinite R-hat for disagreeing stuck chains, got %f", r)
+	}
+	agreeing := [][]int{{50, 50}, {51, 49}}
+	if r := maxIndicatorRHat(agreeing, 100); r > 1.01 {
+		t.Errorf("Expected R-hat near 1 for agreeing chains, got %f", r)
+	}
+}
+
+// TestCompareForkChoice_SimulatedForks builds random fork trees and checks that the sampler's most
+// probable branch agrees with SelectPath whenever the best branch clearly dominates.
+func TestCompareForkChoice_SimulatedForks(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	consensus := NewPathIntegralConsensus(nil)
+	cfg := DefaultPathSamplerConfig()
+	cfg.Samples = 2000
+
+	for trial := 0; trial < 20; trial++ {
+		actions := make(map[Hash]float64)
+		genesis := testGenesis()
+		tree, err := NewBlockTree(genesis, func(block *Block, parent *BlockHeader) (float64, error) {
+			hash, _ := block.Hash()
+			return actions[hash], nil
+		})
+		if err != nil {
+			t.Fatalf("NewBlockTree failed: %v", err)
+		}
+		// Grow a random tree: each new block extends a random existing block
+		blocks := []*Block{genesis}
+		for i := 0; i < 8; i++ {
+			parent := blocks[rng.Intn(len(blocks))]
+			child := childOf(parent, int64(trial*100+i))
+			hash, _ := child.Hash()
+			actions[hash] = rng.Float64() * 4
+			if err := tree.AddBlock(child); err != nil {
+				t.Fatalf("AddBlock failed: %v", err)
+			}
+			blocks = append(blocks, child)
+		}
+
+		cmp, err := consensus.CompareForkChoice(tree, cfg)
+		if err != nil {
+			t.Fatalf("CompareForkChoice failed: %v", err)
+		}
+		exactBest := 0.0
+		for _, b := range cmp.Result.Branches {
+			if b.Branch.Tip == cmp.Deterministic {
+				exactBest = b.ExactProbability
+			}
+		}
+		second := 0.0
+		for _, b := range cmp.Result.Branches {
+			if b.Branch.Tip != cmp.Deterministic && b.ExactProbability > second {
+				second = b.ExactProbability
+			}
+		}
+		if exactBest-second > 0.1 && !cmp.Agree {
+			t.Errorf("Trial %d: sampler picked a different branch although the best has mass %.3f vs %.3f", trial, exactBest, second)
+		}
+		if math.Abs(cmp.DeterministicMass-exactBest) > 0.05 {
+			t.Errorf("Trial %d: sampled mass %.3f of the deterministic choice far from exact %.3f", trial, cmp.DeterministicMass, exactBest)
+		}
+	}
+}
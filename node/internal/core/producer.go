@@ -0,0 +1,303 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BlockBroadcaster hands newly produced blocks to the network layer for gossip.
+type BlockBroadcaster interface {
+	BroadcastBlock(block *Block) error
+}
+
+// FinalityUpdater is implemented by consensus engines that finalize blocks on the block tree.
+// The producer calls it after importing each block.
+type FinalityUpdater interface {
+	UpdateFinality(tree *BlockTree) ([]*Block, error)
+}
+
+// BlockProducerConfig configures a BlockProducer.
+type BlockProducerConfig struct {
+	Proposer       string        // Identity used to sign produced blocks
+	Interval       time.Duration // Produce a block every Interval; zero means only on Trigger
+	MaxTxsPerBlock int           // Upper bound on transactions per block; zero means no limit besides gas
+	AllowEmpty     bool          // Produce blocks even when no transaction could be included
+	SealOnPending  bool          // Produce a block as soon as a transaction enters the pool (dev mode)
+}
+
+// BlockProducer assembles blocks from the TxPool, executes them through the StateManager,
+// imports them through the ConsensusEngine and hands them to the network layer.
+type BlockProducer struct {
+	cfg         BlockProducerConfig
+	engine      ConsensusEngine
+	pool        *TxPool
+	state       *StateManager
+	tree        *BlockTree
+	broadcaster BlockBroadcaster // Optional
+
+	produceMu sync.Mutex // Serializes ProduceBlock
+	trigger   chan struct{}
+	quit      chan struct{}
+	wg        sync.WaitGroup
+	running   bool
+	runMu     sync.Mutex
+}
+
+// NewBlockProducer creates a block producer. The broadcaster may be nil (e.g., single-node setups).
+func NewBlockProducer(cfg BlockProducerConfig, engine ConsensusEngine, pool *TxPool, sm *StateManager, tree *BlockTree, broadcaster BlockBroadcaster) (*BlockProducer, error) {
+	if cfg.Proposer == "" {
+		return nil, fmt.Errorf("block producer requires a proposer identity")
+	}
+	if engine == nil || pool == nil || sm == nil || tree == nil {
+		return nil, fmt.Errorf("block producer requires a consensus engine, tx pool, state manager and block tree")
+	}
+	if cfg.Interval < 0 || cfg.MaxTxsPerBlock < 0 {
+		return nil, fmt.Errorf("block producer interval and max txs must not be negative")
+	}
+	return &BlockProducer{
+		cfg:         cfg,
+		engine:      engine,
+		pool:        pool,
+		state:       sm,
+		tree:        tree,
+		broadcaster: broadcaster,
+		trigger:     make(chan struct{}, 1),
+	}, nil
+}
+
+// Start launches the production loop in the background.
+func (bp *BlockProducer) Start() error {
+	bp.runMu.Lock()
+	defer bp.runMu.Unlock()
+	if bp.running {
+		return fmt.Errorf("block producer already running")
+	}
+	bp.running = true
+	bp.quit = make(chan struct{})
+	var sub *TxPoolSubscription
+	if bp.cfg.SealOnPending {
+		// Subscribe before returning so no transaction added after Start is missed
+		sub = bp.pool.Subscribe(16)
+	}
+	bp.wg.Add(1)
+	go bp.loop(sub)
+	return nil
+}
+
+// Stop halts the production loop and waits for an in-flight block to finish.
+func (bp *BlockProducer) Stop() {
+	bp.runMu.Lock()
+	if !bp.running {
+		bp.runMu.Unlock()
+		return
+	}
+	bp.running = false
+	close(bp.quit)
+	bp.runMu.Unlock()
+	bp.wg.Wait()
+}
+
+// Trigger requests a block as soon as possible. Multiple triggers before the loop
+// wakes up are coalesced into one.
+func (bp *BlockProducer) Trigger() {
+	select {
+	case bp.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// loop produces blocks on every tick or trigger, and on pool additions if sub is non-nil, until stopped.
+func (bp *BlockProducer) loop(sub *TxPoolSubscription) {
+	defer bp.wg.Done()
+
+	var tick <-chan time.Time
+	if bp.cfg.Interval > 0 {
+		ticker := time.NewTicker(bp.cfg.Interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	var pending <-chan TxPoolEvent
+	if sub != nil {
+		defer sub.Unsubscribe()
+		pending = sub.Events()
+	}
+	for {
+		select {
+		case <-bp.quit:
+			return
+		case <-tick:
+		case <-bp.trigger:
+		case ev := <-pending:
+			if ev.Type == TxPoolEventRemoved {
+				continue // Our own inclusions
+			}
+		}
+		if _, err := bp.ProduceBlock(); err != nil && !errors.Is(err, ErrNothingToProduce) && !errors.Is(err, ErrIneligibleProposer) {
+			fmt.Printf("BlockProducer: %v\n", err) // Placeholder log
+		}
+	}
+}
+
+// ErrNothingToProduce is returned by ProduceBlock when no transaction could be included and
+// empty blocks are not allowed.
+var ErrNothingToProduce = fmt.Errorf("no transactions to include")
+
+// ProduceBlock builds, seals and imports one block on top of the current head:
+//  1. bring the live state to the head, or build on the block it holds if the head is on another
+//     branch (see parent)
+//  2. prepare a header via the consensus engine
+//  3. select pending transactions that execute successfully within the gas limit
+//  4. fill in TxRoot, GasUsed and StateRoot, and seal with the proposer identity
+//  5. verify through the consensus engine and add to the block tree
+//  6. if the block became the canonical head, apply it to the live state and update finality
+//  7. remove included transactions from the pool and broadcast the block
+//
+// A block that does not become head (a better branch is known) stays in the tree but its state is
+// not applied, and its transactions remain pending. Later blocks are built on the block the state
+// holds until the head descends from it again.
+func (bp *BlockProducer) ProduceBlock() (*Block, error) {
+	return bp.produce(bp.cfg.AllowEmpty)
+}
+
+// SealNow produces a block on demand (e.g., from a dev-mode API), even if it has no transactions.
+func (bp *BlockProducer) SealNow() (*Block, error) {
+	return bp.produce(true)
+}
+
+// produce implements ProduceBlock; allowEmpty overrides the configured AllowEmpty.
+func (bp *BlockProducer) produce(allowEmpty bool) (*Block, error) {
+	bp.produceMu.Lock()
+	defer bp.produceMu.Unlock()
+
+	parent, err := bp.parent()
+	if err != nil {
+		return nil, err
+	}
+	header, err := bp.engine.Prepare(parent.Header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare header on %d: %w", parent.Header.Number, err)
+	}
+	header.Proposer = bp.cfg.Proposer
+
+	// Execute candidates on a scratch copy of the state; failing transactions are skipped
+	scratch := bp.state.Copy()
+	if err := scratch.BeginBlock(header); err != nil {
+		return nil, fmt.Errorf("failed to begin block %d: %w", header.Number, err)
+	}
+	included := make([]*Transaction, 0)
+	for _, tx := range bp.pool.PendingTransactions() {
+		if bp.cfg.MaxTxsPerBlock > 0 && len(included) >= bp.cfg.MaxTxsPerBlock {
+			break
+		}
+		gas := scratch.TransactionGas(tx)
+		if header.GasUsed+gas > header.GasLimit {
+			continue
+		}
+		if err := scratch.ApplyTransaction(tx); err != nil {
+			continue // Not executable against the current state (e.g., future nonce)
+		}
+		header.GasUsed += gas
+		included = append(included, tx)
+	}
+	if len(included) == 0 && !allowEmpty {
+		return nil, ErrNothingToProduce
+	}
+	if err := scratch.EndBlock(); err != nil {
+		return nil, fmt.Errorf("failed to end block %d: %w", header.Number, err)
+	}
+
+	if header.TxRoot, err = DeriveTxRoot(included); err != nil {
+		return nil, fmt.Errorf("failed to derive tx root: %w", err)
+	}
+	if header.StateRoot, err = scratch.DB().Root(); err != nil {
+		return nil, fmt.Errorf("failed to compute state root: %w", err)
+	}
+	block := NewBlock(header, included)
+	if err := bp.engine.Seal(block, bp.cfg.Proposer); err != nil {
+		return nil, fmt.Errorf("failed to seal block %d: %w", header.Number, err)
+	}
+
+	// Import through the same path as blocks received from peers
+	if err := bp.engine.VerifyHeader(header, parent.Header); err != nil {
+		return nil, fmt.Errorf("produced invalid header: %w", err)
+	}
+	if err := bp.engine.VerifyBlock(block); err != nil {
+		return nil, fmt.Errorf("produced invalid block: %w", err)
+	}
+	if err := bp.tree.AddBlock(block); err != nil {
+		return nil, fmt.Errorf("failed to add produced block to tree: %w", err)
+	}
+	if bp.tree.Head() != block {
+		fmt.Printf("BlockProducer: Produced block %d is not the canonical head; state not applied\n", header.Number) // Placeholder log
+		return block, nil
+	}
+	// The live state holds the parent (the previous head), so it can advance to the new head
+	if err := bp.engine.Finalize(block); err != nil {
+		return nil, fmt.Errorf("failed to apply produced block: %w", err)
+	}
+	if fu, ok := bp.engine.(FinalityUpdater); ok {
+		if _, err := fu.UpdateFinality(bp.tree); err != nil {
+			fmt.Printf("BlockProducer: Failed to update finality after block %d: %v\n", header.Number, err) // Placeholder log
+		}
+	}
+
+	for _, tx := range included {
+		bp.pool.RemoveTransaction(tx)
+	}
+	if bp.broadcaster != nil {
+		if err := bp.broadcaster.BroadcastBlock(block); err != nil {
+			fmt.Printf("BlockProducer: Failed to broadcast block %d: %v\n", header.Number, err) // Placeholder log
+		}
+	}
+	fmt.Printf("BlockProducer: Produced block %d with %d transactions\n", header.Number, len(included)) // Placeholder log
+	return block, nil
+}
+
+// parent returns the block to build on and brings the live state to it. When the head descends
+// from the block whose post-state the live state holds, the blocks in between are applied and the
+// head is returned, and their transactions leave the pool. The state cannot be rolled back, so when the head is on another branch (e.g.,
+// after a produced block lost fork choice) the block the state holds is returned instead.
+func (bp *BlockProducer) parent() (*Block, error) {
+	head := bp.tree.Head()
+	applied := bp.tree.Genesis()
+	if header := bp.state.AppliedBlock(); header != nil {
+		block, ok := bp.tree.GetBlock(header.Hash())
+		if !ok {
+			return nil, fmt.Errorf("state is at block %d (%s), which is not in the block tree", header.Number, header.Hash())
+		}
+		applied = block
+	}
+	appliedHash, err := applied.Hash()
+	if err != nil {
+		return nil, err
+	}
+	headHash, err := head.Hash()
+	if err != nil {
+		return nil, err
+	}
+	if !bp.tree.IsAncestor(appliedHash, headHash) {
+		fmt.Printf("BlockProducer: Head %d is on another branch than the state (at %d); building on the state\n", head.Header.Number, applied.Header.Number) // Placeholder log
+		return applied, nil
+	}
+
+	var path []*Block
+	for block := head; block != applied; {
+		path = append(path, block)
+		parent, ok := bp.tree.GetBlock(block.Header.ParentHash)
+		if !ok {
+			return nil, fmt.Errorf("unknown parent %s of block %d", block.Header.ParentHash, block.Header.Number)
+		}
+		block = parent
+	}
+	for i := len(path) - 1; i >= 0; i-- {
+		if err := bp.engine.Finalize(path[i]); err != nil {
+			return nil, fmt.Errorf("failed to apply block %d on the way to the head: %w", path[i].Header.Number, err)
+		}
+		for _, tx := range path[i].Transactions {
+			bp.pool.RemoveTransaction(tx)
+		}
+	}
+	return head, nil
+}
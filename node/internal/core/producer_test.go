@@ -0,0 +1,224 @@
+package core
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockBroadcaster records blocks handed to the network layer.
+type mockBroadcaster struct {
+	mu     sync.Mutex
+	blocks []*Block
+}
+
+func (m *mockBroadcaster) BroadcastBlock(block *Block) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.blocks = append(m.blocks, block)
+	return nil
+}
+
+func (m *mockBroadcaster) count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.blocks)
+}
+
+// newProducerFixture wires a producer on top of a funded in-memory state.
+func newProducerFixture(t *testing.T, cfg BlockProducerConfig) (*BlockProducer, *TxPool, *InMemoryStateDB, *BlockTree, *mockBroadcaster) {
+	t.Helper()
+	db := NewInMemoryStateDB()
+	_ = db.SetBalance("senderA", 1000)
+	sm := NewStateManager(db)
+	consensus := NewPathIntegralConsensus(sm)
+	root, _ := db.Root()
+	tree, err := NewBlockTree(NewGenesisBlock(root, time.Now().Add(-time.Minute)), consensus.CalculateAction)
+	if err != nil {
+		t.Fatalf("NewBlockTree failed: %v", err)
+	}
+	pool := NewTxPool()
+	broadcaster := &mockBroadcaster{}
+	producer, err := NewBlockProducer(cfg, consensus, pool, sm, tree, broadcaster)
+	if err != nil {
+		t.Fatalf("NewBlockProducer failed: %v", err)
+	}
+	return producer, pool, db, tree, broadcaster
+}
+
+func TestBlockProducer_ProduceBlock(t *testing.T) {
+	producer, pool, db, tree, broadcaster := newProducerFixture(t, BlockProducerConfig{Proposer: "proposerP"})
+
+	t.Run("NothingToProduce", func(t *testing.T) {
+		if _, err := producer.ProduceBlock(); !errors.Is(err, ErrNothingToProduce) {
+			t.Errorf("Expected ErrNothingToProduce with empty pool, got %v", err)
+		}
+	})
+
+	tx0 := NewBaseTransaction(TxTypeTransfer, 0, "senderA", "recipientB", 100)
+	tx0.Fee = 10
+	_ = tx0.Sign()
+	tx1 := NewBaseTransaction(TxTypeTransfer, 1, "senderA", "recipientB", 50)
+	_ = tx1.Sign()
+	future := NewBaseTransaction(TxTypeTransfer, 5, "senderA", "recipientB", 1) // Nonce gap, not executable
+	_ = future.Sign()
+	for _, tx := range []*Transaction{tx0, tx1, future} {
+		if err := pool.AddTransaction(tx); err != nil {
+			t.Fatalf("AddTransaction failed: %v", err)
+		}
+	}
+
+	block, err := producer.ProduceBlock()
+	if err != nil {
+		t.Fatalf("ProduceBlock failed: %v", err)
+	}
+	if block.Header.Number != 1 || len(block.Transactions) != 2 {
+		t.Fatalf("Expected block 1 with 2 transactions, got block %d with %d", block.Header.Number, len(block.Transactions))
+	}
+	if !block.Header.VerifySignature() || block.Header.Proposer != "proposerP" {
+		t.Errorf("Produced block is not signed by the proposer")
+	}
+	if tree.Head() != block {
+		t.Errorf("Produced block should be the new head")
+	}
+	if bal, _ := db.GetBalance("recipientB"); bal != 150 {
+		t.Errorf("Expected live state to reflect the block, recipient has %d", bal)
+	}
+	if fee, _ := db.GetBalance("proposerP"); fee != 10 {
+		t.Errorf("Expected proposer to earn the fee, got %d", fee)
+	}
+	if pending := pool.PendingTransactions(); len(pending) != 1 || pending[0] != future {
+		t.Errorf("Expected only the non-executable tx to remain pending, got %v", pending)
+	}
+	if broadcaster.count() != 1 {
+		t.Errorf("Expected the block to be broadcast once, got %d", broadcaster.count())
+	}
+}
+
+func TestBlockProducer_NotHead(t *testing.T) {
+	producer, pool, db, tree, _ := newProducerFixture(t, BlockProducerConfig{Proposer: "proposerP"})
+	genesis := tree.Genesis()
+	genesisHash, _ := genesis.Hash()
+	_ = tree.SetGapAction(0) // Let the shorter branch win on cumulative action alone
+	// Two quick empty blocks: s (action 0, applied to the live state) and its sibling u (action 1)
+	sibling := func(offset time.Duration) *Block {
+		return NewBlock(&BlockHeader{ParentHash: genesisHash, Number: 1, Timestamp: genesis.Header.Timestamp.Add(offset), StateRoot: genesis.Header.StateRoot}, nil)
+	}
+	s, u := sibling(time.Second), sibling(2*time.Second)
+	for _, b := range []*Block{s, u} {
+		if err := tree.AddBlock(b); err != nil {
+			t.Fatalf("AddBlock failed: %v", err)
+		}
+	}
+	if err := producer.engine.Finalize(s); err != nil {
+		t.Fatalf("Finalize failed: %v", err)
+	}
+
+	tx := NewBaseTransaction(TxTypeTransfer, 0, "senderA", "recipientB", 100)
+	_ = tx.Sign()
+	_ = pool.AddTransaction(tx)
+	// Built on s about a minute later, the block lifts the branch's cumulative action above u's
+	block, err := producer.ProduceBlock()
+	if err != nil {
+		t.Fatalf("ProduceBlock failed: %v", err)
+	}
+	if tree.Head() != u {
+		t.Fatalf("Expected u to stay head over the slow produced block")
+	}
+	if hash, _ := block.Hash(); !tree.IsAncestor(genesisHash, hash) {
+		t.Errorf("Expected the produced block kept in the tree")
+	}
+	if bal, _ := db.GetBalance("recipientB"); bal != 0 {
+		t.Errorf("Expected the state of a non-canonical block not applied, recipient has %d", bal)
+	}
+	if len(pool.PendingTransactions()) != 1 {
+		t.Errorf("Expected the transaction of a non-canonical block to stay pending")
+	}
+
+	// The state cannot follow u's branch, so the next block is built on s again instead of failing
+	again, err := producer.ProduceBlock()
+	if err != nil {
+		t.Fatalf("Expected production to continue after losing fork choice, got %v", err)
+	}
+	if sHash, _ := s.Hash(); again.Header.ParentHash != sHash {
+		t.Errorf("Expected the next block built on s, whose state is live")
+	}
+}
+
+func TestBlockProducer_CatchUp(t *testing.T) {
+	producer, pool, db, tree, _ := newProducerFixture(t, BlockProducerConfig{Proposer: "proposerP"})
+	tx := NewBaseTransaction(TxTypeTransfer, 0, "senderA", "recipientB", 100)
+	_ = tx.Sign()
+	_ = pool.AddTransaction(tx)
+
+	// Another producer on a copy of the state extends the shared tree first
+	peerState := producer.state.Copy()
+	peerPool := NewTxPool()
+	_ = peerPool.AddTransaction(tx)
+	peer, _ := NewBlockProducer(BlockProducerConfig{Proposer: "proposerQ"}, NewPathIntegralConsensus(peerState), peerPool, peerState, tree, nil)
+	first, err := peer.ProduceBlock()
+	if err != nil || tree.Head() != first {
+		t.Fatalf("Expected the peer's block to become head, got %v", err)
+	}
+
+	if _, err := producer.SealNow(); err != nil {
+		t.Fatalf("SealNow failed: %v", err)
+	}
+	if head := tree.Head(); head.Header.Number != 2 {
+		t.Errorf("Expected the produced block to extend the peer's, head is at %d", head.Header.Number)
+	}
+	if bal, _ := db.GetBalance("recipientB"); bal != 100 {
+		t.Errorf("Expected the peer's block applied to the live state, recipient has %d", bal)
+	}
+	if len(pool.PendingTransactions()) != 0 {
+		t.Errorf("Expected the transaction included by the peer to leave the pool")
+	}
+}
+
+func TestBlockProducer_Limits(t *testing.T) {
+	producer, pool, _, _, _ := newProducerFixture(t, BlockProducerConfig{Proposer: "proposerP", MaxTxsPerBlock: 1})
+	for nonce := uint64(0); nonce < 3; nonce++ {
+		tx := NewBaseTransaction(TxTypeTransfer, nonce, "senderA", "recipientB", 1)
+		_ = tx.Sign()
+		_ = pool.AddTransaction(tx)
+	}
+	block, err := producer.ProduceBlock()
+	if err != nil {
+		t.Fatalf("ProduceBlock failed: %v", err)
+	}
+	if len(block.Transactions) != 1 {
+		t.Errorf("Expected MaxTxsPerBlock to cap the block at 1 tx, got %d", len(block.Transactions))
+	}
+}
+
+func TestBlockProducer_Loop(t *testing.T) {
+	producer, pool, _, tree, _ := newProducerFixture(t, BlockProducerConfig{Proposer: "proposerP"})
+	if err := producer.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer producer.Stop()
+	if err := producer.Start(); err == nil {
+		t.Errorf("Expected error starting an already running producer")
+	}
+
+	tx := NewBaseTransaction(TxTypeTransfer, 0, "senderA", "recipientB", 1)
+	_ = tx.Sign()
+	_ = pool.AddTransaction(tx)
+	producer.Trigger()
+
+	deadline := time.After(2 * time.Second)
+	for tree.Head().Header.Number != 1 {
+		select {
+		case <-deadline:
+			t.Fatalf("Triggered producer did not produce a block")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
+
+func TestNewBlockProducer_Validation(t *testing.T) {
+	if _, err := NewBlockProducer(BlockProducerConfig{}, nil, nil, nil, nil, nil); err == nil {
+		t.Errorf("Expected error for missing proposer and dependencies")
+	}
+}
@@ -1,8 +1,10 @@
 package core
 
 import (
+	"encoding/binary"
 	"fmt"
 	"math"
+	"math/bits"
 	"sync"
 )
 
@@ -23,6 +25,32 @@ type QSDManager struct {
 	paramLP *Parameter
 }
 
+// Names of the QSD parameters. The QSDManager's Parameters and the on-chain values QSD
+// transactions use share these names, so TxTypeParamChange transactions checked against a
+// ParameterManager holding the manager's parameters change them, and SyncFromState mirrors them back.
+const (
+	QSDParamCollateralRatio = "qsd_collateral_ratio" // Minimum collateral value / debt of a vault after a mint
+	QSDParamCollateralPrice = "qsd_collateral_price" // QSD value of one unit of native collateral, fed by ParameterAdminAccount (see NewQSDPriceTransaction)
+)
+
+// Values of the QSD parameters while none is set on chain.
+const (
+	DefaultQSDCollateralRatio = 1.5
+	DefaultQSDCollateralPrice = 1.0
+)
+
+// checkCollateralRatio returns an error unless collateral worth collateralValue QSD covers debt at
+// the required ratio. It is the ratio rule of both QSDManager.Mint and QSD mint transactions.
+func checkCollateralRatio(collateralValue, debt, required float64) error {
+	if debt == 0 {
+		return nil
+	}
+	if actual := collateralValue / debt; actual < required {
+		return fmt.Errorf("insufficient collateral. Required ratio: %f, actual ratio: %f", required, actual)
+	}
+	return nil
+}
+
 // NewQSD creates a new QSD instance
 func NewQSD() *QSD {
 	return &QSD{}
@@ -65,10 +93,8 @@ func (m *QSDManager) Mint(owner string, collateralType string, collateralID Hash
 	collateralRatio := m.paramCR.CurrentValue
 
 	ethDecimals := 18.0
-	actualRatio := float64(collateralAmount) / math.Pow(10, ethDecimals) / float64(qsdToMint)
-
-	if actualRatio < collateralRatio {
-		return fmt.Errorf("insufficient collateral. Required ratio: %f, actual ratio: %f", collateralRatio, actualRatio)
+	if err := checkCollateralRatio(float64(collateralAmount)/math.Pow(10, ethDecimals), float64(qsdToMint), collateralRatio); err != nil {
+		return err
 	}
 
 	m.vaults[owner][collateralType] += float64(qsdToMint)
@@ -115,3 +141,217 @@ func (q *QSD) CalculateQSDValue() (float64, error) {
 	// TODO: Implement QSD value calculation logic
 	return 0.0, nil
 }
+
+// --- QSD transactions ---
+//
+// Vaults minted through transactions live in state: a QSD mint locks native collateral (tx.Amount)
+// in the sender's vault and credits the minted QSD to their QSD balance; a burn repays debt and
+// releases collateral in proportion. Mints apply the QSD manager's collateral ratio rule with the
+// on-chain values of its parameters: the collateral price and the required ratio, both updated by
+// TxTypeParamChange transactions through the parameter schedule so every node values collateral
+// alike. There is no on-chain oracle; the parameter admin posts prices with NewQSDPriceTransaction.
+// TODO: Support CUT collateral types.
+
+// Storage keys for QSD state.
+const (
+	qsdBalancePrefix = "qsd/balance/" // qsd/balance/<address> -> QSD balance (uint64)
+	qsdVaultPrefix   = "qsd/vault/"   // qsd/vault/<owner> -> QSDVault
+	qsdSupplyKey     = "qsd/supply"   // Total QSD outstanding (uint64)
+)
+
+// GasQSD is the gas charged for a mint or burn on top of the intrinsic gas.
+const GasQSD uint64 = 5000
+
+// QSDVault is the collateral locked and QSD owed by a vault owner.
+type QSDVault struct {
+	Collateral uint64
+	Debt       uint64
+}
+
+// GetQSDBalance returns the QSD balance of address.
+func GetQSDBalance(db StateDB, address string) (uint64, error) {
+	return getStorageUint64(db, qsdBalancePrefix+address)
+}
+
+// GetQSDSupply returns the total QSD outstanding.
+func GetQSDSupply(db StateDB) (uint64, error) {
+	return getStorageUint64(db, qsdSupplyKey)
+}
+
+// GetQSDVault returns the vault of owner; an empty vault if none exists.
+func GetQSDVault(db StateDB, owner string) (QSDVault, error) {
+	var vault QSDVault
+	_, err := getStorageGob(db, qsdVaultPrefix+owner, &vault)
+	return vault, err
+}
+
+// setQSDVault stores the vault of owner, deleting it once fully closed.
+func setQSDVault(db StateDB, owner string, vault QSDVault) error {
+	if vault == (QSDVault{}) {
+		return db.SetStorage(qsdVaultPrefix+owner, nil)
+	}
+	return setStorageGob(db, qsdVaultPrefix+owner, vault)
+}
+
+// qsdParameters returns the collateral ratio and price QSD transactions use: the on-chain values of
+// the QSD parameters, or their defaults while unset.
+func qsdParameters(db StateDB) (ratio, price float64, err error) {
+	ratio, price = DefaultQSDCollateralRatio, DefaultQSDCollateralPrice
+	if value, found, err := ParameterValue(db, QSDParamCollateralRatio); err != nil {
+		return 0, 0, err
+	} else if found {
+		ratio = value
+	}
+	if value, found, err := ParameterValue(db, QSDParamCollateralPrice); err != nil {
+		return 0, 0, err
+	} else if found {
+		price = value
+	}
+	return ratio, price, nil
+}
+
+// NewQSDPriceTransaction creates an unsigned parameter change setting the collateral price to price
+// at the end of epoch. Like any TxTypeParamChange it must be authorized by ParameterAdminAccount.
+func NewQSDPriceTransaction(nonce, epoch uint64, price float64) (*Transaction, error) {
+	return NewParameterChangeTransaction(nonce, ParameterChange{Epoch: epoch, Name: QSDParamCollateralPrice, Value: price})
+}
+
+// NewQSDMintTransaction creates an unsigned transaction locking collateral to mint qsd.
+func NewQSDMintTransaction(nonce uint64, owner string, collateral, qsd uint64) *Transaction {
+	tx := NewBaseTransaction(TxTypeQSDMint, nonce, owner, "", collateral)
+	tx.Payload = binary.BigEndian.AppendUint64(nil, qsd)
+	return tx
+}
+
+// NewQSDBurnTransaction creates an unsigned transaction repaying qsd of the sender's vault debt.
+func NewQSDBurnTransaction(nonce uint64, owner string, qsd uint64) *Transaction {
+	return NewBaseTransaction(TxTypeQSDBurn, nonce, owner, "", qsd)
+}
+
+// mulDiv returns a*b/c rounded down. Requires b <= c so the result fits in 64 bits.
+func mulDiv(a, b, c uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	q, _ := bits.Div64(hi, lo, c)
+	return q
+}
+
+// validateQSDMint checks that the sender can lock the collateral and that the vault stays above the
+// collateral ratio after minting.
+func validateQSDMint(ctx *NativeContext, tx *Transaction) error {
+	if len(tx.Payload) != 8 {
+		return fmt.Errorf("QSD mint payload must be an 8-byte amount, got %d bytes", len(tx.Payload))
+	}
+	qsd := binary.BigEndian.Uint64(tx.Payload)
+	if qsd == 0 {
+		return fmt.Errorf("cannot mint zero QSD")
+	}
+	if ctx.Spendable < tx.Amount {
+		return fmt.Errorf("insufficient funds: sender %s has %d, needs %d collateral", tx.SenderID, ctx.Spendable, tx.Amount)
+	}
+	vault, err := GetQSDVault(ctx.State.db, tx.SenderID)
+	if err != nil {
+		return err
+	}
+	collateral, debt := vault.Collateral+tx.Amount, vault.Debt+qsd
+	if collateral < vault.Collateral || debt < vault.Debt {
+		return fmt.Errorf("vault of %s overflows", tx.SenderID)
+	}
+	ratio, price, err := qsdParameters(ctx.State.db)
+	if err != nil {
+		return err
+	}
+	if err := checkCollateralRatio(float64(collateral)*price, float64(debt), ratio); err != nil {
+		return fmt.Errorf("vault of %s: %w", tx.SenderID, err)
+	}
+	return nil
+}
+
+// applyQSDMint locks the collateral and credits the minted QSD.
+func applyQSDMint(ctx *NativeContext, tx *Transaction) error {
+	db := ctx.State.db
+	qsd := binary.BigEndian.Uint64(tx.Payload)
+	vault, err := GetQSDVault(db, tx.SenderID)
+	if err != nil {
+		return err
+	}
+	balance, err := GetQSDBalance(db, tx.SenderID)
+	if err != nil {
+		return err
+	}
+	supply, err := GetQSDSupply(db)
+	if err != nil {
+		return err
+	}
+	if err := ctx.State.debit(tx.SenderID, tx.Amount); err != nil {
+		return err
+	}
+	vault.Collateral += tx.Amount
+	vault.Debt += qsd
+	if err := setQSDVault(db, tx.SenderID, vault); err != nil {
+		return err
+	}
+	if err := setStorageUint64(db, qsdBalancePrefix+tx.SenderID, balance+qsd); err != nil {
+		return err
+	}
+	if err := setStorageUint64(db, qsdSupplyKey, supply+qsd); err != nil {
+		return err
+	}
+	ctx.Emit("qsd.mint", tx.Payload)
+	return nil
+}
+
+// validateQSDBurn checks that the sender owes and holds the QSD being repaid.
+func validateQSDBurn(ctx *NativeContext, tx *Transaction) error {
+	if tx.Amount == 0 {
+		return fmt.Errorf("cannot burn zero QSD")
+	}
+	vault, err := GetQSDVault(ctx.State.db, tx.SenderID)
+	if err != nil {
+		return err
+	}
+	if vault.Debt < tx.Amount {
+		return fmt.Errorf("vault of %s owes %d QSD, cannot repay %d", tx.SenderID, vault.Debt, tx.Amount)
+	}
+	balance, err := GetQSDBalance(ctx.State.db, tx.SenderID)
+	if err != nil {
+		return err
+	}
+	if balance < tx.Amount {
+		return fmt.Errorf("insufficient QSD: %s has %d, needs %d", tx.SenderID, balance, tx.Amount)
+	}
+	return nil
+}
+
+// applyQSDBurn burns the repaid QSD and releases collateral in proportion to the debt repaid.
+func applyQSDBurn(ctx *NativeContext, tx *Transaction) error {
+	db := ctx.State.db
+	vault, err := GetQSDVault(db, tx.SenderID)
+	if err != nil {
+		return err
+	}
+	balance, err := GetQSDBalance(db, tx.SenderID)
+	if err != nil {
+		return err
+	}
+	supply, err := GetQSDSupply(db)
+	if err != nil {
+		return err
+	}
+	released := mulDiv(vault.Collateral, tx.Amount, vault.Debt)
+	vault.Collateral -= released
+	vault.Debt -= tx.Amount
+	if err := setQSDVault(db, tx.SenderID, vault); err != nil {
+		return err
+	}
+	if err := setStorageUint64(db, qsdBalancePrefix+tx.SenderID, balance-tx.Amount); err != nil {
+		return err
+	}
+	if err := setStorageUint64(db, qsdSupplyKey, supply-tx.Amount); err != nil {
+		return err
+	}
+	if err := ctx.State.credit(tx.SenderID, released); err != nil {
+		return err
+	}
+	ctx.Emit("qsd.burn", binary.BigEndian.AppendUint64(nil, tx.Amount))
+	return nil
+}
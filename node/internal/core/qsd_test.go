@@ -135,6 +135,96 @@ func TestQSD_Burning(t *testing.T) {
 	// TODO: Add tests for repaying full amount, partial amount, stability fees etc.
 }
 
+func TestQSD_Transactions(t *testing.T) {
+	db := NewInMemoryStateDB()
+	_ = db.SetBalance("userA", 1000)
+	sm := NewStateManager(db)
+	_ = sm.BeginBlock(&BlockHeader{Number: 1, Proposer: "proposerP"})
+	apply := func(tx *Transaction) error {
+		_ = tx.Sign()
+		return sm.ApplyTransaction(tx)
+	}
+
+	t.Run("MintBelowCollateralRatio", func(t *testing.T) {
+		if err := apply(NewQSDMintTransaction(0, "userA", 300, 201)); err == nil {
+			t.Errorf("Expected mint below the 150%% collateral ratio to be rejected")
+		}
+	})
+
+	t.Run("Mint", func(t *testing.T) {
+		if err := apply(NewQSDMintTransaction(0, "userA", 600, 400)); err != nil {
+			t.Fatalf("Mint failed: %v", err)
+		}
+		vault, _ := GetQSDVault(db, "userA")
+		qsd, _ := GetQSDBalance(db, "userA")
+		supply, _ := GetQSDSupply(db)
+		bal, _ := db.GetBalance("userA")
+		if vault != (QSDVault{Collateral: 600, Debt: 400}) || qsd != 400 || supply != 400 || bal != 400 {
+			t.Errorf("Unexpected state after mint: vault %+v, QSD %d, supply %d, balance %d", vault, qsd, supply, bal)
+		}
+	})
+
+	t.Run("OnChainParameters", func(t *testing.T) {
+		// The posted price and required ratio come from the QSD manager's parameters in state
+		pm := NewParameterManager()
+		paramCR, _, _ := createMockQSDParams()
+		_ = pm.AddParameter(paramCR)
+		_ = pm.AddParameter(NewParameter(QSDParamCollateralPrice, &mockDistribution{mean: 1, stdDev: 0.1}))
+		sm.SetParameterManager(pm)
+		price, _ := NewQSDPriceTransaction(0, 0, 2.0)
+		ratio, _ := NewParameterChangeTransaction(1, ParameterChange{Name: QSDParamCollateralRatio, Value: 2.0})
+		for _, tx := range []*Transaction{price, ratio} {
+			if err := apply(tx); err != nil {
+				t.Fatalf("Parameter change failed: %v", err)
+			}
+		}
+		if _, err := sm.applyScheduledParameters(0); err != nil {
+			t.Fatalf("applyScheduledParameters failed: %v", err)
+		}
+		defer func() {
+			_ = db.SetStorage(paramValueKey(QSDParamCollateralPrice), nil)
+			_ = db.SetStorage(paramValueKey(QSDParamCollateralRatio), nil)
+		}()
+
+		// The vault holds 600 collateral for 400 QSD; 100 more makes 700, worth 1400 QSD at price 2
+		scratch := sm.Copy()
+		mint := NewQSDMintTransaction(1, "userA", 100, 301) // 1400 / 701 < 2
+		_ = mint.Sign()
+		if err := scratch.ApplyTransaction(mint); err == nil {
+			t.Errorf("Expected a mint below the on-chain ratio of 2 to be rejected")
+		}
+		mint = NewQSDMintTransaction(1, "userA", 100, 300) // 1400 / 700 = 2, refused at the default 1:1 price
+		_ = mint.Sign()
+		if err := scratch.ApplyTransaction(mint); err != nil {
+			t.Errorf("Expected collateral valued at the on-chain price to cover the mint, got %v", err)
+		}
+	})
+
+	t.Run("BurnMoreThanDebt", func(t *testing.T) {
+		if err := apply(NewQSDBurnTransaction(1, "userA", 401)); err == nil {
+			t.Errorf("Expected repaying more than the debt to be rejected")
+		}
+	})
+
+	t.Run("PartialAndFullBurn", func(t *testing.T) {
+		if err := apply(NewQSDBurnTransaction(1, "userA", 100)); err != nil {
+			t.Fatalf("Burn failed: %v", err)
+		}
+		vault, _ := GetQSDVault(db, "userA")
+		if bal, _ := db.GetBalance("userA"); vault != (QSDVault{Collateral: 450, Debt: 300}) || bal != 550 {
+			t.Errorf("Expected a quarter of the collateral released, got vault %+v, balance %d", vault, bal)
+		}
+		if err := apply(NewQSDBurnTransaction(2, "userA", 300)); err != nil {
+			t.Fatalf("Burn failed: %v", err)
+		}
+		vault, _ = GetQSDVault(db, "userA")
+		supply, _ := GetQSDSupply(db)
+		if bal, _ := db.GetBalance("userA"); vault != (QSDVault{}) || supply != 0 || bal != 1000 {
+			t.Errorf("Expected the vault closed and all collateral returned, got vault %+v, supply %d, balance %d", vault, supply, bal)
+		}
+	})
+}
+
 // TODO: Add TestQSD_LiquidationTrigger
 // TODO: Add TestQSD_LiquidationProcess
 // TODO: Add TestQSD_CollateralRatioCheck
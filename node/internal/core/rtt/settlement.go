@@ -2,7 +2,6 @@ package rtt
 
 import (
 	"fmt"
-	"math/rand" // Keep for placeholder RecordID for now
 	"strconv"
 	"strings"
 	// "time" // Potentially needed for timestamps
@@ -64,7 +63,8 @@ func CalculateOverlap(buyField, sellField *PropensityField, priceRange PriceRang
 }
 
 // AttemptLocalSettlement evaluates local fields and probabilistically initiates settlement.
-// It uses the calculated overlap and a threshold (theta_trade_threshold) to decide.
+// It uses the calculated overlap and a threshold (theta_trade_threshold) to decide; the random
+// draws come from state.Randomness so that nodes sharing the source agree on the outcome.
 func AttemptLocalSettlement(state *LocalNodeStateRTT, assetID string, tradeThreshold float64) (*SettlementRecord, error) {
 	// TODO: Implement the full logic:
 	// 1. Get relevant buy and sell fields from state for the assetID.
@@ -84,6 +84,9 @@ func AttemptLocalSettlement(state *LocalNodeStateRTT, assetID string, tradeThres
 	if state == nil {
 		return nil, fmt.Errorf("cannot attempt settlement with nil state")
 	}
+	if state.Randomness == nil {
+		return nil, fmt.Errorf("cannot attempt settlement without a randomness source")
+	}
 
 	// --- Actual Logic (Minimal for TDD Step 3) ---
 
@@ -126,12 +129,12 @@ func AttemptLocalSettlement(state *LocalNodeStateRTT, assetID string, tradeThres
 			// if settlementProbability > 1.0 { settlementProbability = 1.0 }
 			// if settlementProbability < 0.0 { settlementProbability = 0.0 } // Already handled in CalculateOverlap
 
-			if rand.Float64() < settlementProbability {
+			if state.Randomness.Float64() < settlementProbability {
 				// Settlement occurs!
 				// Create placeholder settlement record.
 				// TODO: Refine Amount, PriceRange representation, CUTs, NodeID, Timestamp
 				record := &SettlementRecord{
-					RecordID:   fmt.Sprintf("settle_%d", rand.Int()), // Placeholder ID
+					RecordID:   fmt.Sprintf("settle_%016x", state.Randomness.Uint64()), // Placeholder ID
 					AssetID:    assetID,
 					Amount:     overlap * 10.0, // Placeholder amount logic (proportional to overlap?)
 					PriceRange: key,            // Use the key string for now
@@ -3,8 +3,15 @@ package rtt
 import (
 	"math"
 	"testing"
+
+	"quantum-resonance-ledger/node/internal/core"
 )
 
+// testRandomness returns a deterministic randomness source for settlement tests.
+func testRandomness(seed byte) RandomSource {
+	return core.NewRandomStream(core.Hash{seed})
+}
+
 // TestCalculateOverlap verifies the calculation of overlap between buy/sell fields.
 func TestCalculateOverlap(t *testing.T) {
 	// t.Skip("TDD Step 3: TestCalculateOverlap now implemented.") // Unskipped
@@ -76,7 +83,8 @@ func TestAttemptLocalSettlement(t *testing.T) {
 
 		// Create a minimal state containing the fields
 		// Note: LocalNodeStateRTT needs BuyFields/SellFields maps initialized
-		state := InitializeRTTState() // Use the initializer from types.go
+		state := InitializeRTTState()        // Use the initializer from types.go
+		state.Randomness = testRandomness(3) // First draw (~0.17) is below the 0.48 overlap
 		state.BuyFields[assetID] = buyField
 		state.SellFields[assetID] = sellField
 		// TODO: Add mock CUTs and NodeID to state once needed
@@ -119,6 +127,7 @@ func TestAttemptLocalSettlement(t *testing.T) {
 		}
 
 		state := InitializeRTTState()
+		state.Randomness = testRandomness(2)
 		state.BuyFields[assetID] = buyField
 		state.SellFields[assetID] = sellField
 
@@ -156,6 +165,7 @@ func TestAttemptLocalSettlement(t *testing.T) {
 		}
 
 		state := InitializeRTTState()
+		state.Randomness = testRandomness(2)
 		state.BuyFields[assetID] = buyField
 		state.SellFields[assetID] = sellField
 
@@ -184,6 +194,35 @@ func TestAttemptLocalSettlement(t *testing.T) {
 		}
 	})
 
+	t.Run("DeterministicForSharedSource", func(t *testing.T) {
+		newState := func() *LocalNodeStateRTT {
+			key := PriceRange{Min: 400, Max: 410}.Key()
+			state := InitializeRTTState()
+			state.Randomness = testRandomness(4)
+			state.BuyFields["ASSET_DET"] = &PropensityField{AssetID: "ASSET_DET", Density: map[string]float64{key: 1.0}}
+			state.SellFields["ASSET_DET"] = &PropensityField{AssetID: "ASSET_DET", Density: map[string]float64{key: 0.5}}
+			return state
+		}
+		a, b := newState(), newState()
+		for i := 0; i < 50; i++ {
+			recA, errA := AttemptLocalSettlement(a, "ASSET_DET", 0.1)
+			recB, errB := AttemptLocalSettlement(b, "ASSET_DET", 0.1)
+			if errA != nil || errB != nil {
+				t.Fatalf("AttemptLocalSettlement failed: %v, %v", errA, errB)
+			}
+			if (recA == nil) != (recB == nil) || (recA != nil && recA.RecordID != recB.RecordID) {
+				t.Fatalf("Trial %d: nodes sharing a randomness source disagreed", i)
+			}
+		}
+	})
+
+	t.Run("MissingRandomness", func(t *testing.T) {
+		state := InitializeRTTState()
+		if _, err := AttemptLocalSettlement(state, "ANY", 0.1); err == nil {
+			t.Errorf("Expected error without a randomness source")
+		}
+	})
+
 	// TODO: Add tests with CUT management integrated
 	// TODO: Add tests for Q imbalance updates (state modification)
 }
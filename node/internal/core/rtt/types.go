@@ -27,11 +27,22 @@ type LocalNodeStateRTT struct {
 	BuyFields  map[string]*PropensityField
 	SellFields map[string]*PropensityField
 
+	// Source of settlement decisions. Must be agreed upon across nodes (e.g., a stream from the
+	// on-chain randomness beacon) so every node reaches the same outcome.
+	Randomness RandomSource
+
 	// TODO: Representation of relevant local CUTs (e.g., map[CUT_ID]CUT_Status)
 	// TODO: Snapshot of recently received neighbor data (e.g., map[NeighborID]NeighborSnapshot)
 	// TODO: Local Quantity Imbalance (Q) for various assets map[AssetID]float64
 }
 
+// RandomSource supplies the random draws used for probabilistic settlement.
+// core.RandomStream implements it.
+type RandomSource interface {
+	Float64() float64 // Uniform in [0, 1)
+	Uint64() uint64
+}
+
 // SettlementRecord captures the essential details of a probabilistic local settlement event.
 type SettlementRecord struct {
 	RecordID   string // Unique identifier for this settlement event
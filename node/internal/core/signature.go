@@ -0,0 +1,27 @@
+package core
+
+import (
+	"bytes"
+	"crypto/sha256"
+)
+
+// SignDigest produces a signature by signer over a 32-byte digest.
+// Placeholder scheme: the "signature" is SHA-256(signer || digest), so it binds the signer to the
+// signed content but anyone who knows the signer ID can forge it.
+// TODO: Replace with real key-based signatures (e.g., Ed25519 or a post-quantum scheme).
+func SignDigest(signer string, digest Hash) Signature {
+	h := sha256.New()
+	h.Write([]byte("qrl-placeholder-sig:"))
+	h.Write([]byte(signer))
+	h.Write([]byte{0})
+	h.Write(digest[:])
+	return Signature(h.Sum(nil))
+}
+
+// VerifyDigestSignature checks a signature produced by SignDigest.
+func VerifyDigestSignature(signer string, digest Hash, sig Signature) bool {
+	if signer == "" || len(sig) == 0 {
+		return false
+	}
+	return bytes.Equal(SignDigest(signer, digest), sig)
+}
@@ -0,0 +1,375 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+)
+
+// SPV verification of external chain events
+//
+// The bridge does not take an adapter's word for a deposit. It tracks the header chain of each
+// external network from a trusted checkpoint, following the branch with the most cumulative proof
+// of work, and requires every lock event to carry a Merkle proof that the deposit transaction is
+// included in a block of that branch (an InclusionProof). A deposit locks its intent only once its
+// block is LockConfirmations deep, and counts as final only once it is FinalConfirmations deep, as
+// seen in the tracked headers.
+
+// externalHeaderDomain separates external header hashes from other hashes.
+const externalHeaderDomain = "qrl-external-header:"
+
+// externalTxDomain separates external transaction leaves from other hashes.
+const externalTxDomain = "qrl-external-tx:"
+
+// ExternalHeader is a block header of an external chain.
+type ExternalHeader struct {
+	Chain      ChainID
+	Height     uint64
+	Parent     Hash
+	TxRoot     Hash   // MerkleRoot of the ExternalTxLeaf of the block's transactions
+	Difficulty uint64 // Expected hash attempts to find a valid Nonce, i.e. the work the header proves
+	Timestamp  uint64
+	Nonce      uint64
+}
+
+// Hash returns the header's hash.
+func (h ExternalHeader) Hash() Hash {
+	buf := []byte(externalHeaderDomain)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(h.Chain)))
+	buf = append(buf, h.Chain...)
+	buf = binary.BigEndian.AppendUint64(buf, h.Height)
+	buf = append(buf, h.Parent[:]...)
+	buf = append(buf, h.TxRoot[:]...)
+	for _, n := range []uint64{h.Difficulty, h.Timestamp, h.Nonce} {
+		buf = binary.BigEndian.AppendUint64(buf, n)
+	}
+	return sha256.Sum256(buf)
+}
+
+// MeetsDifficulty reports whether the header's hash proves its difficulty: read as a big-endian
+// integer, its first 8 bytes must not exceed MaxUint64 / Difficulty.
+func (h ExternalHeader) MeetsDifficulty() bool {
+	if h.Difficulty == 0 {
+		return false
+	}
+	hash := h.Hash()
+	return binary.BigEndian.Uint64(hash[:8]) <= math.MaxUint64/h.Difficulty
+}
+
+// ExternalTxLeaf returns the Merkle leaf committing to the external transaction txHash with
+// contents tx.
+func ExternalTxLeaf(txHash string, tx ExternalTx) Hash {
+	buf := []byte(externalTxDomain)
+	for _, s := range []string{txHash, string(tx.Kind), tx.Address, tx.Asset, tx.Memo} {
+		buf = binary.BigEndian.AppendUint32(buf, uint32(len(s)))
+		buf = append(buf, s...)
+	}
+	buf = binary.BigEndian.AppendUint64(buf, tx.Amount)
+	return sha256.Sum256(buf)
+}
+
+// InclusionProof shows that a transaction is included in a block of an external chain.
+type InclusionProof struct {
+	BlockHash Hash
+	Index     uint64 // Position of the transaction in the block
+	Siblings  []Hash // See MerkleProof
+}
+
+// HeaderSource is implemented by adapters that serve their chain's headers.
+type HeaderSource interface {
+	// HeadersFrom returns the headers of the current best chain from height up to its head.
+	HeadersFrom(height uint64) ([]ExternalHeader, error)
+}
+
+// headerEntry is a header tracked by a HeaderChain.
+type headerEntry struct {
+	header ExternalHeader
+	work   uint64 // Cumulative difficulty since the checkpoint, inclusive
+}
+
+// HeaderChain tracks the headers of an external chain from a trusted checkpoint and follows the
+// branch with the most cumulative work. It is safe for concurrent use.
+type HeaderChain struct {
+	mu            sync.RWMutex
+	chain         ChainID
+	minDifficulty uint64
+	headers       map[Hash]*headerEntry
+	base          uint64 // Height of the checkpoint
+	canonical     []Hash // Best branch by height, canonical[0] is the checkpoint
+}
+
+// NewHeaderChain creates a header chain starting at checkpoint, which is trusted without proof of
+// work. Headers proving less than minDifficulty are rejected.
+func NewHeaderChain(checkpoint ExternalHeader, minDifficulty uint64) *HeaderChain {
+	hash := checkpoint.Hash()
+	return &HeaderChain{
+		chain:         checkpoint.Chain,
+		minDifficulty: max(minDifficulty, 1),
+		headers:       map[Hash]*headerEntry{hash: {header: checkpoint}},
+		base:          checkpoint.Height,
+		canonical:     []Hash{hash},
+	}
+}
+
+// Chain returns the chain whose headers are tracked.
+func (hc *HeaderChain) Chain() ChainID {
+	return hc.chain
+}
+
+// AddHeader validates h against its parent and adds it, switching to its branch if that has more
+// cumulative work than the current one. Known headers are ignored.
+func (hc *HeaderChain) AddHeader(h ExternalHeader) error {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	hash := h.Hash()
+	if _, known := hc.headers[hash]; known {
+		return nil
+	}
+	if h.Chain != hc.chain {
+		return fmt.Errorf("header of %s added to %s header chain", h.Chain, hc.chain)
+	}
+	parent, ok := hc.headers[h.Parent]
+	if !ok {
+		return fmt.Errorf("header %s at height %d has unknown parent %s", hash, h.Height, h.Parent)
+	}
+	if h.Height != parent.header.Height+1 {
+		return fmt.Errorf("header %s at height %d does not follow its parent at height %d", hash, h.Height, parent.header.Height)
+	}
+	if h.Difficulty < hc.minDifficulty {
+		return fmt.Errorf("header %s difficulty %d below minimum %d", hash, h.Difficulty, hc.minDifficulty)
+	}
+	if !h.MeetsDifficulty() {
+		return fmt.Errorf("header %s does not meet its difficulty %d", hash, h.Difficulty)
+	}
+	entry := &headerEntry{header: h, work: parent.work + h.Difficulty}
+	hc.headers[hash] = entry
+	if entry.work > hc.headers[hc.canonical[len(hc.canonical)-1]].work {
+		hc.reorganize(hash)
+	}
+	return nil
+}
+
+// reorganize makes the branch ending at tip canonical. Caller must hold hc.mu.
+func (hc *HeaderChain) reorganize(tip Hash) {
+	height := hc.headers[tip].header.Height
+	canonical := make([]Hash, height-hc.base+1)
+	copy(canonical, hc.canonical)
+	for hash := tip; ; {
+		i := hc.headers[hash].header.Height - hc.base
+		if i < uint64(len(hc.canonical)) && hc.canonical[i] == hash {
+			break
+		}
+		canonical[i] = hash
+		hash = hc.headers[hash].header.Parent
+	}
+	hc.canonical = canonical
+}
+
+// Tip returns the head of the best branch.
+func (hc *HeaderChain) Tip() ExternalHeader {
+	hc.mu.RLock()
+	defer hc.mu.RUnlock()
+	return hc.headers[hc.canonical[len(hc.canonical)-1]].header
+}
+
+// Height returns the height of the best branch's head.
+func (hc *HeaderChain) Height() uint64 {
+	hc.mu.RLock()
+	defer hc.mu.RUnlock()
+	return hc.base + uint64(len(hc.canonical)) - 1
+}
+
+// TotalWork returns the cumulative work of the best branch since the checkpoint.
+func (hc *HeaderChain) TotalWork() uint64 {
+	hc.mu.RLock()
+	defer hc.mu.RUnlock()
+	return hc.headers[hc.canonical[len(hc.canonical)-1]].work
+}
+
+// Confirmations returns how many blocks of the best branch are on top of and including block, or
+// 0 if it is not on the best branch.
+func (hc *HeaderChain) Confirmations(block Hash) uint64 {
+	hc.mu.RLock()
+	defer hc.mu.RUnlock()
+	return hc.confirmations(block)
+}
+
+// confirmations implements Confirmations. Caller must hold hc.mu.
+func (hc *HeaderChain) confirmations(block Hash) uint64 {
+	entry, ok := hc.headers[block]
+	if !ok || entry.header.Height < hc.base {
+		return 0
+	}
+	i := entry.header.Height - hc.base
+	if i >= uint64(len(hc.canonical)) || hc.canonical[i] != block {
+		return 0
+	}
+	return uint64(len(hc.canonical)) - i
+}
+
+// VerifyInclusion checks that proof includes leaf in a tracked block and returns the block's
+// confirmations on the best branch (0 for a block that was reorganized out).
+func (hc *HeaderChain) VerifyInclusion(leaf Hash, proof *InclusionProof) (uint64, error) {
+	hc.mu.RLock()
+	defer hc.mu.RUnlock()
+
+	if proof == nil {
+		return 0, fmt.Errorf("missing inclusion proof")
+	}
+	entry, ok := hc.headers[proof.BlockHash]
+	if !ok {
+		return 0, fmt.Errorf("unknown block %s", proof.BlockHash)
+	}
+	if !VerifyMerkleProof(leaf, proof.Index, proof.Siblings, entry.header.TxRoot) {
+		return 0, fmt.Errorf("transaction not included in block %s", proof.BlockHash)
+	}
+	return hc.confirmations(proof.BlockHash), nil
+}
+
+// SyncHeaders adds the headers of src's best chain that hc does not track yet, going back from
+// hc's head until they connect to a tracked header.
+func (hc *HeaderChain) SyncHeaders(src HeaderSource) error {
+	for from := hc.Height(); ; from-- {
+		headers, err := src.HeadersFrom(from + 1)
+		if err != nil {
+			return fmt.Errorf("failed to fetch %s headers: %w", hc.chain, err)
+		}
+		if len(headers) == 0 {
+			return nil
+		}
+		hc.mu.RLock()
+		_, connects := hc.headers[headers[0].Parent]
+		hc.mu.RUnlock()
+		if connects {
+			for _, h := range headers {
+				if err := hc.AddHeader(h); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if from == hc.base {
+			return fmt.Errorf("%s headers do not connect to the checkpoint", hc.chain)
+		}
+	}
+}
+
+// SPVConfig sets the confirmations, in tracked headers, that lock events require.
+type SPVConfig struct {
+	LockConfirmations  uint64 // Before a deposit locks its intent
+	FinalConfirmations uint64 // Before a deposit counts as final
+}
+
+// spvVerifier verifies the lock events of one external chain.
+type spvVerifier struct {
+	headers *HeaderChain
+	cfg     SPVConfig
+}
+
+// --- BridgeManager integration ---
+
+// EnableSPV makes the bridge verify lock events of headers' chain against headers. With an
+// adapter implementing HeaderSource, SyncExternalChains keeps the headers up to date.
+func (bm *BridgeManager) EnableSPV(headers *HeaderChain, cfg SPVConfig) error {
+	if cfg.LockConfirmations == 0 || cfg.FinalConfirmations < cfg.LockConfirmations {
+		return fmt.Errorf("SPV needs 0 < lock confirmations <= final confirmations, got %d and %d", cfg.LockConfirmations, cfg.FinalConfirmations)
+	}
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	bm.spv[headers.Chain()] = &spvVerifier{headers: headers, cfg: cfg}
+	return nil
+}
+
+// errNotOnBestChain reports a lock event whose block was reorganized out of the tracked headers.
+var errNotOnBestChain = errors.New("not on the best chain")
+
+// verifyLockEvent checks the inclusion proof of a lock event (a deposit, or the finalization of an
+// intent's deposit) on a chain with SPV enabled. It returns false if the deposit's block is not
+// deep enough yet. Other events pass. Caller must hold bm.mu.
+func (bm *BridgeManager) verifyLockEvent(event ExternalChainEvent) (bool, error) {
+	spv, ok := bm.spv[event.Chain]
+	if !ok {
+		return true, nil
+	}
+	var depth uint64
+	switch {
+	case event.Type == ExternalEventDeposit:
+		depth = spv.cfg.LockConfirmations
+	case event.Type == ExternalEventFinalized && bm.isSourceTx(event):
+		depth = spv.cfg.FinalConfirmations
+	default:
+		return true, nil
+	}
+	kind := event.Kind
+	if kind == "" {
+		kind = InstructionLock // User deposits
+	}
+	leaf := ExternalTxLeaf(event.TxHash, ExternalTx{Kind: kind, Asset: event.Asset, Address: event.Address, Amount: event.Amount, Memo: event.Memo})
+	confirmations, err := spv.headers.VerifyInclusion(leaf, event.Proof)
+	if err != nil {
+		return false, fmt.Errorf("unverified %s of %s on %s: %w", event.Type, event.TxHash, event.Chain, err)
+	}
+	if confirmations == 0 {
+		return false, fmt.Errorf("%s of %s on %s: %w", event.Type, event.TxHash, event.Chain, errNotOnBestChain)
+	}
+	return confirmations >= depth, nil
+}
+
+// isSourceTx reports whether event is about the deposit of a tracked intent, or a deposit waiting
+// for confirmations. Caller must hold bm.mu.
+func (bm *BridgeManager) isSourceTx(event ExternalChainEvent) bool {
+	for _, intent := range bm.pendingIntents {
+		if intent.SourceTxHash == event.TxHash && intent.SourceChain == event.Chain {
+			return true
+		}
+	}
+	return bm.isDeferred(event)
+}
+
+// isDeferred reports whether an event about the same transaction as event waits for confirmations.
+// Caller must hold bm.mu.
+func (bm *BridgeManager) isDeferred(event ExternalChainEvent) bool {
+	for _, deferred := range bm.deferred {
+		if deferred.TxHash == event.TxHash && deferred.Chain == event.Chain {
+			return true
+		}
+	}
+	return false
+}
+
+// lockConfirmations returns the confirmations of the source lock of intent and the depth at which
+// it is final: from the tracked headers if its chain is verified with SPV, otherwise as reported
+// by the adapter. Caller must hold bm.mu.
+func (bm *BridgeManager) lockConfirmations(intent *BridgeIntent) (uint64, uint64, error) {
+	if spv, ok := bm.spv[intent.SourceChain]; ok {
+		return spv.headers.Confirmations(intent.SourceBlock), spv.cfg.FinalConfirmations, nil
+	}
+	source, ok := bm.adapters[intent.SourceChain]
+	if !ok {
+		return 0, 0, fmt.Errorf("no adapter for chain %s of intent %s", intent.SourceChain, intent.ID)
+	}
+	confirmations, err := source.Confirmations(intent.SourceTxHash)
+	return confirmations, source.FinalityDepth(), err
+}
+
+// retryDeferred handles again the lock events that waited for confirmations, in their original
+// order. Events whose block was reorganized out are dropped; the adapter reports their inclusion
+// in the new branch.
+func (bm *BridgeManager) retryDeferred() error {
+	bm.mu.Lock()
+	deferred := bm.deferred
+	bm.deferred = nil
+	bm.mu.Unlock()
+
+	var errs []error
+	for _, event := range deferred {
+		if err := bm.HandleExternalChainEvent(event); err != nil && !errors.Is(err, errNotOnBestChain) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
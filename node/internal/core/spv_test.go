@@ -0,0 +1,173 @@
+package core
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSPV_HeaderChain(t *testing.T) {
+	mc := NewMockChain(ChainID_Bitcoin, DefaultMockChainConfig())
+	mc.Mine(5)
+	hc := NewHeaderChain(mc.GenesisHeader(), 16)
+	if err := hc.SyncHeaders(mc); err != nil {
+		t.Fatalf("SyncHeaders failed: %v", err)
+	}
+	if hc.Height() != 5 || hc.TotalWork() != 80 {
+		t.Fatalf("Expected 5 headers with work 80, got height %d, work %d", hc.Height(), hc.TotalWork())
+	}
+
+	tip := hc.Tip()
+	invalid := map[string]ExternalHeader{
+		"UnknownParent": {Chain: ChainID_Bitcoin, Height: 6, Difficulty: 16},
+		"WrongHeight":   {Chain: ChainID_Bitcoin, Height: 7, Parent: tip.Hash(), Difficulty: 16},
+		"LowDifficulty": {Chain: ChainID_Bitcoin, Height: 6, Parent: tip.Hash(), Difficulty: 1},
+		"NoWork":        {Chain: ChainID_Bitcoin, Height: 6, Parent: tip.Hash(), Difficulty: 1 << 60},
+		"OtherChain":    {Chain: ChainID_Ethereum, Height: 6, Parent: tip.Hash(), Difficulty: 16},
+	}
+	for name, h := range invalid {
+		t.Run(name, func(t *testing.T) {
+			if err := hc.AddHeader(h); err == nil {
+				t.Errorf("Expected header to be rejected")
+			}
+		})
+	}
+
+	headers, _ := mc.HeadersFrom(4)
+	orphan := headers[0].Hash()
+	if conf := hc.Confirmations(orphan); conf != 2 {
+		t.Errorf("Expected block 4 to have 2 confirmations, got %d", conf)
+	}
+	_ = mc.Reorg(2)
+	if err := hc.SyncHeaders(mc); err != nil {
+		t.Fatalf("SyncHeaders after reorg failed: %v", err)
+	}
+	headers, _ = mc.HeadersFrom(4)
+	if hc.Height() != 6 || hc.Tip().Hash() != headers[2].Hash() {
+		t.Errorf("Expected to follow the longer fork to height 6, got %d", hc.Height())
+	}
+	if hc.Confirmations(orphan) != 0 || hc.Confirmations(headers[0].Hash()) != 3 {
+		t.Errorf("Expected orphaned block 4 unconfirmed and its replacement 3 deep")
+	}
+}
+
+func TestSPV_Inclusion(t *testing.T) {
+	mc := NewMockChain(ChainID_Bitcoin, DefaultMockChainConfig())
+	mc.Fund("alice", "qBTC", 100_000)
+	for i := 0; i < 3; i++ {
+		_, _ = mc.Deposit("alice", "qBTC", uint64(10_000+i), fmt.Sprintf("memo%d", i))
+	}
+	mc.Mine(2)
+	hc := NewHeaderChain(mc.GenesisHeader(), 16)
+	_ = hc.SyncHeaders(mc)
+	events, _ := mc.PollEvents()
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 deposit events, got %d", len(events))
+	}
+	for _, event := range events {
+		tx := ExternalTx{Kind: InstructionLock, Asset: event.Asset, Address: event.Address, Amount: event.Amount, Memo: event.Memo}
+		if conf, err := hc.VerifyInclusion(ExternalTxLeaf(event.TxHash, tx), event.Proof); err != nil || conf != 2 {
+			t.Errorf("Expected deposit %s included 2 deep, got %d (%v)", event.Memo, conf, err)
+		}
+		tx.Amount++
+		if _, err := hc.VerifyInclusion(ExternalTxLeaf(event.TxHash, tx), event.Proof); err == nil {
+			t.Errorf("Expected altered deposit %s to fail verification", event.Memo)
+		}
+	}
+	if _, err := hc.VerifyInclusion(Hash{}, nil); err == nil {
+		t.Errorf("Expected missing proof to be rejected")
+	}
+}
+
+func TestSPV_Bridge(t *testing.T) {
+	cfg := DefaultMockChainConfig()
+	eth := NewMockChain(ChainID_Ethereum, cfg)
+	eth.Fund("alice_eth", "qBTC", 100_000)
+	manager := NewBridgeManager()
+	_ = manager.RegisterAdapter(eth)
+	if err := manager.EnableSPV(NewHeaderChain(eth.GenesisHeader(), 16), SPVConfig{LockConfirmations: 2, FinalConfirmations: 1}); err == nil {
+		t.Errorf("Expected final confirmations below lock confirmations to be rejected")
+	}
+	if err := manager.EnableSPV(NewHeaderChain(eth.GenesisHeader(), 16), SPVConfig{LockConfirmations: 2, FinalConfirmations: 8}); err != nil {
+		t.Fatalf("EnableSPV failed: %v", err)
+	}
+	intent := signedIntent(&BridgeIntent{UserAddress: "alice", SourceChain: ChainID_Ethereum, DestChain: ChainID_Bitcoin, Asset: "qBTC", Amount: 40_000, DestAddress: testBTCAddress})
+	_ = manager.HandleBridgeIntent(intent)
+	_, _ = manager.ProcessNettingEpoch(0)
+	memo := fmt.Sprintf("%x", intent.ID[:])
+
+	forged := ExternalChainEvent{Chain: ChainID_Ethereum, Type: ExternalEventDeposit, TxHash: "forged", Height: 1, Address: "alice_eth", Asset: "qBTC", Amount: 40_000, Memo: memo}
+	if err := manager.HandleExternalChainEvent(forged); err == nil {
+		t.Errorf("Expected deposit without an inclusion proof to be rejected")
+	}
+
+	_, _ = eth.Deposit("alice_eth", "qBTC", 40_000, memo)
+	eth.Mine(1)
+	if err := manager.SyncExternalChains(); err != nil || intent.Status != IntentPendingSourceLock {
+		t.Fatalf("Expected deposit to wait for 2 confirmations, got %s (%v)", intent.Status, err)
+	}
+	eth.Mine(1)
+	if err := manager.SyncExternalChains(); err != nil || intent.Status != IntentLocked {
+		t.Fatalf("Expected deposit to lock the intent at 2 confirmations, got %s (%v)", intent.Status, err)
+	}
+
+	fake := ExternalChainEvent{Chain: ChainID_Ethereum, Type: ExternalEventFinalized, TxHash: intent.SourceTxHash, Address: "alice_eth", Asset: "qBTC", Amount: 40_000, Memo: memo}
+	if err := manager.HandleExternalChainEvent(fake); err == nil || intent.Status != IntentLocked {
+		t.Errorf("Expected finalization without an inclusion proof to be rejected")
+	}
+
+	eth.Mine(4) // The adapter reports the deposit final at 6 confirmations
+	if err := manager.SyncExternalChains(); err != nil || intent.Status != IntentLocked {
+		t.Fatalf("Expected finalization to wait for 8 confirmations, got %s (%v)", intent.Status, err)
+	}
+	eth.Mine(2)
+	if err := manager.SyncExternalChains(); err != nil || intent.Status != IntentPendingRelease || !intent.SourceFinal {
+		t.Fatalf("Expected deposit final at 8 confirmations, got %s (%v)", intent.Status, err)
+	}
+}
+
+func TestSPV_DeferredFinalization(t *testing.T) {
+	eth := NewMockChain(ChainID_Ethereum, DefaultMockChainConfig())
+	eth.Fund("alice_eth", "qBTC", 100_000)
+	manager := NewBridgeManager()
+	_ = manager.RegisterAdapter(eth)
+	// The adapter reports the deposit final at 6 confirmations, when it also becomes deep enough to lock
+	if err := manager.EnableSPV(NewHeaderChain(eth.GenesisHeader(), 16), SPVConfig{LockConfirmations: 6, FinalConfirmations: 6}); err != nil {
+		t.Fatalf("EnableSPV failed: %v", err)
+	}
+	intent := signedIntent(&BridgeIntent{UserAddress: "alice", SourceChain: ChainID_Ethereum, DestChain: ChainID_Bitcoin, Asset: "qBTC", Amount: 40_000, DestAddress: testBTCAddress})
+	_ = manager.HandleBridgeIntent(intent)
+	_, _ = manager.ProcessNettingEpoch(0)
+	_, _ = eth.Deposit("alice_eth", "qBTC", 40_000, fmt.Sprintf("%x", intent.ID[:]))
+
+	eth.Mine(1)
+	if err := manager.SyncExternalChains(); err != nil || intent.Status != IntentPendingSourceLock {
+		t.Fatalf("Expected deposit deferred, got %s (%v)", intent.Status, err)
+	}
+	eth.Mine(5)
+	if err := manager.SyncExternalChains(); err != nil || intent.Status != IntentPendingRelease || !intent.SourceFinal {
+		t.Fatalf("Expected the finalization handled after its deferred deposit, got %s (%v)", intent.Status, err)
+	}
+}
+
+func TestSPV_InventoryConfirmations(t *testing.T) {
+	manager, eth, _, intent := newInventoryFixture(t, 40_000)
+	if err := manager.EnableSPV(NewHeaderChain(eth.GenesisHeader(), 16), SPVConfig{LockConfirmations: 1, FinalConfirmations: 6}); err != nil {
+		t.Fatalf("EnableSPV failed: %v", err)
+	}
+	_, _ = eth.Deposit("alice_eth", "qBTC", 40_000, fmt.Sprintf("%x", intent.ID[:]))
+	eth.Mine(1)
+	if err := manager.SyncExternalChains(); err != nil || intent.Status != IntentLocked || intent.SourceBlock == (Hash{}) {
+		t.Fatalf("Expected intent Locked in a verified block, got %s (%v)", intent.Status, err)
+	}
+
+	eth.Mine(2) // The adapter counts 3 confirmations, the tracked headers still 1
+	if err := manager.ReleaseFromInventory(); err != nil || intent.DestTxHash != "" {
+		t.Fatalf("Expected no release before the headers confirm the lock, got %q (%v)", intent.DestTxHash, err)
+	}
+	if err := manager.SyncExternalChains(); err != nil {
+		t.Fatalf("SyncExternalChains failed: %v", err)
+	}
+	if err := manager.ReleaseFromInventory(); err != nil || !intent.EarlyRelease {
+		t.Fatalf("Expected early release at 3 tracked confirmations, got %s (%v)", intent.Status, err)
+	}
+}
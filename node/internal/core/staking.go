@@ -0,0 +1,282 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Storage key prefixes for staking state.
+const (
+	stakeKeyPrefix        = "stake/"      // stake/<address> -> bonded amount (uint64)
+	unbondingKeyPrefix    = "unbonding/"  // unbonding/<address> -> []UnbondingEntry (gob)
+	validatorSetKeyPrefix = "validators/" // validators/<epoch> -> ValidatorSet (gob)
+)
+
+// StakingConfig holds the staking parameters applied by the StateManager.
+type StakingConfig struct {
+	UnbondingDelay    uint64 // Blocks between an unbond and the stake becoming withdrawable
+	MinValidatorStake uint64 // Minimum bonded stake to be part of a validator set snapshot
+	SlashPenaltyBps   uint64 // Share of an equivocating validator's stake that is burned, in basis points
+	JailPeriod        uint64 // Blocks an equivocating validator is excluded from proposing
+	// Share of stake burned when a validator commits to the randomness beacon but does not reveal, in basis points
+	WithholdPenaltyBps uint64
+	// Accept equivocation evidence transactions. Off by default: header signatures are still the
+	// SignDigest placeholder, which anyone can compute for any proposer, so evidence could be forged.
+	EvidenceSlashing bool
+}
+
+// DefaultStakingConfig returns the staking parameters used when none are configured.
+func DefaultStakingConfig() StakingConfig {
+	return StakingConfig{
+		UnbondingDelay:     100,
+		MinValidatorStake:  1,
+		SlashPenaltyBps:    500, // 5%
+		JailPeriod:         1000,
+		WithholdPenaltyBps: 100, // 1%
+	}
+}
+
+// UnbondingEntry is stake that has been unbonded and can be withdrawn from ReleaseHeight on.
+type UnbondingEntry struct {
+	Amount        uint64
+	ReleaseHeight uint64
+}
+
+// Validator is a bonded staker in a validator set snapshot.
+type Validator struct {
+	Address string
+	Stake   uint64
+}
+
+// ValidatorSet is the set of validators eligible to propose during an epoch.
+type ValidatorSet struct {
+	Epoch      uint64
+	Validators []Validator // Sorted by address
+	TotalStake uint64
+}
+
+// stakeKey returns the storage key holding the bonded stake of address.
+func stakeKey(address string) string {
+	return stakeKeyPrefix + address
+}
+
+// unbondingKey returns the storage key holding the unbonding entries of address.
+func unbondingKey(address string) string {
+	return unbondingKeyPrefix + address
+}
+
+// validatorSetKey returns the storage key of the snapshot for epoch. Zero-padded so keys sort by epoch.
+func validatorSetKey(epoch uint64) string {
+	return fmt.Sprintf("%s%020d", validatorSetKeyPrefix, epoch)
+}
+
+// GetStake returns the stake currently bonded by address.
+func GetStake(db StateDB, address string) (uint64, error) {
+	return getStorageUint64(db, stakeKey(address))
+}
+
+// GetUnbonding returns the pending unbonding entries of address, oldest first.
+func GetUnbonding(db StateDB, address string) ([]UnbondingEntry, error) {
+	var entries []UnbondingEntry
+	if _, err := getStorageGob(db, unbondingKey(address), &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// setUnbonding stores the unbonding entries of address, deleting the key when there are none.
+func setUnbonding(db StateDB, address string, entries []UnbondingEntry) error {
+	if len(entries) == 0 {
+		return db.SetStorage(unbondingKey(address), nil)
+	}
+	return setStorageGob(db, unbondingKey(address), entries)
+}
+
+// CurrentValidators builds a validator set from the stake currently bonded in db.
+// Stakers below minStake are left out.
+func CurrentValidators(db StateDB, epoch, minStake uint64) (*ValidatorSet, error) {
+	keys, err := db.StorageKeys(stakeKeyPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stakes: %w", err)
+	}
+	set := &ValidatorSet{Epoch: epoch}
+	for _, key := range keys {
+		stake, err := getStorageUint64(db, key)
+		if err != nil {
+			return nil, err
+		}
+		if stake == 0 || stake < minStake {
+			continue
+		}
+		set.Validators = append(set.Validators, Validator{Address: strings.TrimPrefix(key, stakeKeyPrefix), Stake: stake})
+		set.TotalStake += stake
+	}
+	sort.Slice(set.Validators, func(i, j int) bool { return set.Validators[i].Address < set.Validators[j].Address })
+	return set, nil
+}
+
+// ValidatorSetAt returns the snapshot taken for epoch, or nil if none was taken.
+func ValidatorSetAt(db StateDB, epoch uint64) (*ValidatorSet, error) {
+	set := &ValidatorSet{}
+	found, err := getStorageGob(db, validatorSetKey(epoch), set)
+	if err != nil || !found {
+		return nil, err
+	}
+	return set, nil
+}
+
+// ProposerFor selects the proposer for block number from the set, with probability proportional to
+// stake. The selection is a pure function of (seed, number) so every node derives the same proposer;
+// consensus uses the beacon output for the block as seed (see RandomnessAt).
+// Returns "" for an empty set.
+func (vs *ValidatorSet) ProposerFor(seed Hash, number uint64) string {
+	if vs == nil || vs.TotalStake == 0 {
+		return ""
+	}
+	var buf [len(Hash{}) + 8]byte
+	copy(buf[:], seed[:])
+	binary.BigEndian.PutUint64(buf[len(Hash{}):], number)
+	digest := sha256.Sum256(buf[:])
+	target := binary.BigEndian.Uint64(digest[:8]) % vs.TotalStake
+
+	var cumulative uint64
+	for _, v := range vs.Validators {
+		cumulative += v.Stake
+		if target < cumulative {
+			return v.Address
+		}
+	}
+	return vs.Validators[len(vs.Validators)-1].Address // Unreachable if TotalStake is consistent
+}
+
+// WithoutJailed returns a copy of the set without the validators jailed at block number.
+func (vs *ValidatorSet) WithoutJailed(db StateDB, number uint64) (*ValidatorSet, error) {
+	if vs == nil {
+		return nil, nil
+	}
+	out := &ValidatorSet{Epoch: vs.Epoch}
+	for _, v := range vs.Validators {
+		jailed, err := IsJailed(db, v.Address, number)
+		if err != nil {
+			return nil, err
+		}
+		if jailed {
+			continue
+		}
+		out.Validators = append(out.Validators, v)
+		out.TotalStake += v.Stake
+	}
+	return out, nil
+}
+
+// Contains reports whether address is a validator in the set.
+func (vs *ValidatorSet) Contains(address string) bool {
+	if vs == nil {
+		return false
+	}
+	i := sort.Search(len(vs.Validators), func(i int) bool { return vs.Validators[i].Address >= address })
+	return i < len(vs.Validators) && vs.Validators[i].Address == address
+}
+
+// --- Staking transactions ---
+
+// applyBond moves tx.Amount from the sender's balance into their stake.
+func (sm *StateManager) applyBond(tx *Transaction, spendable uint64) error {
+	if tx.Amount == 0 {
+		return fmt.Errorf("bond amount must be positive")
+	}
+	if spendable < tx.Amount {
+		return fmt.Errorf("insufficient funds: sender %s has %d, needs %d", tx.SenderID, spendable+tx.Fee, tx.Amount+tx.Fee)
+	}
+	stake, err := GetStake(sm.db, tx.SenderID)
+	if err != nil {
+		return fmt.Errorf("failed to get stake of %s: %w", tx.SenderID, err)
+	}
+	if err := sm.debit(tx.SenderID, tx.Amount); err != nil {
+		return fmt.Errorf("failed to set sender balance: %w", err)
+	}
+	if err := setStorageUint64(sm.db, stakeKey(tx.SenderID), stake+tx.Amount); err != nil {
+		return fmt.Errorf("failed to set stake of %s: %w", tx.SenderID, err)
+	}
+	return nil
+}
+
+// applyUnbond removes tx.Amount from the sender's stake and queues it for withdrawal after the
+// unbonding delay.
+func (sm *StateManager) applyUnbond(tx *Transaction) error {
+	if tx.Amount == 0 {
+		return fmt.Errorf("unbond amount must be positive")
+	}
+	stake, err := GetStake(sm.db, tx.SenderID)
+	if err != nil {
+		return fmt.Errorf("failed to get stake of %s: %w", tx.SenderID, err)
+	}
+	if stake < tx.Amount {
+		return fmt.Errorf("insufficient stake: %s has %d bonded, wants to unbond %d", tx.SenderID, stake, tx.Amount)
+	}
+	entries, err := GetUnbonding(sm.db, tx.SenderID)
+	if err != nil {
+		return fmt.Errorf("failed to get unbonding entries of %s: %w", tx.SenderID, err)
+	}
+	entries = append(entries, UnbondingEntry{Amount: tx.Amount, ReleaseHeight: sm.blockNumber() + sm.staking.UnbondingDelay})
+	if err := setStorageUint64(sm.db, stakeKey(tx.SenderID), stake-tx.Amount); err != nil {
+		return fmt.Errorf("failed to set stake of %s: %w", tx.SenderID, err)
+	}
+	if err := setUnbonding(sm.db, tx.SenderID, entries); err != nil {
+		return fmt.Errorf("failed to set unbonding entries of %s: %w", tx.SenderID, err)
+	}
+	return nil
+}
+
+// applyWithdraw credits all matured unbonding entries of the sender back to their balance.
+func (sm *StateManager) applyWithdraw(tx *Transaction) error {
+	entries, err := GetUnbonding(sm.db, tx.SenderID)
+	if err != nil {
+		return fmt.Errorf("failed to get unbonding entries of %s: %w", tx.SenderID, err)
+	}
+	height := sm.blockNumber()
+	var released uint64
+	remaining := make([]UnbondingEntry, 0, len(entries))
+	for _, e := range entries {
+		if e.ReleaseHeight <= height {
+			released += e.Amount
+		} else {
+			remaining = append(remaining, e)
+		}
+	}
+	if released == 0 {
+		return fmt.Errorf("nothing to withdraw for %s at height %d", tx.SenderID, height)
+	}
+	if err := setUnbonding(sm.db, tx.SenderID, remaining); err != nil {
+		return fmt.Errorf("failed to set unbonding entries of %s: %w", tx.SenderID, err)
+	}
+	if err := sm.credit(tx.SenderID, released); err != nil {
+		return fmt.Errorf("failed to credit withdrawn stake: %w", err)
+	}
+	return nil
+}
+
+// SnapshotValidatorSet records the currently bonded stake as the validator set for epoch, leaving
+// out validators still jailed at the first block of the epoch.
+// Run by the "validators" epoch end hook; genesis setup may call it for epoch 0.
+func (sm *StateManager) SnapshotValidatorSet(epoch uint64) (*ValidatorSet, error) {
+	set, err := CurrentValidators(sm.db, epoch, sm.staking.MinValidatorStake)
+	if err != nil {
+		return nil, err
+	}
+	if set, err = set.WithoutJailed(sm.db, sm.epochs.FirstBlock(epoch)); err != nil {
+		return nil, err
+	}
+	if err := setStorageGob(sm.db, validatorSetKey(epoch), set); err != nil {
+		return nil, fmt.Errorf("failed to store validator set for epoch %d: %w", epoch, err)
+	}
+	return set, nil
+}
+
+// ValidatorSet returns the validator set snapshot for epoch, or nil if none was taken.
+func (sm *StateManager) ValidatorSet(epoch uint64) (*ValidatorSet, error) {
+	return ValidatorSetAt(sm.db, epoch)
+}
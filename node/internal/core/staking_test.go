@@ -0,0 +1,204 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// signedTx builds and signs a transaction of the given type.
+func signedTx(txType TransactionType, nonce uint64, sender string, amount uint64) *Transaction {
+	tx := NewBaseTransaction(txType, nonce, sender, "", amount)
+	_ = tx.Sign()
+	return tx
+}
+
+func TestStaking_BondUnbondWithdraw(t *testing.T) {
+	db := NewInMemoryStateDB()
+	_ = db.SetBalance("validatorV", 1000)
+	sm := NewStateManager(db)
+	sm.SetStakingConfig(StakingConfig{UnbondingDelay: 10, MinValidatorStake: 1})
+
+	t.Run("Bond", func(t *testing.T) {
+		if err := sm.ApplyTransaction(signedTx(TxTypeBond, 0, "validatorV", 600)); err != nil {
+			t.Fatalf("Bond failed: %v", err)
+		}
+		bal, _ := db.GetBalance("validatorV")
+		stake, _ := GetStake(db, "validatorV")
+		if bal != 400 || stake != 600 {
+			t.Errorf("Expected balance 400 and stake 600, got %d and %d", bal, stake)
+		}
+	})
+
+	t.Run("BondExceedingBalance", func(t *testing.T) {
+		if err := sm.ApplyTransaction(signedTx(TxTypeBond, 1, "validatorV", 401)); err == nil {
+			t.Errorf("Expected error bonding more than the balance")
+		}
+	})
+
+	t.Run("UnbondExceedingStake", func(t *testing.T) {
+		if err := sm.ApplyTransaction(signedTx(TxTypeUnbond, 1, "validatorV", 601)); err == nil {
+			t.Errorf("Expected error unbonding more than the stake")
+		}
+	})
+
+	t.Run("Unbond", func(t *testing.T) {
+		sm.BeginBlock(&BlockHeader{Number: 5, Proposer: "proposerP"})
+		if err := sm.ApplyTransaction(signedTx(TxTypeUnbond, 1, "validatorV", 200)); err != nil {
+			t.Fatalf("Unbond failed: %v", err)
+		}
+		stake, _ := GetStake(db, "validatorV")
+		entries, _ := GetUnbonding(db, "validatorV")
+		if stake != 400 || len(entries) != 1 || entries[0] != (UnbondingEntry{Amount: 200, ReleaseHeight: 15}) {
+			t.Errorf("Expected stake 400 and one entry releasing 200 at 15, got %d and %+v", stake, entries)
+		}
+	})
+
+	t.Run("WithdrawBeforeRelease", func(t *testing.T) {
+		sm.BeginBlock(&BlockHeader{Number: 14, Proposer: "proposerP"})
+		if err := sm.ApplyTransaction(signedTx(TxTypeWithdraw, 2, "validatorV", 0)); err == nil {
+			t.Errorf("Expected error withdrawing before the unbonding delay")
+		}
+	})
+
+	t.Run("WithdrawAfterRelease", func(t *testing.T) {
+		sm.BeginBlock(&BlockHeader{Number: 15, Proposer: "proposerP"})
+		if err := sm.ApplyTransaction(signedTx(TxTypeWithdraw, 2, "validatorV", 0)); err != nil {
+			t.Fatalf("Withdraw failed: %v", err)
+		}
+		bal, _ := db.GetBalance("validatorV")
+		entries, _ := GetUnbonding(db, "validatorV")
+		if bal != 600 || len(entries) != 0 {
+			t.Errorf("Expected balance 600 and no pending entries, got %d and %+v", bal, entries)
+		}
+	})
+}
+
+func TestStaking_ValidatorSetSnapshot(t *testing.T) {
+	db := NewInMemoryStateDB()
+	sm := NewStateManager(db)
+	sm.SetStakingConfig(StakingConfig{UnbondingDelay: 1, MinValidatorStake: 50})
+	_ = sm.Epochs().SetLength(4)
+	for addr, stake := range map[string]uint64{"valB": 300, "valA": 100, "dust": 10} {
+		_ = setStorageUint64(db, stakeKey(addr), stake)
+	}
+
+	// Blocks 0-2 of epoch 0 take no snapshot; block 3 snapshots epoch 1
+	for n := uint64(1); n <= 3; n++ {
+		if err := sm.ApplyBlock(NewBlock(&BlockHeader{Number: n}, nil)); err != nil {
+			t.Fatalf("ApplyBlock %d failed: %v", n, err)
+		}
+		if set, _ := sm.ValidatorSet(1); (set != nil) != (n == 3) {
+			t.Fatalf("After block %d, snapshot for epoch 1 present = %v", n, set != nil)
+		}
+	}
+
+	set, err := sm.ValidatorSet(1)
+	if err != nil {
+		t.Fatalf("ValidatorSet failed: %v", err)
+	}
+	if set.Epoch != 1 || set.TotalStake != 400 || len(set.Validators) != 2 {
+		t.Fatalf("Unexpected snapshot %+v", set)
+	}
+	if set.Validators[0].Address != "valA" || set.Validators[1].Address != "valB" {
+		t.Errorf("Expected validators sorted by address, got %+v", set.Validators)
+	}
+	if set.Contains("dust") || !set.Contains("valB") {
+		t.Errorf("Expected stake below the minimum to be excluded")
+	}
+
+	// Later bonding does not change an existing snapshot
+	_ = setStorageUint64(db, stakeKey("valC"), 1000)
+	if again, _ := sm.ValidatorSet(1); again.TotalStake != 400 {
+		t.Errorf("Snapshot changed after bonding: %+v", again)
+	}
+}
+
+func TestValidatorSet_ProposerFor(t *testing.T) {
+	set := &ValidatorSet{
+		Validators: []Validator{{Address: "heavy", Stake: 900}, {Address: "light", Stake: 100}},
+		TotalStake: 1000,
+	}
+
+	t.Run("Deterministic", func(t *testing.T) {
+		seed := Hash{42}
+		if set.ProposerFor(seed, 7) != set.ProposerFor(seed, 7) {
+			t.Errorf("Expected the same proposer for the same seed and height")
+		}
+	})
+
+	t.Run("ProportionalToStake", func(t *testing.T) {
+		counts := map[string]int{}
+		const rounds = 2000
+		for i := 0; i < rounds; i++ {
+			seed := Hash{byte(i), byte(i >> 8)}
+			counts[set.ProposerFor(seed, uint64(i))]++
+		}
+		share := float64(counts["heavy"]) / rounds
+		if share < 0.85 || share > 0.95 {
+			t.Errorf("Expected heavy validator to propose ~90%% of blocks, got %.3f (%v)", share, counts)
+		}
+	})
+
+	t.Run("EmptySet", func(t *testing.T) {
+		if p := (&ValidatorSet{}).ProposerFor(Hash{1}, 1); p != "" {
+			t.Errorf("Expected no proposer for an empty set, got %s", p)
+		}
+		var nilSet *ValidatorSet
+		if p := nilSet.ProposerFor(Hash{1}, 1); p != "" {
+			t.Errorf("Expected no proposer for a nil set, got %s", p)
+		}
+	})
+}
+
+func TestVerifyHeader_ProposerEligibility(t *testing.T) {
+	db := NewInMemoryStateDB()
+	sm := NewStateManager(db)
+	consensus := NewPathIntegralConsensus(sm)
+	now := time.Unix(1700000100, 0)
+	consensus.now = func() time.Time { return now }
+	parent := &BlockHeader{Number: 7, Timestamp: now.Add(-10 * time.Second), GasLimit: 1_000_000}
+	headerBy := func(proposer string) *BlockHeader {
+		h := &BlockHeader{ParentHash: parent.Hash(), Number: 8, Timestamp: now.Add(-5 * time.Second), GasLimit: 1_000_000}
+		_ = h.Sign(proposer)
+		return h
+	}
+
+	t.Run("AnyProposerWithoutValidators", func(t *testing.T) {
+		if err := consensus.VerifyHeader(headerBy("anyone"), parent); err != nil {
+			t.Errorf("Expected any proposer to be accepted before bonding, got %v", err)
+		}
+	})
+
+	for i := 0; i < 4; i++ {
+		_ = setStorageUint64(db, stakeKey(fmt.Sprintf("val%d", i)), 100)
+	}
+	if _, err := sm.SnapshotValidatorSet(0); err != nil {
+		t.Fatalf("SnapshotValidatorSet failed: %v", err)
+	}
+	expected, err := consensus.ExpectedProposer(parent)
+	if err != nil || expected == "" {
+		t.Fatalf("Expected a selected proposer, got %q (%v)", expected, err)
+	}
+
+	t.Run("SelectedProposer", func(t *testing.T) {
+		if err := consensus.VerifyHeader(headerBy(expected), parent); err != nil {
+			t.Errorf("Expected selected proposer to be accepted, got %v", err)
+		}
+	})
+
+	t.Run("IneligibleProposer", func(t *testing.T) {
+		other := "val0"
+		if other == expected {
+			other = "val1"
+		}
+		err := consensus.VerifyHeader(headerBy(other), parent)
+		if !errors.Is(err, ErrIneligibleProposer) {
+			t.Fatalf("Expected ErrIneligibleProposer, got %v", err)
+		}
+		if PeerPenalty(err) != PenaltySevere {
+			t.Errorf("Expected severe penalty for an ineligible proposer")
+		}
+	})
+}
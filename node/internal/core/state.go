@@ -1,7 +1,13 @@
 package core
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 )
 
@@ -12,6 +18,17 @@ type StateDB interface {
 	SetBalance(address string, balance uint64) error
 	GetNonce(address string) (uint64, error)
 	SetNonce(address string, nonce uint64) error
+	// GetStorage returns the value stored under key, or nil if absent. Native functions
+	// (staking, escrow, ...) keep their records here under namespaced keys like "stake/<address>".
+	GetStorage(key string) ([]byte, error)
+	// SetStorage stores value under key; a nil or empty value deletes the key.
+	SetStorage(key string, value []byte) error
+	// StorageKeys lists all keys with the given prefix in sorted order.
+	StorageKeys(prefix string) ([]string, error)
+	// Root returns a commitment to the entire state, compared against BlockHeader.StateRoot.
+	Root() (Hash, error)
+	// Copy returns an independent copy of the state, e.g. to execute a block speculatively.
+	Copy() StateDB
 	// TODO: Add methods for contract storage, code, etc. later
 }
 
@@ -21,7 +38,8 @@ type InMemoryStateDB struct {
 	mu       sync.RWMutex // Mutex to protect concurrent access
 	balances map[string]uint64
 	nonces   map[string]uint64
-	// TODO: Add maps for contract storage, code, etc.
+	storage  map[string][]byte
+	// TODO: Add maps for contract code, etc.
 }
 
 // NewInMemoryStateDB creates a new in-memory state database.
@@ -29,6 +47,7 @@ func NewInMemoryStateDB() *InMemoryStateDB {
 	return &InMemoryStateDB{
 		balances: make(map[string]uint64),
 		nonces:   make(map[string]uint64),
+		storage:  make(map[string][]byte),
 	}
 }
 
@@ -66,9 +85,165 @@ func (db *InMemoryStateDB) SetNonce(address string, nonce uint64) error {
 	return nil
 }
 
+// GetStorage retrieves the value stored under key. Returns nil if the key is not set.
+func (db *InMemoryStateDB) GetStorage(key string) ([]byte, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	value, ok := db.storage[key]
+	if !ok {
+		return nil, nil
+	}
+	return append([]byte(nil), value...), nil
+}
+
+// SetStorage stores a copy of value under key. An empty value deletes the key.
+func (db *InMemoryStateDB) SetStorage(key string, value []byte) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if len(value) == 0 {
+		delete(db.storage, key)
+		return nil
+	}
+	db.storage[key] = append([]byte(nil), value...)
+	return nil
+}
+
+// StorageKeys lists the storage keys starting with prefix, sorted.
+func (db *InMemoryStateDB) StorageKeys(prefix string) ([]string, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	keys := make([]string, 0)
+	for key := range db.storage {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Root computes a deterministic commitment to all balances, nonces and storage.
+// Placeholder for a Merkle-Patricia trie root: SHA-256 over the sorted account entries.
+func (db *InMemoryStateDB) Root() (Hash, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	addresses := make(map[string]struct{}, len(db.balances)+len(db.nonces))
+	for addr, bal := range db.balances {
+		if bal != 0 {
+			addresses[addr] = struct{}{}
+		}
+	}
+	for addr, nonce := range db.nonces {
+		if nonce != 0 {
+			addresses[addr] = struct{}{}
+		}
+	}
+	sorted := make([]string, 0, len(addresses))
+	for addr := range addresses {
+		sorted = append(sorted, addr)
+	}
+	sort.Strings(sorted)
+
+	var buf bytes.Buffer
+	for _, addr := range sorted {
+		_ = binary.Write(&buf, binary.BigEndian, uint32(len(addr)))
+		buf.WriteString(addr)
+		_ = binary.Write(&buf, binary.BigEndian, db.balances[addr])
+		_ = binary.Write(&buf, binary.BigEndian, db.nonces[addr])
+	}
+
+	keys := make([]string, 0, len(db.storage))
+	for key := range db.storage {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	buf.WriteString("storage")
+	for _, key := range keys {
+		_ = binary.Write(&buf, binary.BigEndian, uint32(len(key)))
+		buf.WriteString(key)
+		_ = binary.Write(&buf, binary.BigEndian, uint32(len(db.storage[key])))
+		buf.Write(db.storage[key])
+	}
+	return sha256.Sum256(buf.Bytes()), nil
+}
+
+// Copy returns a deep copy of the in-memory state.
+func (db *InMemoryStateDB) Copy() StateDB {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	cpy := NewInMemoryStateDB()
+	for addr, bal := range db.balances {
+		cpy.balances[addr] = bal
+	}
+	for addr, nonce := range db.nonces {
+		cpy.nonces[addr] = nonce
+	}
+	for key, value := range db.storage {
+		cpy.storage[key] = append([]byte(nil), value...)
+	}
+	return cpy
+}
+
+// --- Storage helpers ---
+
+// getStorageUint64 reads a big-endian uint64 stored under key (0 if absent).
+func getStorageUint64(db StateDB, key string) (uint64, error) {
+	value, err := db.GetStorage(key)
+	if err != nil || len(value) == 0 {
+		return 0, err
+	}
+	if len(value) != 8 {
+		return 0, fmt.Errorf("storage key %s holds %d bytes, expected 8", key, len(value))
+	}
+	return binary.BigEndian.Uint64(value), nil
+}
+
+// setStorageUint64 stores v under key; zero deletes the key.
+func setStorageUint64(db StateDB, key string, v uint64) error {
+	if v == 0 {
+		return db.SetStorage(key, nil)
+	}
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], v)
+	return db.SetStorage(key, buf[:])
+}
+
+// getStorageGob decodes the gob-encoded value under key into out. Returns false if the key is absent.
+func getStorageGob(db StateDB, key string, out interface{}) (bool, error) {
+	value, err := db.GetStorage(key)
+	if err != nil || len(value) == 0 {
+		return false, err
+	}
+	if err := gob.NewDecoder(bytes.NewReader(value)).Decode(out); err != nil {
+		return false, fmt.Errorf("failed to decode storage key %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// setStorageGob gob-encodes v and stores it under key.
+func setStorageGob(db StateDB, key string, v interface{}) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return fmt.Errorf("failed to encode storage key %s: %w", key, err)
+	}
+	return db.SetStorage(key, buf.Bytes())
+}
+
 // StateManager orchestrates state changes by applying transactions.
 type StateManager struct {
 	db StateDB
+	// Header of the block currently being executed; fees are credited to its proposer
+	block   *BlockHeader
+	staking StakingConfig
+	epochs  *EpochManager     // Shared with copies so registered hooks apply to every execution
+	natives *NativeRegistry   // Shared with copies, like epochs
+	params  *ParameterManager // Bounds for TxTypeParamChange; shared with copies, like epochs
+	logs    []Log             // Logs emitted in the current block
+	// Header of the last block applied with ApplyBlock, i.e. the block whose post-state db holds;
+	// nil while the state is still at genesis
+	applied *BlockHeader
 }
 
 // NewStateManager creates a new state manager.
@@ -77,11 +252,124 @@ func NewStateManager(db StateDB) *StateManager {
 		// Or handle this more gracefully depending on requirements
 		panic("StateDB cannot be nil for StateManager")
 	}
-	return &StateManager{db: db}
+	epochs, _ := NewEpochManager(DefaultEpochLength)
+	registerCoreEpochHooks(epochs)
+	natives := NewNativeRegistry()
+	registerCoreNatives(natives)
+	return &StateManager{db: db, staking: DefaultStakingConfig(), epochs: epochs, natives: natives}
+}
+
+// DB returns the underlying state database.
+func (sm *StateManager) DB() StateDB {
+	return sm.db
+}
+
+// Copy returns a state manager over an independent copy of the state, with the same block context.
+// Used to execute blocks without touching the live state (validation, block production).
+func (sm *StateManager) Copy() *StateManager {
+	return &StateManager{db: sm.db.Copy(), block: sm.block, staking: sm.staking, epochs: sm.epochs, natives: sm.natives, params: sm.params, applied: sm.applied}
+}
+
+// AppliedBlock returns the header of the last block applied with ApplyBlock, or nil if no block
+// has been applied since genesis. Only children of this block can be executed on the state.
+func (sm *StateManager) AppliedBlock() *BlockHeader {
+	return sm.applied
+}
+
+// Epochs returns the epoch manager; subsystems register their epoch hooks on it.
+func (sm *StateManager) Epochs() *EpochManager {
+	return sm.epochs
+}
+
+// SetParameterManager sets the parameters TxTypeParamChange transactions may change and the bounds
+// their values are checked against. Without one, every parameter change is rejected.
+func (sm *StateManager) SetParameterManager(pm *ParameterManager) {
+	sm.params = pm
+}
+
+// ParameterManager returns the parameters TxTypeParamChange transactions may change, or nil.
+func (sm *StateManager) ParameterManager() *ParameterManager {
+	return sm.params
+}
+
+// SetStakingConfig replaces the staking parameters.
+func (sm *StateManager) SetStakingConfig(cfg StakingConfig) {
+	sm.staking = cfg
+}
+
+// StakingConfig returns the staking parameters in use.
+func (sm *StateManager) StakingConfig() StakingConfig {
+	return sm.staking
+}
+
+// BeginBlock sets the block context for subsequent ApplyTransaction calls. On the first block of an
+// epoch it runs the epoch begin hooks. A nil header clears the context.
+func (sm *StateManager) BeginBlock(header *BlockHeader) error {
+	sm.block = header
+	sm.logs = nil
+	if header == nil || header.Number == 0 || !sm.epochs.IsFirstBlock(header.Number) {
+		return nil
+	}
+	if err := sm.beginEpoch(header); err != nil {
+		return fmt.Errorf("failed to begin epoch at block %d: %w", header.Number, err)
+	}
+	return nil
+}
+
+// EndBlock runs the end-of-block state transitions for the current block context. After the last
+// block of an epoch it runs the epoch end hooks (beacon settlement, validator rotation, ...) and
+// records the epoch summary.
+func (sm *StateManager) EndBlock() error {
+	if sm.block == nil || !sm.epochs.IsLastBlock(sm.block.Number) {
+		return nil
+	}
+	if err := sm.endEpoch(sm.block); err != nil {
+		return fmt.Errorf("failed to end epoch at block %d: %w", sm.block.Number, err)
+	}
+	return nil
+}
+
+// ApplyBlock runs BeginBlock, executes all transactions of a block in order, stopping at the first
+// failure, then EndBlock.
+// Callers that must not modify live state on failure should apply to a Copy first.
+func (sm *StateManager) ApplyBlock(block *Block) error {
+	if block == nil || block.Header == nil {
+		return fmt.Errorf("cannot apply nil block or header")
+	}
+	if err := sm.BeginBlock(block.Header); err != nil {
+		return err
+	}
+	for i, tx := range block.Transactions {
+		if err := sm.ApplyTransaction(tx); err != nil {
+			return fmt.Errorf("transaction %d of block %d: %w", i, block.Header.Number, err)
+		}
+	}
+	if err := sm.EndBlock(); err != nil {
+		return err
+	}
+	sm.applied = block.Header
+	return nil
+}
+
+// checkParentState returns an error unless the state holds the post-state of the block's parent:
+// the last applied block, or genesis for block 1 when nothing has been applied yet.
+func (sm *StateManager) checkParentState(header *BlockHeader) error {
+	if sm.applied == nil {
+		if header.Number != 1 {
+			return newValidationError(header.Number, ErrParentStateUnavailable, "state is at genesis")
+		}
+		return nil
+	}
+	if header.ParentHash != sm.applied.Hash() {
+		return newValidationError(header.Number, ErrParentStateUnavailable, "state is at block %d (%s)", sm.applied.Number, sm.applied.Hash())
+	}
+	return nil
 }
 
 // ApplyTransaction validates a transaction against the current state and updates the state accordingly.
-// Placeholder implementation - only handles basic transfer logic for now.
+// Checks the signatures (see VerifyAuthorization), nonce and that the sender can pay the fee, then
+// runs the native function registered for the transaction type (see NativeRegistry). The fee is
+// credited to the proposer of the current block (see BeginBlock); without a block context it is burned.
 func (sm *StateManager) ApplyTransaction(tx *Transaction) error {
 	if tx == nil {
 		return fmt.Errorf("cannot apply nil transaction")
@@ -92,13 +380,13 @@ func (sm *StateManager) ApplyTransaction(tx *Transaction) error {
 		return fmt.Errorf("basic transaction validation failed: %w", err)
 	}
 
-	// --- State Transition Logic (Minimal for Transfer) ---
+	// --- State Transition Logic ---
 
-	// TODO: Add signature verification check here once implemented properly
-	// validSig, err := tx.VerifySignature()
-	// if err != nil || !validSig { ... }
+	if err := sm.VerifyAuthorization(tx); err != nil {
+		return err
+	}
 
-	// Get current state for sender and recipient
+	// Get current state for sender
 	senderNonce, err := sm.db.GetNonce(tx.SenderID)
 	if err != nil {
 		return fmt.Errorf("failed to get sender nonce for %s: %w", tx.SenderID, err)
@@ -107,39 +395,103 @@ func (sm *StateManager) ApplyTransaction(tx *Transaction) error {
 	if err != nil {
 		return fmt.Errorf("failed to get sender balance for %s: %w", tx.SenderID, err)
 	}
-	recipientBalance, err := sm.db.GetBalance(tx.RecipientID)
-	if err != nil {
-		// It's okay if recipient doesn't exist yet, balance is 0
-		recipientBalance = 0
-	}
-
-	// TODO: Validate Nonce
-	// if tx.Nonce != senderNonce {
-	//  return fmt.Errorf("invalid nonce: expected %d, got %d", senderNonce, tx.Nonce)
-	// }
 
-	// TODO: Validate Balance (ensure senderBalance >= tx.Amount)
-	// if senderBalance < tx.Amount {
-	//  return fmt.Errorf("insufficient funds: sender %s has %d, needs %d", tx.SenderID, senderBalance, tx.Amount)
-	// }
+	if tx.Nonce != senderNonce {
+		return fmt.Errorf("invalid nonce: expected %d, got %d", senderNonce, tx.Nonce)
+	}
+	if senderBalance < tx.Fee {
+		return fmt.Errorf("insufficient funds: sender %s has %d, needs %d for the fee", tx.SenderID, senderBalance, tx.Fee)
+	}
 
-	// Perform state updates (minimal transfer logic)
-	newSenderBalance := senderBalance - tx.Amount
-	newRecipientBalance := recipientBalance + tx.Amount
-	newSenderNonce := senderNonce + 1
+	// Type-specific logic. Native functions check all their preconditions before writing anything,
+	// so a failing transaction leaves the state untouched.
+	logs, err := sm.applyNative(tx, senderBalance-tx.Fee)
+	if err != nil {
+		return err
+	}
 
-	if err := sm.db.SetBalance(tx.SenderID, newSenderBalance); err != nil {
+	// Charge the fee and bump the nonce. The handler left at least tx.Fee in the sender's balance.
+	senderBalance, err = sm.db.GetBalance(tx.SenderID)
+	if err != nil {
+		return fmt.Errorf("failed to get sender balance for %s: %w", tx.SenderID, err)
+	}
+	if err := sm.db.SetBalance(tx.SenderID, senderBalance-tx.Fee); err != nil {
 		return fmt.Errorf("failed to set sender balance: %w", err)
 		// TODO: Consider state rollback mechanisms on partial failure
 	}
-	if err := sm.db.SetBalance(tx.RecipientID, newRecipientBalance); err != nil {
-		return fmt.Errorf("failed to set recipient balance: %w", err)
-		// TODO: Consider state rollback mechanisms on partial failure
+	if sm.block != nil {
+		if err := sm.credit(sm.block.Proposer, tx.Fee); err != nil {
+			return fmt.Errorf("failed to credit fee to proposer: %w", err)
+		}
 	}
-	if err := sm.db.SetNonce(tx.SenderID, newSenderNonce); err != nil {
+	if err := sm.db.SetNonce(tx.SenderID, senderNonce+1); err != nil {
 		return fmt.Errorf("failed to set sender nonce: %w", err)
 		// TODO: Consider state rollback mechanisms on partial failure
 	}
+	if sm.block != nil {
+		if err := sm.recordEpochTransaction(tx); err != nil {
+			return fmt.Errorf("failed to record transaction in epoch stats: %w", err)
+		}
+	}
+	if len(logs) > 0 {
+		txHash, err := tx.Hash()
+		if err != nil {
+			return fmt.Errorf("failed to hash transaction: %w", err)
+		}
+		for _, l := range logs {
+			l.TxHash = txHash
+			sm.logs = append(sm.logs, l)
+		}
+	}
 
 	return nil // Success
 }
+
+// applyTransfer moves tx.Amount from sender to recipient (see validateTransfer).
+func (sm *StateManager) applyTransfer(tx *Transaction) error {
+	if tx.Amount == 0 {
+		return nil
+	}
+	if err := sm.debit(tx.SenderID, tx.Amount); err != nil {
+		return fmt.Errorf("failed to set sender balance: %w", err)
+	}
+	if err := sm.credit(tx.RecipientID, tx.Amount); err != nil {
+		return fmt.Errorf("failed to set recipient balance: %w", err)
+	}
+	return nil
+}
+
+// blockNumber returns the number of the block being executed, or 0 without a block context.
+func (sm *StateManager) blockNumber() uint64 {
+	if sm.block == nil {
+		return 0
+	}
+	return sm.block.Number
+}
+
+// debit subtracts amount from the balance of address, failing if the balance is insufficient.
+func (sm *StateManager) debit(address string, amount uint64) error {
+	balance, err := sm.db.GetBalance(address)
+	if err != nil {
+		return err
+	}
+	if balance < amount {
+		return fmt.Errorf("insufficient balance for %s: has %d, needs %d", address, balance, amount)
+	}
+	return sm.db.SetBalance(address, balance-amount)
+}
+
+// credit adds amount to the balance of address. Crediting zero or an empty address is a no-op.
+func (sm *StateManager) credit(address string, amount uint64) error {
+	if address == "" || amount == 0 {
+		return nil
+	}
+	balance, err := sm.db.GetBalance(address)
+	if err != nil {
+		return err
+	}
+	if balance+amount < balance {
+		return fmt.Errorf("balance overflow for %s", address)
+	}
+	return sm.db.SetBalance(address, balance+amount)
+}
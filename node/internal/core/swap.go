@@ -0,0 +1,596 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Atomic swaps
+//
+// Besides bridging through the netting epochs and inventory of a BridgeManager, users can swap
+// native QRL for an asset on an external chain directly with a counterparty, without trusting the
+// bridge. Both parties lock their side under the same hash lock: an escrow on QRL (see escrow.go)
+// and an HTLC on the external chain. The initiator, who chose the secret, locks first; the
+// participant locks once the initiator's lock is confirmed. The initiator claims the participant's
+// lock, revealing the secret, which the participant then uses to claim the initiator's lock. The
+// participant's lock must time out well before the initiator's so that the participant can still
+// claim after the secret is revealed at the last moment. If the swap stalls, each party takes its
+// lock back once it times out.
+//
+// A SwapManager runs the protocol for one party. It watches both locks and submits claims and
+// refunds on the external chain through an HTLCAdapter. QRL transactions need the party's
+// signature, so the manager returns them for the party to sign and submit, numbered after the
+// sender's nonce in the QRL state and the transactions the manager returned before.
+
+// ExternalHTLC is a hash-time-locked contract on an external chain.
+type ExternalHTLC struct {
+	HashLock  Hash
+	Sender    string // Account the asset is locked from and refunded to
+	Recipient string // Account paid by a claim
+	Asset     string
+	Amount    uint64
+	Timeout   uint64 // First height at which the lock can no longer be claimed but can be refunded
+	TxHash    string // Transaction that placed the lock
+	Status    EscrowStatus
+	Preimage  []byte // Revealed preimage once claimed
+}
+
+// HTLCAdapter is a ChainAdapter for a chain with an HTLC contract, which identifies locks by their
+// hash lock. Claims and refunds pay the recipient and sender respectively, whoever submits them.
+type HTLCAdapter interface {
+	ChainAdapter
+	// LockHTLC broadcasts a lock of htlc.Amount from htlc.Sender and returns its transaction hash.
+	LockHTLC(htlc ExternalHTLC) (string, error)
+	// ClaimHTLC broadcasts a claim of the lock under hashLock with its preimage.
+	ClaimHTLC(hashLock Hash, preimage []byte) (string, error)
+	// RefundHTLC broadcasts a refund of the lock under hashLock.
+	RefundHTLC(hashLock Hash) (string, error)
+	// HTLC returns the lock under hashLock at the chain's head, or nil if none is included.
+	HTLC(hashLock Hash) (*ExternalHTLC, error)
+}
+
+// SwapRole is the part a party plays in a swap.
+type SwapRole uint8
+
+const (
+	SwapInitiator   SwapRole = iota // Chose the secret and locks first
+	SwapParticipant                 // Locks second and learns the secret from the initiator's claim
+)
+
+// String returns the name of the role.
+func (r SwapRole) String() string {
+	switch r {
+	case SwapInitiator:
+		return "Initiator"
+	case SwapParticipant:
+		return "Participant"
+	default:
+		return fmt.Sprintf("SwapRole(%d)", uint8(r))
+	}
+}
+
+// SwapStatus is the progress of a swap as seen by the local party.
+type SwapStatus uint8
+
+const (
+	SwapInitiated SwapStatus = iota // The initiator's lock is placed, the participant's is not yet
+	SwapLocked                      // Both locks are placed
+	SwapRedeemed                    // The participant's lock is claimed, revealing the secret
+	SwapCompleted                   // The local party claimed the counterparty's lock
+	SwapRefunded                    // The local party took its lock back
+)
+
+// String returns the name of the status.
+func (s SwapStatus) String() string {
+	switch s {
+	case SwapInitiated:
+		return "Initiated"
+	case SwapLocked:
+		return "Locked"
+	case SwapRedeemed:
+		return "Redeemed"
+	case SwapCompleted:
+		return "Completed"
+	case SwapRefunded:
+		return "Refunded"
+	default:
+		return fmt.Sprintf("SwapStatus(%d)", uint8(s))
+	}
+}
+
+// IsTerminal reports whether the local party is done with the swap.
+func (s SwapStatus) IsTerminal() bool {
+	return s == SwapCompleted || s == SwapRefunded
+}
+
+// SwapLeg is the lock one party places in a swap.
+type SwapLeg struct {
+	Chain     ChainID // ChainID_QRL for an escrow, otherwise the chain of an HTLC
+	Asset     string  // NativeAsset on QRL
+	Sender    string  // Party placing the lock, on Chain
+	Recipient string  // Party claiming the lock with the secret, on Chain
+	Amount    uint64
+	Timeout   uint64 // Height on Chain from which the lock can be refunded
+}
+
+// SwapTerms are the terms both parties agree on before locking.
+type SwapTerms struct {
+	HashLock    Hash
+	Initiator   SwapLeg // Placed first, claimed last
+	Participant SwapLeg // Placed second, claimed first
+}
+
+// validate checks that one leg is a QRL escrow and the other an HTLC on an external chain.
+func (t SwapTerms) validate() error {
+	for _, leg := range []SwapLeg{t.Initiator, t.Participant} {
+		if leg.Amount == 0 || leg.Asset == "" || leg.Sender == "" || leg.Recipient == "" {
+			return fmt.Errorf("swap leg on %s needs an asset, a sender, a recipient and a positive amount", leg.Chain)
+		}
+		if leg.Chain == ChainID_QRL && leg.Asset != NativeAsset {
+			return fmt.Errorf("swap leg on QRL must lock %s, not %s", NativeAsset, leg.Asset)
+		}
+	}
+	if (t.Initiator.Chain == ChainID_QRL) == (t.Participant.Chain == ChainID_QRL) {
+		return fmt.Errorf("swap must pair a QRL escrow with an external HTLC, got %s and %s", t.Initiator.Chain, t.Participant.Chain)
+	}
+	return nil
+}
+
+// external returns the leg on the external chain.
+func (t SwapTerms) external() SwapLeg {
+	if t.Initiator.Chain == ChainID_QRL {
+		return t.Participant
+	}
+	return t.Initiator
+}
+
+// AtomicSwap is a swap tracked by a SwapManager.
+type AtomicSwap struct {
+	Terms    SwapTerms
+	Role     SwapRole
+	Secret   []byte // Known to the initiator; learned by the participant once revealed
+	EscrowID Hash   // ID of the QRL escrow once found in state
+	Status   SwapStatus
+	Claimed  bool   // Claim of the counterparty's lock issued
+	Refunded bool   // Refund of the local party's lock issued
+	Nonce    uint64 // Nonce of the last QRL transaction returned for the swap
+}
+
+// own returns the leg the local party locks.
+func (s *AtomicSwap) own() SwapLeg {
+	if s.Role == SwapInitiator {
+		return s.Terms.Initiator
+	}
+	return s.Terms.Participant
+}
+
+// counterparty returns the leg the local party claims.
+func (s *AtomicSwap) counterparty() SwapLeg {
+	if s.Role == SwapInitiator {
+		return s.Terms.Participant
+	}
+	return s.Terms.Initiator
+}
+
+// SwapConfig configures the safety checks of a SwapManager.
+type SwapConfig struct {
+	BlockTimes     map[ChainID]time.Duration // Expected block interval of each chain, QRL included
+	MinClaimWindow time.Duration             // Time the initiator must have to claim the participant's lock
+	SafetyMargin   time.Duration             // Time the initiator's lock must outlast the participant's by
+	Confirmations  uint64                    // Confirmations of an external lock before it is relied on
+}
+
+// DefaultSwapConfig returns margins suited to the example chains.
+func DefaultSwapConfig() SwapConfig {
+	return SwapConfig{
+		BlockTimes: map[ChainID]time.Duration{
+			ChainID_QRL:      10 * time.Second,
+			ChainID_Ethereum: 12 * time.Second,
+			ChainID_Bitcoin:  10 * time.Minute,
+		},
+		MinClaimWindow: 10 * time.Minute,
+		SafetyMargin:   10 * time.Minute,
+		Confirmations:  2,
+	}
+}
+
+// remaining returns the time until leg times out, seen at height on its chain.
+func (c SwapConfig) remaining(leg SwapLeg, height uint64) (time.Duration, error) {
+	blockTime, ok := c.BlockTimes[leg.Chain]
+	if !ok {
+		return 0, fmt.Errorf("no block time configured for %s", leg.Chain)
+	}
+	if leg.Timeout <= height {
+		return 0, nil
+	}
+	return time.Duration(leg.Timeout-height) * blockTime, nil
+}
+
+// checkTimeouts checks, at the given heights of the initiator's and participant's chains, that the
+// initiator has MinClaimWindow to claim the participant's lock and that the participant then has
+// SafetyMargin to claim the initiator's.
+func (c SwapConfig) checkTimeouts(terms SwapTerms, initiatorHeight, participantHeight uint64) error {
+	initiator, err := c.remaining(terms.Initiator, initiatorHeight)
+	if err != nil {
+		return err
+	}
+	participant, err := c.remaining(terms.Participant, participantHeight)
+	if err != nil {
+		return err
+	}
+	if participant < c.MinClaimWindow {
+		return fmt.Errorf("participant's lock times out in %s, less than the claim window of %s", participant, c.MinClaimWindow)
+	}
+	if initiator < participant+c.SafetyMargin {
+		return fmt.Errorf("initiator's lock times out in %s, less than %s after the participant's", initiator, c.SafetyMargin)
+	}
+	return nil
+}
+
+// SwapManager runs atomic swaps for one party. It is safe for concurrent use.
+type SwapManager struct {
+	mu       sync.Mutex
+	db       StateDB // QRL state the escrows are read from
+	cfg      SwapConfig
+	adapters map[ChainID]HTLCAdapter
+	swaps    map[Hash]*AtomicSwap // By hash lock
+	next     map[string]uint64    // Nonce after the last QRL transaction returned for each sender
+}
+
+// NewSwapManager creates a swap manager reading QRL escrows from db.
+func NewSwapManager(db StateDB, cfg SwapConfig) *SwapManager {
+	return &SwapManager{
+		db:       db,
+		cfg:      cfg,
+		adapters: make(map[ChainID]HTLCAdapter),
+		swaps:    make(map[Hash]*AtomicSwap),
+		next:     make(map[string]uint64),
+	}
+}
+
+// RegisterAdapter connects the manager to an external chain.
+func (m *SwapManager) RegisterAdapter(adapter HTLCAdapter) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	chain := adapter.Chain()
+	if chain == ChainID_QRL {
+		return fmt.Errorf("QRL escrows are read from state, not through an adapter")
+	}
+	if _, exists := m.adapters[chain]; exists {
+		return fmt.Errorf("HTLC adapter for %s already registered", chain)
+	}
+	m.adapters[chain] = adapter
+	return nil
+}
+
+// Initiate starts a swap as the initiator with secret, whose hash must be terms.HashLock, checking
+// that the initiator's lock leaves room for the participant's at QRL height qrlHeight. An HTLC is
+// locked right away; for an escrow, the returned transaction must be signed and submitted.
+func (m *SwapManager) Initiate(terms SwapTerms, secret []byte, qrlHeight uint64) (*Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(secret) == 0 || len(secret) > MaxEscrowPreimageSize {
+		return nil, fmt.Errorf("secret must be 1 to %d bytes, got %d", MaxEscrowPreimageSize, len(secret))
+	}
+	if HashLockOf(secret) != terms.HashLock {
+		return nil, fmt.Errorf("secret does not match hash lock %s", terms.HashLock)
+	}
+	swap := &AtomicSwap{Terms: terms, Role: SwapInitiator, Secret: secret, Status: SwapInitiated}
+	if err := m.checkNew(swap); err != nil {
+		return nil, err
+	}
+	height, err := m.height(terms.Initiator.Chain, qrlHeight)
+	if err != nil {
+		return nil, err
+	}
+	remaining, err := m.cfg.remaining(terms.Initiator, height)
+	if err != nil {
+		return nil, err
+	}
+	if remaining < m.cfg.MinClaimWindow+m.cfg.SafetyMargin {
+		return nil, fmt.Errorf("initiator's lock times out in %s, too soon for the participant to lock", remaining)
+	}
+	tx, err := m.lock(swap)
+	if err != nil {
+		return nil, err
+	}
+	m.swaps[terms.HashLock] = swap
+	return tx, nil
+}
+
+// Participate joins a swap as the participant once the initiator's lock matches terms and is
+// confirmed, and the timeouts leave both parties time to claim. An HTLC is locked right away; for
+// an escrow, the returned transaction must be signed and submitted.
+func (m *SwapManager) Participate(terms SwapTerms, qrlHeight uint64) (*Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	swap := &AtomicSwap{Terms: terms, Role: SwapParticipant, Status: SwapLocked}
+	if err := m.checkNew(swap); err != nil {
+		return nil, err
+	}
+	lock, err := m.lookup(swap, terms.Initiator)
+	if err != nil {
+		return nil, err
+	}
+	if lock == nil || !lock.confirmed || lock.status != EscrowOpen {
+		return nil, fmt.Errorf("initiator's lock under %s is not open and confirmed on %s", terms.HashLock, terms.Initiator.Chain)
+	}
+	initiatorHeight, err := m.height(terms.Initiator.Chain, qrlHeight)
+	if err != nil {
+		return nil, err
+	}
+	participantHeight, err := m.height(terms.Participant.Chain, qrlHeight)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.cfg.checkTimeouts(terms, initiatorHeight, participantHeight); err != nil {
+		return nil, err
+	}
+	tx, err := m.lock(swap)
+	if err != nil {
+		return nil, err
+	}
+	m.swaps[terms.HashLock] = swap
+	return tx, nil
+}
+
+// checkNew checks the terms of a swap the manager is about to track. Caller must hold m.mu.
+func (m *SwapManager) checkNew(swap *AtomicSwap) error {
+	if err := swap.Terms.validate(); err != nil {
+		return err
+	}
+	if _, exists := m.swaps[swap.Terms.HashLock]; exists {
+		return fmt.Errorf("swap with hash lock %s already exists", swap.Terms.HashLock)
+	}
+	if _, ok := m.adapters[swap.Terms.external().Chain]; !ok {
+		return fmt.Errorf("no HTLC adapter registered for %s", swap.Terms.external().Chain)
+	}
+	return nil
+}
+
+// lock places the local party's lock: an HTLC through its adapter, or an escrow through the
+// returned transaction. Caller must hold m.mu.
+func (m *SwapManager) lock(swap *AtomicSwap) (*Transaction, error) {
+	leg := swap.own()
+	if leg.Chain == ChainID_QRL {
+		nonce, err := m.txNonce(swap, leg.Sender, false)
+		if err != nil {
+			return nil, err
+		}
+		return NewEscrowCreateTransaction(nonce, leg.Sender, leg.Recipient, leg.Amount, swap.Terms.HashLock, leg.Timeout)
+	}
+	htlc := ExternalHTLC{HashLock: swap.Terms.HashLock, Sender: leg.Sender, Recipient: leg.Recipient, Asset: leg.Asset, Amount: leg.Amount, Timeout: leg.Timeout}
+	if _, err := m.adapters[leg.Chain].LockHTLC(htlc); err != nil {
+		return nil, fmt.Errorf("failed to lock HTLC on %s: %w", leg.Chain, err)
+	}
+	return nil, nil
+}
+
+// txNonce returns the nonce of a QRL transaction of sender for swap. A transaction returned again
+// keeps the nonce it was first returned with while the state has not moved past it, so that only
+// one of its copies can be included. Otherwise it gets the sender's nonce in state, or the one after
+// the last transaction the manager returned for the sender if that is higher. Caller must hold m.mu.
+func (m *SwapManager) txNonce(swap *AtomicSwap, sender string, again bool) (uint64, error) {
+	nonce, err := m.db.GetNonce(sender)
+	if err != nil {
+		return 0, err
+	}
+	if again && swap.Nonce >= nonce {
+		return swap.Nonce, nil
+	}
+	nonce = max(nonce, m.next[sender])
+	m.next[sender] = nonce + 1
+	swap.Nonce = nonce
+	return nonce, nil
+}
+
+// height returns the current height of chain, qrlHeight for QRL. Caller must hold m.mu.
+func (m *SwapManager) height(chain ChainID, qrlHeight uint64) (uint64, error) {
+	if chain == ChainID_QRL {
+		return qrlHeight, nil
+	}
+	adapter, ok := m.adapters[chain]
+	if !ok {
+		return 0, fmt.Errorf("no HTLC adapter registered for %s", chain)
+	}
+	return adapter.Height(), nil
+}
+
+// swapLock is what a SwapManager observes of a leg's lock.
+type swapLock struct {
+	status    EscrowStatus
+	preimage  []byte
+	confirmed bool
+}
+
+// lookup returns the lock placed for leg of swap, or nil if there is none yet. A lock under the
+// swap's hash lock that does not match leg is an error. Caller must hold m.mu.
+func (m *SwapManager) lookup(swap *AtomicSwap, leg SwapLeg) (*swapLock, error) {
+	if leg.Chain == ChainID_QRL {
+		escrow, err := m.findEscrow(swap, leg)
+		if err != nil || escrow == nil {
+			return nil, err
+		}
+		if escrow.Recipient != leg.Recipient || escrow.Amount != leg.Amount || escrow.Timeout != leg.Timeout {
+			return nil, fmt.Errorf("escrow %s does not match the terms of swap %s", escrow.ID, swap.Terms.HashLock)
+		}
+		return &swapLock{status: escrow.Status, preimage: escrow.Preimage, confirmed: true}, nil
+	}
+	adapter := m.adapters[leg.Chain]
+	htlc, err := adapter.HTLC(swap.Terms.HashLock)
+	if err != nil || htlc == nil {
+		return nil, err
+	}
+	if htlc.Sender != leg.Sender || htlc.Recipient != leg.Recipient || htlc.Asset != leg.Asset || htlc.Amount != leg.Amount || htlc.Timeout != leg.Timeout {
+		return nil, fmt.Errorf("HTLC %s on %s does not match the terms of swap %s", htlc.TxHash, leg.Chain, swap.Terms.HashLock)
+	}
+	confirmations, err := adapter.Confirmations(htlc.TxHash)
+	if err != nil {
+		return nil, err
+	}
+	return &swapLock{status: htlc.Status, preimage: htlc.Preimage, confirmed: confirmations >= m.cfg.Confirmations}, nil
+}
+
+// findEscrow returns the QRL escrow of swap, the first one leg's sender created under the swap's
+// hash lock. Caller must hold m.mu.
+func (m *SwapManager) findEscrow(swap *AtomicSwap, leg SwapLeg) (*Escrow, error) {
+	if swap.EscrowID != (Hash{}) {
+		return GetEscrow(m.db, swap.EscrowID)
+	}
+	escrows, err := EscrowsByHashLock(m.db, swap.Terms.HashLock)
+	if err != nil {
+		return nil, err
+	}
+	for _, escrow := range escrows {
+		if escrow.Sender == leg.Sender {
+			swap.EscrowID = escrow.ID
+			return escrow, nil
+		}
+	}
+	return nil, nil
+}
+
+// Step advances the open swaps at QRL height qrlHeight: the initiator claims the participant's lock
+// once it is confirmed, the participant claims the initiator's lock with the revealed secret, and
+// either refunds its own lock once it times out unclaimed. Claims and refunds of HTLCs are
+// submitted through their adapters; those of escrows are returned for the local party to sign and
+// submit. HTLC claims and refunds are submitted once their adapter accepts them, and retried at
+// the next step otherwise; escrow transactions are returned again at every step until the escrow
+// is seen claimed or refunded.
+func (m *SwapManager) Step(qrlHeight uint64) ([]*Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ids := make([]Hash, 0, len(m.swaps))
+	for id, swap := range m.swaps {
+		if !swap.Status.IsTerminal() {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].Less(ids[j]) })
+	var txs []*Transaction
+	var errs []error
+	for _, id := range ids {
+		tx, err := m.step(m.swaps[id], qrlHeight)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("swap %s: %w", id, err))
+		}
+		if tx != nil {
+			txs = append(txs, tx)
+		}
+	}
+	return txs, errors.Join(errs...)
+}
+
+// step advances one swap. Caller must hold m.mu.
+func (m *SwapManager) step(swap *AtomicSwap, qrlHeight uint64) (*Transaction, error) {
+	own, counterparty := swap.own(), swap.counterparty()
+	mine, err := m.lookup(swap, own)
+	if err != nil {
+		return nil, err
+	}
+	theirs, err := m.lookup(swap, counterparty)
+	if err != nil {
+		return nil, err
+	}
+
+	if theirs != nil && theirs.status == EscrowClaimed && swap.Claimed {
+		swap.Status = SwapCompleted
+		return nil, nil
+	}
+	if mine != nil && mine.status == EscrowRefunded {
+		swap.Status = SwapRefunded
+		return nil, nil
+	}
+	if swap.Role == SwapInitiator && theirs != nil && theirs.status == EscrowOpen && swap.Status == SwapInitiated {
+		swap.Status = SwapLocked
+	}
+	if swap.Role == SwapParticipant && mine != nil && mine.status == EscrowClaimed && swap.Secret == nil {
+		swap.Secret, swap.Status = mine.preimage, SwapRedeemed
+	}
+
+	ownHeight, err := m.height(own.Chain, qrlHeight)
+	if err != nil {
+		return nil, err
+	}
+	if mine != nil && mine.status == EscrowOpen && ownHeight >= own.Timeout {
+		if swap.Refunded && own.Chain != ChainID_QRL {
+			return nil, nil
+		}
+		tx, err := m.refund(swap, own)
+		if err != nil {
+			return nil, err
+		}
+		swap.Refunded = true
+		return tx, nil
+	}
+
+	theirHeight, err := m.height(counterparty.Chain, qrlHeight)
+	if err != nil {
+		return nil, err
+	}
+	if swap.Claimed && counterparty.Chain != ChainID_QRL {
+		return nil, nil
+	}
+	if swap.Secret == nil || theirs == nil || !theirs.confirmed || theirs.status != EscrowOpen || theirHeight >= counterparty.Timeout {
+		return nil, nil
+	}
+	if swap.Role == SwapInitiator && (mine == nil || ownHeight >= own.Timeout) {
+		return nil, nil // Only claim while the participant can still claim in return
+	}
+	tx, err := m.claim(swap, counterparty)
+	if err != nil {
+		return nil, err
+	}
+	swap.Claimed = true
+	if swap.Role == SwapInitiator {
+		swap.Status = SwapRedeemed
+	}
+	return tx, nil
+}
+
+// claim claims leg with the swap's secret. Caller must hold m.mu.
+func (m *SwapManager) claim(swap *AtomicSwap, leg SwapLeg) (*Transaction, error) {
+	if leg.Chain == ChainID_QRL {
+		nonce, err := m.txNonce(swap, leg.Recipient, swap.Claimed)
+		if err != nil {
+			return nil, err
+		}
+		return NewEscrowClaimTransaction(nonce, leg.Recipient, swap.EscrowID, swap.Secret)
+	}
+	if _, err := m.adapters[leg.Chain].ClaimHTLC(swap.Terms.HashLock, swap.Secret); err != nil {
+		return nil, fmt.Errorf("failed to claim HTLC on %s: %w", leg.Chain, err)
+	}
+	return nil, nil
+}
+
+// refund takes leg back after its timeout. Caller must hold m.mu.
+func (m *SwapManager) refund(swap *AtomicSwap, leg SwapLeg) (*Transaction, error) {
+	if leg.Chain == ChainID_QRL {
+		nonce, err := m.txNonce(swap, leg.Sender, swap.Refunded)
+		if err != nil {
+			return nil, err
+		}
+		return NewEscrowRefundTransaction(nonce, leg.Sender, swap.EscrowID), nil
+	}
+	if _, err := m.adapters[leg.Chain].RefundHTLC(swap.Terms.HashLock); err != nil {
+		return nil, fmt.Errorf("failed to refund HTLC on %s: %w", leg.Chain, err)
+	}
+	return nil, nil
+}
+
+// Swap returns a copy of the swap with hashLock.
+func (m *SwapManager) Swap(hashLock Hash) (AtomicSwap, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	swap, ok := m.swaps[hashLock]
+	if !ok {
+		return AtomicSwap{}, false
+	}
+	return *swap, true
+}
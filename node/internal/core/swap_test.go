@@ -0,0 +1,353 @@
+package core
+
+import (
+	"fmt"
+	"testing"
+)
+
+// newSwapFixture returns QRL state funding alice and bob, an ETH mock chain funding their wallets
+// there, and a swap manager for each of them.
+func newSwapFixture(t *testing.T) (*StateManager, *InMemoryStateDB, *MockChain, *SwapManager, *SwapManager) {
+	t.Helper()
+	db := NewInMemoryStateDB()
+	_ = db.SetBalance("alice", 1000)
+	_ = db.SetBalance("bob", 1000)
+	sm := NewStateManager(db)
+	eth := NewMockChain(ChainID_Ethereum, DefaultMockChainConfig())
+	eth.Fund("alice_eth", "ETH", 5000)
+	eth.Fund("bob_eth", "ETH", 5000)
+	alice, bob := NewSwapManager(db, DefaultSwapConfig()), NewSwapManager(db, DefaultSwapConfig())
+	for _, m := range []*SwapManager{alice, bob} {
+		if err := m.RegisterAdapter(eth); err != nil {
+			t.Fatalf("RegisterAdapter failed: %v", err)
+		}
+	}
+	return sm, db, eth, alice, bob
+}
+
+// applySwapTx signs tx and applies it in block height.
+func applySwapTx(t *testing.T, sm *StateManager, tx *Transaction, height uint64) {
+	t.Helper()
+	if tx == nil {
+		t.Fatalf("Expected a QRL transaction to submit")
+	}
+	_ = tx.Sign()
+	_ = sm.BeginBlock(&BlockHeader{Number: height, Proposer: "proposerP"})
+	if err := sm.ApplyTransaction(tx); err != nil {
+		t.Fatalf("Transaction of %s failed: %v", tx.SenderID, err)
+	}
+}
+
+// Alice sells 400 QRL for 1000 ETH of bob's: her escrow times out after 2000s, bob's HTLC after
+// 1200s.
+func qrlForETHTerms(secret []byte) SwapTerms {
+	return SwapTerms{
+		HashLock:    HashLockOf(secret),
+		Initiator:   SwapLeg{Chain: ChainID_QRL, Asset: NativeAsset, Sender: "alice", Recipient: "bob", Amount: 400, Timeout: 210},
+		Participant: SwapLeg{Chain: ChainID_Ethereum, Asset: "ETH", Sender: "bob_eth", Recipient: "alice_eth", Amount: 1000, Timeout: 100},
+	}
+}
+
+func TestSwap_Terms(t *testing.T) {
+	_, _, _, alice, bob := newSwapFixture(t)
+	secret := []byte("swap secret")
+	terms := qrlForETHTerms(secret)
+
+	if _, err := alice.Initiate(terms, []byte("other secret"), 10); err == nil {
+		t.Errorf("Expected a secret not matching the hash lock to be rejected")
+	}
+	bothQRL := terms
+	bothQRL.Participant = SwapLeg{Chain: ChainID_QRL, Asset: NativeAsset, Sender: "bob", Recipient: "alice", Amount: 400, Timeout: 100}
+	if _, err := alice.Initiate(bothQRL, secret, 10); err == nil {
+		t.Errorf("Expected a swap without an external leg to be rejected")
+	}
+	noAdapter := terms
+	noAdapter.Participant.Chain = ChainID_Bitcoin
+	if _, err := alice.Initiate(noAdapter, secret, 10); err == nil {
+		t.Errorf("Expected a swap with an unregistered chain to be rejected")
+	}
+	if _, err := alice.Initiate(terms, secret, 100); err == nil {
+		t.Errorf("Expected an initiator lock timing out in 1100s to leave too little time for the participant")
+	}
+
+	if _, err := bob.Participate(terms, 10); err == nil {
+		t.Errorf("Expected participation before the initiator locked to be rejected")
+	}
+	heights := map[string]uint64{
+		"ClaimWindow":  40,  // 480s for alice to claim
+		"SafetyMargin": 130, // 1560s, leaving bob only 440s after it
+	}
+	for name, timeout := range heights {
+		t.Run(name, func(t *testing.T) {
+			unsafe := terms
+			unsafe.Participant.Timeout = timeout
+			if err := DefaultSwapConfig().checkTimeouts(unsafe, 10, 0); err == nil {
+				t.Errorf("Expected participant timeout %d to be rejected", timeout)
+			}
+		})
+	}
+	if err := DefaultSwapConfig().checkTimeouts(terms, 10, 0); err != nil {
+		t.Errorf("Expected timeouts of the terms to be accepted, got %v", err)
+	}
+}
+
+func TestSwap_QRLForExternal(t *testing.T) {
+	sm, db, eth, alice, bob := newSwapFixture(t)
+	secret := []byte("swap secret")
+	terms := qrlForETHTerms(secret)
+
+	tx, err := alice.Initiate(terms, secret, 10)
+	if err != nil {
+		t.Fatalf("Initiate failed: %v", err)
+	}
+	applySwapTx(t, sm, tx, 10)
+
+	unsafe := terms
+	unsafe.Participant.Timeout = 130
+	if _, err := bob.Participate(unsafe, 10); err == nil {
+		t.Errorf("Expected bob to refuse terms whose escrow does not match")
+	}
+	if tx, err := bob.Participate(terms, 10); err != nil || tx != nil {
+		t.Fatalf("Expected bob to lock his HTLC directly, got %v, %v", tx, err)
+	}
+
+	eth.Mine(1)
+	if txs, err := alice.Step(11); err != nil || len(txs) != 0 {
+		t.Fatalf("Step failed: %v", err)
+	}
+	if swap, _ := alice.Swap(terms.HashLock); swap.Status != SwapLocked {
+		t.Fatalf("Expected alice to wait for 2 confirmations of bob's HTLC, got %s", swap.Status)
+	}
+	eth.Mine(1)
+	_, _ = alice.Step(12)
+	eth.Mine(1)
+	if eth.Balance("alice_eth", "ETH") != 6000 {
+		t.Fatalf("Expected alice to claim bob's HTLC, alice_eth has %d", eth.Balance("alice_eth", "ETH"))
+	}
+
+	txs, err := bob.Step(13)
+	if err != nil || len(txs) != 1 {
+		t.Fatalf("Expected bob to claim alice's escrow with the revealed secret, got %d transactions (%v)", len(txs), err)
+	}
+	applySwapTx(t, sm, txs[0], 13)
+	if bal, _ := db.GetBalance("bob"); bal != 1400 {
+		t.Errorf("Expected bob paid 400 QRL, has %d", bal)
+	}
+	if txs, _ := bob.Step(14); len(txs) != 0 {
+		t.Errorf("Expected the claim to be issued once, got %d more transactions", len(txs))
+	}
+	for name, m := range map[string]*SwapManager{"alice": alice, "bob": bob} {
+		_, _ = m.Step(14)
+		if swap, _ := m.Swap(terms.HashLock); swap.Status != SwapCompleted {
+			t.Errorf("Expected the swap completed for %s, got %s", name, swap.Status)
+		}
+	}
+}
+
+func TestSwap_ExternalForQRL(t *testing.T) {
+	sm, db, eth, alice, bob := newSwapFixture(t)
+	secret := []byte("swap secret")
+	terms := SwapTerms{
+		HashLock:    HashLockOf(secret),
+		Initiator:   SwapLeg{Chain: ChainID_Ethereum, Asset: "ETH", Sender: "alice_eth", Recipient: "bob_eth", Amount: 1000, Timeout: 200},
+		Participant: SwapLeg{Chain: ChainID_QRL, Asset: NativeAsset, Sender: "bob", Recipient: "alice", Amount: 400, Timeout: 110},
+	}
+
+	if tx, err := alice.Initiate(terms, secret, 10); err != nil || tx != nil {
+		t.Fatalf("Expected alice to lock her HTLC directly, got %v, %v", tx, err)
+	}
+	eth.Mine(1)
+	if _, err := bob.Participate(terms, 10); err == nil {
+		t.Errorf("Expected bob to wait for 2 confirmations of alice's HTLC")
+	}
+	eth.Mine(1)
+	tx, err := bob.Participate(terms, 10)
+	if err != nil {
+		t.Fatalf("Participate failed: %v", err)
+	}
+	applySwapTx(t, sm, tx, 10)
+
+	txs, err := alice.Step(11)
+	if err != nil || len(txs) != 1 {
+		t.Fatalf("Expected alice to claim bob's escrow, got %d transactions (%v)", len(txs), err)
+	}
+	applySwapTx(t, sm, txs[0], 11)
+
+	if txs, err := bob.Step(12); err != nil || len(txs) != 0 {
+		t.Fatalf("Step failed: %v", err)
+	}
+	if swap, _ := bob.Swap(terms.HashLock); swap.Status != SwapRedeemed || string(swap.Secret) != string(secret) {
+		t.Fatalf("Expected bob to learn the secret from alice's claim, got %+v", swap)
+	}
+	eth.Mine(1)
+	if eth.Balance("bob_eth", "ETH") != 6000 {
+		t.Errorf("Expected bob to claim alice's HTLC, bob_eth has %d", eth.Balance("bob_eth", "ETH"))
+	}
+	if bal, _ := db.GetBalance("alice"); bal != 1400 {
+		t.Errorf("Expected alice paid 400 QRL, has %d", bal)
+	}
+	_, _ = bob.Step(13)
+	if swap, _ := bob.Swap(terms.HashLock); swap.Status != SwapCompleted {
+		t.Errorf("Expected the swap completed for bob, got %s", swap.Status)
+	}
+}
+
+func TestSwap_Refunds(t *testing.T) {
+	sm, db, eth, alice, bob := newSwapFixture(t)
+	secret := []byte("swap secret")
+	terms := qrlForETHTerms(secret)
+	tx, _ := alice.Initiate(terms, secret, 10)
+	applySwapTx(t, sm, tx, 10)
+	_, _ = bob.Participate(terms, 10)
+
+	// Alice goes offline without claiming
+	eth.Mine(98)
+	if txs, _ := bob.Step(20); len(txs) != 0 || eth.Balance("bob_eth", "ETH") != 4000 {
+		t.Fatalf("Expected bob's HTLC locked until its timeout")
+	}
+	eth.Mine(2)
+	_, _ = bob.Step(20)
+	eth.Mine(1)
+	_, _ = bob.Step(20)
+	if swap, _ := bob.Swap(terms.HashLock); swap.Status != SwapRefunded || eth.Balance("bob_eth", "ETH") != 5000 {
+		t.Errorf("Expected bob refunded after his HTLC timed out, got %s", swap.Status)
+	}
+
+	if txs, _ := alice.Step(209); len(txs) != 0 {
+		t.Errorf("Expected alice's escrow locked until its timeout, got %d transactions", len(txs))
+	}
+	if bal, _ := db.GetBalance("bob"); bal != 1000 {
+		t.Errorf("Expected bob unable to claim alice's escrow without the secret, has %d", bal)
+	}
+	txs, err := alice.Step(210)
+	if err != nil || len(txs) != 1 {
+		t.Fatalf("Expected alice to refund her escrow, got %d transactions (%v)", len(txs), err)
+	}
+	applySwapTx(t, sm, txs[0], 210)
+	_, _ = alice.Step(211)
+	if swap, _ := alice.Swap(terms.HashLock); swap.Status != SwapRefunded {
+		t.Errorf("Expected alice refunded, got %s", swap.Status)
+	}
+	if bal, _ := db.GetBalance("alice"); bal != 1000 {
+		t.Errorf("Expected alice's 400 QRL returned, has %d", bal)
+	}
+}
+
+// flakyHTLCAdapter fails claims and refunds on its mock chain while fail is set.
+type flakyHTLCAdapter struct {
+	*MockChain
+	fail bool
+}
+
+func (a *flakyHTLCAdapter) ClaimHTLC(hashLock Hash, preimage []byte) (string, error) {
+	if a.fail {
+		return "", fmt.Errorf("node unavailable")
+	}
+	return a.MockChain.ClaimHTLC(hashLock, preimage)
+}
+
+func (a *flakyHTLCAdapter) RefundHTLC(hashLock Hash) (string, error) {
+	if a.fail {
+		return "", fmt.Errorf("node unavailable")
+	}
+	return a.MockChain.RefundHTLC(hashLock)
+}
+
+func TestSwap_Retries(t *testing.T) {
+	sm, db, eth, _, bob := newSwapFixture(t)
+	flaky := &flakyHTLCAdapter{MockChain: eth, fail: true}
+	alice := NewSwapManager(db, DefaultSwapConfig())
+	if err := alice.RegisterAdapter(flaky); err != nil {
+		t.Fatalf("RegisterAdapter failed: %v", err)
+	}
+	secret := []byte("swap secret")
+	terms := qrlForETHTerms(secret)
+	tx, _ := alice.Initiate(terms, secret, 10)
+	applySwapTx(t, sm, tx, 10)
+	_, _ = bob.Participate(terms, 10)
+	eth.Mine(2)
+
+	if _, err := alice.Step(12); err == nil {
+		t.Fatalf("Expected the failed HTLC claim to be reported")
+	}
+	if swap, _ := alice.Swap(terms.HashLock); swap.Claimed || swap.Status != SwapLocked {
+		t.Fatalf("Expected the claim not recorded after the adapter failed, got %+v", swap)
+	}
+	flaky.fail = false
+	if _, err := alice.Step(12); err != nil {
+		t.Fatalf("Step failed: %v", err)
+	}
+	if swap, _ := alice.Swap(terms.HashLock); !swap.Claimed || swap.Status != SwapRedeemed {
+		t.Fatalf("Expected the claim retried at the next step, got %+v", swap)
+	}
+	eth.Mine(1)
+	if eth.Balance("alice_eth", "ETH") != 6000 {
+		t.Fatalf("Expected alice to claim bob's HTLC, alice_eth has %d", eth.Balance("alice_eth", "ETH"))
+	}
+
+	// Bob loses his first claim transaction before submitting it
+	lost, _ := bob.Step(13)
+	if len(lost) != 1 {
+		t.Fatalf("Expected bob to claim alice's escrow, got %d transactions", len(lost))
+	}
+	txs, err := bob.Step(14)
+	if err != nil || len(txs) != 1 {
+		t.Fatalf("Expected the claim reissued until the escrow is claimed, got %d transactions (%v)", len(txs), err)
+	}
+	if txs[0].Nonce != lost[0].Nonce {
+		t.Errorf("Expected the reissued claim to keep nonce %d, got %d", lost[0].Nonce, txs[0].Nonce)
+	}
+	applySwapTx(t, sm, txs[0], 14)
+	if txs, _ := bob.Step(15); len(txs) != 0 {
+		t.Errorf("Expected no claim once the escrow is claimed, got %d transactions", len(txs))
+	}
+	if swap, _ := bob.Swap(terms.HashLock); swap.Status != SwapCompleted {
+		t.Errorf("Expected the swap completed for bob, got %s", swap.Status)
+	}
+}
+
+func TestSwap_Nonces(t *testing.T) {
+	sm, db, eth, alice, _ := newSwapFixture(t)
+	submit := func(tx *Transaction, height uint64) {
+		t.Helper()
+		_ = tx.Sign()
+		_ = sm.BeginBlock(&BlockHeader{Number: height, Proposer: "proposerP"})
+		if err := sm.ApplyTransaction(tx); err != nil {
+			t.Fatalf("Transaction of %s with nonce %d failed: %v", tx.SenderID, tx.Nonce, err)
+		}
+	}
+
+	// Alice opens two swaps before submitting either escrow
+	var locks []*Transaction
+	for _, secret := range []string{"first secret", "second secret"} {
+		terms := qrlForETHTerms([]byte(secret))
+		terms.Initiator.Amount = 300
+		tx, err := alice.Initiate(terms, []byte(secret), 10)
+		if err != nil {
+			t.Fatalf("Initiate failed: %v", err)
+		}
+		locks = append(locks, tx)
+	}
+	if locks[0].Nonce != 0 || locks[1].Nonce != 1 {
+		t.Fatalf("Expected the escrows numbered 0 and 1, got %d and %d", locks[0].Nonce, locks[1].Nonce)
+	}
+	for _, tx := range locks {
+		submit(tx, 10)
+	}
+
+	// Nobody participates; both escrows are refunded in the same step
+	eth.Mine(1)
+	refunds, err := alice.Step(210)
+	if err != nil || len(refunds) != 2 {
+		t.Fatalf("Expected two refunds, got %d (%v)", len(refunds), err)
+	}
+	for _, tx := range refunds {
+		submit(tx, 210)
+	}
+	if bal, _ := db.GetBalance("alice"); bal != 1000 {
+		t.Errorf("Expected alice refunded in full, has %d", bal)
+	}
+	if nonce, _ := db.GetNonce("alice"); nonce != 4 {
+		t.Errorf("Expected alice's nonce at 4, got %d", nonce)
+	}
+}
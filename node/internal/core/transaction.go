@@ -2,6 +2,7 @@ package core
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/gob"
 	"fmt"
 )
@@ -14,9 +15,25 @@ type Signature []byte
 type TransactionType uint8
 
 const (
-	TxTypeTransfer TransactionType = iota // Basic transfer
-	TxTypeAnchor                          // Anchoring a proof/hash
-	// Add other types later: Vote, BridgeIntent, Anchor, QSDMint, etc.
+	TxTypeTransfer       TransactionType = iota // Basic transfer
+	TxTypeAnchor                                // Anchoring a proof/hash
+	TxTypeBond                                  // Lock Amount of the sender's balance as validator stake
+	TxTypeUnbond                                // Start unbonding Amount of stake; withdrawable after the unbonding delay
+	TxTypeWithdraw                              // Return matured unbonded stake to the sender's balance
+	TxTypeEvidence                              // Report equivocation; Payload is an encoded EquivocationEvidence
+	TxTypeRandCommit                            // Commit to a beacon secret; Payload is BeaconCommitment(secret, sender)
+	TxTypeRandReveal                            // Reveal the secret committed in the previous epoch; Payload is the secret
+	TxTypeQSDMint                               // Lock Amount as collateral and mint the QSD amount in Payload
+	TxTypeQSDBurn                               // Repay Amount QSD and release the proportional collateral
+	TxTypeBridgeIntent                          // Submit a bridge intent; Payload is an encoded BridgeIntent
+	TxTypeMultisigUpdate                        // Create, change or dissolve the sender's signer set; Payload is an encoded MultisigAccount
+	TxTypeEscrowCreate                          // Lock Amount for RecipientID; Payload is encoded EscrowTerms (hash lock, timeout)
+	TxTypeEscrowClaim                           // Claim an escrow before its timeout; Payload is an encoded EscrowClaim with the preimage
+	TxTypeEscrowRefund                          // Refund a timed-out escrow to its sender; Payload is the escrow ID
+	TxTypeBridgeAdmin                           // Pause bridging or lift a pause; Payload is an encoded BridgeAdminAction
+	TxTypeBridgeRelease                         // Confirm the payout of an intent leaving QRL, or its failure; Payload is an encoded BridgeRelease
+	TxTypeParamChange                           // Schedule a parameter value for the end of an epoch; Payload is an encoded ParameterChange
+	// Add other types later: Vote, etc.
 )
 
 // Transaction represents a basic transaction structure.
@@ -28,8 +45,17 @@ type Transaction struct {
 	RecipientID string // Placeholder for recipient identifier
 	Amount      uint64 // Using uint64 for amount, assuming smallest unit (0 for anchor)
 	Payload     []byte // Data payload (e.g., the hash/proof being anchored)
+	Fee         uint64 // Fee offered to the block proposer; a higher fee can replace a pending tx with the same nonce
 	Signature   Signature
-	// TODO: Add GasPrice, GasLimit, Payload, etc. later
+	// Signatures of the signers of a multisig sender (see MultisigAccount); Signature is not used then
+	CoSignatures []CoSignature
+	// TODO: Add GasPrice, GasLimit, etc. later
+}
+
+// CoSignature is one signer's signature over a transaction's SigningHash.
+type CoSignature struct {
+	Signer    string
+	Signature Signature
 }
 
 // NewTransaction creates a basic transfer transaction (unsigned).
@@ -46,40 +72,119 @@ func NewBaseTransaction(txType TransactionType, nonce uint64, sender, recipient
 	}
 }
 
-// Sign generates and attaches a signature to the transaction.
-// Placeholder implementation.
+// Sign generates and attaches a signature over the transaction's SigningHash.
+// Placeholder implementation: uses the insecure SignDigest scheme keyed by SenderID.
 func (tx *Transaction) Sign( /* privateKey ... */ ) error {
 	// TODO: Implement actual signing using sender's private key
-	// This should sign a hash of the transaction data (excluding the signature field itself)
-	tx.Signature = []byte("mock-signature-for-" + tx.SenderID) // Insecure placeholder
+	digest, err := tx.SigningHash()
+	if err != nil {
+		return fmt.Errorf("failed to compute signing hash: %w", err)
+	}
+	tx.Signature = SignDigest(tx.SenderID, digest)
 	return nil
 }
 
-// VerifySignature checks if the transaction's signature is valid.
-// Placeholder implementation.
+// VerifySignature checks if the transaction's signature is valid for its sender and contents.
+// Placeholder implementation: verifies the SignDigest scheme, not real public-key cryptography.
 func (tx *Transaction) VerifySignature() (bool, error) {
 	// TODO: Implement actual signature verification using sender's public key
-	// This involves hashing the transaction data (excluding signature) and verifying the signature against the hash and public key.
 	if tx.Signature == nil {
 		return false, fmt.Errorf("transaction has no signature")
 	}
-	fmt.Printf("Warning: Using placeholder signature verification for tx from %s\n", tx.SenderID)
-	return true, nil // Assume valid for now
+	digest, err := tx.SigningHash()
+	if err != nil {
+		return false, fmt.Errorf("failed to compute signing hash: %w", err)
+	}
+	return VerifyDigestSignature(tx.SenderID, digest, tx.Signature), nil
 }
 
-// Encode serializes the transaction into a byte slice using gob encoding.
+// CoSign attaches signer's signature for a multisig sender, replacing an earlier one by the same signer.
+// Placeholder implementation: uses the insecure SignDigest scheme keyed by the signer.
+func (tx *Transaction) CoSign(signer string) error {
+	if signer == "" {
+		return fmt.Errorf("co-signer must not be empty")
+	}
+	digest, err := tx.SigningHash()
+	if err != nil {
+		return fmt.Errorf("failed to compute signing hash: %w", err)
+	}
+	sig := CoSignature{Signer: signer, Signature: SignDigest(signer, digest)}
+	for i := range tx.CoSignatures {
+		if tx.CoSignatures[i].Signer == signer {
+			tx.CoSignatures[i] = sig
+			return nil
+		}
+	}
+	tx.CoSignatures = append(tx.CoSignatures, sig)
+	return nil
+}
+
+// VerifySignatures checks every signature attached to the transaction: the sender's signature, if
+// any, and each co-signature, which must come from distinct signers. Whether the signatures present
+// authorize the sender depends on its account and is checked against state (see
+// StateManager.VerifyAuthorization).
+func (tx *Transaction) VerifySignatures() (bool, error) {
+	if tx.Signature == nil && len(tx.CoSignatures) == 0 {
+		return false, fmt.Errorf("transaction has no signature")
+	}
+	if tx.Signature != nil {
+		if valid, err := tx.VerifySignature(); err != nil || !valid {
+			return false, err
+		}
+	}
+	if len(tx.CoSignatures) == 0 {
+		return true, nil
+	}
+	digest, err := tx.SigningHash()
+	if err != nil {
+		return false, fmt.Errorf("failed to compute signing hash: %w", err)
+	}
+	seen := make(map[string]struct{}, len(tx.CoSignatures))
+	for _, cs := range tx.CoSignatures {
+		if _, dup := seen[cs.Signer]; dup {
+			return false, fmt.Errorf("duplicate co-signature by %s", cs.Signer)
+		}
+		seen[cs.Signer] = struct{}{}
+		if !VerifyDigestSignature(cs.Signer, digest, cs.Signature) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// SigningHash returns the hash of the transaction with its signatures removed; this is what the
+// sender and any co-signers sign.
+func (tx *Transaction) SigningHash() (Hash, error) {
+	unsigned := *tx
+	unsigned.Signature = nil
+	unsigned.CoSignatures = nil
+	return unsigned.Hash()
+}
+
+// Gas costs charged for including a transaction in a block.
+const (
+	TxGasBase           uint64 = 1000 // Flat cost of any transaction
+	TxGasPerPayloadByte uint64 = 16   // Additional cost per payload byte
+	TxGasPerCoSignature uint64 = 500  // Additional cost per co-signature to verify
+)
+
+// IntrinsicGas returns the gas a transaction consumes, counted against the block gas limit.
+func (tx *Transaction) IntrinsicGas() uint64 {
+	return TxGasBase + uint64(len(tx.Payload))*TxGasPerPayloadByte + uint64(len(tx.CoSignatures))*TxGasPerCoSignature
+}
 
 // ValidateBasic performs stateless validation checks on the transaction.
 // Checks format, presence of signature, etc. Does NOT check nonce or balance.
 func (tx *Transaction) ValidateBasic() error {
 	// TODO: Add more checks (e.g., non-zero amount for transfers? Sender/Recipient format?)
-	if tx.Signature == nil {
+	if tx.Signature == nil && len(tx.CoSignatures) == 0 {
 		return fmt.Errorf("transaction is missing signature")
 	}
 	// Placeholder: Assume valid if signature exists for now
 	return nil
 }
 
+// Encode serializes the transaction into a byte slice using gob encoding.
 func (tx *Transaction) Encode() ([]byte, error) {
 	var buf bytes.Buffer
 	encoder := gob.NewEncoder(&buf)
@@ -90,6 +195,16 @@ func (tx *Transaction) Encode() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// Hash returns the SHA-256 hash of the encoded transaction (signature included).
+// It identifies a transaction in the pool, the journal and block bodies.
+func (tx *Transaction) Hash() (Hash, error) {
+	data, err := tx.Encode()
+	if err != nil {
+		return Hash{}, err
+	}
+	return sha256.Sum256(data), nil
+}
+
 // DecodeTransaction deserializes a byte slice into a Transaction struct.
 func DecodeTransaction(data []byte) (*Transaction, error) {
 	var tx Transaction
@@ -0,0 +1,147 @@
+package core
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// maxJournalRecordSize bounds a single journal record to guard against reading a corrupt length prefix.
+const maxJournalRecordSize = 4 * 1024 * 1024
+
+// TxJournal is an append-only file of locally submitted transactions.
+// Each record is a big-endian uint32 length followed by the gob-encoded transaction
+// (see Transaction.Encode). It is replayed into the TxPool when the node restarts.
+type TxJournal struct {
+	path   string
+	writer *os.File // Open append handle, created lazily on first Insert
+}
+
+// NewTxJournal creates a journal backed by the file at path.
+// The file is not touched until Load, Insert or Rotate is called.
+func NewTxJournal(path string) *TxJournal {
+	return &TxJournal{path: path}
+}
+
+// Load reads every transaction from the journal and passes it to add.
+// A missing journal file is not an error (fresh node). A truncated final record,
+// e.g. from a crash mid-write, ends loading without error.
+func (j *TxJournal) Load(add func(*Transaction) error) error {
+	file, err := os.Open(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open journal %s: %w", j.path, err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	loaded := 0
+	for {
+		var size uint32
+		if err := binary.Read(reader, binary.BigEndian, &size); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+			return fmt.Errorf("failed to read journal record length: %w", err)
+		}
+		if size > maxJournalRecordSize {
+			return fmt.Errorf("journal record of %d bytes exceeds limit of %d", size, maxJournalRecordSize)
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+				fmt.Printf("TxJournal: Ignoring truncated record at end of %s\n", j.path) // Placeholder log
+				break
+			}
+			return fmt.Errorf("failed to read journal record: %w", err)
+		}
+		tx, err := DecodeTransaction(data)
+		if err != nil {
+			return fmt.Errorf("failed to decode journaled transaction: %w", err)
+		}
+		if err := add(tx); err != nil {
+			return err
+		}
+		loaded++
+	}
+	fmt.Printf("TxJournal: Loaded %d transactions from %s\n", loaded, j.path) // Placeholder log
+	return nil
+}
+
+// Insert appends a single transaction to the journal.
+func (j *TxJournal) Insert(tx *Transaction) error {
+	if tx == nil {
+		return fmt.Errorf("cannot journal nil transaction")
+	}
+	if j.writer == nil {
+		file, err := os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open journal %s for appending: %w", j.path, err)
+		}
+		j.writer = file
+	}
+	return writeJournalRecord(j.writer, tx)
+}
+
+// Rotate atomically replaces the journal contents with txs.
+// The new journal is written to a temporary file and renamed into place.
+func (j *TxJournal) Rotate(txs []*Transaction) error {
+	if j.writer != nil {
+		if err := j.writer.Close(); err != nil {
+			return fmt.Errorf("failed to close journal before rotation: %w", err)
+		}
+		j.writer = nil
+	}
+
+	tmpPath := j.path + ".new"
+	tmp, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create journal %s: %w", tmpPath, err)
+	}
+	for _, tx := range txs {
+		if err := writeJournalRecord(tmp, tx); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close journal %s: %w", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, j.path); err != nil {
+		return fmt.Errorf("failed to replace journal %s: %w", j.path, err)
+	}
+	return nil
+}
+
+// Close releases the journal's file handle, if open.
+func (j *TxJournal) Close() error {
+	if j.writer == nil {
+		return nil
+	}
+	err := j.writer.Close()
+	j.writer = nil
+	return err
+}
+
+// writeJournalRecord writes a length-prefixed encoded transaction to w.
+func writeJournalRecord(w io.Writer, tx *Transaction) error {
+	data, err := tx.Encode()
+	if err != nil {
+		return err
+	}
+	if len(data) > maxJournalRecordSize {
+		return fmt.Errorf("transaction encoding of %d bytes exceeds journal record limit", len(data))
+	}
+	if err := binary.Write(w, binary.BigEndian, uint32(len(data))); err != nil {
+		return fmt.Errorf("failed to write journal record length: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write journal record: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTxJournal_InsertAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transactions.journal")
+	journal := NewTxJournal(path)
+
+	t.Run("LoadMissingFile", func(t *testing.T) {
+		count := 0
+		if err := journal.Load(func(*Transaction) error { count++; return nil }); err != nil {
+			t.Fatalf("Load on missing journal should succeed, got %v", err)
+		}
+		if count != 0 {
+			t.Errorf("Expected no transactions from missing journal, got %d", count)
+		}
+	})
+
+	tx1 := NewBaseTransaction(TxTypeTransfer, 0, "senderA", "recipientB", 100)
+	_ = tx1.Sign()
+	tx2 := NewBaseTransaction(TxTypeTransfer, 1, "senderA", "recipientB", 200)
+	_ = tx2.Sign()
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		if err := journal.Insert(tx1); err != nil {
+			t.Fatalf("Insert tx1 failed: %v", err)
+		}
+		if err := journal.Insert(tx2); err != nil {
+			t.Fatalf("Insert tx2 failed: %v", err)
+		}
+		if err := journal.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+
+		var loaded []*Transaction
+		if err := NewTxJournal(path).Load(func(tx *Transaction) error {
+			loaded = append(loaded, tx)
+			return nil
+		}); err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if len(loaded) != 2 || loaded[0].Nonce != 0 || loaded[1].Amount != 200 {
+			t.Errorf("Unexpected journal contents: %+v", loaded)
+		}
+	})
+
+	t.Run("TruncatedTail", func(t *testing.T) {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			t.Fatalf("Failed to open journal: %v", err)
+		}
+		// A length prefix promising more data than follows, as after a crash mid-write
+		_, _ = file.Write([]byte{0, 0, 0, 50, 1, 2, 3})
+		file.Close()
+
+		count := 0
+		if err := NewTxJournal(path).Load(func(*Transaction) error { count++; return nil }); err != nil {
+			t.Fatalf("Load should tolerate a truncated final record, got %v", err)
+		}
+		if count != 2 {
+			t.Errorf("Expected 2 intact transactions before the truncated record, got %d", count)
+		}
+	})
+
+	t.Run("Rotate", func(t *testing.T) {
+		if err := journal.Rotate([]*Transaction{tx2}); err != nil {
+			t.Fatalf("Rotate failed: %v", err)
+		}
+		var loaded []*Transaction
+		_ = NewTxJournal(path).Load(func(tx *Transaction) error {
+			loaded = append(loaded, tx)
+			return nil
+		})
+		if len(loaded) != 1 || loaded[0].Nonce != 1 {
+			t.Errorf("Expected only tx2 after rotation, got %+v", loaded)
+		}
+	})
+}
+
+func TestTxPool_JournalReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transactions.journal")
+
+	// First "run" of the node: submit local and remote transactions
+	pool := NewTxPool()
+	pool.SetJournal(NewTxJournal(path))
+	local := NewBaseTransaction(TxTypeTransfer, 0, "localUser", "recipientB", 100)
+	_ = local.Sign()
+	remote := NewBaseTransaction(TxTypeTransfer, 0, "remoteUser", "recipientB", 100)
+	_ = remote.Sign()
+	if err := pool.AddLocalTransaction(local); err != nil {
+		t.Fatalf("AddLocalTransaction failed: %v", err)
+	}
+	if err := pool.AddTransaction(remote); err != nil {
+		t.Fatalf("AddTransaction failed: %v", err)
+	}
+	// A transaction relayed by a peer is not journaled even if its sender also submits locally
+	relayed := NewBaseTransaction(TxTypeTransfer, 1, "localUser", "recipientB", 100)
+	_ = relayed.Sign()
+	if err := pool.AddTransaction(relayed); err != nil {
+		t.Fatalf("AddTransaction failed: %v", err)
+	}
+	if locals := pool.LocalTransactions(); len(locals) != 1 || locals[0] != local {
+		t.Fatalf("Expected only the locally submitted transaction to be local, got %+v", locals)
+	}
+	if err := pool.RotateJournal(); err != nil {
+		t.Fatalf("RotateJournal failed: %v", err)
+	}
+
+	// Restart: a fresh pool replays only the local transaction
+	restarted := NewTxPool()
+	restarted.SetJournal(NewTxJournal(path))
+	if err := restarted.LoadJournal(); err != nil {
+		t.Fatalf("LoadJournal failed: %v", err)
+	}
+	pending := restarted.PendingTransactions()
+	if len(pending) != 1 || pending[0].SenderID != "localUser" {
+		t.Fatalf("Expected only the local transaction after restart, got %+v", pending)
+	}
+	if locals := restarted.LocalTransactions(); len(locals) != 1 {
+		t.Errorf("Expected replayed transaction to be tracked as local, got %d local txs", len(locals))
+	}
+
+	t.Run("NoJournalConfigured", func(t *testing.T) {
+		if err := NewTxPool().LoadJournal(); err == nil {
+			t.Errorf("Expected error from LoadJournal without a journal, got nil")
+		}
+	})
+}
@@ -2,9 +2,65 @@ package core
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 )
 
+// TxPoolEventType identifies what happened to a transaction in the pool.
+type TxPoolEventType uint8
+
+const (
+	TxPoolEventAdded    TxPoolEventType = iota // A new transaction entered the pool
+	TxPoolEventReplaced                        // A pending transaction was replaced by one with a higher fee
+	TxPoolEventRemoved                         // A transaction left the pool (e.g., included in a block)
+)
+
+// String returns a human-readable name for the event type.
+func (t TxPoolEventType) String() string {
+	switch t {
+	case TxPoolEventAdded:
+		return "added"
+	case TxPoolEventReplaced:
+		return "replaced"
+	case TxPoolEventRemoved:
+		return "removed"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(t))
+	}
+}
+
+// TxPoolEvent is delivered to subscribers whenever the pool contents change.
+type TxPoolEvent struct {
+	Type     TxPoolEventType
+	Tx       *Transaction // The transaction that was added, removed, or the replacement
+	Replaced *Transaction // The transaction that was evicted (only set for TxPoolEventReplaced)
+}
+
+// TxPoolSubscription is a handle on a stream of pool events.
+// Consumers (RPC subscribers, the gossip layer) read from Events() and call
+// Unsubscribe when done, which closes the channel.
+type TxPoolSubscription struct {
+	id   uint64
+	pool *TxPool
+	ch   chan TxPoolEvent
+}
+
+// Events returns the channel on which pool events are delivered.
+func (s *TxPoolSubscription) Events() <-chan TxPoolEvent {
+	return s.ch
+}
+
+// Unsubscribe stops event delivery and closes the events channel.
+// It is safe to call more than once.
+func (s *TxPoolSubscription) Unsubscribe() {
+	s.pool.mu.Lock()
+	defer s.pool.mu.Unlock()
+	if _, ok := s.pool.subs[s.id]; ok {
+		delete(s.pool.subs, s.id)
+		close(s.ch)
+	}
+}
+
 // TxPool manages pending transactions that haven't been included in a block yet.
 type TxPool struct {
 	mu sync.RWMutex
@@ -12,13 +68,77 @@ type TxPool struct {
 	pending map[string]map[uint64]*Transaction
 	// TODO: Add more sophisticated data structures for prioritization (e.g., heap based on gas price)
 	// TODO: Add limits (max transactions per account, max total transactions)
+
+	// Subscribers to pool events, keyed by subscription ID
+	subs      map[uint64]*TxPoolSubscription
+	nextSubID uint64
+
+	// Hashes of the pending transactions submitted locally, which are journaled to disk. Tracked
+	// per transaction so that a remote transaction from a local sender is not journaled.
+	locals  map[Hash]struct{}
+	journal *TxJournal
+
+	// Checks signatures against the sender's account (e.g., multisig thresholds); optional
+	authorizer TxAuthorizer
+}
+
+// TxAuthorizer checks that a transaction's signatures authorize its sender under the current
+// state. Implemented by StateManager.
+type TxAuthorizer interface {
+	VerifyAuthorization(tx *Transaction) error
 }
 
 // NewTxPool creates a new transaction pool.
 func NewTxPool() *TxPool {
 	return &TxPool{
 		pending: make(map[string]map[uint64]*Transaction),
+		subs:    make(map[uint64]*TxPoolSubscription),
+		locals:  make(map[Hash]struct{}),
+	}
+}
+
+// Subscribe registers a new event subscriber with the given channel buffer size.
+// Delivery never blocks the pool: if a subscriber's buffer is full, the event is dropped for that subscriber.
+func (pool *TxPool) Subscribe(buffer int) *TxPoolSubscription {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if buffer < 0 {
+		buffer = 0
+	}
+	sub := &TxPoolSubscription{
+		id:   pool.nextSubID,
+		pool: pool,
+		ch:   make(chan TxPoolEvent, buffer),
 	}
+	pool.nextSubID++
+	pool.subs[sub.id] = sub
+	return sub
+}
+
+// notify delivers an event to all subscribers. Caller must hold pool.mu.
+func (pool *TxPool) notify(event TxPoolEvent) {
+	for id, sub := range pool.subs {
+		select {
+		case sub.ch <- event:
+		default:
+			fmt.Printf("TxPool: Dropping %s event for slow subscriber %d\n", event.Type, id) // Placeholder log
+		}
+	}
+}
+
+// SetJournal attaches an on-disk journal used to persist locally submitted transactions.
+func (pool *TxPool) SetJournal(journal *TxJournal) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	pool.journal = journal
+}
+
+// SetAuthorizer attaches the state-aware signature check applied to incoming transactions.
+func (pool *TxPool) SetAuthorizer(authorizer TxAuthorizer) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	pool.authorizer = authorizer
 }
 
 // AddTransaction attempts to add a transaction to the pool.
@@ -27,6 +147,42 @@ func (pool *TxPool) AddTransaction(tx *Transaction) error {
 	pool.mu.Lock()
 	defer pool.mu.Unlock()
 
+	return pool.add(tx)
+}
+
+// AddLocalTransaction adds a transaction submitted through this node (e.g., via RPC).
+// The transaction is marked as local and written to the journal, if any, so that it
+// survives a node restart.
+func (pool *TxPool) AddLocalTransaction(tx *Transaction) error {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if tx == nil {
+		return fmt.Errorf("cannot add nil transaction to pool")
+	}
+	// Mark before inserting so that a transaction never sits in the pool without its local mark;
+	// the mark is dropped again if the pool rejects it, unless an identical one was already local.
+	wasLocal := pool.isLocal(tx)
+	if err := pool.markLocal(tx); err != nil {
+		return err
+	}
+	if err := pool.add(tx); err != nil {
+		if !wasLocal {
+			pool.unmarkLocal(tx)
+		}
+		return err
+	}
+	if pool.journal != nil {
+		if err := pool.journal.Insert(tx); err != nil {
+			// The transaction stays in the pool; it just won't survive a restart.
+			fmt.Printf("TxPool: Failed to journal transaction from %s: %v\n", tx.SenderID, err) // Placeholder log
+		}
+	}
+	return nil
+}
+
+// add inserts a transaction into the pool. Caller must hold pool.mu.
+func (pool *TxPool) add(tx *Transaction) error {
 	if tx == nil {
 		return fmt.Errorf("cannot add nil transaction to pool")
 	}
@@ -36,8 +192,18 @@ func (pool *TxPool) AddTransaction(tx *Transaction) error {
 		return fmt.Errorf("invalid transaction (basic validation): %w", err)
 	}
 
+	if valid, err := tx.VerifySignatures(); err != nil {
+		return fmt.Errorf("invalid transaction (signatures): %w", err)
+	} else if !valid {
+		return fmt.Errorf("invalid transaction (signatures): bad signature from %s", tx.SenderID)
+	}
+	if pool.authorizer != nil {
+		if err := pool.authorizer.VerifyAuthorization(tx); err != nil {
+			return fmt.Errorf("unauthorized transaction: %w", err)
+		}
+	}
+
 	// TODO: Add stateful validation using StateManager/StateDB:
-	// - Check signature (properly, once implemented)
 	// - Check nonce (must be current sender nonce from state)
 	// - Check balance (sender must have sufficient funds for amount + gas)
 
@@ -51,13 +217,21 @@ func (pool *TxPool) AddTransaction(tx *Transaction) error {
 
 	// Check if a transaction with the same sender and nonce already exists
 	if existingTx, exists := pool.pending[sender][nonce]; exists {
-		// TODO: Implement replacement logic (e.g., higher gas price)
-		return fmt.Errorf("transaction with sender %s and nonce %d already exists in pool (tx hash: %v)", sender, nonce, existingTx) // Placeholder error
+		// Replace-by-fee: only a strictly higher fee may evict a pending transaction
+		if tx.Fee <= existingTx.Fee {
+			return fmt.Errorf("transaction with sender %s and nonce %d already exists in pool with fee %d (replacement needs a higher fee, got %d)", sender, nonce, existingTx.Fee, tx.Fee)
+		}
+		pool.pending[sender][nonce] = tx
+		pool.unmarkLocal(existingTx)
+		fmt.Printf("TxPool: Replaced transaction from %s with nonce %d (fee %d -> %d)\n", sender, nonce, existingTx.Fee, tx.Fee) // Placeholder log
+		pool.notify(TxPoolEvent{Type: TxPoolEventReplaced, Tx: tx, Replaced: existingTx})
+		return nil
 	}
 
 	// Add the transaction
 	pool.pending[sender][nonce] = tx
 	fmt.Printf("TxPool: Added transaction from %s with nonce %d\n", sender, nonce) // Placeholder log
+	pool.notify(TxPoolEvent{Type: TxPoolEventAdded, Tx: tx})
 
 	return nil
 }
@@ -75,19 +249,145 @@ func (pool *TxPool) RemoveTransaction(tx *Transaction) {
 	nonce := tx.Nonce
 
 	if senderMap, senderExists := pool.pending[sender]; senderExists {
-		if _, txExists := senderMap[nonce]; txExists {
+		if existingTx, txExists := senderMap[nonce]; txExists {
 			delete(senderMap, nonce)
+			pool.unmarkLocal(existingTx)
 			fmt.Printf("TxPool: Removed transaction from %s with nonce %d\n", sender, nonce) // Placeholder log
 			// Clean up sender map if empty
 			if len(senderMap) == 0 {
 				delete(pool.pending, sender)
 			}
+			pool.notify(TxPoolEvent{Type: TxPoolEventRemoved, Tx: existingTx})
 		}
 	}
 }
 
+// NextNonce returns the nonce for sender's next transaction: one past its highest pending nonce,
+// or stateNonce if that is higher (or nothing is pending).
+func (pool *TxPool) NextNonce(sender string, stateNonce uint64) uint64 {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	next := stateNonce
+	for nonce := range pool.pending[sender] {
+		if nonce+1 > next {
+			next = nonce + 1
+		}
+	}
+	return next
+}
+
+// PendingTransactions returns all pending transactions ordered by sender, then by nonce.
+func (pool *TxPool) PendingTransactions() []*Transaction {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	return pool.collect(func(*Transaction) bool { return true })
+}
+
+// LocalTransactions returns the pending transactions that were submitted locally.
+func (pool *TxPool) LocalTransactions() []*Transaction {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	return pool.collect(pool.isLocal)
+}
+
+// markLocal records tx as submitted through this node. Caller must hold pool.mu.
+func (pool *TxPool) markLocal(tx *Transaction) error {
+	hash, err := tx.Hash()
+	if err != nil {
+		return fmt.Errorf("failed to hash local transaction: %w", err)
+	}
+	pool.locals[hash] = struct{}{}
+	return nil
+}
+
+// unmarkLocal forgets tx once it leaves the pool. Caller must hold pool.mu.
+func (pool *TxPool) unmarkLocal(tx *Transaction) {
+	if hash, err := tx.Hash(); err == nil {
+		delete(pool.locals, hash)
+	}
+}
+
+// isLocal reports whether tx was submitted through this node.
+// Caller must hold pool.mu (read or write).
+func (pool *TxPool) isLocal(tx *Transaction) bool {
+	hash, err := tx.Hash()
+	if err != nil {
+		return false
+	}
+	_, ok := pool.locals[hash]
+	return ok
+}
+
+// collect gathers the pending transactions accepted by the filter, ordered by sender, then by
+// nonce. Caller must hold pool.mu (read or write).
+func (pool *TxPool) collect(include func(tx *Transaction) bool) []*Transaction {
+	senders := make([]string, 0, len(pool.pending))
+	for sender := range pool.pending {
+		senders = append(senders, sender)
+	}
+	sort.Strings(senders)
+
+	txs := make([]*Transaction, 0)
+	for _, sender := range senders {
+		nonces := make([]uint64, 0, len(pool.pending[sender]))
+		for nonce := range pool.pending[sender] {
+			nonces = append(nonces, nonce)
+		}
+		sort.Slice(nonces, func(i, j int) bool { return nonces[i] < nonces[j] })
+		for _, nonce := range nonces {
+			if tx := pool.pending[sender][nonce]; include(tx) {
+				txs = append(txs, tx)
+			}
+		}
+	}
+	return txs
+}
+
+// LoadJournal replays the journaled local transactions into the pool (called on startup)
+// and then compacts the journal so it only contains what is actually pending.
+// Transactions that are no longer accepted by the pool are dropped.
+func (pool *TxPool) LoadJournal() error {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if pool.journal == nil {
+		return fmt.Errorf("txpool has no journal configured")
+	}
+
+	dropped := 0
+	err := pool.journal.Load(func(tx *Transaction) error {
+		if err := pool.add(tx); err != nil {
+			dropped++
+			return nil // Stale entries (e.g., duplicates) are expected; keep loading
+		}
+		return pool.markLocal(tx)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load transaction journal: %w", err)
+	}
+	if dropped > 0 {
+		fmt.Printf("TxPool: Dropped %d stale journaled transactions\n", dropped) // Placeholder log
+	}
+
+	return pool.journal.Rotate(pool.collect(pool.isLocal))
+}
+
+// RotateJournal rewrites the journal with the currently pending local transactions,
+// discarding entries for transactions that have since been included or replaced.
+func (pool *TxPool) RotateJournal() error {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if pool.journal == nil {
+		return fmt.Errorf("txpool has no journal configured")
+	}
+	return pool.journal.Rotate(pool.collect(pool.isLocal))
+}
+
 // TODO: Add methods like:
-// - GetPendingTransactions (for block proposal)
 // - GetTransaction(hash)
 // - UpdatePool (e.g., remove transactions invalidated by a new block)
 // - PromoteExecutable (move transactions from future queue to pending when nonce matches)
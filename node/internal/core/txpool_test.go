@@ -1,6 +1,7 @@
 package core
 
 import (
+	"fmt"
 	"testing"
 )
 
@@ -182,5 +183,165 @@ func TestTxPool_RemoveTransaction(t *testing.T) {
 	})
 }
 
-// TODO: Add TestTxPool_RemoveTransaction
-// TODO: Add TestTxPool_GetPendingTransactions
+func TestTxPool_ReplaceByFee(t *testing.T) {
+	pool := NewTxPool()
+	sender := "senderA"
+
+	original := NewBaseTransaction(TxTypeTransfer, 0, sender, "recipientB", 100)
+	original.Fee = 10
+	_ = original.Sign()
+	if err := pool.AddTransaction(original); err != nil {
+		t.Fatalf("Failed to add original tx: %v", err)
+	}
+
+	t.Run("LowerFeeRejected", func(t *testing.T) {
+		cheaper := NewBaseTransaction(TxTypeTransfer, 0, sender, "recipientB", 100)
+		cheaper.Fee = 5
+		_ = cheaper.Sign()
+		if err := pool.AddTransaction(cheaper); err == nil {
+			t.Errorf("Expected error when replacing with a lower fee, got nil")
+		}
+	})
+
+	t.Run("HigherFeeReplaces", func(t *testing.T) {
+		replacement := NewBaseTransaction(TxTypeTransfer, 0, sender, "recipientC", 100)
+		replacement.Fee = 20
+		_ = replacement.Sign()
+		if err := pool.AddTransaction(replacement); err != nil {
+			t.Fatalf("Expected replacement with higher fee to succeed, got %v", err)
+		}
+		pending := pool.PendingTransactions()
+		if len(pending) != 1 || pending[0] != replacement {
+			t.Errorf("Expected only the replacement tx to be pending, got %v", pending)
+		}
+	})
+}
+
+func TestTxPool_AddLocalTransaction(t *testing.T) {
+	pool := NewTxPool()
+	local := NewBaseTransaction(TxTypeTransfer, 0, "senderA", "recipientB", 100)
+	local.Fee = 10
+	_ = local.Sign()
+	if err := pool.AddLocalTransaction(local); err != nil {
+		t.Fatalf("AddLocalTransaction failed: %v", err)
+	}
+
+	t.Run("RejectedNotMarked", func(t *testing.T) {
+		cheaper := NewBaseTransaction(TxTypeTransfer, 0, "senderA", "recipientC", 100)
+		cheaper.Fee = 5
+		_ = cheaper.Sign()
+		if err := pool.AddLocalTransaction(cheaper); err == nil {
+			t.Fatalf("Expected error when replacing with a lower fee, got nil")
+		}
+		if len(pool.locals) != 1 || pool.isLocal(cheaper) {
+			t.Errorf("Expected the rejected transaction not marked local, got %d marks", len(pool.locals))
+		}
+	})
+
+	t.Run("ResubmittedStaysLocal", func(t *testing.T) {
+		if err := pool.AddLocalTransaction(local); err == nil {
+			t.Fatalf("Expected error resubmitting a pending transaction, got nil")
+		}
+		if txs := pool.LocalTransactions(); len(txs) != 1 || txs[0] != local {
+			t.Errorf("Expected the pending transaction to stay local, got %v", txs)
+		}
+	})
+}
+
+func TestTxPool_Subscription(t *testing.T) {
+	pool := NewTxPool()
+	sub := pool.Subscribe(10)
+
+	tx := NewBaseTransaction(TxTypeTransfer, 0, "senderA", "recipientB", 100)
+	_ = tx.Sign()
+	_ = pool.AddTransaction(tx)
+
+	replacement := NewBaseTransaction(TxTypeTransfer, 0, "senderA", "recipientB", 100)
+	replacement.Fee = 1
+	_ = replacement.Sign()
+	_ = pool.AddTransaction(replacement)
+
+	pool.RemoveTransaction(replacement)
+
+	expected := []struct {
+		eventType TxPoolEventType
+		tx        *Transaction
+		replaced  *Transaction
+	}{
+		{TxPoolEventAdded, tx, nil},
+		{TxPoolEventReplaced, replacement, tx},
+		{TxPoolEventRemoved, replacement, nil},
+	}
+	for i, want := range expected {
+		select {
+		case ev := <-sub.Events():
+			if ev.Type != want.eventType || ev.Tx != want.tx || ev.Replaced != want.replaced {
+				t.Errorf("Event %d: expected %s, got %s (tx %p, replaced %p)", i, want.eventType, ev.Type, ev.Tx, ev.Replaced)
+			}
+		default:
+			t.Fatalf("Event %d (%s) was not delivered", i, want.eventType)
+		}
+	}
+
+	t.Run("Unsubscribe", func(t *testing.T) {
+		sub.Unsubscribe()
+		sub.Unsubscribe() // Must be safe to call twice
+		if _, ok := <-sub.Events(); ok {
+			t.Errorf("Expected events channel to be closed after Unsubscribe")
+		}
+		// Adding after unsubscribe must not panic on the closed channel
+		other := NewBaseTransaction(TxTypeTransfer, 1, "senderA", "recipientB", 100)
+		_ = other.Sign()
+		if err := pool.AddTransaction(other); err != nil {
+			t.Fatalf("AddTransaction failed after unsubscribe: %v", err)
+		}
+	})
+
+	t.Run("SlowSubscriberDoesNotBlock", func(t *testing.T) {
+		slow := pool.Subscribe(0) // Unbuffered and never read
+		defer slow.Unsubscribe()
+		blocked := NewBaseTransaction(TxTypeTransfer, 2, "senderA", "recipientB", 100)
+		_ = blocked.Sign()
+		if err := pool.AddTransaction(blocked); err != nil {
+			t.Fatalf("AddTransaction failed with slow subscriber: %v", err)
+		}
+	})
+}
+
+func TestTxPool_PendingTransactions(t *testing.T) {
+	pool := NewTxPool()
+	for _, spec := range []struct {
+		sender string
+		nonce  uint64
+	}{{"senderB", 1}, {"senderA", 1}, {"senderB", 0}, {"senderA", 0}} {
+		tx := NewBaseTransaction(TxTypeTransfer, spec.nonce, spec.sender, "recipient", 1)
+		_ = tx.Sign()
+		if err := pool.AddTransaction(tx); err != nil {
+			t.Fatalf("AddTransaction failed: %v", err)
+		}
+	}
+
+	pending := pool.PendingTransactions()
+	if len(pending) != 4 {
+		t.Fatalf("Expected 4 pending transactions, got %d", len(pending))
+	}
+	wantOrder := []string{"senderA/0", "senderA/1", "senderB/0", "senderB/1"}
+	for i, tx := range pending {
+		got := fmt.Sprintf("%s/%d", tx.SenderID, tx.Nonce)
+		if got != wantOrder[i] {
+			t.Errorf("Position %d: expected %s, got %s", i, wantOrder[i], got)
+		}
+	}
+
+	t.Run("NextNonce", func(t *testing.T) {
+		if n := pool.NextNonce("senderA", 0); n != 2 {
+			t.Errorf("Expected next nonce 2 after pending 0 and 1, got %d", n)
+		}
+		if n := pool.NextNonce("senderA", 5); n != 5 {
+			t.Errorf("Expected state nonce 5 to win over stale pending, got %d", n)
+		}
+		if n := pool.NextNonce("unknown", 3); n != 3 {
+			t.Errorf("Expected state nonce for a sender with nothing pending, got %d", n)
+		}
+	})
+}
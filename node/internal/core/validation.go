@@ -0,0 +1,103 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Header rule violations returned (wrapped in a *ValidationError) by ConsensusEngine.VerifyHeader.
+var (
+	ErrNilHeader                = errors.New("nil header")
+	ErrInvalidParentHash        = errors.New("parent hash does not match parent header")
+	ErrInvalidNumber            = errors.New("block number is not parent number + 1")
+	ErrTimestampNotAfterParent  = errors.New("timestamp is not after parent timestamp")
+	ErrFutureTimestamp          = errors.New("timestamp too far in the future")
+	ErrGasLimitTooHigh          = errors.New("gas limit exceeds maximum")
+	ErrGasUsedExceedsLimit      = errors.New("gas used exceeds gas limit")
+	ErrInvalidWSIValue          = errors.New("WSI value is not a finite non-negative number")
+	ErrMissingProposer          = errors.New("header has no proposer")
+	ErrInvalidProposerSignature = errors.New("invalid proposer signature")
+	ErrIneligibleProposer       = errors.New("proposer not selected for this block")
+)
+
+// Block rule violations returned (wrapped in a *ValidationError) by ConsensusEngine.VerifyBlock.
+var (
+	ErrNilBlock            = errors.New("nil block")
+	ErrBlockTooLarge       = errors.New("block exceeds maximum size")
+	ErrTxRootMismatch      = errors.New("transaction root does not match header")
+	ErrGasUsedMismatch     = errors.New("gas used does not match transactions")
+	ErrInvalidTransaction  = errors.New("invalid transaction")
+	ErrStateRootMismatch   = errors.New("state root does not match header")
+	ErrStateExecutionError = errors.New("state execution failed")
+	// Only children of the block the live state is at can be executed; per-block state is not kept
+	ErrParentStateUnavailable = errors.New("state of the parent block is not available")
+)
+
+// ValidationError reports which rule a block or header violated.
+// Use errors.Is against the Err* sentinels to identify the rule, and PeerPenalty to decide how to
+// treat the peer that sent it.
+type ValidationError struct {
+	Number uint64 // Number of the offending block
+	Rule   error  // One of the Err* sentinels above
+	Detail string // Human-readable specifics
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	if e.Detail == "" {
+		return fmt.Sprintf("block %d: %v", e.Number, e.Rule)
+	}
+	return fmt.Sprintf("block %d: %v: %s", e.Number, e.Rule, e.Detail)
+}
+
+// Unwrap exposes the violated rule to errors.Is.
+func (e *ValidationError) Unwrap() error {
+	return e.Rule
+}
+
+// newValidationError builds a ValidationError with a formatted detail message.
+func newValidationError(number uint64, rule error, format string, args ...interface{}) *ValidationError {
+	return &ValidationError{Number: number, Rule: rule, Detail: fmt.Sprintf(format, args...)}
+}
+
+// Peer penalties for blocks that fail validation.
+const (
+	PenaltyNone   = 0   // Not the peer's fault (e.g., our clock may be behind)
+	PenaltyMinor  = 10  // Possibly honest mistakes
+	PenaltySevere = 100 // Provably malicious or badly broken; disconnect
+)
+
+// PeerPenalty returns how severely to penalize a peer that relayed a block failing with err.
+// Errors that are not validation errors (e.g., local storage failures) carry no penalty.
+func PeerPenalty(err error) int {
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		return PenaltyNone
+	}
+	switch {
+	case errors.Is(verr.Rule, ErrFutureTimestamp), errors.Is(verr.Rule, ErrStateExecutionError),
+		errors.Is(verr.Rule, ErrParentStateUnavailable):
+		return PenaltyNone
+	case errors.Is(verr.Rule, ErrTimestampNotAfterParent), errors.Is(verr.Rule, ErrBlockTooLarge):
+		return PenaltyMinor
+	default:
+		return PenaltySevere
+	}
+}
+
+// ValidationConfig holds the limits enforced by header and block validation.
+type ValidationConfig struct {
+	MaxClockDrift time.Duration // How far ahead of the local clock a header timestamp may be
+	MaxGasLimit   uint64        // Upper bound on BlockHeader.GasLimit
+	MaxBlockSize  uint64        // Upper bound on the encoded size of a block's transactions, in bytes
+}
+
+// DefaultValidationConfig returns the limits used when none are configured.
+func DefaultValidationConfig() ValidationConfig {
+	return ValidationConfig{
+		MaxClockDrift: 15 * time.Second,
+		MaxGasLimit:   10_000_000,
+		MaxBlockSize:  2 * 1024 * 1024,
+	}
+}
@@ -0,0 +1,201 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// sealTestBlock fills in TxRoot, GasUsed and StateRoot for a block built on the state in sm, then
+// signs the header as proposer.
+func sealTestBlock(t *testing.T, sm *StateManager, block *Block, proposer string) {
+	t.Helper()
+	header := block.Header
+	header.Proposer = proposer
+	txRoot, err := DeriveTxRoot(block.Transactions)
+	if err != nil {
+		t.Fatalf("DeriveTxRoot failed: %v", err)
+	}
+	header.TxRoot = txRoot
+	header.GasUsed = 0
+	for _, tx := range block.Transactions {
+		header.GasUsed += tx.IntrinsicGas()
+	}
+	scratch := sm.Copy()
+	if err := scratch.ApplyBlock(block); err != nil {
+		t.Fatalf("ApplyBlock failed while sealing: %v", err)
+	}
+	header.StateRoot, _ = scratch.DB().Root()
+	if err := header.Sign(proposer); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+}
+
+func TestVerifyHeader(t *testing.T) {
+	consensus := NewPathIntegralConsensus(NewStateManager(NewInMemoryStateDB()))
+	now := time.Unix(1700000100, 0)
+	consensus.now = func() time.Time { return now }
+
+	parent := &BlockHeader{Number: 7, Timestamp: now.Add(-10 * time.Second), GasLimit: 1_000_000}
+	validHeader := func() *BlockHeader {
+		h := &BlockHeader{
+			ParentHash: parent.Hash(),
+			Number:     8,
+			Timestamp:  now.Add(-5 * time.Second),
+			GasLimit:   1_000_000,
+		}
+		_ = h.Sign("proposerP")
+		return h
+	}
+
+	if err := consensus.VerifyHeader(validHeader(), parent); err != nil {
+		t.Fatalf("Expected valid header to pass, got %v", err)
+	}
+
+	cases := []struct {
+		name    string
+		mutate  func(h *BlockHeader)
+		resign  bool
+		want    error
+		penalty int
+	}{
+		{"WrongParentHash", func(h *BlockHeader) { h.ParentHash = Hash{1} }, true, ErrInvalidParentHash, PenaltySevere},
+		{"WrongNumber", func(h *BlockHeader) { h.Number = 9 }, true, ErrInvalidNumber, PenaltySevere},
+		{"TimestampBeforeParent", func(h *BlockHeader) { h.Timestamp = parent.Timestamp }, true, ErrTimestampNotAfterParent, PenaltyMinor},
+		{"FutureTimestamp", func(h *BlockHeader) { h.Timestamp = now.Add(time.Hour) }, true, ErrFutureTimestamp, PenaltyNone},
+		{"GasLimitTooHigh", func(h *BlockHeader) { h.GasLimit = DefaultValidationConfig().MaxGasLimit + 1 }, true, ErrGasLimitTooHigh, PenaltySevere},
+		{"GasUsedOverLimit", func(h *BlockHeader) { h.GasUsed = h.GasLimit + 1 }, true, ErrGasUsedExceedsLimit, PenaltySevere},
+		{"Unsigned", func(h *BlockHeader) { h.Signature = nil }, false, ErrInvalidProposerSignature, PenaltySevere},
+		{"TamperedAfterSigning", func(h *BlockHeader) { h.GasUsed = 1 }, false, ErrInvalidProposerSignature, PenaltySevere},
+		{"MissingProposer", func(h *BlockHeader) { h.Proposer = "" }, false, ErrMissingProposer, PenaltySevere},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			h := validHeader()
+			c.mutate(h)
+			if c.resign {
+				_ = h.Sign(h.Proposer)
+			}
+			err := consensus.VerifyHeader(h, parent)
+			if !errors.Is(err, c.want) {
+				t.Fatalf("Expected %v, got %v", c.want, err)
+			}
+			if p := PeerPenalty(err); p != c.penalty {
+				t.Errorf("Expected penalty %d, got %d", c.penalty, p)
+			}
+		})
+	}
+
+	t.Run("NilHeader", func(t *testing.T) {
+		if err := consensus.VerifyHeader(nil, parent); !errors.Is(err, ErrNilHeader) {
+			t.Errorf("Expected ErrNilHeader, got %v", err)
+		}
+	})
+}
+
+func TestVerifyBlock(t *testing.T) {
+	db := NewInMemoryStateDB()
+	_ = db.SetBalance("senderA", 1000)
+	sm := NewStateManager(db)
+	consensus := NewPathIntegralConsensus(sm)
+
+	newBlock := func() *Block {
+		tx := NewBaseTransaction(TxTypeTransfer, 0, "senderA", "recipientB", 100)
+		tx.Fee = 5
+		_ = tx.Sign()
+		block := NewBlock(&BlockHeader{Number: 1, Timestamp: time.Now(), GasLimit: 1_000_000}, []*Transaction{tx})
+		sealTestBlock(t, sm, block, "proposerP")
+		return block
+	}
+
+	t.Run("ValidBlock", func(t *testing.T) {
+		if err := consensus.VerifyBlock(newBlock()); err != nil {
+			t.Fatalf("Expected valid block to pass, got %v", err)
+		}
+		if bal, _ := db.GetBalance("senderA"); bal != 1000 {
+			t.Errorf("VerifyBlock must not modify live state, sender balance is %d", bal)
+		}
+	})
+
+	t.Run("TxRootMismatch", func(t *testing.T) {
+		block := newBlock()
+		extra := NewBaseTransaction(TxTypeTransfer, 1, "senderA", "recipientB", 1)
+		_ = extra.Sign()
+		block.Transactions = append(block.Transactions, extra)
+		if err := consensus.VerifyBlock(block); !errors.Is(err, ErrTxRootMismatch) {
+			t.Errorf("Expected ErrTxRootMismatch, got %v", err)
+		}
+	})
+
+	t.Run("UnsignedTransaction", func(t *testing.T) {
+		block := newBlock()
+		block.Transactions[0].Signature = Signature("forged")
+		block.Header.TxRoot, _ = DeriveTxRoot(block.Transactions)
+		if err := consensus.VerifyBlock(block); !errors.Is(err, ErrInvalidTransaction) {
+			t.Errorf("Expected ErrInvalidTransaction, got %v", err)
+		}
+	})
+
+	t.Run("GasUsedMismatch", func(t *testing.T) {
+		block := newBlock()
+		block.Header.GasUsed++
+		if err := consensus.VerifyBlock(block); !errors.Is(err, ErrGasUsedMismatch) {
+			t.Errorf("Expected ErrGasUsedMismatch, got %v", err)
+		}
+	})
+
+	t.Run("StateRootMismatch", func(t *testing.T) {
+		block := newBlock()
+		block.Header.StateRoot = Hash{7}
+		err := consensus.VerifyBlock(block)
+		if !errors.Is(err, ErrStateRootMismatch) {
+			t.Errorf("Expected ErrStateRootMismatch, got %v", err)
+		}
+		if PeerPenalty(err) != PenaltySevere {
+			t.Errorf("Expected severe penalty for state root mismatch")
+		}
+	})
+
+	t.Run("BlockTooLarge", func(t *testing.T) {
+		small := NewPathIntegralConsensus(sm)
+		cfg := DefaultValidationConfig()
+		cfg.MaxBlockSize = 10
+		small.SetValidationConfig(cfg)
+		if err := small.VerifyBlock(newBlock()); !errors.Is(err, ErrBlockTooLarge) {
+			t.Errorf("Expected ErrBlockTooLarge, got %v", err)
+		}
+	})
+
+	t.Run("ParentStateUnavailable", func(t *testing.T) {
+		local := NewStateManager(db.Copy())
+		engine := NewPathIntegralConsensus(local)
+		block := newBlock()
+		if err := engine.Finalize(block); err != nil {
+			t.Fatalf("Finalize failed: %v", err)
+		}
+
+		// A sibling of the applied block would need the genesis state, which is gone
+		sibling := newBlock()
+		sibling.Header.Timestamp = sibling.Header.Timestamp.Add(time.Second)
+		sealTestBlock(t, sm, sibling, "proposerP")
+		err := engine.VerifyBlock(sibling)
+		if !errors.Is(err, ErrParentStateUnavailable) {
+			t.Fatalf("Expected ErrParentStateUnavailable for a block off the applied chain, got %v", err)
+		}
+		if PeerPenalty(err) != PenaltyNone {
+			t.Errorf("Expected no penalty when the parent state is missing locally")
+		}
+
+		child := NewBlock(&BlockHeader{ParentHash: block.Header.Hash(), Number: 2, Timestamp: time.Now(), GasLimit: 1_000_000}, nil)
+		sealTestBlock(t, local, child, "proposerP")
+		if err := engine.VerifyBlock(child); err != nil {
+			t.Errorf("Expected a child of the applied block to verify, got %v", err)
+		}
+	})
+
+	t.Run("NonValidationErrorHasNoPenalty", func(t *testing.T) {
+		if PeerPenalty(errors.New("disk full")) != PenaltyNone {
+			t.Errorf("Expected no penalty for non-validation errors")
+		}
+	})
+}
@@ -28,5 +28,61 @@ func CreateAnchorTransaction(nonce uint64, sender string, proofHash Hash) (*Tran
 	return tx, nil
 }
 
-// TODO: Add VerificationManager if needed for tracking/querying anchors later.
-// type VerificationManager struct { ... }
+// GasAnchor is the gas charged for recording an anchor on top of the intrinsic gas.
+const GasAnchor uint64 = 2000
+
+// anchorPrefix is the storage key prefix of anchored proofs: anchor/<proof hash> -> AnchorRecord.
+const anchorPrefix = "anchor/"
+
+// AnchorRecord records who anchored a proof hash and in which block.
+type AnchorRecord struct {
+	Sender string
+	Block  uint64
+}
+
+// anchorKey returns the storage key of proofHash.
+func anchorKey(proofHash Hash) string {
+	return fmt.Sprintf("%s%x", anchorPrefix, proofHash[:])
+}
+
+// GetAnchor returns the record of proofHash, or nil if it was never anchored.
+func GetAnchor(db StateDB, proofHash Hash) (*AnchorRecord, error) {
+	record := &AnchorRecord{}
+	found, err := getStorageGob(db, anchorKey(proofHash), record)
+	if err != nil || !found {
+		return nil, err
+	}
+	return record, nil
+}
+
+// validateAnchor checks that an anchor transaction carries a proof hash that is not anchored yet.
+func validateAnchor(ctx *NativeContext, tx *Transaction) error {
+	if tx.Amount != 0 {
+		return fmt.Errorf("anchor transaction must not carry an amount")
+	}
+	if len(tx.Payload) != len(Hash{}) {
+		return fmt.Errorf("anchor payload must be a %d-byte proof hash, got %d bytes", len(Hash{}), len(tx.Payload))
+	}
+	var proofHash Hash
+	copy(proofHash[:], tx.Payload)
+	existing, err := GetAnchor(ctx.State.db, proofHash)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("proof %s already anchored by %s in block %d", proofHash, existing.Sender, existing.Block)
+	}
+	return nil
+}
+
+// applyAnchor records the proof hash of an anchor transaction.
+func applyAnchor(ctx *NativeContext, tx *Transaction) error {
+	var proofHash Hash
+	copy(proofHash[:], tx.Payload)
+	record := &AnchorRecord{Sender: tx.SenderID, Block: ctx.State.blockNumber()}
+	if err := setStorageGob(ctx.State.db, anchorKey(proofHash), record); err != nil {
+		return err
+	}
+	ctx.Emit("anchor", proofHash[:])
+	return nil
+}
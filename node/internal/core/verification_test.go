@@ -57,5 +57,38 @@ func TestVerification_AnchorTxCreation(t *testing.T) {
 	// assuming sha256.Sum256 always returns non-nil [32]byte.
 }
 
-// TODO: Add TestVerification_AnchorTxValidation (requires consensus/state integration)
+func TestVerification_AnchorTxValidation(t *testing.T) {
+	db := NewInMemoryStateDB()
+	sm := NewStateManager(db)
+	_ = sm.BeginBlock(&BlockHeader{Number: 3, Proposer: "proposerP"})
+	proofHash := sha256.Sum256([]byte("data to be anchored"))
+
+	tx, _ := CreateAnchorTransaction(0, "verifierOrg", proofHash)
+	_ = tx.Sign()
+	if err := sm.ApplyTransaction(tx); err != nil {
+		t.Fatalf("Anchor rejected: %v", err)
+	}
+	record, err := GetAnchor(db, proofHash)
+	if err != nil || record == nil || record.Sender != "verifierOrg" || record.Block != 3 {
+		t.Fatalf("Expected anchor recorded by verifierOrg in block 3, got %+v (%v)", record, err)
+	}
+
+	t.Run("Duplicate", func(t *testing.T) {
+		again, _ := CreateAnchorTransaction(1, "verifierOrg", proofHash)
+		_ = again.Sign()
+		if err := sm.ApplyTransaction(again); err == nil {
+			t.Errorf("Expected an already anchored proof to be rejected")
+		}
+	})
+
+	t.Run("MalformedPayload", func(t *testing.T) {
+		bad := NewBaseTransaction(TxTypeAnchor, 1, "verifierOrg", "", 0)
+		bad.Payload = []byte("short")
+		_ = bad.Sign()
+		if err := sm.ApplyTransaction(bad); err == nil {
+			t.Errorf("Expected anchor without a 32-byte proof hash to be rejected")
+		}
+	})
+}
+
 // TODO: Add TestVerification_ProofRetrieval (requires state/storage implementation)
@@ -102,9 +102,14 @@ func (wm *WSIManager) CalculatePegPenalty() (float64, error) {
 		return math.Inf(1), fmt.Errorf("cannot calculate peg penalty due to value error: %w", err)
 	}
 
-	deviation := currentValue - wm.targetPeg
-	penalty := deviation * deviation
-	return penalty, nil
+	return wm.PegPenaltyOf(currentValue), nil
+}
+
+// PegPenaltyOf returns the peg penalty (value - TargetPeg)^2 of a given WSI value, e.g. one
+// committed in a block header, without querying the oracles.
+func (wm *WSIManager) PegPenaltyOf(value float64) float64 {
+	deviation := value - wm.targetPeg
+	return deviation * deviation
 }
 
 // TODO: Add methods related to updating weights based on Hamiltonian gradients.